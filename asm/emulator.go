@@ -0,0 +1,759 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Same initial stack pointer as VisUAL
+	stackStart = 0xFF000000
+	// Data declared with FILL/DCD is placed after the code
+	dataStart = 0x00100000
+
+	pageSize = 4096
+
+	defaultMaxSteps = 200_000_000
+)
+
+// ErrStepLimit is wrapped by the error of a program stopped because it ran for too many steps
+var ErrStepLimit = errors.New("step limit reached")
+
+// Machine is a small emulator of the subset of the ARM instruction set understood by VisUAL
+// and produced by the compiler. It lets the compiled programs run without the java simulator.
+type Machine struct {
+	Regs [16]uint32
+	N    bool
+	Z    bool
+	C    bool
+	V    bool
+
+	// MaxSteps is the number of executed instructions after which the execution is stopped, 0 for the default
+	MaxSteps int
+	Steps    int
+
+	code   []Instruction
+	labels map[string]uint32
+	memory map[uint32]*[pageSize]byte
+	output strings.Builder
+}
+
+// NewMachine loads the assembly text in a new machine.
+func NewMachine(text string) (*Machine, error) {
+	m := &Machine{labels: make(map[string]uint32), memory: make(map[uint32]*[pageSize]byte)}
+
+	dataAddr := uint32(dataStart)
+	for _, inst := range Parse(text) {
+		switch inst.Op {
+		case "FILL", "DCD":
+			size := 0
+			if inst.Op == "FILL" {
+				if len(inst.Args) == 0 {
+					return nil, fmt.Errorf("FILL without size")
+				}
+				n, err := parseNumber(inst.Args[0])
+				if err != nil {
+					return nil, err
+				}
+				size = int(n)
+			} else {
+				size = 4 * len(inst.Args)
+			}
+			if err := m.define(inst.Label, dataAddr); err != nil {
+				return nil, err
+			}
+			if inst.Op == "DCD" {
+				for i, arg := range inst.Args {
+					n, err := parseNumber(arg)
+					if err != nil {
+						return nil, err
+					}
+					m.store32(dataAddr+uint32(4*i), uint32(n))
+				}
+			}
+			dataAddr += uint32((size + 3) &^ 3)
+		default:
+			if err := m.define(inst.Label, uint32(4*len(m.code))); err != nil {
+				return nil, err
+			}
+			if inst.Op != "" {
+				m.code = append(m.code, inst)
+			}
+		}
+	}
+	m.Regs[13] = stackStart
+	return m, nil
+}
+
+// define gives the address to the label, a label defined twice is an error as it is for an assembler
+func (m *Machine) define(label string, addr uint32) error {
+	if label == "" {
+		return nil
+	}
+	label = strings.ToLower(label)
+	if _, ok := m.labels[label]; ok {
+		return fmt.Errorf("label %s is defined twice", label)
+	}
+	m.labels[label] = addr
+	return nil
+}
+
+// Output returns what the program printed with println.
+func (m *Machine) Output() string {
+	return m.output.String()
+}
+
+func (m *Machine) page(addr uint32) *[pageSize]byte {
+	key := addr / pageSize
+	p, ok := m.memory[key]
+	if !ok {
+		p = new([pageSize]byte)
+		m.memory[key] = p
+	}
+	return p
+}
+
+func (m *Machine) load8(addr uint32) uint32 {
+	return uint32(m.page(addr)[addr%pageSize])
+}
+
+func (m *Machine) store8(addr uint32, value uint32) {
+	m.page(addr)[addr%pageSize] = byte(value)
+}
+
+func (m *Machine) load32(addr uint32) uint32 {
+	return m.load8(addr) | m.load8(addr+1)<<8 | m.load8(addr+2)<<16 | m.load8(addr+3)<<24
+}
+
+func (m *Machine) store32(addr uint32, value uint32) {
+	m.store8(addr, value)
+	m.store8(addr+1, value>>8)
+	m.store8(addr+2, value>>16)
+	m.store8(addr+3, value>>24)
+}
+
+// readString reads a string printed by println: a word with its length followed by its characters, 0 is the empty string
+func (m *Machine) readString(addr uint32) string {
+	if addr == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	length := m.load32(addr)
+	for i := uint32(0); i < length; i++ {
+		builder.WriteByte(byte(m.load8(addr + 4 + i)))
+	}
+	return builder.String()
+}
+
+func parseNumber(s string) (int64, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+	var n uint64
+	var err error
+	switch {
+	case strings.HasPrefix(strings.ToLower(s), "0x"):
+		n, err = strconv.ParseUint(s[2:], 16, 32)
+	case strings.HasPrefix(s, "&"):
+		n, err = strconv.ParseUint(s[1:], 16, 32)
+	case strings.HasPrefix(strings.ToLower(s), "0b"):
+		n, err = strconv.ParseUint(s[2:], 2, 32)
+	default:
+		n, err = strconv.ParseUint(s, 10, 32)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if negative {
+		return -int64(n), nil
+	}
+	return int64(n), nil
+}
+
+func parseRegister(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "SP":
+		return 13, nil
+	case "LR":
+		return 14, nil
+	case "PC":
+		return 15, nil
+	}
+	if strings.HasPrefix(s, "R") {
+		n, err := strconv.Atoi(s[1:])
+		if err == nil && n >= 0 && n < 16 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid register %q", s)
+}
+
+func (m *Machine) condition(cond string) bool {
+	switch cond {
+	case "", "AL":
+		return true
+	case "EQ":
+		return m.Z
+	case "NE":
+		return !m.Z
+	case "CS", "HS":
+		return m.C
+	case "CC", "LO":
+		return !m.C
+	case "MI":
+		return m.N
+	case "PL":
+		return !m.N
+	case "VS":
+		return m.V
+	case "VC":
+		return !m.V
+	case "HI":
+		return m.C && !m.Z
+	case "LS":
+		return !m.C || m.Z
+	case "GE":
+		return m.N == m.V
+	case "LT":
+		return m.N != m.V
+	case "GT":
+		return !m.Z && m.N == m.V
+	case "LE":
+		return m.Z || m.N != m.V
+	}
+	return false
+}
+
+func (m *Machine) reg(n int) uint32 {
+	if n == 15 {
+		// PC reads as the address of the current instruction + 8
+		return m.Regs[15] + 8
+	}
+	return m.Regs[n]
+}
+
+// shift applies a shift operation and returns the result with the shifter carry.
+func (m *Machine) shift(kind string, value uint32, amount uint32) (uint32, bool) {
+	carry := m.C
+	if amount == 0 {
+		return value, carry
+	}
+	switch kind {
+	case "LSL":
+		if amount > 32 {
+			return 0, false
+		}
+		carry = (uint64(value)<<amount)&(1<<32) != 0
+		if amount == 32 {
+			return 0, carry
+		}
+		return value << amount, carry
+	case "LSR":
+		if amount > 32 {
+			return 0, false
+		}
+		carry = (value>>(amount-1))&1 != 0
+		if amount == 32 {
+			return 0, carry
+		}
+		return value >> amount, carry
+	case "ASR":
+		if amount >= 32 {
+			if int32(value) < 0 {
+				return 0xFFFFFFFF, true
+			}
+			return 0, false
+		}
+		carry = (value>>(amount-1))&1 != 0
+		return uint32(int32(value) >> amount), carry
+	case "ROR":
+		result := bits.RotateLeft32(value, -int(amount%32))
+		return result, result&(1<<31) != 0
+	}
+	return value, carry
+}
+
+// operand2 evaluates a flexible second operand made of the given arguments (immediate or register with an optional shift).
+func (m *Machine) operand2(args []string) (uint32, bool, error) {
+	if len(args) == 0 {
+		return 0, m.C, fmt.Errorf("missing operand")
+	}
+	if strings.HasPrefix(strings.TrimSpace(args[0]), "#") {
+		n, err := parseNumber(args[0])
+		return uint32(n), m.C, err
+	}
+	r, err := parseRegister(args[0])
+	if err != nil {
+		return 0, m.C, err
+	}
+	value := m.reg(r)
+	if len(args) == 1 {
+		return value, m.C, nil
+	}
+	fields := strings.Fields(args[1])
+	if len(fields) != 2 {
+		return 0, m.C, fmt.Errorf("invalid shift %q", args[1])
+	}
+	var amount uint32
+	if strings.HasPrefix(fields[1], "#") {
+		n, err := parseNumber(fields[1])
+		if err != nil {
+			return 0, m.C, err
+		}
+		amount = uint32(n)
+	} else {
+		r, err := parseRegister(fields[1])
+		if err != nil {
+			return 0, m.C, err
+		}
+		amount = m.reg(r) & 0xFF
+	}
+	result, carry := m.shift(strings.ToUpper(fields[0]), value, amount)
+	return result, carry, nil
+}
+
+// dataOperand is the second operand of a data processing instruction, its immediate must be encodable.
+func (m *Machine) dataOperand(args []string) (uint32, bool, error) {
+	if len(args) > 0 && strings.HasPrefix(strings.TrimSpace(args[0]), "#") {
+		n, err := parseNumber(args[0])
+		if err == nil && !IsEncodableImmediate(uint32(n)) {
+			return 0, m.C, fmt.Errorf("immediate %s cannot be encoded", args[0])
+		}
+	}
+	return m.operand2(args)
+}
+
+func (m *Machine) setNZ(result uint32) {
+	m.N = int32(result) < 0
+	m.Z = result == 0
+}
+
+func (m *Machine) add(a, b uint32, carryIn uint32) uint32 {
+	result64 := uint64(a) + uint64(b) + uint64(carryIn)
+	result := uint32(result64)
+	m.setNZ(result)
+	m.C = result64>>32 != 0
+	m.V = (a^result)&(b^result)&(1<<31) != 0
+	return result
+}
+
+// address computes the address of a memory operand and applies the write-back if any.
+func (m *Machine) address(args []string) (uint32, error) {
+	mem := strings.TrimSpace(args[0])
+	writeBack := strings.HasSuffix(mem, "!")
+	mem = strings.TrimSuffix(mem, "!")
+	if !strings.HasPrefix(mem, "[") || !strings.HasSuffix(mem, "]") {
+		return 0, fmt.Errorf("invalid address %q", mem)
+	}
+	parts := splitArgs(mem[1 : len(mem)-1])
+	base, err := parseRegister(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	var offset uint32
+	if len(parts) > 1 {
+		if strings.HasPrefix(parts[1], "#") {
+			n, err := parseNumber(parts[1])
+			if err != nil {
+				return 0, err
+			}
+			offset = uint32(n)
+		} else {
+			offset, _, err = m.operand2(parts[1:])
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	addr := m.reg(base) + offset
+	if len(args) > 1 {
+		// Post-indexed
+		post, _, err := m.operand2(args[1:])
+		if err != nil {
+			return 0, err
+		}
+		m.Regs[base] = m.reg(base) + post
+		return addr, nil
+	}
+	if writeBack {
+		m.Regs[base] = addr
+	}
+	return addr, nil
+}
+
+func parseRegisterList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid register list %q", s)
+	}
+	var registers [16]bool
+	for _, part := range strings.Split(s[1:len(s)-1], ",") {
+		part = strings.TrimSpace(part)
+		if bounds := strings.Split(part, "-"); len(bounds) == 2 {
+			from, err := parseRegister(bounds[0])
+			if err != nil {
+				return nil, err
+			}
+			to, err := parseRegister(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+			for r := from; r <= to; r++ {
+				registers[r] = true
+			}
+			continue
+		}
+		r, err := parseRegister(part)
+		if err != nil {
+			return nil, err
+		}
+		registers[r] = true
+	}
+	list := make([]int, 0)
+	for r, present := range registers {
+		if present {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+func (m *Machine) jump(addr uint32) {
+	// -4 since the program counter is incremented after each instruction
+	m.Regs[15] = addr - 4
+}
+
+// Run executes the program from its first instruction until END, the end of the code or an error.
+func (m *Machine) Run() error {
+	maxSteps := m.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = defaultMaxSteps
+	}
+	for {
+		index := m.Regs[15] / 4
+		if m.Regs[15]%4 != 0 || int(index) >= len(m.code) {
+			return nil
+		}
+		if m.Steps >= maxSteps {
+			return fmt.Errorf("program stopped after %d instructions: %w", m.Steps, ErrStepLimit)
+		}
+		m.Steps++
+		inst := m.code[index]
+		if inst.Op == "END" {
+			return nil
+		}
+		if m.condition(inst.Cond) {
+			if err := m.execute(inst); err != nil {
+				return fmt.Errorf("instruction %d (%s): %w", index, inst.String(), err)
+			}
+		}
+		m.Regs[15] += 4
+	}
+}
+
+func (m *Machine) execute(inst Instruction) error {
+	args := inst.Args
+	switch inst.Op {
+	case "MOV", "MVN":
+		if len(args) < 2 {
+			return fmt.Errorf("missing operand")
+		}
+		rd, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		value, carry, err := m.dataOperand(args[1:])
+		if err != nil {
+			return err
+		}
+		if inst.Op == "MVN" {
+			value = ^value
+		}
+		if rd == 15 {
+			m.jump(value)
+			return nil
+		}
+		m.Regs[rd] = value
+		if inst.SetFlags {
+			m.setNZ(value)
+			m.C = carry
+		}
+	case "ADD", "ADC", "SUB", "SBC", "RSB", "RSC", "AND", "ORR", "EOR", "BIC":
+		if len(args) < 2 {
+			return fmt.Errorf("missing operand")
+		}
+		rd, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		rn := rd
+		operands := args[1:]
+		if len(args) > 2 && !strings.Contains(args[2], " ") || len(args) > 3 {
+			rn, err = parseRegister(args[1])
+			if err != nil {
+				return err
+			}
+			operands = args[2:]
+		}
+		a := m.reg(rn)
+		b, carry, err := m.dataOperand(operands)
+		if err != nil {
+			return err
+		}
+		var result uint32
+		flags := m.N
+		flagsZ, flagsC, flagsV := m.Z, m.C, m.V
+		carryIn := uint32(0)
+		if m.C {
+			carryIn = 1
+		}
+		switch inst.Op {
+		case "ADD":
+			result = m.add(a, b, 0)
+		case "ADC":
+			result = m.add(a, b, carryIn)
+		case "SUB":
+			result = m.add(a, ^b, 1)
+		case "SBC":
+			result = m.add(a, ^b, carryIn)
+		case "RSB":
+			result = m.add(b, ^a, 1)
+		case "RSC":
+			result = m.add(b, ^a, carryIn)
+		default:
+			switch inst.Op {
+			case "AND":
+				result = a & b
+			case "ORR":
+				result = a | b
+			case "EOR":
+				result = a ^ b
+			case "BIC":
+				result = a &^ b
+			}
+			m.setNZ(result)
+			m.C = carry
+		}
+		if !inst.SetFlags {
+			m.N, m.Z, m.C, m.V = flags, flagsZ, flagsC, flagsV
+		}
+		if rd == 15 {
+			m.jump(result)
+			return nil
+		}
+		m.Regs[rd] = result
+	case "LSL", "LSR", "ASR", "ROR":
+		if len(args) < 2 {
+			return fmt.Errorf("missing operand")
+		}
+		rd, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		rn := rd
+		amountArg := args[1]
+		if len(args) > 2 {
+			rn, err = parseRegister(args[1])
+			if err != nil {
+				return err
+			}
+			amountArg = args[2]
+		}
+		amount, _, err := m.operand2([]string{amountArg})
+		if err != nil {
+			return err
+		}
+		result, carry := m.shift(inst.Op, m.reg(rn), amount&0xFF)
+		m.Regs[rd] = result
+		if inst.SetFlags {
+			m.setNZ(result)
+			m.C = carry
+		}
+	case "MUL":
+		if len(args) < 3 {
+			return fmt.Errorf("missing operand")
+		}
+		registers := make([]int, 3)
+		for i := range registers {
+			r, err := parseRegister(args[i])
+			if err != nil {
+				return err
+			}
+			registers[i] = r
+		}
+		result := m.reg(registers[1]) * m.reg(registers[2])
+		m.Regs[registers[0]] = result
+		if inst.SetFlags {
+			m.setNZ(result)
+		}
+	case "CMP", "CMN", "TST", "TEQ":
+		if len(args) < 2 {
+			return fmt.Errorf("missing operand")
+		}
+		rn, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		a := m.reg(rn)
+		b, carry, err := m.dataOperand(args[1:])
+		if err != nil {
+			return err
+		}
+		switch inst.Op {
+		case "CMP":
+			m.add(a, ^b, 1)
+		case "CMN":
+			m.add(a, b, 0)
+		case "TST":
+			m.setNZ(a & b)
+			m.C = carry
+		case "TEQ":
+			m.setNZ(a ^ b)
+			m.C = carry
+		}
+	case "LDR", "STR", "LDRB", "STRB":
+		if len(args) < 2 {
+			return fmt.Errorf("missing operand")
+		}
+		rd, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(args[1], "=") {
+			if inst.Op != "LDR" {
+				return fmt.Errorf("literal only allowed with LDR")
+			}
+			literal := strings.TrimSpace(args[1][1:])
+			if addr, ok := m.labels[strings.ToLower(literal)]; ok {
+				m.Regs[rd] = addr
+				return nil
+			}
+			n, err := parseNumber(literal)
+			if err != nil {
+				return err
+			}
+			m.Regs[rd] = uint32(n)
+			return nil
+		}
+		addr, err := m.address(args[1:])
+		if err != nil {
+			return err
+		}
+		switch inst.Op {
+		case "LDR":
+			value := m.load32(addr)
+			if rd == 15 {
+				m.jump(value)
+				return nil
+			}
+			m.Regs[rd] = value
+		case "LDRB":
+			m.Regs[rd] = m.load8(addr)
+		case "STR":
+			m.store32(addr, m.reg(rd))
+		case "STRB":
+			m.store8(addr, m.reg(rd))
+		}
+	case "STMFD", "STMDB", "PUSH":
+		base, list, writeBack, err := multipleArgs(inst)
+		if err != nil {
+			return err
+		}
+		addr := m.reg(base) - uint32(4*len(list))
+		for i, r := range list {
+			value := m.reg(r)
+			if r == 15 {
+				value = m.Regs[15] + 4
+			}
+			m.store32(addr+uint32(4*i), value)
+		}
+		if writeBack {
+			m.Regs[base] = addr
+		}
+	case "LDMFD", "LDMIA", "POP":
+		base, list, writeBack, err := multipleArgs(inst)
+		if err != nil {
+			return err
+		}
+		addr := m.reg(base)
+		jumpTo := int64(-1)
+		for i, r := range list {
+			value := m.load32(addr + uint32(4*i))
+			if r == 15 {
+				jumpTo = int64(value)
+				continue
+			}
+			m.Regs[r] = value
+		}
+		if writeBack {
+			m.Regs[base] = addr + uint32(4*len(list))
+		}
+		if jumpTo >= 0 {
+			m.jump(uint32(jumpTo))
+		}
+	case "B", "BL":
+		if len(args) != 1 {
+			return fmt.Errorf("missing label")
+		}
+		label := strings.ToLower(args[0])
+		addr, ok := m.labels[label]
+		if !ok {
+			return fmt.Errorf("unknown label %q", args[0])
+		}
+		if inst.Op == "BL" {
+			m.Regs[14] = m.Regs[15] + 4
+			if label == "println" {
+				m.output.WriteString(m.readString(m.Regs[0]))
+			}
+		}
+		m.jump(addr)
+	case "BX":
+		if len(args) != 1 {
+			return fmt.Errorf("missing register")
+		}
+		r, err := parseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		m.jump(m.reg(r))
+	default:
+		return fmt.Errorf("unknown instruction %s", inst.Op)
+	}
+	return nil
+}
+
+func multipleArgs(inst Instruction) (int, []int, bool, error) {
+	args := inst.Args
+	if inst.Op == "PUSH" || inst.Op == "POP" {
+		list, err := parseRegisterList(strings.Join(args, ", "))
+		return 13, list, true, err
+	}
+	if len(args) < 2 {
+		return 0, nil, false, fmt.Errorf("missing operand")
+	}
+	baseArg := strings.TrimSpace(args[0])
+	writeBack := strings.HasSuffix(baseArg, "!")
+	base, err := parseRegister(strings.TrimSuffix(baseArg, "!"))
+	if err != nil {
+		return 0, nil, false, err
+	}
+	list, err := parseRegisterList(strings.Join(args[1:], ", "))
+	return base, list, writeBack, err
+}
+
+// Emulate runs the assembly text and returns what was printed by the program.
+func Emulate(text string) (string, error) {
+	m, err := NewMachine(text)
+	if err != nil {
+		return "", err
+	}
+	err = m.Run()
+	return m.Output(), err
+}
@@ -0,0 +1,14 @@
+package asm
+
+import "math/bits"
+
+// IsEncodableImmediate reports whether the value can be the immediate operand of a data processing instruction:
+// an 8-bit value rotated right by an even amount.
+func IsEncodableImmediate(value uint32) bool {
+	for rotation := 0; rotation < 32; rotation += 2 {
+		if bits.RotateLeft32(value, rotation) <= 0xFF {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package asm
+
+import (
+	"strings"
+)
+
+// Instruction is one line of the VisUAL assembly produced by the compiler.
+// A line can hold a label, an instruction (or a directive such as FILL/END) and a comment,
+// any of which may be empty.
+type Instruction struct {
+	Label    string
+	Op       string // Upper case mnemonic without condition and S suffix (e.g. ADD, LDRB, STMFD, FILL)
+	Cond     string // Condition suffix (e.g. EQ, NE), empty for always
+	SetFlags bool   // S suffix
+	Args     []string
+	Comment  string
+}
+
+var conditions = []string{"EQ", "NE", "CS", "HS", "CC", "LO", "MI", "PL", "VS", "VC", "HI", "LS", "GE", "LT", "GT", "LE", "AL"}
+
+// dataOps are the instructions accepting the S suffix
+var dataOps = map[string]struct{}{
+	"MOV": {}, "MVN": {}, "ADD": {}, "ADC": {}, "SUB": {}, "SBC": {}, "RSB": {}, "RSC": {},
+	"AND": {}, "ORR": {}, "EOR": {}, "BIC": {}, "LSL": {}, "LSR": {}, "ASR": {}, "ROR": {}, "MUL": {},
+}
+
+var otherOps = map[string]struct{}{
+	"CMP": {}, "CMN": {}, "TST": {}, "TEQ": {},
+	"LDR": {}, "STR": {}, "LDRB": {}, "STRB": {},
+	"STMFD": {}, "LDMFD": {}, "STMDB": {}, "LDMIA": {}, "STMIA": {}, "LDMDB": {}, "STMED": {}, "LDMED": {},
+	"STMEA": {}, "LDMEA": {}, "STMFA": {}, "LDMFA": {}, "PUSH": {}, "POP": {},
+	"B": {}, "BL": {}, "BX": {},
+}
+
+var directives = map[string]struct{}{
+	"FILL": {}, "DCD": {}, "END": {},
+}
+
+func isCondition(s string) bool {
+	for _, c := range conditions {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMnemonic splits a mnemonic like BLE, ADDCS or LSRS into its operation, condition and S flag.
+func parseMnemonic(word string) (op, cond string, setFlags bool, ok bool) {
+	upper := strings.ToUpper(word)
+	if _, isDirective := directives[upper]; isDirective {
+		return upper, "", false, true
+	}
+	// Try every prefix, longest first, the remaining part must be a valid suffix
+	for i := len(upper); i > 0; i-- {
+		base := upper[:i]
+		rest := upper[i:]
+		_, isData := dataOps[base]
+		_, isOther := otherOps[base]
+		if !isData && !isOther {
+			continue
+		}
+		s := false
+		if isData && strings.HasSuffix(rest, "S") && (len(rest) == 1 || len(rest) == 3) {
+			s = true
+			rest = rest[:len(rest)-1]
+		}
+		if rest == "" {
+			return base, "", s, true
+		}
+		if isCondition(rest) {
+			return base, rest, s, true
+		}
+	}
+	return "", "", false, false
+}
+
+// splitArgs splits the operands at the commas that are not inside brackets or braces.
+func splitArgs(s string) []string {
+	var args []string
+	depth := 0
+	current := ""
+	for _, r := range s {
+		switch r {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(current))
+				current = ""
+				continue
+			}
+		}
+		current += string(r)
+	}
+	if strings.TrimSpace(current) != "" {
+		args = append(args, strings.TrimSpace(current))
+	}
+	return args
+}
+
+// ParseLine reads a single line of assembly.
+func ParseLine(line string) Instruction {
+	var inst Instruction
+	if index := strings.Index(line, ";"); index != -1 {
+		inst.Comment = strings.TrimSpace(line[index+1:])
+		line = line[:index]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return inst
+	}
+
+	startsWithSpace := line[0] == ' ' || line[0] == '\t'
+	if !startsWithSpace {
+		op, _, _, isOp := parseMnemonic(fields[0])
+		// A label can be named like an instruction (e.g. mul), it is then followed by another instruction
+		secondIsOp := false
+		if len(fields) > 1 && op != "B" && op != "BL" && op != "BX" {
+			_, _, _, secondIsOp = parseMnemonic(fields[1])
+		}
+		if !isOp || secondIsOp {
+			inst.Label = fields[0]
+			line = strings.TrimSpace(line)[len(fields[0]):]
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return inst
+	}
+
+	op, cond, s, _ := parseMnemonic(fields[0])
+	if op == "" {
+		// Unknown mnemonic, keep it as is
+		op = strings.ToUpper(fields[0])
+	}
+	inst.Op = op
+	inst.Cond = cond
+	inst.SetFlags = s
+	rest := strings.TrimSpace(line)
+	rest = strings.TrimSpace(rest[len(fields[0]):])
+	inst.Args = splitArgs(rest)
+	return inst
+}
+
+// Parse reads a whole assembly text, one Instruction per line.
+func Parse(text string) []Instruction {
+	lines := strings.Split(text, "\n")
+	instructions := make([]Instruction, 0, len(lines))
+	for _, line := range lines {
+		instructions = append(instructions, ParseLine(line))
+	}
+	return instructions
+}
+
+// Mnemonic returns the full mnemonic of the instruction (operation, S flag and condition).
+func (i Instruction) Mnemonic() string {
+	mnemonic := i.Op
+	if i.SetFlags {
+		mnemonic += "S"
+	}
+	return mnemonic + i.Cond
+}
+
+// IsEmpty reports whether the line has neither a label nor an instruction.
+func (i Instruction) IsEmpty() bool {
+	return i.Label == "" && i.Op == ""
+}
+
+func (i Instruction) String() string {
+	str := i.Label
+	if i.Op != "" {
+		if str != "" {
+			str += " "
+		}
+		str += i.Mnemonic()
+		if len(i.Args) > 0 {
+			str += " " + strings.Join(i.Args, ", ")
+		}
+	}
+	if i.Comment != "" {
+		if str != "" {
+			str += " "
+		}
+		str += "; " + i.Comment
+	}
+	return str
+}
+
+// Format writes back the instructions as assembly text.
+func Format(instructions []Instruction) string {
+	var builder strings.Builder
+	for _, inst := range instructions {
+		builder.WriteString(inst.String())
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
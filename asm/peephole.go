@@ -0,0 +1,220 @@
+package asm
+
+import (
+	"strings"
+)
+
+// Optimize runs the peephole optimiser on the instructions until no more pattern matches.
+//
+// The patterns are:
+//   - ADD/SUB Rn, Rn, #0 and MOV Rn, Rn are removed
+//   - STR Ra, [SP] followed by LDR Rb, [SP] becomes STR Ra, [SP] followed by MOV Rb, Ra (or nothing if Ra = Rb)
+//   - MOV Ra, Rb followed by MOV Rb, Ra loses the second move, and the first one too when Ra is R9
+//     since R9 only saves the frame pointer while walking the dynamic links
+//   - SUB SP, SP, #n directly followed by ADD SP, SP, #n (or the opposite) is removed
+//   - B to the next line is removed
+//
+// A store to a released stack slot is kept since the generated code can still read below SP.
+// Only unconditional instructions are changed and an instruction having a label is never merged
+// with the previous one since it can be reached by a branch.
+func Optimize(instructions []Instruction) []Instruction {
+	result := make([]Instruction, len(instructions))
+	copy(result, instructions)
+	for {
+		changed := false
+		result, changed = peephole(result)
+		if !changed {
+			return result
+		}
+	}
+}
+
+// OptimizeText runs the peephole optimiser on assembly text.
+func OptimizeText(text string) string {
+	return Format(Optimize(Parse(text)))
+}
+
+// canonicalRegister gives the same name to the aliases of a register.
+func canonicalRegister(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "SP":
+		return "R13"
+	case "LR":
+		return "R14"
+	case "PC":
+		return "R15"
+	}
+	return s
+}
+
+func isRegister(s string) bool {
+	_, err := parseRegister(s)
+	return err == nil
+}
+
+func isPlain(inst Instruction) bool {
+	return inst.Op != "" && inst.Cond == "" && !inst.SetFlags
+}
+
+// isTopOfStack reports whether the memory operand is [SP] or [SP, #0].
+func isTopOfStack(args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+	mem := strings.TrimSpace(args[1])
+	if !strings.HasPrefix(mem, "[") || !strings.HasSuffix(mem, "]") {
+		return false
+	}
+	parts := splitArgs(mem[1 : len(mem)-1])
+	if canonicalRegister(parts[0]) != "R13" {
+		return false
+	}
+	if len(parts) == 1 {
+		return true
+	}
+	n, err := parseNumber(parts[1])
+	return len(parts) == 2 && err == nil && n == 0
+}
+
+// stackAdjustment returns n for ADD SP, SP, #n and -n for SUB SP, SP, #n.
+func stackAdjustment(inst Instruction) (int64, bool) {
+	if !isPlain(inst) || (inst.Op != "ADD" && inst.Op != "SUB") || len(inst.Args) != 3 {
+		return 0, false
+	}
+	if canonicalRegister(inst.Args[0]) != "R13" || canonicalRegister(inst.Args[1]) != "R13" {
+		return 0, false
+	}
+	if !strings.HasPrefix(inst.Args[2], "#") {
+		return 0, false
+	}
+	n, err := parseNumber(inst.Args[2])
+	if err != nil {
+		return 0, false
+	}
+	if inst.Op == "SUB" {
+		return -n, true
+	}
+	return n, true
+}
+
+// isUseless reports whether the instruction has no effect.
+func isUseless(inst Instruction) bool {
+	if !isPlain(inst) {
+		return false
+	}
+	switch inst.Op {
+	case "ADD", "SUB":
+		if len(inst.Args) != 3 || canonicalRegister(inst.Args[0]) != canonicalRegister(inst.Args[1]) {
+			return false
+		}
+		n, err := parseNumber(inst.Args[2])
+		return strings.HasPrefix(inst.Args[2], "#") && err == nil && n == 0
+	case "MOV":
+		return len(inst.Args) == 2 && isRegister(inst.Args[1]) && canonicalRegister(inst.Args[0]) == canonicalRegister(inst.Args[1])
+	}
+	return false
+}
+
+func isRegisterMove(inst Instruction) bool {
+	return isPlain(inst) && inst.Op == "MOV" && len(inst.Args) == 2 && isRegister(inst.Args[0]) && isRegister(inst.Args[1])
+}
+
+// next returns the index of the next instruction after i, skipping the comments.
+// It returns -1 if there is none or if a label is found before.
+func next(instructions []Instruction, i int) int {
+	for j := i + 1; j < len(instructions); j++ {
+		if instructions[j].Label != "" {
+			return -1
+		}
+		if instructions[j].Op != "" {
+			return j
+		}
+	}
+	return -1
+}
+
+// branchesToNextLine reports whether the instruction at i is a branch to the label of the next instruction.
+func branchesToNextLine(instructions []Instruction, i int) bool {
+	inst := instructions[i]
+	if inst.Op != "B" || inst.Cond != "" || len(inst.Args) != 1 {
+		return false
+	}
+	target := strings.ToLower(inst.Args[0])
+	for j := i + 1; j < len(instructions); j++ {
+		if strings.ToLower(instructions[j].Label) == target {
+			return true
+		}
+		if instructions[j].Op != "" {
+			return false
+		}
+	}
+	return false
+}
+
+// remove drops the instruction at i, its label is kept on its own line.
+func remove(instructions []Instruction, i int) {
+	instructions[i] = Instruction{Label: instructions[i].Label}
+}
+
+// peephole runs one pass over the instructions.
+func peephole(instructions []Instruction) ([]Instruction, bool) {
+	changed := false
+	for i := 0; i < len(instructions); i++ {
+		inst := instructions[i]
+		if inst.Op == "" {
+			continue
+		}
+		if isUseless(inst) || branchesToNextLine(instructions, i) {
+			remove(instructions, i)
+			changed = true
+			continue
+		}
+
+		j := next(instructions, i)
+		if j == -1 {
+			continue
+		}
+		following := instructions[j]
+
+		switch {
+		case isPlain(inst) && inst.Op == "STR" && isTopOfStack(inst.Args) && isPlain(following) && following.Op == "LDR" && isTopOfStack(following.Args):
+			// The value is still in the register
+			if canonicalRegister(inst.Args[0]) == canonicalRegister(following.Args[0]) {
+				remove(instructions, j)
+			} else {
+				instructions[j] = Instruction{Op: "MOV", Args: []string{following.Args[0], inst.Args[0]}, Comment: following.Comment}
+			}
+			changed = true
+		case isRegisterMove(inst) && isRegisterMove(following) &&
+			canonicalRegister(inst.Args[0]) == canonicalRegister(following.Args[1]) &&
+			canonicalRegister(inst.Args[1]) == canonicalRegister(following.Args[0]):
+			remove(instructions, j)
+			if canonicalRegister(inst.Args[0]) == "R9" {
+				remove(instructions, i)
+			}
+			changed = true
+		default:
+			first, ok1 := stackAdjustment(inst)
+			second, ok2 := stackAdjustment(following)
+			if ok1 && ok2 && first == -second {
+				remove(instructions, i)
+				remove(instructions, j)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return instructions, false
+	}
+
+	// Drop the lines emptied by the pass
+	result := make([]Instruction, 0, len(instructions))
+	for _, inst := range instructions {
+		if inst.IsEmpty() && inst.Comment == "" {
+			continue
+		}
+		result = append(result, inst)
+	}
+	return result, true
+}
@@ -0,0 +1,337 @@
+// Package ast declares the typed syntax tree of a compilation unit.
+// The tree is built by the parser from its graph, every node keeps the id of its graph node
+// so the scopes and symbols found by the semantic analysis can be looked up from the tree.
+package ast
+
+import "strconv"
+
+// Pos is a position in the source file, lines and columns start at 1
+type Pos struct {
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position is in the source, nodes added by the compiler have no position
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Before reports whether p is before q in the source
+func (p Pos) Before(q Pos) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Column < q.Column)
+}
+
+// Span is the source of a node, from the start of its first token to the end of its last token
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Node is implemented by every node of the tree
+type Node interface {
+	// ID is the node of the parser graph
+	ID() int
+	// Pos is the position used by the diagnostics about the node
+	Pos() Pos
+	Span() Span
+}
+
+// Expr is an expression
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Stmt is a statement of a body
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Decl is a declaration of a declarative part
+type Decl interface {
+	Node
+	declNode()
+}
+
+// Meta holds what every node has, it is embedded in the nodes
+type Meta struct {
+	NodeID   int
+	Position Pos
+	Extent   Span
+}
+
+func (m *Meta) ID() int {
+	return m.NodeID
+}
+
+func (m *Meta) Pos() Pos {
+	return m.Position
+}
+
+func (m *Meta) Span() Span {
+	return m.Extent
+}
+
+// File is the main procedure of the file
+type File struct {
+	Meta
+	Name  *Ident
+	Decls []Decl
+	Body  []Stmt
+	// EndName is nil when the name is omitted after end
+	EndName *Ident
+}
+
+// Declarations
+
+// SubprogramDecl is a procedure or a function
+type SubprogramDecl struct {
+	Meta
+	Name   *Ident
+	Params []*Param
+	// Result is the return type of a function, nil for a procedure
+	Result  *Ident
+	Decls   []Decl
+	Body    []Stmt
+	EndName *Ident
+}
+
+// IsFunction reports whether the subprogram returns a value
+func (d *SubprogramDecl) IsFunction() bool {
+	return d.Result != nil
+}
+
+type Mode int
+
+const (
+	// In is the mode of the parameters declared without mode
+	In Mode = iota
+	InOut
+)
+
+func (m Mode) String() string {
+	if m == InOut {
+		return "in out"
+	}
+	return "in"
+}
+
+// Param declares the parameters sharing a mode and a type
+type Param struct {
+	Meta
+	Names []*Ident
+	Mode  Mode
+	Type  *Ident
+}
+
+// VarDecl declares the variables sharing a type
+type VarDecl struct {
+	Meta
+	Names []*Ident
+	Type  *Ident
+	// Value is the initial value, nil if there is none
+	Value Expr
+}
+
+// TypeDecl is the incomplete declaration of a type (type T;)
+type TypeDecl struct {
+	Meta
+	Name *Ident
+}
+
+// AccessTypeDecl declares an access to Target
+type AccessTypeDecl struct {
+	Meta
+	Name   *Ident
+	Target *Ident
+}
+
+// RecordDecl declares a record type
+type RecordDecl struct {
+	Meta
+	Name   *Ident
+	Fields []*Field
+}
+
+// Field declares the fields of a record sharing a type
+type Field struct {
+	Meta
+	Names []*Ident
+	Type  *Ident
+}
+
+// Statements
+
+// AssignStmt is Target := Value
+type AssignStmt struct {
+	Meta
+	Target Expr
+	Value  Expr
+}
+
+// CallStmt is a call to a procedure
+type CallStmt struct {
+	Meta
+	Name *Ident
+	// Args is nil when the procedure is called without parentheses
+	Args []Expr
+}
+
+// ReturnStmt leaves the subprogram, Value is nil in a procedure
+type ReturnStmt struct {
+	Meta
+	Value Expr
+}
+
+type IfStmt struct {
+	Meta
+	Cond  Expr
+	Body  []Stmt
+	Elifs []*ElsifClause
+	// Else is nil when there is no else part
+	Else []Stmt
+}
+
+type ElsifClause struct {
+	Meta
+	Cond Expr
+	Body []Stmt
+}
+
+// ForStmt is for Var in [reverse] Low .. High loop Body end loop
+type ForStmt struct {
+	Meta
+	Var     *Ident
+	Reverse bool
+	Low     Expr
+	High    Expr
+	Body    []Stmt
+}
+
+type WhileStmt struct {
+	Meta
+	Cond Expr
+	Body []Stmt
+}
+
+// BadStmt is a statement the parser could not understand
+type BadStmt struct {
+	Meta
+}
+
+// Expressions
+
+type Ident struct {
+	Meta
+	// Name is the identifier as written in the source
+	Name string
+}
+
+type IntLit struct {
+	Meta
+	Value int
+}
+
+type CharLit struct {
+	Meta
+	Value byte
+}
+
+// StringLit is a string literal, Value holds the characters without the quotes
+type StringLit struct {
+	Meta
+	Value string
+}
+
+type BoolLit struct {
+	Meta
+	Value bool
+}
+
+type NullLit struct {
+	Meta
+}
+
+// BinaryExpr is X Op Y, Op is the operator of the parser graph (+ - * / rem and or "and then" "or else" = != < <= > >=)
+type BinaryExpr struct {
+	Meta
+	Op string
+	X  Expr
+	Y  Expr
+}
+
+// UnaryExpr is - X or not X
+type UnaryExpr struct {
+	Meta
+	Op string
+	X  Expr
+}
+
+// CallExpr is a call to a function
+type CallExpr struct {
+	Meta
+	Fun *Ident
+	// Args is nil when the function is called without parentheses
+	Args []Expr
+}
+
+// SelectorExpr is X.Sel, the access to a field of a record
+type SelectorExpr struct {
+	Meta
+	X   Expr
+	Sel *Ident
+}
+
+// NewExpr is new Type
+type NewExpr struct {
+	Meta
+	Type *Ident
+}
+
+// AttributeExpr is Prefix'Name, like Integer'First, or Prefix'Name(Args), like Character'Val(X).
+// The prefix is a type, or an object for S'Length.
+type AttributeExpr struct {
+	Meta
+	Prefix *Ident
+	Name   *Ident
+	// Args is nil when the attribute has no arguments
+	Args []Expr
+}
+
+// BadExpr is an expression the parser could not understand
+type BadExpr struct {
+	Meta
+}
+
+func (*SubprogramDecl) declNode() {}
+func (*VarDecl) declNode()        {}
+func (*TypeDecl) declNode()       {}
+func (*AccessTypeDecl) declNode() {}
+func (*RecordDecl) declNode()     {}
+
+func (*AssignStmt) stmtNode() {}
+func (*CallStmt) stmtNode()   {}
+func (*ReturnStmt) stmtNode() {}
+func (*IfStmt) stmtNode()     {}
+func (*ForStmt) stmtNode()    {}
+func (*WhileStmt) stmtNode()  {}
+func (*BadStmt) stmtNode()    {}
+
+func (*Ident) exprNode()         {}
+func (*IntLit) exprNode()        {}
+func (*CharLit) exprNode()       {}
+func (*StringLit) exprNode()     {}
+func (*BoolLit) exprNode()       {}
+func (*NullLit) exprNode()       {}
+func (*BinaryExpr) exprNode()    {}
+func (*UnaryExpr) exprNode()     {}
+func (*CallExpr) exprNode()      {}
+func (*SelectorExpr) exprNode()  {}
+func (*NewExpr) exprNode()       {}
+func (*AttributeExpr) exprNode() {}
+func (*BadExpr) exprNode()       {}
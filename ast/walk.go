@@ -0,0 +1,257 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func walkIdent(v Visitor, ident *Ident) {
+	if ident != nil {
+		Walk(v, ident)
+	}
+}
+
+func walkIdents(v Visitor, idents []*Ident) {
+	for _, ident := range idents {
+		Walk(v, ident)
+	}
+}
+
+func walkExprs(v Visitor, exprs []Expr) {
+	for _, expr := range exprs {
+		Walk(v, expr)
+	}
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+func walkDecls(v Visitor, decls []Decl) {
+	for _, decl := range decls {
+		Walk(v, decl)
+	}
+}
+
+// Walk traverses the tree in depth-first order, the children are visited in source order:
+// it starts by calling v.Visit(node), node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor w for each of the
+// non-nil children of node, followed by a call of w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		walkIdent(v, n.Name)
+		walkDecls(v, n.Decls)
+		walkStmts(v, n.Body)
+		walkIdent(v, n.EndName)
+
+	// Declarations
+	case *SubprogramDecl:
+		walkIdent(v, n.Name)
+		for _, param := range n.Params {
+			Walk(v, param)
+		}
+		walkIdent(v, n.Result)
+		walkDecls(v, n.Decls)
+		walkStmts(v, n.Body)
+		walkIdent(v, n.EndName)
+	case *Param:
+		walkIdents(v, n.Names)
+		walkIdent(v, n.Type)
+	case *VarDecl:
+		walkIdents(v, n.Names)
+		walkIdent(v, n.Type)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *TypeDecl:
+		walkIdent(v, n.Name)
+	case *AccessTypeDecl:
+		walkIdent(v, n.Name)
+		walkIdent(v, n.Target)
+	case *RecordDecl:
+		walkIdent(v, n.Name)
+		for _, field := range n.Fields {
+			Walk(v, field)
+		}
+	case *Field:
+		walkIdents(v, n.Names)
+		walkIdent(v, n.Type)
+
+	// Statements
+	case *AssignStmt:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+	case *CallStmt:
+		walkIdent(v, n.Name)
+		walkExprs(v, n.Args)
+	case *ReturnStmt:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *IfStmt:
+		Walk(v, n.Cond)
+		walkStmts(v, n.Body)
+		for _, elif := range n.Elifs {
+			Walk(v, elif)
+		}
+		walkStmts(v, n.Else)
+	case *ElsifClause:
+		Walk(v, n.Cond)
+		walkStmts(v, n.Body)
+	case *ForStmt:
+		walkIdent(v, n.Var)
+		Walk(v, n.Low)
+		Walk(v, n.High)
+		walkStmts(v, n.Body)
+	case *WhileStmt:
+		Walk(v, n.Cond)
+		walkStmts(v, n.Body)
+
+	// Expressions
+	case *BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+	case *UnaryExpr:
+		Walk(v, n.X)
+	case *CallExpr:
+		walkIdent(v, n.Fun)
+		walkExprs(v, n.Args)
+	case *SelectorExpr:
+		Walk(v, n.X)
+		walkIdent(v, n.Sel)
+	case *NewExpr:
+		walkIdent(v, n.Type)
+	case *AttributeExpr:
+		walkIdent(v, n.Prefix)
+		walkIdent(v, n.Name)
+		walkExprs(v, n.Args)
+
+	case *BadStmt, *BadExpr, *Ident, *IntLit, *CharLit, *StringLit, *BoolLit, *NullLit:
+		// nothing to do
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree in depth-first order: it starts by calling f(node), node must not be nil.
+// If f returns true, Inspect invokes f recursively for each of the non-nil children of node,
+// followed by a call of f(nil). Returning false prunes the subtree of node.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+type traversal struct {
+	pre  func(Node) bool
+	post func(Node)
+	// stack holds the nodes whose children are being visited
+	stack []Node
+}
+
+func (t *traversal) Visit(node Node) Visitor {
+	if node == nil {
+		last := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		if t.post != nil {
+			t.post(last)
+		}
+		return nil
+	}
+	if t.pre != nil && !t.pre(node) {
+		return nil
+	}
+	t.stack = append(t.stack, node)
+	return t
+}
+
+// Traverse traverses the tree in depth-first order calling pre before the children of each node
+// and post after them, either can be nil. If pre returns false the children of the node are skipped
+// and post is not called for it.
+func Traverse(node Node, pre func(Node) bool, post func(Node)) {
+	Walk(&traversal{pre: pre, post: post}, node)
+}
+
+// Replace puts new in place of the expression old in the tree under root, old is found by identity.
+// It reports whether old was found.
+func Replace(root Node, old, new Expr) bool {
+	replaced := false
+	Inspect(root, func(node Node) bool {
+		if replaced || node == nil {
+			return false
+		}
+		replaced = replaceChild(node, old, new)
+		return !replaced
+	})
+	return replaced
+}
+
+// replaceChild replaces old when it is a child of node
+func replaceChild(node Node, old, new Expr) bool {
+	swap := func(expr *Expr) bool {
+		if *expr == old {
+			*expr = new
+			return true
+		}
+		return false
+	}
+	swapAll := func(exprs []Expr) bool {
+		for i := range exprs {
+			if swap(&exprs[i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch n := node.(type) {
+	case *VarDecl:
+		return n.Value != nil && swap(&n.Value)
+	case *AssignStmt:
+		return swap(&n.Target) || swap(&n.Value)
+	case *CallStmt:
+		return swapAll(n.Args)
+	case *ReturnStmt:
+		return n.Value != nil && swap(&n.Value)
+	case *IfStmt:
+		return swap(&n.Cond)
+	case *ElsifClause:
+		return swap(&n.Cond)
+	case *ForStmt:
+		return swap(&n.Low) || swap(&n.High)
+	case *WhileStmt:
+		return swap(&n.Cond)
+	case *BinaryExpr:
+		return swap(&n.X) || swap(&n.Y)
+	case *UnaryExpr:
+		return swap(&n.X)
+	case *CallExpr:
+		return swapAll(n.Args)
+	case *SelectorExpr:
+		return swap(&n.X)
+	case *AttributeExpr:
+		return swapAll(n.Args)
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Attributes is
+   X : Integer := Integer'Last;
+   C : Character := Character'Val(65);
+   S : String := "hello";
+   B : Boolean := Boolean'First;
+
+   function Next(C : Character) return Character is
+   begin
+      if C = Character'Last then
+         return Character'First;
+      end if;
+      return Character'Succ(C);
+   end Next;
+
+begin
+   Put(Character'Val(66));
+   Put_Line(Integer'Image(X - Integer'Last + 5));
+   Put_Line(Integer'Image(Integer'First - Integer'First - 3));
+   Put_Line(Integer'Image(0));
+   Put_Line(Integer'Image(-7));
+   Put_Line(Integer'Image(Character'Pos(C)));
+   Put(Character'Succ(C));
+   Put(Character'Pred(C));
+   Put_Line(Character'Image(C));
+   Put_Line(Boolean'Image(B));
+   Put_Line(Boolean'Image(Boolean'Succ(B)));
+   Put_Line(Integer'Image(S'Length));
+   Put_Line(Integer'Image(Integer'Pred(Integer'Succ(41) + 1)));
+   for I in Integer'Val(1) .. Integer'Pos(3) loop
+      Put(Character'Val(Character'Pos('a') + I));
+   end loop;
+   New_Line;
+   if Next(Character'Last) = Character'First and Next(C) = 'B' then
+      Put_Line("wraps");
+   end if;
+   if Character'Pos(Character'Last) = 255 and Boolean'Last then
+      Put_Line("bounds");
+   end if;
+end Attributes;
@@ -0,0 +1,23 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Constants is
+
+   procedure PrintInt(N: Integer) is
+      C: Integer := N rem 10;
+   begin
+      if N > 9 then PrintInt(N / 10); end if;
+      Put(Character'Val(48 + C));
+   end;
+
+   x : integer;
+begin
+   x := 1000003;
+   printint(x); New_Line;
+   x := x - 1000000;
+   printint(x); New_Line;
+   if x < 70000 then printint(70000); New_Line; end if;
+   x := 65280 + 255;
+   printint(x); New_Line;
+   x := 16777216 / 65536;
+   printint(x); New_Line;
+end;
@@ -0,0 +1,16 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Division is
+   procedure Show(A: Integer; B: Integer) is
+   begin
+      Put(A / B); Put(' '); Put(A rem B); New_Line;
+   end;
+begin
+   Show(7, 2);
+   Show(-7, 2);
+   Show(7, -2);
+   Show(-7, -2);
+   Show(-2147483648, 10);
+   Show(2147483647, -1);
+   Show(6, 3);
+end;
@@ -0,0 +1,29 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Lengths is
+   Nul : String := Character'Image(Character'Val(0));
+   Empty : String;
+   Number : String := Integer'Image(-2147483647 - 1);
+
+   procedure Show(S : String) is
+   begin
+      Put(S'Length);
+      Put(':');
+      Put(S);
+      Put_Line("|");
+   end Show;
+
+begin
+   Show(Nul);
+   Show(Empty);
+   Show("");
+   Show(Number);
+   Show(Integer'Image(42));
+   Show(Character'Image('x'));
+   Show(Boolean'Image(Nul'Length = 3));
+   Empty := Nul;
+   Show(Empty);
+   Put(Nul);
+   Put(Character'Val(0));
+   Put_Line(Nul);
+end Lengths;
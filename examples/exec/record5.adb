@@ -0,0 +1,35 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Record5 is
+
+   type P is record N : Integer; T : String; end record;
+   type R is record A : Integer; Q : P; S : String; end record;
+
+   X, Y, Z, W : R;
+
+   procedure Compare(Left, Right : R) is
+   begin
+      if Left = Right then Put('*'); else Put('.'); end if;
+      if Left /= Right then Put('*'); else Put('.'); end if;
+   end;
+
+begin
+   X.S := Integer'Image(5);
+   Y.S := " 5";
+   Compare(X, Y);
+   X.Q.T := "ab";
+   Y.Q.T := Character'Image('a');
+   Compare(X, Y);
+   Y.Q.T := "'a'";
+   Compare(X, Y);
+   X.Q.T := Y.Q.T;
+   Compare(X, Y);
+   Y.S := "";
+   Compare(X, Y);
+   X.S := "";
+   Compare(X, Y);
+   W.S := "";
+   W.Q.T := "";
+   Compare(Z, W);
+   New_Line;
+end Record5;
@@ -0,0 +1,34 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Strings is
+   Greeting : String := "Hello, ""world""!";
+   Empty : String := "";
+
+   procedure Show(S : String) is
+   begin
+      Put("[");
+      Put(S);
+      Put_Line("]");
+   end Show;
+
+   function Pick(B : Boolean) return String is
+   begin
+      if B then
+         return "yes";
+      end if;
+      return "no";
+   end Pick;
+
+begin
+   Put_Line(Greeting);
+   Show("x");
+   Show(Empty);
+   Show("a longer string, longer than a few words");
+   Put(Pick(True));
+   Put(' ');
+   Put_Line(Pick(False));
+   Greeting := Pick(False);
+   Put_Line(Greeting);
+   Put_Line("");
+   Put_Line("Hello");
+end Strings;
@@ -0,0 +1,671 @@
+// Package generator writes random well-typed programs in the subset of Ada understood by the compiler.
+// The programs always terminate and their output does not depend on the evaluation order of the
+// operands, so they can be run through the different backends and compared.
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Config bounds the size of the generated programs
+type Config struct {
+	Seed int64
+	// MaxDepth is the number of nested subprogram levels
+	MaxDepth int
+	// MaxSubprograms is the number of subprograms declared in each subprogram
+	MaxSubprograms int
+	// MaxStatements is the number of statements of each sequence of statements
+	MaxStatements int
+	// MaxExpression is the depth of the expressions
+	MaxExpression int
+	// NoAccess leaves out the access type and its nodes, for the backends without a heap
+	NoAccess bool
+}
+
+// DefaultConfig gives programs of a few dozen to a few hundred lines
+func DefaultConfig(seed int64) Config {
+	return Config{Seed: seed, MaxDepth: 2, MaxSubprograms: 2, MaxStatements: 4, MaxExpression: 3}
+}
+
+type kind int
+
+const (
+	integerKind kind = iota
+	characterKind
+	booleanKind
+	recordKind
+	accessKind
+)
+
+type adaType struct {
+	kind   kind
+	name   string
+	fields []field
+}
+
+type field struct {
+	name string
+	typ  *adaType
+}
+
+var (
+	integerType   = &adaType{kind: integerKind, name: "Integer"}
+	characterType = &adaType{kind: characterKind, name: "Character"}
+	booleanType   = &adaType{kind: booleanKind, name: "Boolean"}
+	// listType is the access type of the linked nodes declared by every program
+	listType = &adaType{kind: accessKind, name: "List"}
+)
+
+type variable struct {
+	name string
+	typ  *adaType
+	// assignable is false for the in parameters and the loop variables
+	assignable bool
+	// shared variables can be used by the nested subprograms, the others stay local to their subprogram
+	// so that an in out argument is never aliased
+	shared bool
+	// pending records are not initialised yet, they cannot be read
+	pending bool
+}
+
+type subprogram struct {
+	name       string
+	params     []param
+	returnType *adaType
+}
+
+type param struct {
+	name  string
+	typ   *adaType
+	inOut bool
+}
+
+// scope is a subprogram being generated
+type scope struct {
+	parent *scope
+	// function bodies are pure: they only assign their own variables and do not print
+	function    bool
+	returnType  *adaType
+	variables   []*variable
+	subprograms []*subprogram
+	// counters are the variables of the while loops, the other statements do not see them
+	counters []string
+	loops    int
+}
+
+type generator struct {
+	config  Config
+	random  *rand.Rand
+	records []*adaType
+	names   int
+	out     strings.Builder
+}
+
+// Generate writes a random program, the same config always gives the same program
+func Generate(config Config) string {
+	g := &generator{config: config, random: rand.New(rand.NewSource(config.Seed))}
+	g.out.WriteString("with Ada.Text_IO; use Ada.Text_IO;\n\n")
+	fmt.Fprintf(&g.out, "-- generated with seed %d\n", config.Seed)
+	g.out.WriteString("procedure Main is\n")
+	if !config.NoAccess {
+		g.out.WriteString("   type Node;\n   type List is access Node;\n")
+		g.out.WriteString("   type Node is record\n      Value: Integer;\n      Next: List;\n   end record;\n")
+	}
+	for i := 0; i < 1+g.random.Intn(2); i++ {
+		g.record()
+	}
+
+	main := &scope{}
+	declarations, body := g.subprogramBody(main, 1)
+	g.out.WriteString(declarations)
+	g.out.WriteString("begin\n")
+	g.out.WriteString(body)
+	g.out.WriteString("end Main;\n")
+	return g.out.String()
+}
+
+func (g *generator) name(prefix string) string {
+	g.names++
+	return prefix + strconv.Itoa(g.names)
+}
+
+// record declares a record type, the later records can contain the earlier ones
+func (g *generator) record() {
+	record := &adaType{kind: recordKind, name: g.name("R")}
+	candidates := append([]*adaType{integerType, characterType, booleanType}, g.records...)
+	for i := 0; i < 1+g.random.Intn(3); i++ {
+		record.fields = append(record.fields, field{name: g.name("F"), typ: candidates[g.random.Intn(len(candidates))]})
+	}
+	fmt.Fprintf(&g.out, "   type %s is record\n", record.name)
+	for _, f := range record.fields {
+		fmt.Fprintf(&g.out, "      %s: %s;\n", f.name, f.typ.name)
+	}
+	g.out.WriteString("   end record;\n")
+	g.records = append(g.records, record)
+}
+
+func (g *generator) randomType() *adaType {
+	switch n := g.random.Intn(10); {
+	case n < 4:
+		return integerType
+	case n < 6:
+		return characterType
+	case n < 7:
+		return booleanType
+	case n < 8 && !g.config.NoAccess:
+		return listType
+	}
+	return g.records[g.random.Intn(len(g.records))]
+}
+
+func indent(level int) string {
+	return strings.Repeat("   ", level)
+}
+
+// subprogramBody generates the declarations and the statements of a subprogram
+func (g *generator) subprogramBody(s *scope, level int) (string, string) {
+	var declarations strings.Builder
+	var records []*variable
+	count := 1 + g.random.Intn(4)
+	for i := 0; i < count; i++ {
+		v := &variable{name: g.name("V"), typ: g.randomType(), assignable: true, shared: g.random.Intn(2) == 0}
+		if i == 0 && s.returnType != nil && s.returnType.kind == recordKind {
+			// The returned record
+			v.typ = s.returnType
+		}
+		fmt.Fprintf(&declarations, "%s%s: %s", indent(level), v.name, v.typ.name)
+		if v.typ == listType {
+			// The accesses are never null so that they can always be followed
+			declarations.WriteString(" := new Node")
+		} else if v.typ.kind != recordKind {
+			// The other variables may not be initialised yet, a variable is never read before being assigned
+			literal, _ := g.literal(v.typ)
+			declarations.WriteString(" := " + literal)
+		}
+		declarations.WriteString(";\n")
+		s.variables = append(s.variables, v)
+		if v.typ.kind == recordKind {
+			records = append(records, v)
+		}
+	}
+	if level <= g.config.MaxDepth {
+		for i := 0; i < g.random.Intn(g.config.MaxSubprograms+1); i++ {
+			declarations.WriteString(g.subprogram(s, level))
+		}
+	}
+	// The records are initialised field by field, the nested subprograms are only called afterwards
+	for _, v := range records {
+		v.pending = true
+	}
+	var body strings.Builder
+	for _, v := range s.variables {
+		if v.typ.kind == recordKind && v.assignable {
+			g.initialise(&body, s, v.name, v.typ, level)
+			v.pending = false
+		}
+	}
+	body.WriteString(g.statements(s, level, g.config.MaxStatements))
+	if s.returnType != nil {
+		fmt.Fprintf(&body, "%sreturn %s;\n", indent(level), g.expression(s, s.returnType, g.config.MaxExpression))
+	}
+	for _, counter := range s.counters {
+		fmt.Fprintf(&declarations, "%s%s: Integer;\n", indent(level), counter)
+	}
+	return declarations.String(), body.String()
+}
+
+func (g *generator) initialise(body *strings.Builder, s *scope, name string, t *adaType, level int) {
+	for _, f := range t.fields {
+		if f.typ.kind == recordKind {
+			g.initialise(body, s, name+"."+f.name, f.typ, level)
+			continue
+		}
+		// No function is called, it could read a record that is not initialised yet
+		fmt.Fprintf(body, "%s%s.%s := %s;\n", indent(level), name, f.name, g.expression(s, f.typ, 0))
+	}
+}
+
+// subprogram declares a procedure or a function, some of them overload the name of a previous one
+func (g *generator) subprogram(parent *scope, level int) string {
+	sub := &subprogram{name: g.name("P")}
+	s := &scope{parent: parent}
+	if g.random.Intn(2) == 0 {
+		sub.name = g.name("F")
+		sub.returnType = g.randomType()
+		s.function = true
+		s.returnType = sub.returnType
+	}
+
+	var overloaded *subprogram
+	for _, other := range parent.subprograms {
+		if (other.returnType == nil) == (sub.returnType == nil) && len(other.params) > 0 && g.random.Intn(3) == 0 {
+			overloaded = other
+			sub.name = other.name
+			break
+		}
+	}
+	for i := 0; i < g.random.Intn(4); i++ {
+		p := param{name: g.name("A"), typ: g.randomType(), inOut: !s.function && g.random.Intn(3) == 0}
+		sub.params = append(sub.params, p)
+	}
+	if overloaded != nil {
+		// The first parameter tells the overloads apart
+		p := param{name: g.name("A"), typ: integerType}
+		if overloaded.params[0].typ == integerType {
+			p.typ = characterType
+		}
+		sub.params = append([]param{p}, sub.params...)
+	}
+	for _, p := range sub.params {
+		s.variables = append(s.variables, &variable{name: p.name, typ: p.typ, assignable: p.inOut})
+	}
+
+	var header strings.Builder
+	if sub.returnType == nil {
+		fmt.Fprintf(&header, "%sprocedure %s", indent(level), sub.name)
+	} else {
+		fmt.Fprintf(&header, "%sfunction %s", indent(level), sub.name)
+	}
+	if len(sub.params) > 0 {
+		var params []string
+		for _, p := range sub.params {
+			mode := ""
+			if p.inOut {
+				mode = "in out "
+			}
+			params = append(params, p.name+": "+mode+p.typ.name)
+		}
+		header.WriteString("(" + strings.Join(params, "; ") + ")")
+	}
+	if sub.returnType != nil {
+		header.WriteString(" return " + sub.returnType.name)
+	}
+	header.WriteString(" is\n")
+
+	declarations, body := g.subprogramBody(s, level+1)
+	// The subprogram is visible after its body so that it cannot be recursive
+	parent.subprograms = append(parent.subprograms, sub)
+	return header.String() + declarations + indent(level) + "begin\n" + body + indent(level) + "end " + sub.name + ";\n"
+}
+
+// visible returns the variables of the type that the scope can read
+func (s *scope) visible(t *adaType) []*variable {
+	var result []*variable
+	for current := s; current != nil; current = current.parent {
+		for _, v := range current.variables {
+			if v.typ == t && (current == s || v.shared) && !v.pending {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
+
+// assignable returns the variables of the scope that a statement can assign
+func (s *scope) assignable(t *adaType) []*variable {
+	var result []*variable
+	for _, v := range s.visible(t) {
+		if v.assignable && (!s.function || s.owns(v)) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (s *scope) owns(v *variable) bool {
+	for _, own := range s.variables {
+		if own == v {
+			return true
+		}
+	}
+	return false
+}
+
+// callable returns the subprograms visible from the scope, functions only call functions
+func (s *scope) callable(function bool) []*subprogram {
+	var result []*subprogram
+	for current := s; current != nil; current = current.parent {
+		for _, sub := range current.subprograms {
+			if (sub.returnType != nil) == function {
+				result = append(result, sub)
+			}
+		}
+	}
+	return result
+}
+
+func (g *generator) statements(s *scope, level int, count int) string {
+	var b strings.Builder
+	for i := 0; i < 1+g.random.Intn(count); i++ {
+		b.WriteString(g.statement(s, level))
+	}
+	return b.String()
+}
+
+func (g *generator) statement(s *scope, level int) string {
+	prefix := indent(level)
+	nested := level < 8 && s.loops < 2
+	for {
+		switch g.random.Intn(10) {
+		case 0, 1:
+			if statement, ok := g.assignment(s); ok {
+				return prefix + statement
+			}
+		case 2:
+			if !s.function {
+				t := []*adaType{integerType, characterType}[g.random.Intn(2)]
+				return prefix + "Put(" + g.expression(s, t, g.config.MaxExpression) + ");\n"
+			}
+		case 3:
+			if !s.function {
+				return prefix + "New_Line;\n"
+			}
+		case 4:
+			if statement, ok := g.call(s); ok {
+				return prefix + statement
+			}
+		case 5:
+			if nested {
+				return g.ifStatement(s, level)
+			}
+		case 6:
+			if nested {
+				variable := &variable{name: g.name("I"), typ: integerType}
+				from := g.random.Intn(5) - 1
+				to := from + g.random.Intn(4)
+				reverse := ""
+				if g.random.Intn(3) == 0 {
+					reverse = "reverse "
+				}
+				s.loops++
+				s.variables = append(s.variables, variable)
+				body := g.statements(s, level+1, 3)
+				s.variables = s.variables[:len(s.variables)-1]
+				s.loops--
+				return fmt.Sprintf("%sfor %s in %s%d .. %d loop\n%s%send loop;\n", prefix, variable.name, reverse, from, to, body, prefix)
+			}
+		case 7:
+			if nested {
+				counter := g.name("W")
+				s.counters = append(s.counters, counter)
+				s.loops++
+				body := g.statements(s, level+1, 3)
+				s.loops--
+				return fmt.Sprintf("%s%s := 0;\n%swhile %s < %d loop\n%s%s%s := %s + 1;\n%send loop;\n",
+					prefix, counter, prefix, counter, 1+g.random.Intn(3), body, indent(level+1), counter, counter, prefix)
+			}
+		case 8:
+			if s.returnType != nil && g.random.Intn(3) == 0 {
+				return fmt.Sprintf("%sif %s then\n%sreturn %s;\n%send if;\n", prefix, g.expression(s, booleanType, 2),
+					indent(level+1), g.expression(s, s.returnType, g.config.MaxExpression), prefix)
+			}
+		case 9:
+			if !s.function && g.random.Intn(2) == 0 {
+				t := []*adaType{integerType, characterType}[g.random.Intn(2)]
+				return prefix + "Put(" + g.expression(s, t, g.config.MaxExpression) + "); New_Line;\n"
+			}
+		}
+	}
+}
+
+func (g *generator) ifStatement(s *scope, level int) string {
+	prefix := indent(level)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sif %s then\n", prefix, g.expression(s, booleanType, g.config.MaxExpression))
+	b.WriteString(g.statements(s, level+1, 3))
+	for i := 0; i < g.random.Intn(3); i++ {
+		fmt.Fprintf(&b, "%selsif %s then\n", prefix, g.expression(s, booleanType, g.config.MaxExpression))
+		b.WriteString(g.statements(s, level+1, 3))
+	}
+	if g.random.Intn(2) == 0 {
+		b.WriteString(prefix + "else\n")
+		b.WriteString(g.statements(s, level+1, 3))
+	}
+	b.WriteString(prefix + "end if;\n")
+	return b.String()
+}
+
+// assignment assigns a variable, a field of a record or a field of a node
+func (g *generator) assignment(s *scope) (string, bool) {
+	var targets []string
+	var types []*adaType
+	for _, t := range append([]*adaType{integerType, characterType, booleanType, listType}, g.records...) {
+		for _, v := range s.assignable(t) {
+			targets = append(targets, v.name)
+			types = append(types, t)
+			if t.kind == recordKind {
+				for _, f := range t.fields {
+					targets = append(targets, v.name+"."+f.name)
+					types = append(types, f.typ)
+				}
+			}
+		}
+	}
+	if !s.function {
+		// The nodes are shared, a function cannot modify them
+		for _, v := range s.visible(listType) {
+			if !v.assignable {
+				// The semantic analysis rejects the assignment of a field through an in parameter
+				continue
+			}
+			targets = append(targets, v.name+".Value", v.name+".Next")
+			types = append(types, integerType, listType)
+		}
+	}
+	if len(targets) == 0 {
+		return "", false
+	}
+	n := g.random.Intn(len(targets))
+	return targets[n] + " := " + g.expression(s, types[n], g.config.MaxExpression) + ";\n", true
+}
+
+// call calls a procedure, the in out arguments are distinct variables of the scope
+func (g *generator) call(s *scope) (string, bool) {
+	if s.function {
+		return "", false
+	}
+	procedures := s.callable(false)
+	if len(procedures) == 0 {
+		return "", false
+	}
+	sub := procedures[g.random.Intn(len(procedures))]
+	used := map[*variable]bool{}
+	args := make([]string, len(sub.params))
+	for i, p := range sub.params {
+		if !p.inOut {
+			continue
+		}
+		var candidates []*variable
+		for _, v := range s.variables {
+			if v.typ == p.typ && v.assignable && !v.shared && !used[v] {
+				candidates = append(candidates, v)
+			}
+		}
+		if len(candidates) == 0 {
+			return "", false
+		}
+		v := candidates[g.random.Intn(len(candidates))]
+		used[v] = true
+		args[i] = v.name
+	}
+	for i, p := range sub.params {
+		if p.inOut {
+			continue
+		}
+		arg, ok := g.argument(s, p.typ, used)
+		if !ok {
+			return "", false
+		}
+		args[i] = arg
+	}
+	if len(args) == 0 {
+		return sub.name + ";\n", true
+	}
+	return sub.name + "(" + strings.Join(args, ", ") + ");\n", true
+}
+
+// argument is an expression given to an in parameter. A record can be passed by reference so it is
+// a variable of the scope that is neither shared, so that the callee cannot modify it, nor an in out argument.
+func (g *generator) argument(s *scope, t *adaType, used map[*variable]bool) (string, bool) {
+	if t.kind != recordKind {
+		return g.expression(s, t, g.config.MaxExpression), true
+	}
+	var candidates []*variable
+	for _, v := range s.variables {
+		if v.typ == t && !v.shared && !used[v] {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	return candidates[g.random.Intn(len(candidates))].name, true
+}
+
+// expression writes an expression of the type, the binary operations are always in parentheses.
+// There must be a visible variable of a record type.
+func (g *generator) expression(s *scope, t *adaType, depth int) string {
+	for i := 0; i < 20; i++ {
+		if e, ok := g.tryExpression(s, t, depth); ok {
+			return e
+		}
+	}
+	switch t.kind {
+	case accessKind:
+		return "new Node"
+	case recordKind:
+		if e, ok := g.variable(s, t); ok {
+			return e
+		}
+		panic("no value of type " + t.name)
+	}
+	e, _ := g.literal(t)
+	return e
+}
+
+func (g *generator) tryExpression(s *scope, t *adaType, depth int) (string, bool) {
+	n := g.random.Intn(10)
+	if depth <= 0 {
+		n = g.random.Intn(3)
+	}
+	switch n {
+	case 0:
+		return g.literal(t)
+	case 1, 2:
+		return g.variable(s, t)
+	case 3:
+		return g.functionCall(s, t, depth)
+	}
+
+	switch t.kind {
+	case integerKind:
+		switch n {
+		case 4, 5, 6:
+			op := []string{"+", "-", "*"}[g.random.Intn(3)]
+			return "(" + g.expression(s, t, depth-1) + " " + op + " " + g.expression(s, t, depth-1) + ")", true
+		case 7:
+			op := []string{"/", "rem"}[g.random.Intn(2)]
+			return "(" + g.expression(s, t, depth-1) + " " + op + " " + strconv.Itoa(1+g.random.Intn(9)) + ")", true
+		case 8:
+			return "(-" + g.expression(s, t, depth-1) + ")", true
+		}
+		if lists := s.visible(listType); len(lists) > 0 {
+			return lists[g.random.Intn(len(lists))].name + ".Value", true
+		}
+	case characterKind:
+		if n < 7 {
+			return "Character'Val(65 + (" + g.expression(s, integerType, depth-1) + " rem 26))", true
+		}
+	case booleanKind:
+		switch n {
+		case 4, 5:
+			op := []string{"<", "<=", ">", ">=", "=", "/="}[g.random.Intn(6)]
+			return "(" + g.expression(s, integerType, depth-1) + " " + op + " " + g.expression(s, integerType, depth-1) + ")", true
+		case 6:
+			op := []string{"and", "or", "and then", "or else"}[g.random.Intn(4)]
+			return "(" + g.expression(s, t, depth-1) + " " + op + " " + g.expression(s, t, depth-1) + ")", true
+		case 7:
+			return "(not " + g.expression(s, t, depth-1) + ")", true
+		case 8:
+			op := []string{"=", "/="}[g.random.Intn(2)]
+			return "(" + g.expression(s, characterType, depth-1) + " " + op + " " + g.expression(s, characterType, depth-1) + ")", true
+		default:
+			// Records and accesses are compared as a whole
+			candidates := g.records
+			if !g.config.NoAccess {
+				candidates = append([]*adaType{listType}, g.records...)
+			}
+			other := candidates[g.random.Intn(len(candidates))]
+			left, ok := g.variable(s, other)
+			if !ok {
+				return "", false
+			}
+			right, _ := g.variable(s, other)
+			return "(" + left + " = " + right + ")", true
+		}
+	case accessKind:
+		return "new Node", true
+	}
+	return "", false
+}
+
+func (g *generator) literal(t *adaType) (string, bool) {
+	switch t.kind {
+	case integerKind:
+		return strconv.Itoa(g.random.Intn(100)), true
+	case characterKind:
+		return "'" + string(rune('a'+g.random.Intn(26))) + "'", true
+	case booleanKind:
+		return []string{"True", "False"}[g.random.Intn(2)], true
+	}
+	return "", false
+}
+
+// variable reads a visible variable or a field of a visible record
+func (g *generator) variable(s *scope, t *adaType) (string, bool) {
+	var candidates []string
+	for _, v := range s.visible(t) {
+		candidates = append(candidates, v.name)
+	}
+	for _, record := range g.records {
+		for _, v := range s.visible(record) {
+			for _, f := range record.fields {
+				if f.typ == t {
+					candidates = append(candidates, v.name+"."+f.name)
+				}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	return candidates[g.random.Intn(len(candidates))], true
+}
+
+func (g *generator) functionCall(s *scope, t *adaType, depth int) (string, bool) {
+	var candidates []*subprogram
+	for _, f := range s.callable(true) {
+		if f.returnType == t {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 0 || depth <= 0 {
+		return "", false
+	}
+	f := candidates[g.random.Intn(len(candidates))]
+	if len(f.params) == 0 {
+		return f.name, true
+	}
+	var args []string
+	for _, p := range f.params {
+		arg, ok := g.argument(s, p.typ, nil)
+		if !ok {
+			return "", false
+		}
+		args = append(args, arg)
+	}
+	return f.name + "(" + strings.Join(args, ", ") + ")", true
+}
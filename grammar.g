@@ -143,7 +143,8 @@ primary_expr
     | 'not'
     | 'new'
     | ident '(' expr_plus_comma ')'
-    | 'character' ''' 'val' '(' expr ')' ;
+    | ident ''' ident
+    | ident ''' ident '(' expr_plus_comma ')' ;
 
 expr_plus_comma
     : expr expr_plus_comma2 ;
@@ -0,0 +1,235 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// End is the terminal that follows the start symbol, the end of the input
+const End = "$"
+
+// Production is a rule Left -> Right, an empty Right is an epsilon production.
+// Line is the line of the rule in the grammar file.
+type Production struct {
+	Left  string
+	Right []string
+	Line  int
+}
+
+func (p Production) String() string {
+	if len(p.Right) == 0 {
+		return p.Left + " -> ''"
+	}
+	return p.Left + " -> " + strings.Join(p.Right, " ")
+}
+
+// Grammar is a context free grammar, the symbols that are not the left side of a production are terminals.
+// In the yacc files the quoted terminals keep their quotes, so 'type' and the nonterminal type differ.
+type Grammar struct {
+	Name        string
+	Start       string
+	Productions []Production
+	// Nonterminals are in the order of their first production
+	Nonterminals []string
+	nonterminals map[string]struct{}
+}
+
+// IsTerminal reports whether the symbol is a terminal of the grammar
+func (g *Grammar) IsTerminal(symbol string) bool {
+	_, ok := g.nonterminals[symbol]
+	return !ok
+}
+
+// Terminals returns the terminals used by the productions
+func (g *Grammar) Terminals() []string {
+	seen := make(map[string]struct{})
+	var terminals []string
+	for _, p := range g.Productions {
+		for _, symbol := range p.Right {
+			if _, ok := seen[symbol]; !ok && g.IsTerminal(symbol) {
+				seen[symbol] = struct{}{}
+				terminals = append(terminals, symbol)
+			}
+		}
+	}
+	return terminals
+}
+
+func (g *Grammar) add(p Production) {
+	if g.nonterminals == nil {
+		g.nonterminals = make(map[string]struct{})
+	}
+	if _, ok := g.nonterminals[p.Left]; !ok {
+		g.nonterminals[p.Left] = struct{}{}
+		g.Nonterminals = append(g.Nonterminals, p.Left)
+	}
+	if g.Start == "" {
+		g.Start = p.Left
+	}
+	g.Productions = append(g.Productions, p)
+}
+
+// Parse reads a grammar written with one production per line, where two single quotes are epsilon:
+//
+//	expr -> or_expr
+//	expr -> ''
+//
+// or in the yacc syntax of grammar.g: "expr : or_expr | /*eps*/ ;". The first rule gives the start symbol.
+func Parse(name, text string) (*Grammar, error) {
+	var g *Grammar
+	var err error
+	if isArrowGrammar(text) {
+		g, err = parseArrows(text)
+	} else {
+		g, err = parseYacc(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	if len(g.Productions) == 0 {
+		return nil, fmt.Errorf("%s: no production", name)
+	}
+	g.Name = name
+	return g, nil
+}
+
+func isArrowGrammar(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[1] == "->" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseArrows(text string) (*Grammar, error) {
+	g := &Grammar{}
+	for i, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 || fields[1] != "->" {
+			return nil, fmt.Errorf("%d: expected a production like \"left -> right\", got %q", i+1, line)
+		}
+		p := Production{Left: fields[0], Line: i + 1}
+		for _, symbol := range fields[2:] {
+			if symbol != "''" {
+				p.Right = append(p.Right, symbol)
+			}
+		}
+		g.add(p)
+	}
+	return g, nil
+}
+
+// item is a word, a quoted terminal or one of : | ; of a yacc grammar
+type item struct {
+	text string
+	line int
+}
+
+func parseYacc(text string) (*Grammar, error) {
+	items, err := yaccItems(text)
+	if err != nil {
+		return nil, err
+	}
+	g := &Grammar{}
+	for i := 0; i < len(items); {
+		left := items[i]
+		if !isWord(left.text) || i+1 >= len(items) || items[i+1].text != ":" {
+			return nil, fmt.Errorf("%d: expected a rule like \"left : right ;\", got %q", left.line, left.text)
+		}
+		i += 2
+		p := Production{Left: left.text, Line: left.line}
+		for {
+			if i >= len(items) || items[i].text == ":" {
+				return nil, fmt.Errorf("%d: rule %s is not ended by ;", left.line, left.text)
+			}
+			symbol := items[i]
+			i++
+			if symbol.text != "|" && symbol.text != ";" {
+				p.Right = append(p.Right, symbol.text)
+				continue
+			}
+			g.add(p)
+			if symbol.text == ";" {
+				break
+			}
+			p = Production{Left: left.text, Line: symbol.line}
+			if i < len(items) {
+				p.Line = items[i].line
+			}
+		}
+	}
+	return g, nil
+}
+
+// yaccItems cuts the rules after %% into items, the comments are skipped
+func yaccItems(text string) ([]item, error) {
+	// The declarations before %%, like %token, are blanked to keep the line numbers
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "%%") {
+			for j := 0; j <= i; j++ {
+				lines[j] = ""
+			}
+		} else if strings.HasPrefix(line, "%") {
+			lines[i] = ""
+		}
+	}
+	text = strings.Join(lines, "\n")
+
+	var items []item
+	line := 1
+	for len(text) > 0 {
+		switch c := text[0]; {
+		case c == '\n':
+			line++
+			text = text[1:]
+		case c == ' ' || c == '\t' || c == '\r':
+			text = text[1:]
+		case strings.HasPrefix(text, "/*"):
+			end := strings.Index(text, "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unterminated comment", line)
+			}
+			line += strings.Count(text[:end], "\n")
+			text = text[end+2:]
+		case strings.HasPrefix(text, "'''"):
+			// The quote itself
+			items = append(items, item{text[:3], line})
+			text = text[3:]
+		case c == '\'':
+			end := strings.IndexAny(text[1:], "'\n")
+			if end < 0 || text[1+end] != '\'' {
+				return nil, fmt.Errorf("%d: unterminated terminal %s", line, strings.SplitN(text, "\n", 2)[0])
+			}
+			items = append(items, item{text[:end+2], line})
+			text = text[end+2:]
+		case c == ':' || c == '|' || c == ';':
+			items = append(items, item{text[:1], line})
+			text = text[1:]
+		default:
+			end := strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) })
+			if end < 0 {
+				end = len(text)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("%d: unexpected character %q", line, text[0])
+			}
+			items = append(items, item{text[:end], line})
+			text = text[end:]
+		}
+	}
+	return items, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+func isWord(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) }) < 0
+}
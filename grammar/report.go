@@ -0,0 +1,60 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteSets writes the nullable nonterminals and the FIRST and FOLLOW sets
+func (a *Analysis) WriteSets(w io.Writer) {
+	var nullable []string
+	for _, nonterminal := range a.Grammar.Nonterminals {
+		if a.Nullable[nonterminal] {
+			nullable = append(nullable, nonterminal)
+		}
+	}
+	fmt.Fprintf(w, "Nullable: %s\n", strings.Join(nullable, " "))
+	fmt.Fprintln(w, "FIRST")
+	for _, nonterminal := range a.Grammar.Nonterminals {
+		fmt.Fprintf(w, "  %s: %s\n", nonterminal, strings.Join(a.First[nonterminal].Sorted(), " "))
+	}
+	fmt.Fprintln(w, "FOLLOW")
+	for _, nonterminal := range a.Grammar.Nonterminals {
+		fmt.Fprintf(w, "  %s: %s\n", nonterminal, strings.Join(a.Follow[nonterminal].Sorted(), " "))
+	}
+}
+
+// WriteTable writes the parse table, one line per nonterminal and lookahead terminal
+func (a *Analysis) WriteTable(w io.Writer) {
+	fmt.Fprintln(w, "Parse table")
+	for _, nonterminal := range a.Grammar.Nonterminals {
+		row := a.Table[nonterminal]
+		fmt.Fprintf(w, "  %s\n", nonterminal)
+		for _, terminal := range a.terminals(row) {
+			productions := make([]string, len(row[terminal]))
+			for i, p := range row[terminal] {
+				productions[i] = a.Grammar.Productions[p].String()
+			}
+			fmt.Fprintf(w, "    %s: %s\n", terminal, strings.Join(productions, " | "))
+		}
+	}
+}
+
+// WriteConflicts writes the conflicts with their productions and their lines, and returns their number
+func (a *Analysis) WriteConflicts(w io.Writer) int {
+	conflicts := a.Conflicts()
+	for _, conflict := range conflicts {
+		kind := "FIRST/FIRST"
+		if conflict.FirstFollow {
+			kind = "FIRST/FOLLOW"
+		}
+		fmt.Fprintf(w, "%s conflict for %s on %s\n", kind, conflict.Nonterminal, conflict.Terminal)
+		for _, p := range conflict.Productions {
+			fmt.Fprintf(w, "  %s:%d: %s\n", a.Grammar.Name, p.Line, p)
+		}
+	}
+	fmt.Fprintf(w, "%s: %d nonterminals, %d terminals, %d productions, %d conflicts\n", a.Grammar.Name,
+		len(a.Grammar.Nonterminals), len(a.Grammar.Terminals()), len(a.Grammar.Productions), len(conflicts))
+	return len(conflicts)
+}
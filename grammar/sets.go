@@ -0,0 +1,161 @@
+package grammar
+
+import (
+	"sort"
+)
+
+// Set is a set of terminals
+type Set map[string]struct{}
+
+// Sorted returns the terminals of the set in order
+func (s Set) Sorted() []string {
+	terminals := make([]string, 0, len(s))
+	for terminal := range s {
+		terminals = append(terminals, terminal)
+	}
+	sort.Strings(terminals)
+	return terminals
+}
+
+// addAll adds the terminals of other and reports whether the set grew
+func (s Set) addAll(other Set) bool {
+	grew := false
+	for terminal := range other {
+		if _, ok := s[terminal]; !ok {
+			s[terminal] = struct{}{}
+			grew = true
+		}
+	}
+	return grew
+}
+
+// Analysis holds the sets of the nonterminals of a grammar and its LL(1) parse table
+type Analysis struct {
+	Grammar  *Grammar
+	Nullable map[string]bool
+	First    map[string]Set
+	Follow   map[string]Set
+	// Table gives the indexes of the productions to use for a nonterminal and a lookahead terminal,
+	// an entry with several productions is a conflict
+	Table map[string]map[string][]int
+}
+
+// Conflict is an entry of the parse table with several productions
+type Conflict struct {
+	Nonterminal string
+	Terminal    string
+	Productions []Production
+	// FirstFollow is set when a production is chosen because the terminal follows the nonterminal
+	FirstFollow bool
+}
+
+// Analyse computes the nullable nonterminals, the FIRST and FOLLOW sets and the parse table of the grammar
+func Analyse(g *Grammar) *Analysis {
+	a := &Analysis{Grammar: g, Nullable: make(map[string]bool), First: make(map[string]Set), Follow: make(map[string]Set)}
+	for _, nonterminal := range g.Nonterminals {
+		a.First[nonterminal] = make(Set)
+		a.Follow[nonterminal] = make(Set)
+	}
+
+	// The sets grow until they stay the same
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			first, nullable := a.FirstOf(p.Right)
+			if a.First[p.Left].addAll(first) {
+				changed = true
+			}
+			if nullable && !a.Nullable[p.Left] {
+				a.Nullable[p.Left] = true
+				changed = true
+			}
+		}
+	}
+
+	a.Follow[g.Start][End] = struct{}{}
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			for i, symbol := range p.Right {
+				if g.IsTerminal(symbol) {
+					continue
+				}
+				first, nullable := a.FirstOf(p.Right[i+1:])
+				if a.Follow[symbol].addAll(first) {
+					changed = true
+				}
+				if nullable && a.Follow[symbol].addAll(a.Follow[p.Left]) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	a.Table = make(map[string]map[string][]int)
+	for _, nonterminal := range g.Nonterminals {
+		a.Table[nonterminal] = make(map[string][]int)
+	}
+	for i, p := range g.Productions {
+		first, nullable := a.FirstOf(p.Right)
+		if nullable {
+			first.addAll(a.Follow[p.Left])
+		}
+		for terminal := range first {
+			a.Table[p.Left][terminal] = append(a.Table[p.Left][terminal], i)
+		}
+	}
+	return a
+}
+
+// FirstOf returns the terminals that can start a sequence of symbols and whether the sequence can be empty
+func (a *Analysis) FirstOf(symbols []string) (Set, bool) {
+	first := make(Set)
+	for _, symbol := range symbols {
+		if a.Grammar.IsTerminal(symbol) {
+			first[symbol] = struct{}{}
+			return first, false
+		}
+		first.addAll(a.First[symbol])
+		if !a.Nullable[symbol] {
+			return first, false
+		}
+	}
+	return first, true
+}
+
+// Conflicts returns the entries of the parse table with several productions, by nonterminal and terminal
+func (a *Analysis) Conflicts() []Conflict {
+	var conflicts []Conflict
+	for _, nonterminal := range a.Grammar.Nonterminals {
+		row := a.Table[nonterminal]
+		for _, terminal := range a.terminals(row) {
+			if len(row[terminal]) < 2 {
+				continue
+			}
+			conflict := Conflict{Nonterminal: nonterminal, Terminal: terminal}
+			for _, i := range row[terminal] {
+				p := a.Grammar.Productions[i]
+				conflict.Productions = append(conflict.Productions, p)
+				if first, _ := a.FirstOf(p.Right); !contains(first, terminal) {
+					conflict.FirstFollow = true
+				}
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// terminals returns the terminals of a row of the table in order
+func (a *Analysis) terminals(row map[string][]int) []string {
+	terminals := make(Set)
+	for terminal := range row {
+		terminals[terminal] = struct{}{}
+	}
+	return terminals.Sorted()
+}
+
+func contains(s Set, terminal string) bool {
+	_, ok := s[terminal]
+	return ok
+}
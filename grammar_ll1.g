@@ -156,12 +156,16 @@ primary_expr
     | '(' expr ')'
     | 'not'
     | 'new' ident
-    | ident primary_expr2
-    | 'character' ''' 'val' '(' expr ')' ;
+    | ident primary_expr2 ;
 
 primary_expr2
     : access2
-    | '(' expr_plus_comma ')' primary_expr3 ;
+    | '(' expr_plus_comma ')' primary_expr3
+    | ''' ident attribute_args ;
+
+attribute_args
+    : '(' expr_plus_comma ')'
+    | /*eps*/ ;
 
 primary_expr3
     : '.' ident access2
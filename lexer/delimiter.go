@@ -0,0 +1,47 @@
+package lexer
+
+import "gada/token"
+
+// compoundDelimiters maps the first character of a delimiter to the second characters
+// that make a compound delimiter with it
+var compoundDelimiters = map[rune]map[byte]token.Token{
+	'*': {'*': token.EXPON},
+	'/': {'=': token.NEQ},
+	'=': {'>': token.ARROW},
+	'.': {'.': token.DOUBLE_DOT},
+	':': {'=': token.ASSIGN},
+	'<': {'=': token.LEQ, '>': token.BOX, '<': token.LLABEL},
+	'>': {'=': token.GEQ, '>': token.RLABEL},
+}
+
+var simpleDelimiters = map[rune]token.Token{
+	'*': token.MUL,
+	'/': token.QUO,
+	'=': token.EQL,
+	'.': token.PERIOD,
+	':': token.COLON,
+	'<': token.LSS,
+	'>': token.GTR,
+}
+
+// readDelimiter returns the delimiter starting with first, the second character of a
+// compound delimiter is read so the span of the token covers both characters.
+// A compound delimiter cannot contain spaces: ": =" is a colon followed by an equal sign.
+func (l *Lexer) readDelimiter(first rune) token.Token {
+	if next := l.peekBytes(1); len(next) == 1 {
+		if tkn, ok := compoundDelimiters[first][next[0]]; ok {
+			l.readRune()
+			l.column++
+			return tkn
+		}
+	}
+	return simpleDelimiters[first]
+}
+
+// delimiterType returns the type of the token, as the other cases of Read do
+func delimiterType(tkn token.Token) string {
+	if token.IsSeparator(tkn) {
+		return "Separator"
+	}
+	return "Operator"
+}
@@ -0,0 +1,76 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ErrorCode is the kind of a lexical error
+type ErrorCode int
+
+const (
+	// UnterminatedString is a string literal without its closing quote on its line
+	UnterminatedString ErrorCode = iota + 1
+	// NewlineInCharacter is a character literal cut by the end of its line
+	NewlineInCharacter
+	// UnterminatedCharacter is a character literal cut by the end of the text
+	UnterminatedCharacter
+	// InvalidCharacter is a character that starts no token, or a character literal of several characters
+	InvalidCharacter
+	// InvalidNumber is a numeric literal that is malformed or out of range
+	InvalidNumber
+	// BadIdentifier is an identifier breaking the rules of Ada identifiers
+	BadIdentifier
+)
+
+var errorCodes = map[ErrorCode]string{
+	UnterminatedString:    "Unterminated string",
+	NewlineInCharacter:    "New line in character literal",
+	UnterminatedCharacter: "Unterminated character literal",
+	InvalidCharacter:      "Unexpected character",
+	InvalidNumber:         "Invalid numeric literal",
+	BadIdentifier:         "Invalid identifier",
+}
+
+func (c ErrorCode) String() string {
+	if text, ok := errorCodes[c]; ok {
+		return text
+	}
+	return "Lexical error " + strconv.Itoa(int(c))
+}
+
+// Error is a lexical error, the text of its Span gives no token
+type Error struct {
+	Code      ErrorCode
+	Span      Span
+	Beginning Position
+	End       Position
+	// Reason tells what is wrong when the code is not enough, like the digit that does not belong to the base
+	Reason string
+}
+
+func (e Error) Error() string {
+	text := strconv.Itoa(e.Beginning.Line) + ":" + strconv.Itoa(e.Beginning.Column) + " " + e.Code.String()
+	if e.Reason != "" {
+		text += ": " + e.Reason
+	}
+	return text
+}
+
+// fail records a lexical error on the text read since start, the lexer goes on after it
+func (l *Lexer) fail(code ErrorCode, beginning Position, start int, reason string) {
+	l.Errors = append(l.Errors, Error{Code: code, Span: Span{Start: start, End: l.offset}, Beginning: beginning, End: Position{l.line, l.column}, Reason: reason})
+}
+
+// Report formats an error as the compiler prints it: the beginning of its line is followed by its text in red
+func (l *Lexer) Report(e Error) string {
+	red := "\x1b[0;31m"
+	reset := "\x1b[0m"
+
+	before := strings.TrimLeft(l.File.Slice(Span{Start: l.File.LineStart(e.Beginning.Line), End: e.Span.Start}), " \t")
+	text := l.FileName + ":" + strconv.Itoa(e.Beginning.Line) + ":" + strconv.Itoa(e.Beginning.Column) + " " + e.Code.String() + ": " + before + red + l.File.Slice(e.Span) + reset
+	if e.Reason != "" {
+		text += " " + e.Reason
+	}
+	return text
+}
@@ -3,23 +3,32 @@ package lexer
 import (
 	"bufio"
 	"gada/token"
-	"github.com/charmbracelet/log"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
-	FileName    string
-	fullText    string
-	line        int
-	column      int
-	reader      *bufio.Reader
-	startedLine string
+	FileName string
+	// File is the text being read, the Span of a token locates it in the text
+	File   *SourceFile
+	line   int
+	column int
+	// offset is the offset of the next rune to read, lastSize the size in bytes of the last read rune
+	offset   int
+	lastSize int
+	// previous is the last token returned by NextToken
+	previous Token
+	// trivia is set by NewTriviaLexer, triviaEnd is the offset after the trivia of the previous token
+	trivia    bool
+	triviaEnd int
 
 	Tokens []Token
 	Lexi   []string
+	// Errors are the lexical errors, the text of an error gives no token
+	Errors []Error
 }
 
 type Position struct {
@@ -33,338 +42,276 @@ type Token struct {
 	Value     int
 	Beginning Position
 	End       Position
-}
-
-var logger *log.Logger
-
-func init() {
-	logger = log.New(os.Stderr)
+	// IntValue is the value of an INT literal, RealValue the value of a REAL literal
+	IntValue  int64
+	RealValue float64
+	// Span is the bytes of the token in the File of its Lexer, it is empty for the tokens added by the parser
+	Span Span
+	// Leading and Trailing are the comments and the whitespace around the token, read in trivia mode only.
+	// The trailing trivia ends with the line of the token.
+	Leading  []Trivia
+	Trailing []Trivia
 }
 
 func NewLexer(fileName, text string) *Lexer {
 	text = strings.Replace(text, "\r\n", "\n", -1)
-	reader := bufio.NewReader(strings.NewReader(text))
-	return &Lexer{reader: reader, FileName: fileName, fullText: text}
+	return &Lexer{FileName: fileName, File: NewSourceFile(fileName, text), line: 1, column: 1, Lexi: make([]string, 0)}
 }
 
 func (l *Lexer) readRune() (rune, int, error) {
-	r, size, err := l.reader.ReadRune()
-	if err != nil {
-		return r, size, err
+	if l.offset >= len(l.File.Text) {
+		return 0, 0, io.EOF
 	}
-	l.startedLine += string(r)
+	r, size := utf8.DecodeRuneInString(l.File.Text[l.offset:])
+	l.offset += size
+	l.lastSize = size
 	return r, size, nil
 }
 
 func (l *Lexer) unreadRune() error {
-	err := l.reader.UnreadRune()
-	if err != nil {
-		return err
+	if l.lastSize == 0 {
+		return bufio.ErrInvalidUnreadRune
 	}
-	l.startedLine = l.startedLine[:len(l.startedLine)-1]
+	l.offset -= l.lastSize
+	l.lastSize = 0
 	return nil
 }
 
-// Read reads the text and returns the list of Tokens and the associated lexicon.
+// lineBefore returns the beginning of the current line without its last n bytes
+func (l *Lexer) lineBefore(n int) string {
+	start := l.File.LineStart(l.line)
+	if n > l.offset-start {
+		return ""
+	}
+	return l.File.Text[start : l.offset-n]
+}
+
+// Read reads the rest of the text and returns the list of Tokens and the associated lexicon.
+// In trivia mode the EOF token is kept at the end of the list, it holds the trivia after the last token.
 func (l *Lexer) Read() ([]Token, []string) {
 	tokens := make([]Token, 0)
-	lexi := make([]string, 0)
-	l.column++
-	l.line++
-	position := 1
+	tkn := l.NextToken()
+	for ; tkn.Value != token.EOF; tkn = l.NextToken() {
+		tokens = append(tokens, tkn)
+	}
+	if l.trivia {
+		tokens = append(tokens, tkn)
+	}
+	l.Tokens = tokens
+	return tokens, l.Lexi
+}
+
+// emit returns a token read from the start offset, the next token may depend on it
+func (l *Lexer) emit(tkn Token, start int) Token {
+	tkn.Span = Span{Start: start, End: l.offset}
+	if l.trivia {
+		l.attachTrivia(&tkn)
+	}
+	l.previous = tkn
+	return tkn
+}
+
+// literal adds the word of a token to the lexicon and returns the token at its position
+func (l *Lexer) literal(tkn Token, word string, start int) Token {
+	l.Lexi = append(l.Lexi, word)
+	tkn.Position = len(l.Lexi)
+	return l.emit(tkn, start)
+}
+
+// NextToken reads the next token of the text, the words of the literals and of the errors are added to Lexi.
+// An EOF token is returned once the whole text is read.
+func (l *Lexer) NextToken() Token {
 	for {
 		beginPos := Position{l.line, l.column}
+		start := l.offset
 		r, _, err := l.readRune()
+		if err != nil {
+			return l.emit(Token{Value: token.EOF, Beginning: beginPos, End: beginPos}, start)
+		}
 		l.column++
-		if err == nil {
-			switch r {
-			case '\n':
-				l.line++
-				l.column = 1
-				l.startedLine = ""
-			case '+':
-				tokens = append(tokens, Token{Type: "Operator", Value: token.ADD, Beginning: beginPos, End: Position{l.line, l.column}})
-			case '-':
-				// comments are --
-				r, _, err := l.readRune()
-				l.column++
-				if err == nil {
-					// Check if it's a comment.
-					if r == '-' {
-						// Skip until the end of the line.
-						for {
-							r, _, err := l.readRune()
-							l.column++
-							if err == nil {
-								if r == '\n' {
-									l.unreadRune()
-									l.column--
-									break
-								}
-							} else {
-								break
-							}
-						}
-					} else {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.SUB, Beginning: beginPos, End: Position{l.line, l.column}})
-						l.unreadRune()
-						l.column--
-					}
-				}
-			case '*':
-				tokens = append(tokens, Token{Type: "Operator", Value: token.MUL, Beginning: beginPos, End: Position{l.line, l.column}})
-			case '/':
-				r, _, err := l.readRune()
-				l.column++
-				if err == nil {
-					if r == '=' {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.NEQ, Beginning: beginPos, End: Position{l.line, l.column}})
-					} else {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.QUO, Beginning: beginPos, End: Position{l.line, l.column}})
-						l.unreadRune()
-						l.column--
-					}
-				}
-			case '=':
-				tokens = append(tokens, Token{Type: "Operator", Value: token.EQL, Beginning: beginPos, End: Position{l.line, l.column}})
-			case '.':
-				tokens = append(tokens, Token{Type: "Operator", Value: token.PERIOD, Beginning: beginPos, End: Position{l.line, l.column}})
-			case ';':
-				tokens = append(tokens, Token{Type: "Separator", Value: token.SEMICOLON, Beginning: beginPos, End: Position{l.line, l.column}})
-			case ',':
-				tokens = append(tokens, Token{Type: "Separator", Value: token.COMMA, Beginning: beginPos, End: Position{l.line, l.column}})
-			case ':':
-				tokens = append(tokens, Token{Type: "Separator", Value: token.COLON, Beginning: beginPos, End: Position{l.line, l.column}})
-			case '(':
-				tokens = append(tokens, Token{Type: "Separator", Value: token.LPAREN, Beginning: beginPos, End: Position{l.line, l.column}})
-			case ')':
-				tokens = append(tokens, Token{Type: "Separator", Value: token.RPAREN, Beginning: beginPos, End: Position{l.line, l.column}})
-			case '>':
-				r, _, err := l.readRune()
-				l.column++
-				if err == nil {
-					if r == '=' {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.GEQ, Beginning: beginPos, End: Position{l.line, l.column}})
-					} else {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.GTR, Beginning: beginPos, End: Position{l.line, l.column}})
-						l.unreadRune()
-						l.column--
+		switch r {
+		case '\n':
+			l.line++
+			l.column = 1
+		case '+':
+			return l.emit(Token{Type: "Operator", Value: token.ADD, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case '-':
+			// comments are --
+			r, _, err := l.readRune()
+			l.column++
+			if err != nil {
+				continue
+			}
+			// Check if it's a comment.
+			if r == '-' {
+				// Skip until the end of the line.
+				for {
+					r, _, err := l.readRune()
+					l.column++
+					if err != nil {
+						break
 					}
-				}
-			case '<':
-				r, _, err := l.readRune()
-				l.column++
-				if err == nil {
-					if r == '=' {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.LEQ, Beginning: beginPos, End: Position{l.line, l.column}})
-					} else {
-						tokens = append(tokens, Token{Type: "Operator", Value: token.LSS, Beginning: beginPos, End: Position{l.line, l.column}})
+					if r == '\n' {
 						l.unreadRune()
 						l.column--
-					}
-				}
-			case '\'':
-				// Check if the keyword 'character' is present before.
-				if len(tokens) > 0 {
-					if tokens[len(tokens)-1].Value == token.IDENT && strings.ToLower(lexi[len(lexi)-1]) == "character" {
-						// change previous token to char
-						tokens[len(tokens)-1].Value = token.CHAR_TOK
-						// remove from lexicon
-						lexi = lexi[:len(lexi)-1]
-						position--
-						tokens = append(tokens, Token{Type: "Operator", Value: token.CAST, Beginning: beginPos, End: Position{l.line, l.column}})
 						break
 					}
 				}
-				// A char is a single character surrounded by single quotes.
-				r, _, err := l.readRune()
-				l.column++
-				if err == nil {
-					char := string(r)
-					r, _, err := l.readRune()
-					if err == nil {
-						l.column++
-						if r == '\'' {
-							tokens = append(tokens, Token{Type: "Literal", Position: position, Value: token.CHAR, Beginning: beginPos, End: Position{l.line, l.column}})
-							lexi = append(lexi, char)
-							position++
-						} else {
-							// Send an error
-							unexpected := char + string(r)
-							eofBreaked := true
-							for {
-								r, _, err := l.readRune()
-								//l.column
-								if err == nil {
-									l.column++
-									if r == '\'' {
-										eofBreaked = false
-										break
-									} else if r == '\n' {
-										l.logUnexpected(l, l.line, l.column, unexpected)
-										tokens = append(tokens, Token{Type: "ILLEGAL", Position: position, Value: token.ILLEGAL, Beginning: beginPos, End: Position{l.line, l.column}})
-										lexi = append(lexi, "Lexical error: new line in rune at line "+strconv.FormatInt(int64(l.line), 10)+" and column "+strconv.FormatInt(int64(l.column)-1, 10)+".")
-										position++
-										l.column--
-										l.unreadRune()
-										break
-									} else {
-										unexpected += string(r)
-									}
-								} else {
-									l.logUnexpected(l, l.line, l.column, unexpected)
-									tokens = append(tokens, Token{Type: "ILLEGAL", Position: position, Value: token.ILLEGAL, Beginning: beginPos, End: Position{l.line, l.column}})
-									lexi = append(lexi, "Lexical error: unexpected end of file at line "+strconv.FormatInt(int64(l.line), 10)+" and column "+strconv.FormatInt(int64(l.column)-1, 10)+".")
-									position++
-									break
-								}
-							}
-							if !eofBreaked {
-								l.logUnexpectedChar(l, l.line, l.column, unexpected)
-								tokens = append(tokens, Token{Type: "ILLEGAL", Position: position, Value: token.ILLEGAL, Beginning: beginPos, End: Position{l.line, l.column}})
-								lexi = append(lexi, "Lexical error: unexpected character '"+char+unexpected+"' at line "+strconv.FormatInt(int64(l.line), 10)+" between column "+strconv.FormatInt(int64(beginPos.Column), 10)+" and "+strconv.FormatInt(int64(l.column), 10)+".")
-								position++
-							}
-						}
-					}
-				}
-			case '"':
-				// A string is a sequence of characters surrounded by double quotes.
-				str := ""
-				for {
-					r, _, err := l.readRune()
-					l.column++
-					if err == nil {
-						if r == '"' {
-							break
-						} else {
-							str += string(r)
-						}
-					} else {
-						l.logUnexpected(l, l.line, l.column, str)
-						tokens = append(tokens, Token{Type: "ILLEGAL", Position: position, Value: token.ILLEGAL, Beginning: beginPos, End: Position{l.line, l.column}})
-						lexi = append(lexi, "Lexical error: unexpected end of file at line "+strconv.FormatInt(int64(l.line), 10)+" and column "+strconv.FormatInt(int64(l.column)-1, 10)+".")
-						position++
-						break
-					}
+				continue
+			}
+			l.unreadRune()
+			l.column--
+			return l.emit(Token{Type: "Operator", Value: token.SUB, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case '*', '/', '=', '.', ':', '<', '>':
+			tkn := l.readDelimiter(r)
+			return l.emit(Token{Type: delimiterType(tkn), Value: int(tkn), Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case ';':
+			return l.emit(Token{Type: "Separator", Value: token.SEMICOLON, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case ',':
+			return l.emit(Token{Type: "Separator", Value: token.COMMA, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case '(':
+			return l.emit(Token{Type: "Separator", Value: token.LPAREN, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case ')':
+			return l.emit(Token{Type: "Separator", Value: token.RPAREN, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+		case '\'':
+			// After a name the quote is the tick of an attribute (Integer'Image, S'Length):
+			// a character literal cannot follow an identifier or a closing parenthesis.
+			if isAttributePrefix(l.previous) {
+				return l.emit(Token{Type: "Operator", Value: token.TICK, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+			}
+			if tkn, ok := l.readCharacter(beginPos, start); ok {
+				return tkn
+			}
+		case '"':
+			// A string is a sequence of characters surrounded by double quotes on a single line.
+			str, terminated := l.readString()
+			if !terminated {
+				l.fail(UnterminatedString, beginPos, start, "a string ends on its line with \"")
+				continue
+			}
+			return l.literal(Token{Type: "Literal", Value: token.STRING, Beginning: beginPos, End: Position{l.line, l.column}}, str, start)
+		default:
+			switch {
+			case unicode.IsSpace(r):
+				continue
+			case isDecimalDigit(r):
+				text := l.readNumber(r)
+				kind, intValue, realValue, reason := parseNumber(text)
+				switch kind {
+				case token.INT:
+					tkn := Token{Type: "Literal", Value: token.INT, IntValue: intValue, Beginning: beginPos, End: Position{l.line, l.column}}
+					return l.literal(tkn, strconv.FormatInt(intValue, 10), start)
+				case token.REAL:
+					tkn := Token{Type: "Literal", Value: token.REAL, RealValue: realValue, Beginning: beginPos, End: Position{l.line, l.column}}
+					return l.literal(tkn, strconv.FormatFloat(realValue, 'g', -1, 64), start)
 				}
-				tokens = append(tokens, Token{Type: "Literal", Position: position, Value: token.STRING, Beginning: beginPos, End: Position{l.line, l.column}})
-				lexi = append(lexi, str)
-				position++
-			default:
-				if unicode.IsSpace(r) {
+				l.fail(InvalidNumber, beginPos, start, reason)
+				continue
+			case token.IsIdentifierStart(r):
+				name := l.readIdentifier(r)
+				if reason := token.CheckIdentifier(name); reason != "" {
+					l.fail(BadIdentifier, beginPos, start, reason)
 					continue
-				} else if unicode.IsDigit(r) {
-					number := string(r)
-					for {
-						r, _, err := l.readRune()
-						l.column++
-						if err == nil {
-							if unicode.IsDigit(r) {
-								number += string(r)
-								continue
-							} else {
-								l.unreadRune()
-								l.column--
-								break
-							}
-						} else {
-							break
-						}
-					}
-					tokens = append(tokens, Token{Type: "Literal", Position: position, Value: token.INT, Beginning: beginPos, End: Position{l.line, l.column}})
-					lexi = append(lexi, number)
-					position++
-				} else if unicode.IsLetter(r) {
-					name := string(r)
-					for {
-						r, _, err := l.readRune()
-						l.column++
-						if err == nil {
-							if token.CanBeIdentifier(r) {
-								name += string(r)
-								continue
-							} else {
-								//// Check if we have a lexical error.
-								//if !unicode.IsSpace(r) && !token.IsOperatorString(string(r)) {
-								//	println("Lexical error: unexpected character '" + string(r) + "' at line " + strconv.FormatInt(int64(l.line), 10) + " and column " + strconv.FormatInt(int64(l.column), 10) + ".")
-								//}
-								l.unreadRune()
-								l.column--
-								break
-							}
-						} else {
-							l.column--
-							break
-						}
-					}
-					if token.IsKeywordString(name) {
-						// Check if it is them rem operator
-						if name == "rem" {
-							tokens = append(tokens, Token{Type: "Operator", Value: token.REM, Beginning: beginPos, End: Position{l.line, l.column}})
-						} else {
-							tokens = append(tokens, Token{Type: "Keyword", Value: int(token.LookupIdent(name)), Beginning: beginPos, End: Position{l.line, l.column}})
-						}
-					} else {
-						tokens = append(tokens, Token{Type: "Literals", Position: position, Value: token.IDENT, Beginning: beginPos, End: Position{l.line, l.column}})
-						lexi = append(lexi, name)
-						position++
-					}
-				} else {
-					// Check if we have a lexical error.
-					if !unicode.IsSpace(r) {
-						l.logUnexpected(l, l.line, l.column, string(r))
-						tokens = append(tokens, Token{Type: "ILLEGAL", Position: position, Value: token.ILLEGAL, Beginning: beginPos, End: Position{l.line, l.column}})
-						lexi = append(lexi, "Lexical error: unexpected character '"+string(r)+"' at line "+strconv.FormatInt(int64(l.line), 10)+" and column "+strconv.FormatInt(int64(l.column)-1, 10)+".")
-						position++
-					}
 				}
+				if !token.IsKeywordString(name) {
+					return l.literal(Token{Type: "Literals", Value: token.IDENT, Beginning: beginPos, End: Position{l.line, l.column}}, name, start)
+				}
+				// Check if it is them rem operator
+				if token.FoldIdentifier(name) == "rem" {
+					return l.emit(Token{Type: "Operator", Value: token.REM, Beginning: beginPos, End: Position{l.line, l.column}}, start)
+				}
+				return l.emit(Token{Type: "Keyword", Value: int(token.LookupIdent(name)), Beginning: beginPos, End: Position{l.line, l.column}}, start)
 			}
-		} else {
-			if err.Error() != "EOF" {
-				panic(err)
-			}
-			break
+			l.fail(InvalidCharacter, beginPos, start, "")
+		}
+	}
+}
+
+// readCharacter reads a character literal after its opening quote, ok is false after a lexical error.
+// The newline ending a literal is not read.
+func (l *Lexer) readCharacter(beginPos Position, start int) (tkn Token, ok bool) {
+	var char rune
+	for length := 0; ; length++ {
+		r, _, err := l.readRune()
+		switch {
+		case err != nil:
+			l.fail(UnterminatedCharacter, beginPos, start, "")
+			return Token{}, false
+		case r == '\n':
+			l.unreadRune()
+			l.fail(NewlineInCharacter, beginPos, start, "")
+			return Token{}, false
+		}
+		l.column++
+		switch {
+		case length == 0:
+			// The character can be a quote: '''
+			char = r
+		case r != '\'':
+		case length == 1:
+			return l.literal(Token{Type: "Literal", Value: token.CHAR, Beginning: beginPos, End: Position{l.line, l.column}}, string(char), start), true
+		default:
+			l.fail(InvalidCharacter, beginPos, start, "a character literal holds a single character")
+			return Token{}, false
 		}
 	}
-	l.Tokens = tokens
-	l.Lexi = lexi
-	return tokens, lexi
 }
 
-func (l *Lexer) logUnexpectedChar(lexer *Lexer, line, column int, unexpected string) {
-	red := "\x1b[0;31m"
-	reset := "\x1b[0m"
+// readIdentifier reads the rest of an identifier starting with first
+func (l *Lexer) readIdentifier(first rune) string {
+	start := l.offset - utf8.RuneLen(first)
+	for {
+		r, _, err := l.readRune()
+		if err != nil {
+			break
+		}
+		if !token.CanBeIdentifier(r) {
+			l.unreadRune()
+			break
+		}
+		l.column++
+	}
+	return l.File.Text[start:l.offset]
+}
 
-	startedLine := l.startedLine[:len(l.startedLine)-len(unexpected)-2]
-	startedLine = strings.TrimLeft(startedLine, " ")
-	logger.Warn(lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column) + " Unexpected character: " + startedLine + red + "'" + unexpected + "'" + reset)
+// isAttributePrefix reports whether the token can end the prefix of an attribute
+func isAttributePrefix(tkn Token) bool {
+	return tkn.Value == token.IDENT || tkn.Value == token.RPAREN
 }
 
-func (l *Lexer) logUnexpected(lexer *Lexer, line, column int, unexpected string) {
-	red := "\x1b[0;31m"
-	reset := "\x1b[0m"
+// Word returns the word of the lexicon at a token position, the positions start at 1.
+// A token without word, like the ones added by the parser after an error, gives an empty word.
+func (l *Lexer) Word(position int) string {
+	if position < 1 || position > len(l.Lexi) {
+		return ""
+	}
+	return l.Lexi[position-1]
+}
 
-	startedLine := l.startedLine[:len(l.startedLine)-len(unexpected)]
-	startedLine = strings.TrimSpace(startedLine)
-	logger.Warn(lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column) + " Unexpected token: " + startedLine + red + unexpected + reset)
+// sourceLine returns the text of a line between two columns, the columns are clamped to the line
+func (l *Lexer) sourceLine(line int, minColumn int, maxColumn int) string {
+	if line < 1 || line > l.File.LineCount() {
+		return ""
+	}
+	start := l.File.Offset(Position{Line: line, Column: minColumn})
+	end := l.File.Offset(Position{Line: line, Column: maxColumn})
+	if start > end {
+		return ""
+	}
+	return l.File.Text[start:end]
 }
 
 func (l *Lexer) GetLineUpToToken(tkn Token) string {
-	line := tkn.Beginning.Line
-	maxColumn := tkn.Beginning.Column
-	return strings.TrimLeft(strings.Split(l.fullText, "\n")[line-1][:maxColumn-1], " ")
+	return strings.TrimLeft(l.sourceLine(tkn.Beginning.Line, 1, tkn.Beginning.Column), " ")
 }
 
 func (l *Lexer) GetLineUpToTokenIncluded(tkn Token) string {
-	line := tkn.Beginning.Line
-	maxColumn := tkn.End.Column
-	return strings.TrimLeft(strings.Split(l.fullText, "\n")[line-1][:maxColumn-1], " ")
+	return strings.TrimLeft(l.sourceLine(tkn.Beginning.Line, 1, tkn.End.Column), " ")
 }
 
 func (l *Lexer) GetToken(tkn Token) string {
-	line := tkn.Beginning.Line
-	minColumn := tkn.Beginning.Column
-	maxColumn := tkn.End.Column
-	return strings.Split(l.fullText, "\n")[line-1][minColumn-1 : maxColumn-1]
+	if tkn.Span.End > tkn.Span.Start {
+		return l.File.Slice(tkn.Span)
+	}
+	return l.sourceLine(tkn.Beginning.Line, tkn.Beginning.Column, tkn.End.Column)
 }
@@ -0,0 +1,207 @@
+package lexer
+
+import (
+	"gada/token"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func isDecimalDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// digitValue returns the value of an extended digit (0-9, a-f), 16 if r is not a digit
+func digitValue(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'f':
+		return int(r-'a') + 10
+	case r >= 'A' && r <= 'F':
+		return int(r-'A') + 10
+	}
+	return 16
+}
+
+// peekBytes returns the next n bytes without reading them, less if the text ends before
+func (l *Lexer) peekBytes(n int) string {
+	end := l.offset + n
+	if end > len(l.File.Text) {
+		end = len(l.File.Text)
+	}
+	return l.File.Text[l.offset:end]
+}
+
+// consume reads the next rune and adds it to the text of the literal
+func (l *Lexer) consume(text *strings.Builder) {
+	r, _, err := l.readRune()
+	if err == nil {
+		l.column++
+		text.WriteRune(r)
+	}
+}
+
+// readDigits reads the digits and the underscores following the literal
+func (l *Lexer) readDigits(text *strings.Builder, extended bool) {
+	for {
+		next := l.peekBytes(1)
+		if len(next) == 0 {
+			return
+		}
+		r := rune(next[0])
+		if r == '_' || isDecimalDigit(r) || (extended && digitValue(r) < 16) {
+			l.consume(text)
+			continue
+		}
+		return
+	}
+}
+
+// readNumber reads a numeric literal starting with the digit first:
+//
+//	decimal_literal ::= numeral [.numeral] [exponent]
+//	based_literal   ::= base # based_numeral [.based_numeral] # [exponent]
+//	exponent        ::= E [+] numeral | E - numeral
+//
+// The literal is read as a whole and checked afterwards, so an invalid literal gives a single error.
+// A period is part of the literal only if it is followed by a digit so ranges like 1..10 are kept.
+func (l *Lexer) readNumber(first rune) string {
+	var text strings.Builder
+	text.WriteRune(first)
+	l.readDigits(&text, false)
+
+	if next := l.peekBytes(1); len(next) == 1 && next[0] == '#' {
+		l.consume(&text)
+		l.readDigits(&text, true)
+		if next := l.peekBytes(2); len(next) == 2 && next[0] == '.' && digitValue(rune(next[1])) < 16 {
+			l.consume(&text)
+			l.readDigits(&text, true)
+		}
+		if next := l.peekBytes(1); len(next) == 1 && next[0] == '#' {
+			l.consume(&text)
+		}
+	} else if next := l.peekBytes(2); len(next) == 2 && next[0] == '.' && isDecimalDigit(rune(next[1])) {
+		l.consume(&text)
+		l.readDigits(&text, false)
+	}
+
+	// The exponent
+	next := l.peekBytes(3)
+	if len(next) >= 2 && (next[0] == 'e' || next[0] == 'E') {
+		if isDecimalDigit(rune(next[1])) {
+			l.consume(&text)
+			l.readDigits(&text, false)
+		} else if len(next) == 3 && (next[1] == '+' || next[1] == '-') && isDecimalDigit(rune(next[2])) {
+			l.consume(&text)
+			l.consume(&text)
+			l.readDigits(&text, false)
+		}
+	}
+	return text.String()
+}
+
+// checkNumeral returns an error if the underscores of the numeral are not between two digits
+func checkNumeral(numeral string) string {
+	if numeral == "" {
+		return "missing digits"
+	}
+	if numeral[0] == '_' || numeral[len(numeral)-1] == '_' || strings.Contains(numeral, "__") {
+		return "an underscore should be between two digits"
+	}
+	return ""
+}
+
+// parseNumber returns the kind and the value of a numeric literal, the value of a REAL is in realValue.
+// An INT literal is at most 2**31, the magnitude of the smallest Integer: the semantic analysis checks that
+// the literals above Integer'Last are negated. The kind of an invalid literal is ILLEGAL and reason tells why.
+func parseNumber(text string) (kind token.Token, intValue int64, realValue float64, reason string) {
+	base := 10
+	mantissa := text
+	exponentText := ""
+
+	if hash := strings.IndexByte(text, '#'); hash >= 0 {
+		if err := checkNumeral(text[:hash]); err != "" {
+			return token.ILLEGAL, 0, 0, err
+		}
+		b, _ := strconv.Atoi(strings.ReplaceAll(text[:hash], "_", ""))
+		if b < 2 || b > 16 {
+			return token.ILLEGAL, 0, 0, "the base should be between 2 and 16"
+		}
+		base = b
+		end := strings.LastIndexByte(text, '#')
+		if end == hash {
+			return token.ILLEGAL, 0, 0, "missing closing #"
+		}
+		mantissa = text[hash+1 : end]
+		if end+1 < len(text) {
+			exponentText = text[end+2:]
+		}
+	} else if e := strings.IndexAny(text, "eE"); e >= 0 {
+		mantissa = text[:e]
+		exponentText = text[e+1:]
+	}
+
+	exponent := 0
+	if exponentText != "" {
+		if err := checkNumeral(strings.TrimLeft(exponentText, "+-")); err != "" {
+			return token.ILLEGAL, 0, 0, err
+		}
+		value, convErr := strconv.Atoi(strings.ReplaceAll(exponentText, "_", ""))
+		if convErr != nil {
+			return token.ILLEGAL, 0, 0, "the exponent is out of range"
+		}
+		exponent = value
+	}
+
+	integerPart, fractionPart, isReal := strings.Cut(mantissa, ".")
+	if err := checkNumeral(integerPart); err != "" {
+		return token.ILLEGAL, 0, 0, err
+	}
+	if isReal {
+		if err := checkNumeral(fractionPart); err != "" {
+			return token.ILLEGAL, 0, 0, err
+		}
+	}
+	digits := strings.ReplaceAll(integerPart+fractionPart, "_", "")
+	for _, digit := range digits {
+		if digitValue(digit) >= base {
+			return token.ILLEGAL, 0, 0, "the digit " + string(digit) + " is not a base " + strconv.Itoa(base) + " digit"
+		}
+	}
+
+	if isReal {
+		fractionDigits := len(strings.ReplaceAll(fractionPart, "_", ""))
+		var value float64
+		if base == 10 {
+			// ParseFloat rounds correctly where the multiplications below may not
+			value, _ = strconv.ParseFloat(digits[:len(digits)-fractionDigits]+"."+digits[len(digits)-fractionDigits:]+"e"+strconv.Itoa(exponent), 64)
+		} else {
+			for _, digit := range digits {
+				value = value*float64(base) + float64(digitValue(digit))
+			}
+			value *= math.Pow(float64(base), float64(exponent-fractionDigits))
+		}
+		if math.IsInf(value, 0) {
+			return token.ILLEGAL, 0, 0, "the real literal is out of range"
+		}
+		return token.REAL, 0, value, ""
+	}
+
+	if exponent < 0 {
+		return token.ILLEGAL, 0, 0, "an integer literal cannot have a negative exponent"
+	}
+	value, _ := new(big.Int).SetString(digits, base)
+	if value.Sign() != 0 {
+		// Beyond 32 the literal overflows whatever the mantissa
+		if exponent > 32 {
+			return token.ILLEGAL, 0, 0, "the integer literal does not fit in 32 bits"
+		}
+		value.Mul(value, new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(int64(exponent)), nil))
+	}
+	if value.Cmp(big.NewInt(-math.MinInt32)) > 0 {
+		return token.ILLEGAL, 0, 0, "the integer literal does not fit in 32 bits"
+	}
+	return token.INT, value.Int64(), 0, ""
+}
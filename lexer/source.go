@@ -0,0 +1,106 @@
+package lexer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Span is the byte offsets of a token in its SourceFile, End is the offset after the last byte
+type Span struct {
+	Start int
+	End   int
+}
+
+// SourceFile is a text with the offsets of its lines, like a go/token.File: the lines and the
+// columns of the tokens are converted to byte offsets without reading the text again.
+// The columns count runes, as the Lexer does.
+type SourceFile struct {
+	Name string
+	Text string
+	// lines are the offsets of the first byte of each line
+	lines []int
+}
+
+func NewSourceFile(name, text string) *SourceFile {
+	file := &SourceFile{Name: name, Text: text, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			file.lines = append(file.lines, i+1)
+		}
+	}
+	return file
+}
+
+// LineCount returns the number of lines, a text ending with a newline ends with an empty line
+func (f *SourceFile) LineCount() int {
+	return len(f.lines)
+}
+
+// LineStart returns the offset of the first byte of a line, the lines start at 1
+func (f *SourceFile) LineStart(line int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(f.lines) {
+		return len(f.Text)
+	}
+	return f.lines[line-1]
+}
+
+// Line returns the text of a line without its newline
+func (f *SourceFile) Line(line int) string {
+	if line < 1 || line > len(f.lines) {
+		return ""
+	}
+	end := len(f.Text)
+	if line < len(f.lines) {
+		end = f.lines[line] - 1
+	}
+	return f.Text[f.lines[line-1]:end]
+}
+
+// Position returns the line and the column of an offset
+func (f *SourceFile) Position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(f.Text) {
+		offset = len(f.Text)
+	}
+	// The first line starts at 0, so the search finds the line after the one of the offset
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset })
+	return Position{Line: line, Column: utf8.RuneCountInString(f.Text[f.lines[line-1]:offset]) + 1}
+}
+
+// Offset returns the offset of a position, a column after the end of its line gives the end of the line
+func (f *SourceFile) Offset(position Position) int {
+	if position.Line < 1 {
+		return 0
+	}
+	if position.Line > len(f.lines) {
+		return len(f.Text)
+	}
+	text := f.Line(position.Line)
+	offset := f.lines[position.Line-1]
+	for column := 1; column < position.Column && len(text) > 0; column++ {
+		_, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		offset += size
+	}
+	return offset
+}
+
+// Slice returns the text of a span, clamped to the text
+func (f *SourceFile) Slice(span Span) string {
+	start, end := span.Start, span.End
+	if start < 0 {
+		start = 0
+	}
+	if end > len(f.Text) {
+		end = len(f.Text)
+	}
+	if start > end {
+		return ""
+	}
+	return f.Text[start:end]
+}
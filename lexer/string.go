@@ -0,0 +1,31 @@
+package lexer
+
+import "strings"
+
+// readString reads a string literal after its opening quote and returns its value.
+// A quote is written twice inside the literal ("say ""hi""") and a literal cannot span lines:
+// terminated is false when the line or the text ends before the closing quote, the newline is not read.
+func (l *Lexer) readString() (value string, terminated bool) {
+	var text strings.Builder
+	for {
+		r, _, err := l.readRune()
+		if err != nil {
+			return text.String(), false
+		}
+		if r == '\n' {
+			l.unreadRune()
+			return text.String(), false
+		}
+		l.column++
+		if r == '"' {
+			if next := l.peekBytes(1); len(next) == 1 && next[0] == '"' {
+				l.readRune()
+				l.column++
+				text.WriteRune('"')
+				continue
+			}
+			return text.String(), true
+		}
+		text.WriteRune(r)
+	}
+}
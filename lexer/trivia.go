@@ -0,0 +1,129 @@
+package lexer
+
+import (
+	"gada/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TriviaKind int
+
+const (
+	// Whitespace is a run of spaces other than newlines
+	Whitespace TriviaKind = iota
+	// Newline is a line feed, or a carriage return followed by a line feed
+	Newline
+	// Comment is a -- comment without its newline
+	Comment
+	// Skipped is text giving no token, like a lexical error
+	Skipped
+)
+
+// Trivia is text between the tokens that the parser does not see
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+// NewTriviaLexer returns a Lexer whose tokens carry the comments and the whitespace around them.
+// The text is kept as is, so Text gives it back byte for byte.
+func NewTriviaLexer(fileName, text string) *Lexer {
+	return &Lexer{FileName: fileName, File: NewSourceFile(fileName, text), line: 1, column: 1, Lexi: make([]string, 0), trivia: true}
+}
+
+// Text writes back tokens read in trivia mode with their trivia. The tokens of Read, which end
+// with the EOF token in this mode, give the text read.
+func (l *Lexer) Text(tokens []Token) string {
+	var text strings.Builder
+	for _, tkn := range tokens {
+		for _, trivia := range tkn.Leading {
+			text.WriteString(trivia.Text)
+		}
+		text.WriteString(l.File.Slice(tkn.Span))
+		for _, trivia := range tkn.Trailing {
+			text.WriteString(trivia.Text)
+		}
+	}
+	return text.String()
+}
+
+// attachTrivia gives a token the trivia since the previous one, then reads its trailing trivia:
+// the whitespace and the comment after it up to the end of its line.
+func (l *Lexer) attachTrivia(tkn *Token) {
+	tkn.Leading = splitTrivia(l.File.Text[l.triviaEnd:tkn.Span.Start])
+	if tkn.Value == token.EOF {
+		return
+	}
+
+	text := l.File.Text
+	end := l.offset
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if r == '\n' || !unicode.IsSpace(r) {
+			break
+		}
+		end += size
+	}
+	if strings.HasPrefix(text[end:], "--") {
+		if newline := strings.IndexByte(text[end:], '\n'); newline >= 0 {
+			end += newline
+		} else {
+			end = len(text)
+		}
+	}
+	if end < len(text) && text[end] == '\n' {
+		end++
+	}
+	tkn.Trailing = splitTrivia(text[l.offset:end])
+
+	// The trivia is read like the spaces between the tokens
+	for l.offset < end {
+		r, _, _ := l.readRune()
+		if r == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+	}
+	l.triviaEnd = end
+}
+
+// splitTrivia cuts the text between two tokens into whitespace, newlines, comments and skipped text
+func splitTrivia(text string) []Trivia {
+	var trivia []Trivia
+	add := func(kind TriviaKind, size int) {
+		if n := len(trivia); n > 0 && kind != Newline && trivia[n-1].Kind == kind {
+			trivia[n-1].Text += text[:size]
+		} else {
+			trivia = append(trivia, Trivia{Kind: kind, Text: text[:size]})
+		}
+		text = text[size:]
+	}
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		switch {
+		case r == '\n':
+			add(Newline, 1)
+		case strings.HasPrefix(text, "\r\n"):
+			add(Newline, 2)
+		case unicode.IsSpace(r):
+			add(Whitespace, size)
+		case strings.HasPrefix(text, "--"):
+			end := strings.IndexByte(text, '\n')
+			if end < 0 {
+				end = len(text)
+			}
+			// A comment ending a line written with CRLF keeps its text without the carriage return
+			if end > 2 && text[end-1] == '\r' && end < len(text) {
+				end--
+			}
+			trivia = append(trivia, Trivia{Kind: Comment, Text: text[:end]})
+			text = text[end:]
+		default:
+			add(Skipped, size)
+		}
+	}
+	return trivia
+}
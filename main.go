@@ -1,9 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"gada/asm"
+	"gada/generator"
+	"gada/grammar"
 	"gada/reader"
+	"github.com/charmbracelet/log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +20,14 @@ func main() {
 	if len(argsWithoutProg) > 0 {
 		if argsWithoutProg[0] == "run" {
 			compileConfig := reader.CompileConfig{Path: getProgramName(2)}
+			compileConfig.OptimizationLevel = getOptimizationLevel(argsWithoutProg)
+			compileConfig.CrashReport = getCrashReport(argsWithoutProg)
+			if _, target := containsArgument(argsWithoutProg, "--target"); target == reader.TargetWasm {
+				if err := reader.RunWasm(compileConfig, os.Stdout); err != nil {
+					log.Fatal("Run failed", "error", err)
+				}
+				return
+			}
 
 			reader.CompileFile(compileConfig)
 
@@ -22,6 +37,54 @@ func main() {
 			return
 		}
 
+		if argsWithoutProg[0] == "interp" {
+			compileConfig := reader.CompileConfig{Path: getProgramName(2), CrashReport: getCrashReport(argsWithoutProg)}
+			if err := reader.InterpretFile(compileConfig, os.Stdout); err != nil {
+				log.Fatal("Interpretation failed", "error", err)
+			}
+			return
+		}
+
+		if argsWithoutProg[0] == "generate" {
+			seed := int64(1)
+			if given, value := containsArgument(argsWithoutProg, "--seed"); given {
+				parsed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					log.Fatal("Invalid seed", "value", value)
+				}
+				seed = parsed
+			}
+			fmt.Print(generator.Generate(generator.DefaultConfig(seed)))
+			return
+		}
+
+		if argsWithoutProg[0] == "difftest" {
+			os.Exit(diffTest(argsWithoutProg[1:]))
+		}
+
+		if argsWithoutProg[0] == "grammar" {
+			os.Exit(checkGrammars(argsWithoutProg[1:]))
+		}
+
+		if argsWithoutProg[0] == "build" {
+			compileConfig := reader.CompileConfig{Path: getProgramName(2), Target: reader.TargetX86}
+			compileConfig.OptimizationLevel = getOptimizationLevel(argsWithoutProg)
+			compileConfig.CrashReport = getCrashReport(argsWithoutProg)
+			if target, targetValue := containsArgument(argsWithoutProg, "--target"); target {
+				compileConfig.Target = targetValue
+			}
+			if emit, emitValue := containsArgument(argsWithoutProg, "--emit"); emit {
+				compileConfig.Emit = emitValue
+			}
+
+			output, err := reader.BuildFile(compileConfig)
+			if err != nil {
+				log.Fatal("Build failed", "error", err)
+			}
+			log.Info("Build successful", "output", output)
+			return
+		}
+
 		compileConfig := reader.CompileConfig{Path: getProgramName(1)}
 
 		// Arguments
@@ -33,6 +96,8 @@ func main() {
 		} else {
 			compileConfig.PythonExecutable = "python3"
 		}
+		compileConfig.OptimizationLevel = getOptimizationLevel(argsWithoutProg)
+		compileConfig.CrashReport = getCrashReport(argsWithoutProg)
 
 		reader.CompileFile(compileConfig)
 		return
@@ -40,10 +105,111 @@ func main() {
 	reader.CompileFile(reader.CompileConfig{Path: "examples/expressions/helloWorld.ada", PrintAst: true})
 }
 
+// diffTest compares the interpreter with the ARM backend on the given files and folders, by default
+// examples/exec and examples/correctsyntax, or on random programs without access types with --generate=N,
+// and returns the exit status
+func diffTest(args []string) int {
+	all, _ := containsArgument(args, "--all")
+	maxSteps := 5_000_000
+	if limit, value := containsArgument(args, "--max-steps"); limit {
+		steps, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal("Invalid step limit", "value", value)
+		}
+		maxSteps = steps
+	}
+	var paths []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			paths = append(paths, arg)
+		}
+	}
+	var files []string
+	if generate, value := containsArgument(args, "--generate"); generate {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal("Invalid number of programs", "value", value)
+		}
+		folder, err := os.MkdirTemp("", "gada-generated")
+		if err != nil {
+			log.Fatal("Cannot create the folder of the programs", "error", err)
+		}
+		for seed := 1; seed <= count; seed++ {
+			file := filepath.Join(folder, "generated"+strconv.Itoa(seed)+".adb")
+			// The ARM backend has no heap for the access types
+			config := generator.DefaultConfig(int64(seed))
+			config.NoAccess = true
+			if err := os.WriteFile(file, []byte(generator.Generate(config)), 0644); err != nil {
+				log.Fatal("Cannot write the program", "error", err)
+			}
+			files = append(files, file)
+		}
+	} else if len(paths) == 0 {
+		paths = []string{"examples/exec", "examples/correctsyntax"}
+	}
+	for _, path := range paths {
+		if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+			folder := reader.ListFiles(path)
+			sort.Strings(folder)
+			files = append(files, folder...)
+			continue
+		}
+		files = append(files, path)
+	}
+
+	divergences, skips := reader.DiffTest(files, maxSteps, all)
+	for _, skip := range skips {
+		fmt.Println(skip)
+	}
+	for _, divergence := range divergences {
+		fmt.Println(divergence)
+	}
+	if len(divergences) > 0 {
+		return 1
+	}
+	log.Info("No divergence", "files", len(files), "skipped", len(skips))
+	return 0
+}
+
+// checkGrammars prints the sets, the LL(1) parse table and the conflicts of the given grammar files, by default
+// the grammars of the repository, only the conflicts with --conflicts. It returns 1 when there are conflicts.
+func checkGrammars(args []string) int {
+	onlyConflicts, _ := containsArgument(args, "--conflicts")
+	var files []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			files = append(files, arg)
+		}
+	}
+	if len(files) == 0 {
+		files = []string{"grammar.g", "grammar_ll1.g", "grammar_ll1_v2", "grammar_ll1_v2_simple"}
+	}
+	status := 0
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatal("Cannot read the grammar", "error", err)
+		}
+		g, err := grammar.Parse(file, string(text))
+		if err != nil {
+			log.Fatal("Invalid grammar", "error", err)
+		}
+		analysis := grammar.Analyse(g)
+		if !onlyConflicts {
+			analysis.WriteSets(os.Stdout)
+			analysis.WriteTable(os.Stdout)
+		}
+		if analysis.WriteConflicts(os.Stdout) > 0 {
+			status = 1
+		}
+	}
+	return status
+}
+
 func getProgramName(startIndex int) string {
 	var programName string
 	for _, arg := range os.Args[startIndex:] {
-		if !strings.HasPrefix(arg, "--") {
+		if !strings.HasPrefix(arg, "-") {
 			programName = arg
 			break
 		}
@@ -64,3 +230,21 @@ func containsArgument(args []string, arg string) (bool, string) {
 	}
 	return false, ""
 }
+
+// getOptimizationLevel returns 1 if -O1 is given, 0 otherwise
+func getOptimizationLevel(args []string) int {
+	if optimize, _ := containsArgument(args, "-O1"); optimize {
+		return 1
+	}
+	return 0
+}
+
+// getCrashReport returns the folder of the reproducers of the internal compiler errors given by --crash-report,
+// crash-reports when no folder is given and "" when there is no option
+func getCrashReport(args []string) string {
+	report, folder := containsArgument(args, "--crash-report")
+	if report && folder == "" {
+		return "crash-reports"
+	}
+	return folder
+}
@@ -3,14 +3,15 @@ package parser
 import (
 	"fmt"
 	"gada/asm"
+	"gada/ast"
 	"github.com/charmbracelet/log"
-	"golang.org/x/exp/maps"
-	"math/rand"
 	"os"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type AssemblyFile struct {
@@ -20,8 +21,19 @@ type AssemblyFile struct {
 
 	WritingAtEnd bool
 
-	ForCounter  int
-	CurrentAddr int
+	ForCounter   int
+	LabelCounter int
+	CurrentAddr  int
+
+	// Data holds the string constants, written after the routines. constants gives the label of each string.
+	Data      string
+	constants map[string]string
+
+	// subprograms gives the label of each subprogram by its symbol
+	subprograms map[string]string
+
+	// err is the first construct the backend cannot compile
+	err error
 }
 
 type Register int
@@ -78,6 +90,62 @@ func NewAssemblyFile(fileName string) AssemblyFile {
 	return assembler
 }
 
+// NewLabelID returns a number unique to the compilation to build the labels of a construct,
+// the labels are the same every time the same file is compiled.
+func (a *AssemblyFile) NewLabelID() int {
+	a.LabelCounter++
+	return a.LabelCounter
+}
+
+// subprogramLabel returns the label of the subprogram with the symbol: the names of the subprograms enclosing its
+// declaration and its own name after a prefix no routine of the runtime uses, followed by a new label id
+func (a *AssemblyFile) subprogramLabel(symbol string) string {
+	if label, ok := a.subprograms[symbol]; ok {
+		return label
+	}
+	if a.subprograms == nil {
+		a.subprograms = map[string]string{}
+	}
+	var names []string
+	for _, part := range strings.Split(symbol, ".") {
+		name, _, _ := strings.Cut(part, "(")
+		names = append(names, name)
+	}
+	label := "ada_" + labelName(strings.Join(names, "_")) + "_" + strconv.Itoa(a.NewLabelID())
+	a.subprograms[symbol] = label
+	return label
+}
+
+// labelName converts an identifier to the characters the assemblers accept in a label: ASCII letters, digits
+// and underscores. Any other rune is written as its hexadecimal code between underscores.
+func labelName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < utf8.RuneSelf && (r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "_%x_", r)
+		}
+	}
+	return b.String()
+}
+
+// subprogramName returns the label name of the procedure or function containing the node, main for the main procedure.
+func subprogramName(graph Graph, node int) string {
+	scope := graph.getScope(node)
+	if scope != nil {
+		switch symbol := scope.ScopeSymbol.(type) {
+		case Procedure:
+			if symbol.PName != "file" {
+				return labelName(symbol.PName)
+			}
+		case Function:
+			return labelName(symbol.FName)
+		}
+	}
+	return "main"
+}
+
 func (a *AssemblyFile) Name() string {
 	return a.FileName
 }
@@ -97,6 +165,32 @@ func (a *AssemblyFile) Fill(n int) string {
 	return addr
 }
 
+// StringConstant returns the label of a string constant in the data section: a word with its length
+// followed by its characters, so that they can contain NUL. VisUAL has no byte directive so the characters
+// are packed in little-endian words.
+func (a *AssemblyFile) StringConstant(value string) string {
+	if label, ok := a.constants[value]; ok {
+		return label
+	}
+	if a.constants == nil {
+		a.constants = map[string]string{}
+	}
+	label := "str" + strconv.Itoa(len(a.constants))
+	a.constants[value] = label
+
+	bytes := []byte(value)
+	for len(bytes)%4 != 0 {
+		bytes = append(bytes, 0)
+	}
+	words := []string{fmt.Sprintf("0x%08X", len(value))}
+	for i := 0; i < len(bytes); i += 4 {
+		word := uint32(bytes[i]) | uint32(bytes[i+1])<<8 | uint32(bytes[i+2])<<16 | uint32(bytes[i+3])<<24
+		words = append(words, fmt.Sprintf("0x%08X", word))
+	}
+	a.Data += label + " DCD " + strings.Join(words, ", ") + " ; " + strconv.Quote(value) + "\n"
+	return label
+}
+
 func (a *AssemblyFile) Stmfd(register Register) {
 	if a.WritingAtEnd {
 		a.EndText += "STMFD SP!, {" + register.String() + "}\n"
@@ -227,12 +321,52 @@ func (a *AssemblyFile) StrWithOffset(register Register, offset int) {
 	}
 }
 
+// Mov puts the value in the register with MOV or MVN when it fits in an immediate (8 bits rotated by an even amount),
+// otherwise the value is loaded from the literal pool with LDR =
 func (a *AssemblyFile) Mov(register Register, value int) {
-	str := strconv.Itoa(value)
+	var line string
+	switch {
+	case asm.IsEncodableImmediate(uint32(value)):
+		line = "MOV " + register.String() + ", #" + strconv.FormatUint(uint64(uint32(value)), 10)
+	case asm.IsEncodableImmediate(^uint32(value)):
+		line = "MVN " + register.String() + ", #" + strconv.FormatUint(uint64(^uint32(value)), 10)
+	default:
+		line = "LDR " + register.String() + ", =" + strconv.Itoa(value)
+	}
+	if a.WritingAtEnd {
+		a.EndText += line + "\n"
+	} else {
+		a.Text += line + "\n"
+	}
+}
+
+// scratchRegister returns a register that can be overwritten to hold an immediate used with the given register.
+// R12 is the scratch register, LR is used when R12 is the operand since it is saved by the procedure prologue.
+func scratchRegister(register Register) Register {
+	if register == R12 {
+		return LR
+	}
+	return R12
+}
+
+// immediateOp emits "op register, register, #value" when value is encodable,
+// "negatedOp register, register, #-value" when -value is, and loads value in a scratch register otherwise.
+func (a *AssemblyFile) immediateOp(op, negatedOp string, register Register, value int) {
+	var lines string
+	switch {
+	case asm.IsEncodableImmediate(uint32(value)):
+		lines = op + " " + register.String() + ", " + register.String() + ", #" + strconv.FormatUint(uint64(uint32(value)), 10) + "\n"
+	case asm.IsEncodableImmediate(uint32(-value)):
+		lines = negatedOp + " " + register.String() + ", " + register.String() + ", #" + strconv.FormatUint(uint64(uint32(-value)), 10) + "\n"
+	default:
+		scratch := scratchRegister(register)
+		a.Mov(scratch, value)
+		lines = op + " " + register.String() + ", " + register.String() + ", " + scratch.String() + "\n"
+	}
 	if a.WritingAtEnd {
-		a.EndText += "LDR " + register.String() + ", =" + str + "\n"
+		a.EndText += lines
 	} else {
-		a.Text += "LDR " + register.String() + ", =" + str + "\n"
+		a.Text += lines
 	}
 }
 
@@ -278,15 +412,28 @@ func (a *AssemblyFile) Or(register1 Register, register2 Register) {
 	}
 }
 
-func (a *AssemblyFile) Add(register Register, value int) {
+// OpRegisters writes a data processing instruction working on registers only (e.g. ADD R0, R1, R2)
+func (a *AssemblyFile) OpRegisters(op string, dest Register, register1 Register, register2 Register) {
+	if a.WritingAtEnd {
+		a.EndText += op + " " + dest.String() + ", " + register1.String() + ", " + register2.String() + "\n"
+	} else {
+		a.Text += op + " " + dest.String() + ", " + register1.String() + ", " + register2.String() + "\n"
+	}
+}
+
+func (a *AssemblyFile) Xor(register Register, value int) {
 	str := strconv.Itoa(value)
 	if a.WritingAtEnd {
-		a.EndText += "ADD " + register.String() + ", " + register.String() + ", #" + str + "\n"
+		a.EndText += "EOR " + register.String() + ", " + register.String() + ", #" + str + "\n"
 	} else {
-		a.Text += "ADD " + register.String() + ", " + register.String() + ", #" + str + "\n"
+		a.Text += "EOR " + register.String() + ", " + register.String() + ", #" + str + "\n"
 	}
 }
 
+func (a *AssemblyFile) Add(register Register, value int) {
+	a.immediateOp("ADD", "SUB", register, value)
+}
+
 func (a *AssemblyFile) AddFromStackPointer(register Register, intermediateRegister Register) {
 	if a.WritingAtEnd {
 		a.EndText += "LDR " + intermediateRegister.String() + ", [SP]\n"
@@ -308,12 +455,7 @@ func (a *AssemblyFile) AddWithOffset(register Register, intermediateRegister Reg
 }
 
 func (a *AssemblyFile) Sub(register Register, value int) {
-	str := strconv.Itoa(value)
-	if a.WritingAtEnd {
-		a.EndText += "SUB " + register.String() + ", " + register.String() + ", #" + str + "\n"
-	} else {
-		a.Text += "SUB " + register.String() + ", " + register.String() + ", #" + str + "\n"
-	}
+	a.immediateOp("SUB", "ADD", register, value)
 }
 
 func (a *AssemblyFile) SubFromStackPointer(register Register, intermediateRegister Register) {
@@ -346,16 +488,6 @@ func (a *AssemblyFile) Negate(register Register) {
 	}
 }
 
-func (a *AssemblyFile) Not(register Register) {
-	if a.WritingAtEnd {
-		a.EndText += "; Not " + register.String() + "\n"
-		a.EndText += "MVN " + register.String() + ", " + register.String() + "\n"
-	} else {
-		a.Text += "; Not " + register.String() + "\n"
-		a.Text += "MVN " + register.String() + ", " + register.String() + "\n"
-	}
-}
-
 func (a *AssemblyFile) Positive(register Register) {
 	if a.WritingAtEnd {
 		a.EndText += fmt.Sprintf(`; Make %[1]v positive
@@ -374,12 +506,23 @@ RSBLT   %[1]v, %[1]v, #0 ; If %[1]v is less than zero, negate it
 	}
 }
 
+// Cmp compares the register with the value, using CMN for encodable negative values
 func (a *AssemblyFile) Cmp(register Register, value int) {
-	str := strconv.Itoa(value)
+	var line string
+	switch {
+	case asm.IsEncodableImmediate(uint32(value)):
+		line = "CMP " + register.String() + ", #" + strconv.FormatUint(uint64(uint32(value)), 10)
+	case asm.IsEncodableImmediate(uint32(-value)):
+		line = "CMN " + register.String() + ", #" + strconv.FormatUint(uint64(uint32(-value)), 10)
+	default:
+		scratch := scratchRegister(register)
+		a.Mov(scratch, value)
+		line = "CMP " + register.String() + ", " + scratch.String()
+	}
 	if a.WritingAtEnd {
-		a.EndText += "CMP " + register.String() + ", #" + str + "\n"
+		a.EndText += line + "\n"
 	} else {
-		a.Text += "CMP " + register.String() + ", #" + str + "\n"
+		a.Text += line + "\n"
 	}
 }
 
@@ -474,14 +617,39 @@ func changeOrAddExtension(s string) string {
 	return s[:index] + ".s"
 }
 
-func ReadASTToASM(graph Graph) {
+// ReadASTToASM compiles the AST and writes the assembly file in examples/asm.
+// The peephole optimiser is run when optimizationLevel is at least 1.
+func ReadASTToASM(graph Graph, optimizationLevel int) error {
+	file, err := GenerateASM(graph, optimizationLevel)
+	if err != nil {
+		return err
+	}
+	file.Write()
+	return nil
+}
+
+// GenerateASM compiles the AST to assembly without writing the file, the error locates the first
+// construct the backend does not support.
+func GenerateASM(graph Graph, optimizationLevel int) (AssemblyFile, error) {
 	file := NewAssemblyFile(changeOrAddExtension(fmt.Sprintf("examples/asm/%s", getStringFromRight(graph.fileName))))
 
+	// The backend has no heap, the fields of the records designated by an access cannot be reached
+	ast.Inspect(graph.File(), func(node ast.Node) bool {
+		if decl, ok := node.(*ast.AccessTypeDecl); ok {
+			file.fail(graph, decl, "access types are not supported by the ARM backend")
+		}
+		return file.err == nil
+	})
+	if file.err != nil {
+		return file, file.err
+	}
+
 	file.Text += "STR_OUT      FILL    0x1000\n"
 	file.Text += "MOV R11, SP\n"
 
-	file.ReadFile(graph, 0)
+	file.ReadFile(graph, graph.File())
 
+	file.Text += "end_main\n"
 	file.Text += "end\n\n"
 
 	file.Text += file.EndText
@@ -502,7 +670,7 @@ mul_loop LSRS    R2, R2, #1
 
 	// Integer division algorithm
 	file.Text += `
-;       Integer division routine
+;       Integer division routine, the quotient is truncated and the remainder has the sign of the dividend
 ;       Arguments:
 ;       R1 = Dividend
 ;       R2 = Divisor
@@ -511,36 +679,33 @@ mul_loop LSRS    R2, R2, #1
 ;       R1 = Remainder
 div32    STMFD   SP!, {LR, R2-R5}
          MOV     R0, #0
-         MOV     R3, #0
+         MOV     R3, #0 ; bit 0 negates the quotient, bit 1 the remainder
          CMP     R1, #0
          RSBLT   R1, R1, #0
-         EORLT   R3, R3, #1
+         EORLT   R3, R3, #3
          CMP     R2, #0
          RSBLT   R2, R2, #0
          EORLT   R3, R3, #1
          MOV     R4, R2
          MOV     R5, #1
-div_max  LSL     R4, R4, #1
+;       the magnitudes are unsigned, the one of -2147483648 is 2147483648
+div_max  CMP     R4, R1
+         BHS     div_loop
+         TST     R4, #0x80000000
+         BNE     div_loop
+         LSL     R4, R4, #1
          LSL     R5, R5, #1
-         CMP     R4, R1
-         BLE     div_max
-div_loop LSR     R4, R4, #1
-         LSR     R5, R5, #1
-         CMP     R4,R1
-         BGT     div_loop
-         ADD     R0, R0, R5
-         SUB     R1, R1, R4
-         CMP     R1, R2
-         BGE     div_loop
-         CMP     R3, #1
-         BNE     div_exit
-         CMP     R1, #0
-         ADDNE   R0, R0, #1
-         RSB     R0, R0, #0
-         RSB     R1, R1, #0
-         ADDNE   R1, R1, R2
-div_exit CMP     R0, #0
-         ADDEQ   R1, R1, R4
+         B       div_max
+div_loop CMP     R1, R4
+         SUBHS   R1, R1, R4
+         ADDHS   R0, R0, R5
+         LSR     R4, R4, #1
+         LSRS    R5, R5, #1
+         BNE     div_loop
+         TST     R3, #1
+         RSBNE   R0, R0, #0
+         TST     R3, #2
+         RSBNE   R1, R1, #0
          LDMFD   SP!, {PC, R2-R5}
 `
 
@@ -559,30 +724,22 @@ minus_sign
 
 	file.Text += `
 println      STMFD   SP!, {LR, R0-R3}
-             MOV     R3, R0
-             LDR     R1, =STR_OUT ; address of the output buffer
-PRINTLN_LOOP LDRB    R2, [R0], #1
-             STRB    R2, [R1], #1
-             TST     R2, R2
-             BNE     PRINTLN_LOOP
-             MOV     R2, #0
-             STRB    R2, [R1, #-1]
              MOV     R2, #0
-             STRB    R2, [R1]
-
+             CMP     R0, #0 ; a string never assigned is empty
+             LDRNE   R2, [R0], #4 ; the length is followed by the characters
+             LDR     R1, =STR_OUT ; address of the output buffer
+PRINTLN_LOOP SUBS    R2, R2, #1
+             LDRBGE  R3, [R0], #1
+             STRBGE  R3, [R1], #1
+             BGE     PRINTLN_LOOP
+             MOV     R3, #0
+             STRB    R3, [R1]
 
              ;       we need to clear the output buffer
-             LDR     R1, =STR_OUT
-             MOV     R0, R3
-CLEAN        LDRB    R2, [R0], #1
-             MOV     R3, #0
-             STRB    R3, [R1], #1
-             TST     R2, R2
-             BNE     CLEAN
-             ;       clear 3 more because why not
-             STRB    R3, [R1], #1
-             STRB    R3, [R1], #1
-             STRB    R3, [R1], #1
+             LDR     R2, =STR_OUT
+CLEAN        CMP     R2, R1
+             STRBLS  R3, [R2], #1
+             BLS     CLEAN
 
              LDMFD   SP!, {PC, R0-R3}
 `
@@ -602,6 +759,9 @@ MOV R7, R0
 to_ascii_loop MOV     R1, R0 ; Save the value in R6
               MOV     R2, #10
               BL      div32 ; R0 = R0 / 10, R1 = R0 % 10
+              CMP     R1, #0 ; the magnitude of -2147483648 is still negative
+              RSBLT   R1, R1, #0
+              RSBLT   R0, R0, #0
               ADD     R1, R1, #48 ; Convert digit to ASCII
               STRB    R1, [R3, R4] ; Store the ASCII digit
               ADD     R4, R4, #1 ; Increment digit counter
@@ -619,7 +779,99 @@ to_ascii_loop MOV     R1, R0 ; Save the value in R6
               LDMFD   SP!, {PC, R4-R7}
 `
 
-	file.Write()
+	// Images of Integer'Image and Character'Image, to_ascii writes the digits from the least significant one
+	// followed by the sign (0 or -) so int_image copies them backwards. int_text is the text printed by Put,
+	// without the space of the image before a positive number. The length of an image is stored before it.
+	file.Text += `
+int_image     STMFD   SP!, {LR, R0-R7}
+              MOV     R6, #32 ; a space before a positive number
+              B       image_digits
+int_text      STMFD   SP!, {LR, R0-R7}
+              MOV     R6, #0
+image_digits  MOV     R7, R3 ; address of the image
+              ADD     R5, R3, #4 ; address of the characters
+              SUB     SP, SP, #12
+              MOV     R3, SP
+              BL      to_ascii
+              MOV     R4, #0
+image_count   LDRB    R1, [R3, R4]
+              CMP     R1, #48
+              ADDGE   R4, R4, #1
+              BGE     image_count
+              CMP     R1, #0
+              MOVEQ   R1, R6
+              CMP     R1, #0
+              STRBNE  R1, [R5], #1
+image_copy    SUBS    R4, R4, #1
+              LDRB    R1, [R3, R4]
+              STRB    R1, [R5], #1
+              BNE     image_copy
+              SUB     R1, R5, R7
+              SUB     R1, R1, #4
+              STR     R1, [R7] ; length of the image
+              ADD     SP, SP, #12
+              LDMFD   SP!, {PC, R0-R7}
+
+char_image    STMFD   SP!, {LR, R1}
+              MOV     R1, #3 ; length of the image
+              STR     R1, [R3]
+              MOV     R1, #39 ; quote
+              STRB    R1, [R3, #4]
+              STRB    R0, [R3, #5]
+              STRB    R1, [R3, #6]
+              LDMFD   SP!, {PC, R1}
+
+str_length    STMFD   SP!, {LR}
+              CMP     R0, #0 ; a string never assigned is empty
+              LDRNE   R0, [R0] ; the length is stored before the characters
+              LDMFD   SP!, {PC}
+
+;       str_equal compares the characters of the strings R0 and R1, the flags are EQ when they are equal
+str_equal     STMFD   SP!, {LR, R0-R4}
+              MOV     R2, #0
+              CMP     R0, #0
+              LDRNE   R2, [R0], #4
+              MOV     R3, #0
+              CMP     R1, #0
+              LDRNE   R3, [R1], #4
+              CMP     R2, R3
+              BNE     equal_end
+equal_loop    SUBS    R2, R2, #1
+              BLT     equal_same
+              LDRB    R3, [R0], #1
+              LDRB    R4, [R1], #1
+              CMP     R3, R4
+              BEQ     equal_loop
+              B       equal_end
+equal_same    CMP     R2, R2
+equal_end     LDMFD   SP!, {PC, R0-R4}
+`
+
+	file.Text += file.Data
+
+	if optimizationLevel >= 1 {
+		file.Text = asm.OptimizeText(file.Text)
+	}
+	return file, file.err
+}
+
+// UnsupportedError is a construct of a valid program that the ARM backend cannot compile
+type UnsupportedError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.File + ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + ": " + e.Message
+}
+
+// fail records an error at the node, only the first one is kept
+func (a *AssemblyFile) fail(graph Graph, node ast.Node, format string, args ...any) {
+	if a.err == nil {
+		a.err = &UnsupportedError{File: graph.fileName, Line: node.Pos().Line, Column: node.Pos().Column, Message: fmt.Sprintf(format, args...)}
+	}
 }
 
 func (a *AssemblyFile) CallProcedure(name string) {
@@ -662,361 +914,363 @@ func (a *AssemblyFile) CallWithParameters(blName string, scope *Scope, removedOf
 	}
 }
 
-func (a *AssemblyFile) ReadFile(graph Graph, node int) {
-	// Read all the children
-	children := graph.GetChildren(node)
-
-	var declNode int
-	var bodyNode int
-
-	for _, child := range children {
-		if graph.GetNode(child) == "decl" {
-			declNode = child
-		} else if graph.GetNode(child) == "body" {
-			bodyNode = child
-		}
-	}
-
+func (a *AssemblyFile) ReadFile(graph Graph, file *ast.File) {
 	a.StmfdMultiple([]Register{R10, R11, LR})
-	a.Mov(R10, getRegion(graph, node))
+	a.Mov(R10, getRegion(graph, file.ID()))
 	a.MovRegister(R11, SP)
 	a.Sub(R11, 4)
 
-	a.ReadDecl(graph, declNode, All)
-	a.ReadBody(graph, bodyNode)
+	a.ReadDecl(graph, file.Decls, All)
+	a.ReadBody(graph, file.Body)
+}
+
+func (a *AssemblyFile) ReadIf(graph Graph, stmt *ast.IfStmt) {
+	a.readIf(graph, stmt.ID(), stmt.Cond, stmt.Body, stmt.Elifs, stmt.Else)
 }
 
-func (a *AssemblyFile) ReadIf(graph Graph, node int) {
+// readIf writes the if statement, the first elif is written as an if statement nested in the else part
+func (a *AssemblyFile) readIf(graph Graph, node int, cond ast.Expr, body []ast.Stmt, elifs []*ast.ElsifClause, elseBody []ast.Stmt) {
 	a.AddComment("If statement")
 	a.AddComment("Start of condition")
 
 	// Read condition
-	condition := graph.GetChildren(node)[0]
-	a.ReadOperand(graph, condition)
+	a.ReadOperandToRegister(graph, cond, R0)
 
-	a.Ldr(R0, 0)
-	a.CommentPreviousLine("Load result of condition")
-	a.Add(SP, 4)
-	a.CommentPreviousLine("Remove the result of the condition from the stack")
-
-	randomLabel := strconv.Itoa(rand.Int())
+	label := strconv.Itoa(a.NewLabelID()) + "_" + subprogramName(graph, node)
 
 	a.Cmp(R0, 0)
 	a.AddComment("End of condition")
-	a.BranchToLabelWithCondition("else"+randomLabel, "EQ")
+	a.BranchToLabelWithCondition("else_"+label, "EQ")
 
 	// Read body
-	a.ReadBody(graph, graph.GetChildren(node)[1])
-
-	a.BranchToLabel("end_if_" + randomLabel)
+	a.ReadBody(graph, body)
 
-	if len(graph.GetChildren(node)) >= 3 {
-		// Read elsif or else
-		if graph.GetNode(graph.GetChildren(node)[2]) == "elif" {
-			a.AddComment("Elif statement")
-			a.AddLabel("else" + randomLabel)
+	a.BranchToLabel("end_if_" + label)
 
-			// Elif statement
-			a.ReadIf(graph, graph.GetChildren(node)[2])
+	if len(elifs) > 0 {
+		a.AddComment("Elif statement")
+		a.AddLabel("else_" + label)
 
-			a.AddComment("End of elif statement")
+		// Elif statement, the next elifs and the else part belong to it
+		elif := elifs[0]
+		a.readIf(graph, elif.ID(), elif.Cond, elif.Body, elifs[1:], elseBody)
 
-			if len(graph.GetChildren(node)) >= 4 {
-				// Else statement
-				a.AddComment("Else statement")
-				a.ReadBody(graph, graph.GetChildren(node)[3])
-			}
-		} else {
-			// Else statement
-			a.AddLabel("else" + randomLabel)
-			a.ReadBody(graph, graph.GetChildren(node)[2])
-		}
+		a.AddComment("End of elif statement")
+	} else if elseBody != nil {
+		// Else statement
+		a.AddLabel("else_" + label)
+		a.ReadBody(graph, elseBody)
 	} else {
-		a.AddLabel("else" + randomLabel)
+		a.AddLabel("else_" + label)
 	}
-	a.AddLabel("end_if_" + randomLabel)
+	a.AddLabel("end_if_" + label)
 
 	a.AddComment("End of if statement")
 }
 
-func (a *AssemblyFile) ReadBody(graph Graph, node int) {
-	// Read all the children
-	children := graph.GetChildren(node)
-	for _, child := range children {
-		switch graph.GetNode(child) {
-		case ":=":
-			left := graph.GetChildren(child)[0]
-			right := graph.GetChildren(child)[1]
+func (a *AssemblyFile) ReadBody(graph Graph, stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		graph.visit(stmt.ID())
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			a.ReadAssign(graph, s)
+		case *ast.ForStmt:
+			a.ReadFor(graph, s)
+		case *ast.WhileStmt:
+			a.ReadWhile(graph, s)
+		case *ast.CallStmt:
+			if s.Name.ID() == s.ID() {
+				// A name alone which is not a procedure, the semantic analysis reported it
+				continue
+			}
+			a.Call(graph, s.ID(), s.Name, s.Args)
+		case *ast.ReturnStmt:
+			a.ReadReturn(graph, s)
+			if s.Value == nil {
+				return
+			}
+		case *ast.IfStmt:
+			a.ReadIf(graph, s)
+		}
+	}
+}
 
-			a.AddComment("Assignment of " + graph.GetNode(left))
-			a.ReadOperand(graph, right)
+func (a *AssemblyFile) ReadAssign(graph Graph, stmt *ast.AssignStmt) {
+	name := accessName(stmt.Target)
 
-			// Get the address of the ident using the symbol table
-			scope := graph.getScope(node)
-			endScope, offset := goUpScope(graph, scope, left, graph.GetNode(left))
+	a.AddComment("Assignment of " + name)
+	// A scalar value stays in R0 while looking for the variable
+	inRegister := isRegisterOperand(graph, stmt.Value)
+	if inRegister {
+		a.ReadOperandToRegister(graph, stmt.Value, R0)
+	} else {
+		a.ReadOperand(graph, stmt.Value)
+	}
 
-			a.MovRegister(R9, R11)
-			if scope == endScope {
-				a.AddComment(fmt.Sprintf("(S) Store the value of %v", graph.GetNode(left)))
-			} else {
-				// Loop through dynamic links until we reach the correct region
+	// Get the address of the ident using the symbol table
+	scope := graph.getScope(stmt.ID())
+	endScope, offset := goUpScope(graph, scope, stmt.Target)
 
-				random := strconv.Itoa(rand.Int()) + "_" + graph.GetNode(node)
+	a.MovRegister(R9, R11)
+	if scope == endScope {
+		a.AddComment(fmt.Sprintf("(S) Store the value of %v", name))
+	} else {
+		// Loop through dynamic links until we reach the correct region
 
-				a.LdrFromFramePointer(R8, 4)
-				a.Cmp(R8, endScope.Region)
-				a.BranchToLabelWithCondition("notload_"+random, EQ)
-				a.AddLabel("load_" + random)
-				a.LdrFromFramePointer(R11, 8)
-				a.LdrFromFramePointer(R8, 4)
-				a.Cmp(R8, endScope.Region)
-				a.BranchToLabelWithCondition("load_"+random, NE)
+		label := labelName(name) + "_" + strconv.Itoa(a.NewLabelID())
 
-				a.AddLabel("notload_" + random)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("notload_"+label, EQ)
+		a.AddLabel("load_" + label)
+		a.LdrFromFramePointer(R11, 8)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("load_"+label, NE)
 
-				// Go 1 level up
-				a.LdrFromFramePointer(R11, 8)
+		a.AddLabel("notload_" + label)
 
-			}
-			name := graph.GetNode(left)
-			isAccess := false
-			if name == "access" {
-				// If this is an access we are looking to the right most
-				r := graph.GetChildren(left)[1]
-				for graph.GetNode(r) == "access" {
-					r = graph.GetChildren(r)[1]
-				}
-				name = graph.GetNode(r)
-				isAccess = true
-			}
-			// What's the size of the type?
-			var typeSize int
-			if isAccess {
-				// TODO: Size of the field
-				typeSize = 4
-			} else {
-				typeSize = getTypeSize(endScope.Table[name][0].Type(), *scope)
-			}
+		// Go 1 level up
+		a.LdrFromFramePointer(R11, 8)
 
-			sOffset := 0
-			for typeSize > 0 {
-				a.Ldr(R0, sOffset)
-				a.StrFromFramePointer(R0, offset+sOffset)
+	}
+	// What's the size of the type?
+	var typeSize int
+	if target, isAccess := stmt.Target.(*ast.SelectorExpr); isAccess {
+		_, fieldType := fieldLayout(scope, target)
+		typeSize = getTypeSize(fieldType, *scope)
+	} else {
+		typeSize = getTypeSize(endScope.Table[name][0].Type(), *scope)
+	}
 
-				sOffset += 4
-				typeSize -= 4
-			}
+	if inRegister {
+		a.StrFromFramePointer(R0, offset)
+	} else {
+		for word := 0; word < typeSize; word += 4 {
+			a.Ldr(R0, word)
+			a.StrFromFramePointer(R0, offset+word)
+		}
 
-			a.Add(SP, 4)
-
-			// Restore R9
-			a.MovRegister(R11, R9)
-		case "for":
-			a.ReadFor(graph, child)
-		case "while":
-			a.ReadWhile(graph, child)
-		case "call":
-			name := graph.GetChildren(child)[0]
-			var args int
-			if len(graph.GetChildren(child)) > 1 {
-				args = graph.GetChildren(child)[1]
-			} else {
-				args = -1
-			}
+		a.Add(SP, typeSize)
+	}
 
-			a.Call(child, graph, name, args)
-		case "return":
-			a.AddComment("Return statement")
-			if len(graph.GetChildren(child)) == 0 {
-				// Leave the procedure
-				// Return the in out parameters
-				symbol := graph.fullSymbols[node]
-				_, isFunction := symbol.(Function)
-				_, isProcedure := symbol.(Procedure)
-				if isFunction {
-					paramOffset := 0
-					for _, param := range symbol.(Function).Params {
-						if param.Offset > paramOffset {
-							paramOffset = param.Offset
-						}
-					}
-					for i := 1; i < len(symbol.(Function).Params)+1; i++ {
-						if symbol.(Function).Params[i].IsParamIn && symbol.(Function).Params[i].IsParamOut {
-							// Load the address of the in out parameter
-							a.LdrFromFramePointer(R0, paramOffset-symbol.(Function).Params[i].Offset+16)
-							a.CommentPreviousLine("Load the address of the in out parameter")
-							a.LdrFromFramePointer(R1, paramOffset-symbol.(Function).Params[i].Offset+4+16)
-							a.CommentPreviousLine("Load the value of the in out parameter")
-							a.StrFrom(R1, R0, 0)
-							a.CommentPreviousLine("Store the value of the in out parameter")
-						}
-					}
-				} else if isProcedure {
-					paramOffset := 0
-					for _, param := range symbol.(Procedure).Params {
-						if param.Offset > paramOffset {
-							paramOffset = param.Offset
-						}
-					}
-					for i := 1; i < len(symbol.(Procedure).Params)+1; i++ {
-						if symbol.(Procedure).Params[i].IsParamIn && symbol.(Procedure).Params[i].IsParamOut {
-							// Load the address of the in out parameter
-							a.LdrFromFramePointer(R0, paramOffset-symbol.(Procedure).Params[i].Offset+16)
-							a.CommentPreviousLine("Load the address of the in out parameter")
-							a.LdrFromFramePointer(R1, paramOffset-symbol.(Procedure).Params[i].Offset+4+16)
-							a.CommentPreviousLine("Load the value of the in out parameter")
-							a.StrFrom(R1, R0, 0)
-							a.CommentPreviousLine("Store the value of the in out parameter")
-						}
-					}
-				}
+	// Restore R9
+	a.MovRegister(R11, R9)
+}
 
-				// Need to quit every loop we are in
+// accessName returns the name used in the comments and labels about the variable: its lowercase name, access for a field
+func accessName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return getSymbolType(e.Name)
+	case *ast.SelectorExpr:
+		return "access"
+	}
+	return ""
+}
 
-				a.Add(SP, getDeclOffset(graph, node))
-				a.LdmfdMultiple([]Register{R10, R11, PC})
-				a.CommentPreviousLine("Return from the procedure")
-				return
-			}
+func (a *AssemblyFile) ReadReturn(graph Graph, stmt *ast.ReturnStmt) {
+	a.AddComment("Return statement")
+	scope := graph.getScope(stmt.ID())
+	if main, ok := findMotherFunc(scope).(Procedure); ok && main.PName == "file" {
+		// The main procedure has no caller to return to, the program ends
+		a.BranchToLabel("end_main")
+		return
+	}
+	if stmt.Value == nil {
+		// Leave the procedure
+		// Need to quit every loop we are in
+		scope, inLoop := a.leaveLoops(scope)
 
-			// Read the return operand
-			operand := graph.GetChildren(child)[0]
-			a.ReadOperand(graph, operand)
+		// Return the in out parameters
+		a.returnInOut(scope.ScopeSymbol, scope)
 
-			// Move the result to R0
-			a.Ldr(R0, 0)
+		a.clearDeclarations(graph, stmt, inLoop)
+		a.LdmfdMultiple([]Register{R10, R11, PC})
+		a.CommentPreviousLine("Return from the procedure")
+		return
+	}
 
-			// Save the result at the right place
-			// We have to jump the parameters
-			scope := graph.getScope(node)
-			fnc, isFunction := scope.ScopeSymbol.(Function)
-			paramOffset := 0
-			if isFunction {
-				for _, param := range fnc.Params {
-					size := getTypeSize(param.Type(), *scope)
-					paramOffset += size
-					if param.IsParamIn && param.IsParamOut {
-						paramOffset += 4
-					}
-				}
-			}
+	// Read the return operand
+	a.ReadOperand(graph, stmt.Value)
 
-			symbol := graph.fullSymbols[node]
-			if symbol == nil {
-				symbol = scope.ScopeSymbol
-			}
+	// Move the result to R0
+	a.Ldr(R0, 0)
 
-			/*returnedSize := getTypeSize(fnc.ReturnType, *scope)
-			offset := 0
-			for returnedSize > 0 {
-				a.Ldr(R0, offset)
-				a.StrFromFramePointer(R0, 16+paramOffset)
+	// The result is on the stack, the frames of the loops can be left
+	scope, inLoop := a.leaveLoops(scope)
+
+	// Save the result at the right place
+	// We have to jump the parameters
+	fnc, isFunction := scope.ScopeSymbol.(Function)
+	paramOffset := 0
+	if isFunction {
+		for _, param := range fnc.Params {
+			size := getTypeSize(param.Type(), *scope)
+			paramOffset += size
+			if param.IsParamIn && param.IsParamOut {
 				paramOffset += 4
-				returnedSize -= 4
-				offset += 4
-			}*/
-
-			returnedSize := getTypeSize(fnc.ReturnType, *scope)
-			offset := returnedSize - 4
-			offset2 := 0
-			paramOffset += returnedSize - 4
-			for returnedSize > 0 {
-				a.Ldr(R0, offset2)
-				a.StrFromFramePointer(R0, 16+paramOffset)
-				paramOffset -= 4
-				returnedSize -= 4
-				offset -= 4
-				offset2 += 4
 			}
+		}
+	}
 
-			// Leave the procedure
-			a.Add(SP, getTypeSize(scope.ScopeSymbol.(Function).ReturnType, *scope))
-			a.CommentPreviousLine("Remove the return value from the stack")
-
-			// Return the in out parameters
-			_, isProcedure := symbol.(Procedure)
-			if isFunction {
-				for i := 1; i < len(symbol.(Function).Params)+1; i++ {
-					if symbol.(Function).Params[i].IsParamIn && symbol.(Function).Params[i].IsParamOut {
-						// Load the address of the in out parameter
-						a.LdrFromFramePointer(R0, paramOffset-symbol.(Function).Params[i].Offset+16)
-						a.CommentPreviousLine("Load the address of the in out parameter")
-						a.LdrFromFramePointer(R1, paramOffset-symbol.(Function).Params[i].Offset+4+16)
-						a.CommentPreviousLine("Load the value of the in out parameter")
-						a.StrFrom(R1, R0, 0)
-						a.CommentPreviousLine("Store the value of the in out parameter")
-					}
-				}
-			} else if isProcedure {
-				paramOffset := 0
-				for _, param := range symbol.(Procedure).Params {
-					if param.Offset > paramOffset {
-						paramOffset = param.Offset
-					}
-				}
-				for i := 1; i < len(symbol.(Procedure).Params); i++ {
-					if symbol.(Procedure).Params[i].IsParamIn && symbol.(Procedure).Params[i].IsParamOut {
-						// Load the address of the in out parameter
-						a.LdrFromFramePointer(R0, paramOffset-symbol.(Procedure).Params[i].Offset+16)
-						a.CommentPreviousLine("Load the address of the in out parameter")
-						a.LdrFromFramePointer(R1, paramOffset-symbol.(Procedure).Params[i].Offset+4+16)
-						a.CommentPreviousLine("Load the value of the in out parameter")
-						a.StrFrom(R1, R0, 0)
-						a.CommentPreviousLine("Store the value of the in out parameter")
-					}
-				}
-			}
+	symbol := graph.fullSymbols[stmt.ID()]
+	if symbol == nil {
+		symbol = scope.ScopeSymbol
+	}
+
+	// The words of the result keep their order above the parameters
+	returnedSize := getTypeSize(fnc.ReturnType, *scope)
+	for word := 0; word < returnedSize; word += 4 {
+		a.Ldr(R0, word)
+		a.StrFromFramePointer(R0, 16+paramOffset+word)
+	}
 
-			fmt.Println("offset", getDeclOffset(graph, node))
-			a.Add(SP, getDeclOffset(graph, node))
-			a.CommentPreviousLine("Clear the stack of declarations: " + strconv.Itoa(getDeclOffset(graph, node)))
+	// Leave the procedure
+	a.Add(SP, returnedSize)
+	a.CommentPreviousLine("Remove the return value from the stack")
 
-			a.LdmfdMultiple([]Register{R10, R11, PC})
-			a.CommentPreviousLine("Return from the procedure with params")
-		case "if":
-			a.ReadIf(graph, child)
+	// Return the in out parameters
+	a.returnInOut(symbol, scope)
+
+	a.clearDeclarations(graph, stmt, inLoop)
+
+	a.LdmfdMultiple([]Register{R10, R11, PC})
+	a.CommentPreviousLine("Return from the procedure with params")
+}
+
+// leaveLoops restores the frame pointer of the subprogram when the statement is inside loops, each loop has its own frame.
+// It returns the scope of the subprogram and whether there was a loop to leave.
+func (a *AssemblyFile) leaveLoops(scope *Scope) (*Scope, bool) {
+	inLoop := false
+	for ; isLoopScope(scope); scope = scope.parent {
+		a.LdrFromFramePointer(R11, 8)
+		a.CommentPreviousLine("Leave the frame of the loop")
+		inLoop = true
+	}
+	return scope, inLoop
+}
+
+// isLoopScope reports whether the scope is the one of a for loop, it declares the index of the loop
+func isLoopScope(scope *Scope) bool {
+	for _, symbols := range scope.Table {
+		if variable, ok := symbols[0].(Variable); ok && variable.IsLoop {
+			return true
 		}
 	}
+	return false
 }
 
-func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
-	if graph.GetNode(name) == "new_line" {
-		a.Sub(SP, 4)
-		a.Mov(R0, 10)
-		a.Str(R0)
-		a.MovRegister(R0, SP)
-		a.CallProcedure("println")
+// clearDeclarations moves the stack pointer back to the saved registers of the subprogram before returning
+func (a *AssemblyFile) clearDeclarations(graph Graph, stmt *ast.ReturnStmt, inLoop bool) {
+	if inLoop {
+		// The loops left their counters on the stack as well
+		a.MovRegister(SP, R11)
 		a.Add(SP, 4)
+		a.CommentPreviousLine("Clear the stack of the loops and of the declarations")
 		return
 	}
-	if graph.GetNode(name) == "put" {
+	a.Add(SP, getDeclOffset(graph, stmt.ID()))
+	a.CommentPreviousLine("Clear the stack of declarations: " + strconv.Itoa(getDeclOffset(graph, stmt.ID())))
+}
+
+// returnInOut copies the values of the in out parameters of the subprogram to the variables of the caller
+func (a *AssemblyFile) returnInOut(symbol Symbol, scope *Scope) {
+	var params map[int]*Variable
+	switch subprogram := symbol.(type) {
+	case Function:
+		params = subprogram.Params
+	case Procedure:
+		params = subprogram.Params
+	}
+
+	// The parameters are pushed in order, the last one is just above the frame
+	paramOffset := 0
+	for _, param := range params {
+		if param.Offset > paramOffset {
+			paramOffset = param.Offset
+		}
+	}
+	for i := 1; i < len(params)+1; i++ {
+		if params[i].IsParamIn && params[i].IsParamOut {
+			// Load the address of the in out parameter
+			a.LdrFromFramePointer(R0, paramOffset-params[i].Offset+16)
+			a.CommentPreviousLine("Load the address of the in out parameter")
+			// The value is above its address
+			for word := 0; word < getTypeSize(params[i].SType, *scope); word += 4 {
+				a.LdrFromFramePointer(R1, paramOffset-params[i].Offset+4+16+word)
+				a.CommentPreviousLine("Load the value of the in out parameter")
+				a.StrFrom(R1, R0, word)
+				a.CommentPreviousLine("Store the value of the in out parameter")
+			}
+		}
+	}
+}
+
+// newLine prints a line feed, the string of one character is built on the stack
+func (a *AssemblyFile) newLine() {
+	a.Sub(SP, 8)
+	a.Mov(R0, 10)
+	a.StrFrom(R0, SP, 4)
+	a.Mov(R0, 1)
+	a.Str(R0)
+	a.MovRegister(R0, SP)
+	a.CallProcedure("println")
+	a.Add(SP, 8)
+}
+
+// putString prints a string, its value is the address of its length followed by its characters
+func (a *AssemblyFile) putString(graph Graph, expr ast.Expr) {
+	a.ReadOperandToRegister(graph, expr, R0)
+	a.CallProcedure("println")
+}
+
+// Call writes the call to the subprogram name, node is the call statement or expression
+func (a *AssemblyFile) Call(graph Graph, node int, name *ast.Ident, args []ast.Expr) {
+	switch getSymbolType(name.Name) {
+	case "new_line":
+		a.newLine()
+		return
+	case "put_line":
+		a.AddComment("Put_Line statement")
+		a.putString(graph, args[0])
+		a.newLine()
+		a.AddComment("End of Put_Line statement")
+		return
+	case "put":
+		// The semantic analysis chose the overload from the type of the argument
+		put, _ := graph.fullSymbols[name.ID()].(Procedure)
+		if put.Params[1].SType == "string" {
+			a.AddComment("Put statement")
+			a.putString(graph, args[0])
+			a.AddComment("End of put statement")
+			return
+		}
 		a.AddComment("Put statement")
-		a.ReadOperand(graph, graph.GetChildren(args)[0])
+		a.ReadOperand(graph, args[0])
 
-		// TODO: check for variable that could be a char (or char in record)
-		isChar := graph.GetNode(graph.GetChildren(args)[0]) == "cast" || graph.GetNode(graph.GetChildren(args)[0])[0] == '\''
+		isChar := put.Params[1].SType == "character"
 		if isChar {
 			a.AddComment("Printing char")
 			// Move the result to R0
 			a.Ldr(R0, 0)
 
-			a.Sub(SP, 4)
-
-			// Store result in SP with a zero first
-			a.Mov(R1, 0)
-			a.Str(R1)
+			// Store a string of one character: its length then the character
 			a.Sub(SP, 4)
 			a.Str(R0)
+			a.Mov(R1, 1)
+			a.Sub(SP, 4)
+			a.Str(R1)
 			a.MovRegister(R0, SP)
 		} else {
 			// Move the result to R0
 			a.Ldr(R0, 0)
 
-			addr := a.Fill(12)
+			addr := a.Fill(16)
 			a.LdrAddr(R3, addr)
 
 			// Cast the result
-			a.CallProcedure("to_ascii")
+			a.CallProcedure("int_text")
 
 			a.LdrAddr(R0, addr)
 		}
@@ -1025,7 +1279,7 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 			if to string
 			addr                    FILL    12
 			                        LDR     R3, =addr
-			                        BL      to_ascii
+			                        BL      int_text
 			                        ldr     r0, =addr
 		*/
 
@@ -1041,7 +1295,7 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 		return
 	}
 
-	symbol := graph.fullSymbols[name]
+	symbol := graph.fullSymbols[name.ID()]
 	_, isFunction := symbol.(Function)
 	_, isProcedure := symbol.(Procedure)
 	if isFunction {
@@ -1051,7 +1305,7 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 
 	a.AddComment("Read the arguments")
 	removedOffset := 0
-	for k, arg := range graph.GetChildren(args) {
+	for k, arg := range args {
 		a.ReadOperand(graph, arg)
 
 		// Reserve 4 bytes if the argument is in out mode
@@ -1059,7 +1313,7 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 			if symbol.(Function).Params[k+1].IsParamIn && symbol.(Function).Params[k+1].IsParamOut {
 				a.Sub(SP, 4)
 				a.CommentPreviousLine("Reserve space for the in out parameter")
-				a.StoreAddress(arg, graph)
+				a.StoreAddress(graph, arg)
 				a.AddComment("Stored the address of the in out parameter")
 
 				removedOffset += 4
@@ -1069,7 +1323,7 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 			if symbol.(Procedure).Params[k+1].IsParamIn && symbol.(Procedure).Params[k+1].IsParamOut {
 				a.Sub(SP, 4)
 				a.CommentPreviousLine("Reserve space for the in out parameter")
-				a.StoreAddress(arg, graph)
+				a.StoreAddress(graph, arg)
 				a.AddComment("Stored the address of the in out parameter")
 
 				removedOffset += 4
@@ -1079,32 +1333,33 @@ func (a *AssemblyFile) Call(node int, graph Graph, name int, args int) {
 	}
 
 	a.AddComment("Arguments read, call the procedure")
-	a.CallWithParameters(graph.symbols[name], graph.getScope(node), removedOffset) // TODO: record fix
+	a.CallWithParameters(a.subprogramLabel(graph.symbols[name.ID()]), graph.getScope(node), removedOffset) // TODO: record fix
 }
 
-func (a *AssemblyFile) StoreAddress(node int, graph Graph) {
-	scope := graph.getScope(node)
+func (a *AssemblyFile) StoreAddress(graph Graph, expr ast.Expr) {
+	scope := graph.getScope(expr.ID())
+	name := accessName(expr)
 
-	endScope, offset := goUpScope(graph, scope, node, graph.GetNode(node))
+	endScope, offset := goUpScope(graph, scope, expr)
 
 	if scope == endScope {
 		a.MovRegister(R0, R11)
 		a.Add(R0, offset)
 	} else {
 		// Loop through dynamic links until we reach the correct region
-		random := strconv.Itoa(rand.Int()) + "_" + graph.GetNode(node)
+		label := labelName(name) + "_" + strconv.Itoa(a.NewLabelID())
 
 		a.MovRegister(R9, R11)
 		a.LdrFromFramePointer(R8, 4)
 		a.Cmp(R8, endScope.Region)
-		a.BranchToLabelWithCondition("notload_"+random, EQ)
-		a.AddLabel("load_" + random)
+		a.BranchToLabelWithCondition("notload_"+label, EQ)
+		a.AddLabel("load_" + label)
 		a.LdrFromFramePointer(R11, 8)
 		a.LdrFromFramePointer(R8, 4)
 		a.Cmp(R8, endScope.Region)
-		a.BranchToLabelWithCondition("load_"+random, NE)
+		a.BranchToLabelWithCondition("load_"+label, NE)
 
-		a.AddLabel("notload_" + random)
+		a.AddLabel("notload_" + label)
 
 		// Go 1 level up
 		a.LdrFromFramePointer(R11, 8)
@@ -1118,42 +1373,37 @@ func (a *AssemblyFile) StoreAddress(node int, graph Graph) {
 	// Move the stack pointer
 
 	a.Str(R0)
-	a.CommentPreviousLine("Store the value of " + graph.GetNode(node))
+	a.CommentPreviousLine("Store the value of " + name)
 }
 
-func (a *AssemblyFile) ReadWhile(graph Graph, node int) {
+func (a *AssemblyFile) ReadWhile(graph Graph, stmt *ast.WhileStmt) {
 	a.AddComment("While statement")
 	a.AddComment("Start of condition")
 
-	randomLabel := strconv.Itoa(rand.Int())
+	label := strconv.Itoa(a.NewLabelID()) + "_" + subprogramName(graph, stmt.ID())
 
-	a.AddLabel("while" + randomLabel)
+	a.AddLabel("while_" + label)
 
 	// Read condition
-	condition := graph.GetChildren(node)[0]
-	a.ReadOperand(graph, condition)
-
-	a.Ldr(R0, 0)
-	a.CommentPreviousLine("Load result of while condition")
-	a.Add(SP, 4)
+	a.ReadOperandToRegister(graph, stmt.Cond, R0)
 
 	a.Cmp(R0, 0)
 	a.AddComment("End of condition")
-	a.BranchToLabelWithCondition("endwhile"+randomLabel, "EQ")
+	a.BranchToLabelWithCondition("endwhile_"+label, "EQ")
 
 	// Read body
-	a.ReadBody(graph, graph.GetChildren(node)[1])
+	a.ReadBody(graph, stmt.Body)
 
 	// Go to the beginning of the loop
-	a.BranchToLabel("while" + randomLabel)
+	a.BranchToLabel("while_" + label)
 
 	// End of the while loop
-	a.AddLabel("endwhile" + randomLabel)
+	a.AddLabel("endwhile_" + label)
 
 	a.AddComment("End of while statement")
 }
 
-func (a *AssemblyFile) ReadFor(graph Graph, node int) {
+func (a *AssemblyFile) ReadFor(graph Graph, stmt *ast.ForStmt) {
 	goodCounter := a.ForCounter
 	a.ForCounter++
 
@@ -1161,7 +1411,7 @@ func (a *AssemblyFile) ReadFor(graph Graph, node int) {
 
 	a.StmfdMultiple([]Register{R10, R11})
 
-	a.Mov(R10, getRegion(graph, node))
+	a.Mov(R10, getRegion(graph, stmt.ID()))
 	a.MovRegister(R11, SP)
 	a.Sub(R11, 4)
 
@@ -1169,33 +1419,36 @@ func (a *AssemblyFile) ReadFor(graph Graph, node int) {
 	a.Sub(SP, 4)
 	a.CommentPreviousLine("Reserve space for the index")
 
-	children := graph.GetChildren(node)
-	counterStart, err := strconv.Atoi(graph.GetNode(children[2]))
-	if err != nil {
-		a.ReadOperand(graph, children[2])
+	// A reverse loop counts down from the high bound to the low bound
+	start, end := stmt.Low, stmt.High
+	if stmt.Reverse {
+		start, end = stmt.High, stmt.Low
+	}
+
+	if counterStart, ok := start.(*ast.IntLit); ok {
+		a.Mov(R0, counterStart.Value)
+		a.CommentPreviousLine("Load to R0 the value of the counter: " + strconv.Itoa(counterStart.Value))
+	} else {
+		a.ReadOperand(graph, start)
 		a.Ldr(R0, 0)
 		a.CommentPreviousLine("Load to R0 the value of the counter")
 		a.Add(SP, 4)
-	} else {
-		a.Mov(R0, counterStart)
-		a.CommentPreviousLine("Load to R0 the value of the counter: " + strconv.Itoa(counterStart))
 	}
 
 	a.Str(R0)
 	a.CommentPreviousLine("Store the value of the counter")
 
-	counterEnd, err := strconv.Atoi(graph.GetNode(children[3]))
-	if err != nil {
-		a.ReadOperand(graph, children[3])
-		a.Ldr(R1, 0)
-		a.CommentPreviousLine("Load to R1 the value of the max")
-	} else {
+	if counterEnd, ok := end.(*ast.IntLit); ok {
 		// Reserve space for the max
 		a.Sub(SP, 4)
 		a.CommentPreviousLine("Reserve space for the max")
 
-		a.Mov(R1, counterEnd)
-		a.CommentPreviousLine("Load to R1 the value of the max: " + strconv.Itoa(counterEnd))
+		a.Mov(R1, counterEnd.Value)
+		a.CommentPreviousLine("Load to R1 the value of the max: " + strconv.Itoa(counterEnd.Value))
+	} else {
+		a.ReadOperand(graph, end)
+		a.Ldr(R1, 0)
+		a.CommentPreviousLine("Load to R1 the value of the max")
 	}
 	a.Str(R1)
 	a.CommentPreviousLine("Store the value of the max")
@@ -1210,18 +1463,18 @@ func (a *AssemblyFile) ReadFor(graph Graph, node int) {
 
 	a.CmpRegisters(R0, R1)
 	a.CommentPreviousLine("Compare the counter with the max")
-	if graph.GetNode(children[1]) == "not reverse" {
+	if !stmt.Reverse {
 		a.BranchToLabelWithCondition("endfor"+strconv.Itoa(goodCounter), "GT")
 	} else {
 		a.BranchToLabelWithCondition("endfor"+strconv.Itoa(goodCounter), "LT")
 	}
 
 	// Read the body of the for loop
-	a.ReadBody(graph, children[4])
+	a.ReadBody(graph, stmt.Body)
 
 	// Increment the counter
 	a.Ldr(R0, 4)
-	if graph.GetNode(children[1]) == "not reverse" {
+	if !stmt.Reverse {
 		a.Add(R0, 1)
 	} else {
 		a.Sub(R0, 1)
@@ -1251,64 +1504,63 @@ const (
 	All
 )
 
-func (a *AssemblyFile) ReadDecl(graph Graph, node int, mode DeclMode) {
-	// Read all the children
-	children := graph.GetChildren(node)
+// declKind returns the name of the declaration in the parser graph, the declarations are written sorted by kind
+func declKind(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.SubprogramDecl:
+		if d.IsFunction() {
+			return "function"
+		}
+		return "procedure"
+	case *ast.VarDecl:
+		return "var"
+	}
+	return "type"
+}
+
+// declName returns the name sorting the variable declarations, sametype when several variables are declared
+func declName(decl *ast.VarDecl) string {
+	if len(decl.Names) > 1 {
+		return "sametype"
+	}
+	return getSymbolType(decl.Names[0].Name)
+}
+
+func (a *AssemblyFile) ReadDecl(graph Graph, decls []ast.Decl, mode DeclMode) {
+	decls = slices.Clone(decls)
 
 	// Extend sort for var nodes
-	slices.SortFunc(children, func(a, b int) int {
-		nodeA := graph.GetNode(a)
-		nodeB := graph.GetNode(b)
-
-		if nodeA == "var" && nodeB == "var" {
-			sortedA := maps.Keys(graph.gmap[a])
-			slices.Sort(sortedA)
-			sortedB := maps.Keys(graph.gmap[b])
-			slices.Sort(sortedB)
-
-			nameA := graph.GetNode(sortedA[0])
-			nameB := graph.GetNode(sortedB[0])
-			return strings.Compare(nameA, nameB)
+	slices.SortFunc(decls, func(a, b ast.Decl) int {
+		varA, isVarA := a.(*ast.VarDecl)
+		varB, isVarB := b.(*ast.VarDecl)
+		if isVarA && isVarB {
+			return strings.Compare(declName(varA), declName(varB))
 		}
-		return strings.Compare(nodeA, nodeB)
+		return strings.Compare(declKind(a), declKind(b))
 	})
 
-	for _, child := range children {
-		switch graph.GetNode(child) {
-		case "var":
+	for _, decl := range decls {
+		switch d := decl.(type) {
+		case *ast.VarDecl:
 			if mode == All || mode == OnlyVar {
-				a.ReadVar(graph, child)
-			}
-		case "procedure":
-			if mode == All || mode == OnlyFuncAndProc {
-				a.ReadProcedure(graph, child)
+				a.ReadVar(graph, d)
 			}
-		case "function":
+		case *ast.SubprogramDecl:
 			if mode == All || mode == OnlyFuncAndProc {
-				a.ReadProcedure(graph, child)
+				a.ReadProcedure(graph, d)
 			}
 		}
 	}
 }
 
-func (a *AssemblyFile) ReadProcedure(graph Graph, node int) {
-	children := graph.GetChildren(node)
-	procedureName := graph.GetNode(children[0])
-
-	var bodyNode int
-	var declNode int
-	for _, child := range children {
-		if graph.GetNode(child) == "decl" {
-			declNode = child
-		} else if graph.GetNode(child) == "body" {
-			bodyNode = child
-		}
-	}
+func (a *AssemblyFile) ReadProcedure(graph Graph, decl *ast.SubprogramDecl) {
+	node := decl.ID()
+	procedureName := getSymbolType(decl.Name.Name)
 
 	a.WritingAtEnd = true
 	// Note: single character labels are not allowed
 	a.AddComment("Procedure " + procedureName)
-	a.AddLabel(graph.symbols[node])
+	a.AddLabel(a.subprogramLabel(graph.symbols[node]))
 
 	a.StmfdMultiple([]Register{R10, R11, LR})
 	a.Mov(R10, getRegion(graph, node))
@@ -1317,56 +1569,14 @@ func (a *AssemblyFile) ReadProcedure(graph Graph, node int) {
 	a.Sub(R11, 4) // SP points to R10 so we need to subtract 4
 
 	a.AddComment("Read variable declarations")
-	a.ReadDecl(graph, declNode, OnlyVar)
+	a.ReadDecl(graph, decl.Decls, OnlyVar)
 
 	a.AddComment("Read the body of the procedure")
 	// Read the body of the procedure
-	a.ReadBody(graph, bodyNode)
+	a.ReadBody(graph, decl.Body)
 
 	// Return the in out parameters
-	symbol := graph.fullSymbols[node]
-	_, isFunction := symbol.(Function)
-	_, isProcedure := symbol.(Procedure)
-	if isFunction {
-		paramOffset := 0
-		for _, param := range symbol.(Function).Params {
-			if param.Offset > paramOffset {
-				paramOffset = param.Offset
-				if param.IsParamIn && param.IsParamOut {
-					paramOffset += 4
-				}
-			}
-		}
-		for i := 1; i < len(symbol.(Function).Params)+1; i++ {
-			if symbol.(Function).Params[i].IsParamIn && symbol.(Function).Params[i].IsParamOut {
-				// Load the address of the in out parameter
-				a.LdrFromFramePointer(R0, paramOffset-symbol.(Function).Params[i].Offset+16)
-				a.CommentPreviousLine("Load the address of the in out parameter")
-				a.LdrFromFramePointer(R1, paramOffset-symbol.(Function).Params[i].Offset+4+16)
-				a.CommentPreviousLine("Load the value of the in out parameter")
-				a.StrFrom(R1, R0, 0)
-				a.CommentPreviousLine("Store the value of the in out parameter")
-			}
-		}
-	} else if isProcedure {
-		paramOffset := 0
-		for _, param := range symbol.(Procedure).Params {
-			if param.Offset > paramOffset {
-				paramOffset = param.Offset
-			}
-		}
-		for i := 1; i < len(symbol.(Procedure).Params)+1; i++ {
-			if symbol.(Procedure).Params[i].IsParamIn && symbol.(Procedure).Params[i].IsParamOut {
-				// Load the address of the in out parameter
-				a.LdrFromFramePointer(R0, paramOffset-symbol.(Procedure).Params[i].Offset+16)
-				a.CommentPreviousLine("Load the address of the in out parameter")
-				a.LdrFromFramePointer(R1, paramOffset-symbol.(Procedure).Params[i].Offset+4+16)
-				a.CommentPreviousLine("Load the value of the in out parameter")
-				a.StrFrom(R1, R0, 0)
-				a.CommentPreviousLine("Store the value of the in out parameter")
-			}
-		}
-	}
+	a.returnInOut(graph.fullSymbols[node], graph.getScope(node))
 
 	a.Add(SP, getDeclOffset(graph, node))
 	a.CommentPreviousLine("Clear the stack of declarations: " + strconv.Itoa(getDeclOffset(graph, node)))
@@ -1378,39 +1588,21 @@ func (a *AssemblyFile) ReadProcedure(graph Graph, node int) {
 
 	a.WritingAtEnd = false
 
-	a.ReadDecl(graph, declNode, OnlyFuncAndProc)
+	a.ReadDecl(graph, decl.Decls, OnlyFuncAndProc)
 }
 
-func (a *AssemblyFile) ReadVar(graph Graph, node int) {
-	var nameList []string
-	var hasValue bool
-
-	name := graph.GetChildren(node)[0]
-
-	if graph.GetNode(name) == "sametype" {
-		for _, child := range graph.GetChildren(name) {
-			nameList = append(nameList, graph.GetNode(child))
-		}
-	} else {
-		nameList = append(nameList, graph.GetNode(name))
-	}
-	if len(graph.GetChildren(node)) > 2 {
-		hasValue = true
-	}
-
+func (a *AssemblyFile) ReadVar(graph Graph, decl *ast.VarDecl) {
 	// use values from the symbol table
-	scope := graph.getScope(node)
+	scope := graph.getScope(decl.ID())
 
-	for _, name := range nameList {
+	for _, ident := range decl.Names {
+		name := getSymbolType(ident.Name)
 		for _, symbol := range scope.Table[name] {
 			if variable, ok := symbol.(Variable); ok {
-				if hasValue {
-					//offset := getTypeSize(variable.SType, *scope)
-
+				if decl.Value != nil {
 					a.AddComment("Read the value of " + name)
 
-					value := graph.GetChildren(node)[2]
-					a.ReadOperand(graph, value)
+					a.ReadOperand(graph, decl.Value)
 					a.Ldr(R0, 0)
 
 					// Load the int value to r0
@@ -1428,113 +1620,188 @@ func (a *AssemblyFile) ReadVar(graph Graph, node int) {
 	}
 }
 
-func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
+func (a *AssemblyFile) ReadOperand(graph Graph, expr ast.Expr) {
+	// Scalar expressions are evaluated in registers, only the result is pushed
+	if isRegisterOperand(graph, expr) {
+		a.ReadOperandToRegister(graph, expr, R0)
+		a.Sub(SP, 4)
+		a.Str(R0)
+		return
+	}
+
 	// Read left and right operands and do the operation
 	// If the operands are values, use them
 	// Else, save them in stack and use them
-	children := graph.GetChildren(node)
-	// sort
-
-	if len(children) == 0 {
-		// The operand is a value
-		// Different cases: int, char or ident
-		intValue, err := strconv.Atoi(graph.GetNode(node))
-		if err == nil {
-			// Move the stack pointer
-			a.Sub(SP, 4)
+	switch e := expr.(type) {
+	case *ast.IntLit:
+		// Move the stack pointer
+		a.Sub(SP, 4)
 
-			// The operand is an int
-			// Load the int value to r0
-			a.Mov(R0, intValue)
-			a.Str(R0)
+		// The operand is an int
+		// Load the int value to r0
+		a.Mov(R0, e.Value)
+		a.Str(R0)
+	case *ast.BoolLit:
+		// Move the stack pointer
+		a.Sub(SP, 4)
+
+		// The operand is a bool
+		// Load the bool value to r0
+		if e.Value {
+			a.Mov(R0, 1)
 		} else {
-			if graph.GetNode(node) == "true" {
-				// Move the stack pointer
-				a.Sub(SP, 4)
+			a.Mov(R0, 0)
+		}
+		a.Str(R0)
+	case *ast.CharLit:
+		// Move the stack pointer
+		a.Sub(SP, 4)
 
-				// The operand is a bool
-				// Load the bool value to r0
-				a.Mov(R0, 1)
-				a.Str(R0)
-			} else if graph.GetNode(node) == "false" {
-				// Move the stack pointer
-				a.Sub(SP, 4)
+		// The operand is a char
+		// Load the char value to r0
+		a.Mov(R0, int(e.Value))
+		a.Str(R0)
+	case *ast.StringLit:
+		a.Sub(SP, 4)
 
-				// The operand is a bool
-				// Load the bool value to r0
-				a.Mov(R0, 0)
-				a.Str(R0)
-			} else if graph.GetNode(node)[0] == '\'' {
-				// Move the stack pointer
-				a.Sub(SP, 4)
+		// The operand is the address of the string
+		a.LdrAddr(R0, a.StringConstant(e.Value))
+		a.Str(R0)
+	case *ast.NullLit, *ast.NewExpr:
+		// The ARM backend has no heap for the access types, a null pushed in their place keeps the stack balanced
+		a.fail(graph, e, "access values are not supported by the ARM backend")
+		a.Sub(SP, 4)
+		a.Mov(R0, 0)
+		a.Str(R0)
+	case *ast.Ident:
+		a.readIdent(graph, e)
+	case *ast.BinaryExpr:
+		a.readBinary(graph, e)
+	case *ast.AttributeExpr:
+		a.readImage(graph, e)
+	case *ast.UnaryExpr:
+		// Read right operand
+		a.ReadOperand(graph, e.X)
 
-				// The operand is a char
-				// Load the char value to r0
-				a.Mov(R0, int(graph.GetRealNode(node)[1]))
-				a.Str(R0)
-			} else {
-				// The operand is an ident
-				// Load the ident value to r0
+		a.Ldr(R0, 0)
+		if e.Op == "-" {
+			a.Negate(R0)
+		} else {
+			// The booleans are 0 and 1
+			a.Xor(R0, 1)
+			a.CommentPreviousLine("Not " + R0.String())
+		}
 
-				// Get the address of the ident using the symbol table
-				scope := graph.getScope(node)
+		a.Str(R0)
+	case *ast.CallExpr:
+		a.Call(graph, e.ID(), e.Fun, e.Args)
+	case *ast.SelectorExpr:
+		a.readSelector(graph, e)
+	}
+}
 
-				endScope, offset := goUpScope(graph, scope, node, graph.GetNode(node))
+// readImage pushes the value of the attributes calling the runtime: the address of the image
+// or the length of a string. The image is written in a buffer of the expression, it is overwritten
+// when the expression is evaluated again.
+func (a *AssemblyFile) readImage(graph Graph, attribute *ast.AttributeExpr) {
+	if getSymbolType(attribute.Name.Name) == "length" {
+		a.ReadOperandToRegister(graph, attribute.Prefix, R0)
+		a.CallProcedure("str_length")
+		a.Sub(SP, 4)
+		a.Str(R0)
+		return
+	}
 
-				a.MovRegister(R9, R11)
-				if scope == endScope {
-					a.AddComment(fmt.Sprintf("(S) Load the value of %v", graph.GetNode(node)))
-				} else {
-					// Loop through dynamic links until we reach the correct region
-					random := strconv.Itoa(rand.Int()) + "_" + graph.GetNode(node)
+	a.ReadOperandToRegister(graph, attribute.Args[0], R0)
+	switch getSymbolType(attribute.Prefix.Name) {
+	case "boolean":
+		a.Cmp(R0, 0)
+		a.LdrAddr(R0, a.StringConstant("FALSE"))
+		label := "image_" + strconv.Itoa(a.NewLabelID())
+		a.BranchToLabelWithCondition(label, EQ)
+		a.LdrAddr(R0, a.StringConstant("TRUE"))
+		a.AddLabel(label)
+	case "character":
+		// The length and three characters
+		addr := a.Fill(8)
+		a.LdrAddr(R3, addr)
+		a.CallProcedure("char_image")
+		a.LdrAddr(R0, addr)
+	default:
+		// The length, a minus sign and ten digits
+		addr := a.Fill(16)
+		a.LdrAddr(R3, addr)
+		a.CallProcedure("int_image")
+		a.LdrAddr(R0, addr)
+	}
+	a.Sub(SP, 4)
+	a.Str(R0)
+}
 
-					a.LdrFromFramePointer(R8, 4)
-					a.Cmp(R8, endScope.Region)
-					a.BranchToLabelWithCondition("notload_"+random, EQ)
-					a.AddLabel("load_" + random)
-					a.LdrFromFramePointer(R11, 8)
-					a.LdrFromFramePointer(R8, 4)
-					a.Cmp(R8, endScope.Region)
-					a.BranchToLabelWithCondition("load_"+random, NE)
+// readIdent pushes the value of the variable, a record is pushed 4 bytes at a time
+func (a *AssemblyFile) readIdent(graph Graph, ident *ast.Ident) {
+	name := getSymbolType(ident.Name)
 
-					a.AddLabel("notload_" + random)
+	// Get the address of the ident using the symbol table
+	scope := graph.getScope(ident.ID())
 
-					// Go 1 level up
-					a.LdrFromFramePointer(R11, 8)
+	endScope, offset := goUpScope(graph, scope, ident)
 
-					a.AddComment(fmt.Sprintf("(NS) Load the value of %v", graph.GetNode(node)))
-				}
+	a.MovRegister(R9, R11)
+	if scope == endScope {
+		a.AddComment(fmt.Sprintf("(S) Load the value of %v", name))
+	} else {
+		// Loop through dynamic links until we reach the correct region
+		label := labelName(name) + "_" + strconv.Itoa(a.NewLabelID())
 
-				// What's the size of the type?
-				typeSize := getTypeSize(endScope.Table[graph.GetNode(node)][0].Type(), *scope)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("notload_"+label, EQ)
+		a.AddLabel("load_" + label)
+		a.LdrFromFramePointer(R11, 8)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("load_"+label, NE)
 
-				for typeSize > 0 {
-					a.LdrFromFramePointer(R0, offset)
+		a.AddLabel("notload_" + label)
 
-					// Move the stack pointer
-					a.Sub(SP, 4)
-					a.CommentPreviousLine("Reserve space for the value of " + graph.GetNode(node))
+		// Go 1 level up
+		a.LdrFromFramePointer(R11, 8)
 
-					a.Str(R0)
-					a.CommentPreviousLine("Store the value of " + graph.GetNode(node))
+		a.AddComment(fmt.Sprintf("(NS) Load the value of %v", name))
+	}
 
-					offset += 4
-					typeSize -= 4
-				}
+	// What's the size of the type?
+	typeSize := getTypeSize(endScope.Table[name][0].Type(), *scope)
+	a.pushWords(offset, typeSize, name)
 
-				// Restore R9
-				a.MovRegister(R11, R9)
-			}
-		}
+	// Restore R9
+	a.MovRegister(R11, R9)
+}
+
+// pushWords pushes the value of size bytes at offset from the frame pointer, starting with the last word
+// so that the words keep the order they have in the frame
+func (a *AssemblyFile) pushWords(offset int, size int, name string) {
+	for word := size - 4; word >= 0; word -= 4 {
+		a.LdrFromFramePointer(R0, offset+word)
+
+		// Move the stack pointer
+		a.Sub(SP, 4)
+		a.CommentPreviousLine("Reserve space for the value of " + name)
+
+		a.Str(R0)
+		a.CommentPreviousLine("Store the value of " + name)
 	}
+}
 
-	switch graph.GetNode(node) {
+func (a *AssemblyFile) readBinary(graph Graph, expr *ast.BinaryExpr) {
+	switch expr.Op {
 	case "+":
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 		a.Ldr(R0, 0)
 		a.AddWithOffset(R0, R1, 4) // same as ldr from offset 8 then add
 
@@ -1544,10 +1811,10 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 		a.Str(R0)
 	case "-":
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 		a.Ldr(R0, 0)
 		a.SubWithOffset(R0, R1, 4)
 
@@ -1557,10 +1824,10 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 		a.Str(R0)
 	case "*":
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 
 		// Left operand in R1, right operand in R2
 		a.Ldr(R1, 0)
@@ -1575,10 +1842,10 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 		a.Str(R0)
 	case "/", "rem":
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 
 		// Left operand in R0, right operand in R1
 		a.Ldr(R2, 0)
@@ -1587,7 +1854,7 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 		// Use the division algorithm at the label div32
 		a.CallProcedure("div32")
 
-		if graph.GetNode(node) == "rem" {
+		if expr.Op == "rem" {
 			a.MovRegister(R0, R1)
 		}
 
@@ -1595,12 +1862,12 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 
 		// Save the result in stack
 		a.Str(R0)
-	case "and":
+	case "and", "and then":
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 
 		// Left operand in R1, right operand in R2
 		a.Ldr(R1, 0)
@@ -1611,14 +1878,37 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 
 		a.Add(SP, 4)
 
+		// Save the result in stack
+		a.Str(R0)
+	case "or", "or else":
+		// Read left operand
+		a.ReadOperand(graph, expr.X)
+
+		// Read right operand
+		a.ReadOperand(graph, expr.Y)
+
+		// Left operand in R1, right operand in R2
+		a.Ldr(R1, 0)
+		a.Ldr(R2, 4)
+
+		// Use the OR operation
+		a.OpRegisters("ORR", R0, R1, R2)
+
+		a.Add(SP, 4)
+
 		// Save the result in stack
 		a.Str(R0)
 	case ">", "=", "<", "<=", ">=", "/=", "!=":
+		if size := operandSize(graph, expr.X); size > 4 {
+			a.compareRecords(graph, expr, size)
+			return
+		}
+
 		// Read left operand
-		a.ReadOperand(graph, children[0])
+		a.ReadOperand(graph, expr.X)
 
 		// Read right operand
-		a.ReadOperand(graph, children[1])
+		a.ReadOperand(graph, expr.Y)
 
 		// Left operand in R0, right operand in R1
 		a.Ldr(R1, 0)
@@ -1626,7 +1916,7 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 
 		// Compare the operands
 		a.CmpRegisters(R0, R1)
-		switch graph.GetNode(node) {
+		switch expr.Op {
 		case ">":
 			a.MovCond(R0, 1, GT)
 			a.MovCond(R0, 0, LE)
@@ -1651,106 +1941,157 @@ func (a *AssemblyFile) ReadOperand(graph Graph, node int) {
 
 		// Save the result in stack
 		a.Str(R0)
-	case "cast":
-		// Read the operand
-		a.ReadOperand(graph, children[1])
-
-		// Move the result to R0
-		a.Ldr(R0, 0)
-
-		/*addr := a.Fill(12)
-		a.LdrAddr(R3, addr)
+	}
+}
 
-		// Cast the result
-		a.CallProcedure("to_ascii")
+// compareRecords pushes whether the records of the equality are equal or not, they are compared word by word
+// and their strings character by character
+func (a *AssemblyFile) compareRecords(graph Graph, expr *ast.BinaryExpr, size int) {
+	a.ReadOperand(graph, expr.X)
+	a.ReadOperand(graph, expr.Y)
 
-		a.LdrAddr(R0, addr)*/
-	case "call":
-		if graph.GetNode(children[0]) == "-" {
-			// Read right operand
-			a.ReadOperand(graph, children[1])
+	texts := map[int]bool{}
+	if typ, scope := operandType(graph, expr.X); typ != "" {
+		stringOffsets(typ, *scope, 0, texts)
+	}
+	label := "record_" + strconv.Itoa(a.NewLabelID())
+	for word := 0; word < size; word += 4 {
+		a.Ldr(R0, word)
+		a.Ldr(R1, size+word)
+		if texts[word] {
+			a.CallProcedure("str_equal")
+		} else {
+			a.CmpRegisters(R0, R1)
+		}
+		a.BranchToLabelWithCondition("end_"+label, NE)
+	}
+	a.AddLabel("end_" + label)
+	if expr.Op == "=" {
+		a.MovCond(R0, 1, EQ)
+		a.MovCond(R0, 0, NE)
+	} else {
+		a.MovCond(R0, 1, NE)
+		a.MovCond(R0, 0, EQ)
+	}
 
-			a.Ldr(R0, 0)
-			a.Negate(R0)
+	// Remove both records and save the result in stack
+	a.Add(SP, 2*size-4)
+	a.Str(R0)
+}
 
-			a.Str(R0)
-		} else if graph.GetNode(children[0]) == "not" {
-			// Read right operand
-			a.ReadOperand(graph, children[1])
+// operandType returns the type of the value of a variable, a field or a call and the scope of the expression,
+// the type is empty for the other expressions
+func operandType(graph Graph, expr ast.Expr) (string, *Scope) {
+	scope := graph.getScope(expr.ID())
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if variable, _, ok := lookupVariable(scope, getSymbolType(e.Name)); ok {
+			return variable.SType, scope
+		}
+	case *ast.SelectorExpr:
+		_, fieldType := selectorLayout(graph, scope, e)
+		return fieldType, scope
+	case *ast.CallExpr:
+		if function, ok := graph.fullSymbols[e.Fun.ID()].(Function); ok {
+			return function.ReturnType, scope
+		}
+	}
+	return "", scope
+}
 
-			a.Ldr(R0, 0)
-			a.Not(R0)
+// operandSize returns the size of the value of the expression on the stack
+func operandSize(graph Graph, expr ast.Expr) int {
+	if typ, scope := operandType(graph, expr); typ != "" {
+		return getTypeSize(typ, *scope)
+	}
+	return 4
+}
 
-			a.Str(R0)
-		} else {
-			name := graph.GetChildren(node)[0]
-			var args int
-			if len(graph.GetChildren(node)) > 1 {
-				args = graph.GetChildren(node)[1]
-			} else {
-				args = graph.GetChildren(node)[0]
+// stringOffsets adds the offsets of the strings of a value of the type, with the strings of its nested records
+func stringOffsets(typ string, scope Scope, offset int, texts map[int]bool) {
+	if typ == "string" {
+		texts[offset] = true
+		return
+	}
+	if recScope := getMeScope(typ, scope); recScope != nil {
+		if rec, ok := recScope.Table[typ][0].(Record); ok {
+			for name, field := range rec.Fields {
+				stringOffsets(field, *recScope, offset+rec.FieldsOffset[name], texts)
 			}
-
-			a.Call(node, graph, name, args)
-
-			// Move the stack pointer
-			/*size := getTypeSize(graph.fullSymbols[graph.GetChildren(node)[0]].(Function).ReturnType, *graph.getScope(node))
-			fmt.Println(size)
-			a.Sub(SP, getTypeSize(graph.fullSymbols[graph.GetChildren(node)[0]].(Function).ReturnType, *graph.getScope(node)))
-
-			o := 0
-			for size > 0 {
-				a.Ldr(R0, o)
-				a.StrWithOffset(R0, o)
-				size -= 4
-				o += 4
-			}*/
 		}
 	}
+}
 
-	if graph.GetNode(node) == "access" {
-		// The operand is an ident
-		// Load the ident value to r0
-
-		// Get the address of the ident using the symbol table
-		scope := graph.getScope(node)
+// readSelector pushes the value of the field of the record
+// selectorLayout returns the offset of the field from the start of the outermost record and the type of the field,
+// the outermost record being a variable or the value returned by a function
+func selectorLayout(graph Graph, scope *Scope, sel *ast.SelectorExpr) (int, string) {
+	root, fields := selectorPath(sel)
+	if call, ok := root.(*ast.CallExpr); ok {
+		function, _ := graph.fullSymbols[call.Fun.ID()].(Function)
+		return recordLayout(scope, function.ReturnType, fields)
+	}
+	return fieldLayout(scope, sel)
+}
 
-		endScope, offset := goUpScope(graph, scope, node, graph.GetNode(node))
+// readFieldOfCall pushes the field of the record returned by the call: the record is pushed,
+// then the words of the field are moved to the top of the record and the other words are removed
+func (a *AssemblyFile) readFieldOfCall(graph Graph, expr *ast.SelectorExpr, call *ast.CallExpr) {
+	scope := graph.getScope(expr.ID())
+	offset, fieldType := selectorLayout(graph, scope, expr)
+	recordSize := operandSize(graph, call)
+	fieldSize := getTypeSize(fieldType, *scope)
+
+	a.ReadOperand(graph, call)
+	a.AddComment("Keep the field of the returned record")
+	for word := fieldSize - 4; word >= 0; word -= 4 {
+		a.Ldr(R0, offset+word)
+		a.StrWithOffset(R0, recordSize-fieldSize+word)
+	}
+	a.Add(SP, recordSize-fieldSize)
+}
 
-		if scope == endScope {
-			a.LdrFrom(R0, R11, offset)
-			a.CommentPreviousLine(fmt.Sprintf("(S) Load the value of %v", graph.GetNode(node)))
-		} else {
-			// Loop through dynamic links until we reach the correct region
-			random := strconv.Itoa(rand.Int()) + "_" + graph.GetNode(node)
+func (a *AssemblyFile) readSelector(graph Graph, expr *ast.SelectorExpr) {
+	// The record returned by a function has no address, it is pushed on the stack
+	root, _ := selectorPath(expr)
+	if call, ok := root.(*ast.CallExpr); ok {
+		a.readFieldOfCall(graph, expr, call)
+		return
+	}
 
-			a.MovRegister(R9, R11)
-			a.LdrFromFramePointer(R8, 4)
-			a.Cmp(R8, endScope.Region)
-			a.BranchToLabelWithCondition("notload_"+random, EQ)
-			a.AddLabel("load_" + random)
-			a.LdrFromFramePointer(R11, 8)
-			a.LdrFromFramePointer(R8, 4)
-			a.Cmp(R8, endScope.Region)
-			a.BranchToLabelWithCondition("load_"+random, NE)
+	// Get the address of the ident using the symbol table
+	scope := graph.getScope(expr.ID())
 
-			a.AddLabel("notload_" + random)
+	endScope, offset := goUpScope(graph, scope, expr)
+	_, fieldType := fieldLayout(scope, expr)
 
-			// Go 1 level up
-			a.LdrFromFramePointer(R11, 8)
+	a.MovRegister(R9, R11)
+	if scope == endScope {
+		a.AddComment("(S) Load the value of access")
+	} else {
+		// Loop through dynamic links until we reach the correct region
+		label := "access_" + strconv.Itoa(a.NewLabelID())
 
-			a.LdrFromFramePointer(R0, offset)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("notload_"+label, EQ)
+		a.AddLabel("load_" + label)
+		a.LdrFromFramePointer(R11, 8)
+		a.LdrFromFramePointer(R8, 4)
+		a.Cmp(R8, endScope.Region)
+		a.BranchToLabelWithCondition("load_"+label, NE)
 
-			// Restore R9
-			a.MovRegister(R11, R9)
+		a.AddLabel("notload_" + label)
 
-			a.CommentPreviousLine(fmt.Sprintf("(NS) Load the value of %v", graph.GetNode(node)))
-		}
-		// Move the stack pointer
-		a.Sub(SP, 4)
-		a.CommentPreviousLine("Reserve space for the value of " + graph.GetNode(node))
+		// Go 1 level up
+		a.LdrFromFramePointer(R11, 8)
 
-		a.Str(R0)
-		a.CommentPreviousLine("Store the value of " + graph.GetNode(node))
+		a.AddComment("(NS) Load the value of access")
 	}
+
+	// The field might be a record itself
+	a.pushWords(offset, getTypeSize(fieldType, *scope), "access")
+
+	// Restore R9
+	a.MovRegister(R11, R9)
 }
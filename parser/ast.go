@@ -3,7 +3,9 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"gada/ast"
 	"gada/lexer"
+	"gada/token"
 	"slices"
 	"sort"
 	"strings"
@@ -25,10 +27,18 @@ type Graph struct {
 	hasReturn   map[int]struct{}
 	nbNode      int
 	lexer       *lexer.Lexer
+	// file is the typed tree of the graph, it shares the ids of the nodes
+	file *ast.File
+	// current is the node processed by the running phase, shared by the copies of the graph
+	current *int
+	// phase is the name of the running phase, shared by the copies of the graph
+	phase *string
+	// semanticErrors counts the errors reported by the semantic analysis
+	semanticErrors *int
 }
 
 func (g Graph) GetNode(node int) string {
-	return strings.ToLower(g.types[node])
+	return token.FoldIdentifier(g.types[node])
 }
 
 func (g Graph) GetRealNode(node int) string {
@@ -74,6 +84,16 @@ func fromJSON(jsonStr string) (*Node, error) {
 	return &node, nil
 }
 
+// quoteString writes a string as an Ada literal, the quotes inside it are doubled
+func quoteString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// unquoteString returns the value of a string written by quoteString
+func unquoteString(literal string) string {
+	return strings.ReplaceAll(literal[1:len(literal)-1], `""`, `"`)
+}
+
 func nodeManagement(node Node, lexer lexer.Lexer) (string, bool) {
 	// this change node Types depending on his current type and childs
 	// this is the function choosing if a node has some interest and change their name
@@ -82,13 +102,16 @@ func nodeManagement(node Node, lexer lexer.Lexer) (string, bool) {
 		return "file", true
 		// ident
 	case "Ident":
-		return lexer.Lexi[node.Index-1], true
+		return lexer.Word(node.Index), true
 		// Int
 	case "PrimaryExprInt":
-		return lexer.Lexi[node.Index-1], true
+		return lexer.Word(node.Index), true
 		// Char
 	case "PrimaryExprChar":
-		return "'" + lexer.Lexi[node.Index-1] + "'", true
+		return "'" + lexer.Word(node.Index) + "'", true
+		// String
+	case "PrimaryExprString":
+		return quoteString(lexer.Word(node.Index)), true
 		// True
 	case "PrimaryExprTrue":
 		return "True", true
@@ -101,116 +124,37 @@ func nodeManagement(node Node, lexer lexer.Lexer) (string, bool) {
 		// assignation
 	case ":=":
 		return ":=", true
-		// equality
-	case "EqualityExpr":
-		for _, child := range node.Children {
-			if child.Type == "EqualityExprTailEql" {
-				return "=", true
-			} else if child.Type == "EqualityExprTailNeq" {
-				return "!=", true
-			}
-		}
-		return node.Type, false
+		// binary operators, the left operand is the first child
 	case "EqualityExprTailEql":
 		return "=", true
 	case "EqualityExprTailNeq":
 		return "!=", true
-	// and or
-	case "OrExpr":
-		for _, child := range node.Children {
-			if child.Type == "OrExprTailOr" {
-				return "or", true
-			}
-		}
-		return node.Type, false
 	case "OrExprTail2":
-		for _, child := range node.Children {
-			if child.Type == "OrExprTailOr" {
-				return "or", true
-			}
-		}
+		return "or", true
 	case "OrExprTail2Else":
-		for _, child := range node.Children {
-			if child.Type == "OrExprTailOr" {
-				return "or else", true
-			}
-		}
-		return node.Type, false
-	case "OrExprTail":
-		if (len(node.Children)) > 1 {
-			return "or", true
-		}
-		return node.Type, false
-	case "AndExprTail2Then":
-		for _, child := range node.Children {
-			if child.Type == "AndExprTailAnd" {
-				return "and then", true
-			}
-		}
-		return node.Type, false
-	case "AndExpr":
-		for _, child := range node.Children {
-			if child.Type == "AndExprTailAnd" {
-				return "and", true
-			}
-		}
-		return node.Type, false
-	case "AndExprTail":
-		if (len(node.Children)) > 1 {
-			return "and", true
-		}
-		return node.Type, false
+		return "or else", true
 	case "AndExprTail2":
-		for _, child := range node.Children {
-			if child.Type == "AndExprTailAnd" {
-				return "and", true
-			}
-		}
-		// operators
-	case "AdditiveExpr":
-		for _, child := range node.Children {
-			if child.Type == "AdditiveExprTailAdd" {
-				return "+", true
-			} else if child.Type == "AdditiveExprTailSub" {
-				return "-", true
-			}
-		}
-		return node.Type, false
+		return "and", true
+	case "AndExprTail2Then":
+		return "and then", true
 	case "AdditiveExprTailAdd":
 		return "+", true
 	case "AdditiveExprTailSub":
 		return "-", true
-	case "MultiplicativeExpr":
-		for _, child := range node.Children {
-			if child.Type == "MultiplicativeExprTailMul" {
-				return "*", true
-			} else if child.Type == "MultiplicativeExprTailQuo" {
-				return "/", true
-			} else if child.Type == "MultiplicativeExprTailRem" {
-				return "rem", true
-			}
-		}
-		return node.Type, false
 	case "MultiplicativeExprTailRem":
 		return "rem", true
 	case "MultiplicativeExprTailQuo":
 		return "/", true
 	case "MultiplicativeExprTailMul":
 		return "*", true
-		// relational expr
-	case "RelationalExpr":
-		for _, child := range node.Children {
-			if child.Type == "RelationalExprTailLss" {
-				return "<", true
-			} else if child.Type == "RelationalExprTailLeq" {
-				return "<=", true
-			} else if child.Type == "RelationalExprTailGtr" {
-				return ">", true
-			} else if child.Type == "RelationalExprTailGeq" {
-				return ">=", true
-			}
-		}
-		return node.Type, false
+	case "RelationalExprTailLss":
+		return "<", true
+	case "RelationalExprTailLeq":
+		return "<=", true
+	case "RelationalExprTailGtr":
+		return ">", true
+	case "RelationalExprTailGeq":
+		return ">=", true
 		// procedure call
 	case "InstrIdent":
 		for _, child := range node.Children {
@@ -225,6 +169,8 @@ func nodeManagement(node Node, lexer lexer.Lexer) (string, bool) {
 				return "call", true
 			} else if child.Type == "PrimaryExpr2Period" { // call ident.ident
 				return "access", true
+			} else if child.Type == "PrimaryExpr2Tick" { // attribute ident'ident
+				return "attribute", true
 			}
 		}
 		return node.Type, false
@@ -294,15 +240,14 @@ func nodeManagement(node Node, lexer lexer.Lexer) (string, bool) {
 		return "attrib", true
 	case "PrimaryExprNew":
 		return "ExprNew", true
-		// Char cast
-	case "PrimaryExprCharTok":
-		return "CharTok", true
+		// attribute, its designator and arguments go up to the attribute node
+	case "PrimaryExpr2Tick":
+		return "tick", true
 	case "InstrReturn":
 		return "return", true
 	default:
 		return node.Type, false
 	}
-	return "", false
 }
 
 func addNodes(node *Node, graph *Graph, lexer lexer.Lexer, depth int, newName bool) {
@@ -356,16 +301,20 @@ func createGraph(node Node, lexer lexer.Lexer) *Graph {
 	graph.symbols = make(map[int]string)
 	graph.fullSymbols = make(map[int]Symbol)
 	graph.nbNode = 0
+	graph.current = new(int)
+	graph.phase = new(string)
+	graph.semanticErrors = new(int)
+	graph.lexer = &lexer
 	addNodes(&node, &graph, lexer, 1, true)
 
 	return &graph
 }
 
 func clearchains(g *Graph) {
-	// remove chains of single node link to each other
+	// remove chains of single node link to each other, the root (node 0) has no father and stays
 	for term, _ := range g.meaningful {
 		tpTo := term
-		for len(g.gmap[g.fathers[tpTo]]) == 1 && !keepUsefulNodes(g, g.fathers[tpTo]) {
+		for tpTo != 0 && len(g.gmap[g.fathers[tpTo]]) == 1 && !keepUsefulNodes(g, g.fathers[tpTo]) {
 			pastNode := tpTo
 			tpTo = g.fathers[tpTo]
 			if pastNode != term {
@@ -669,7 +618,7 @@ func removeUselessTerminals(g *Graph) {
 	uselessKeywords := []string{"Access2", "InstrPlus2", "DeclStarBegin", "Instr2Semicolon", "ExprPlusComma2Rparen", "",
 		"ElseIfStar", "IdentPlusComma2Colon", "ParamPlusSemicolon2RParen", "PrimaryExpr3", "InitSemicolon", "ParamsOpt",
 		"ModeOpt", "ReverseInstr", "decl", "ChampsPlus2End", "ElseInstrOptEnd", "ExprOptSemicolon",
-		"OrExprTail", "AndExprTail", "EqualityExprTail", "RelationalExprTail", "IdentPlusComma2Semicolon"}
+		"OrExprTail", "AndExprTail", "AttributeArgs", "EqualityExprTail", "RelationalExprTail", "IdentPlusComma2Semicolon"}
 
 	for term := range g.terminals {
 		if Contains(uselessKeywords, g.types[term]) {
@@ -753,8 +702,10 @@ func upTheNode(g *Graph, node int) {
 		if g.types[g.fathers[node]] == "ParamPlusSemicolon2" {
 			goUpChilds(g, g.fathers[node])
 		}
-	case "CharTok":
-		makeChild(g, node, "cast", "char")
+	case "tick":
+		if g.types[g.fathers[node]] == "attribute" {
+			goUpChilds(g, node)
+		}
 	}
 }
 
@@ -783,6 +734,7 @@ func toAst(node Node, lexer lexer.Lexer) Graph {
 	clearchains(graph)
 	removeUselessTerminals(graph)
 	clearchains(graph)
+	graph.file = buildFile(*graph)
 
 	return *graph
 
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"gada/ast"
+	"math"
+)
+
+// attributeArity is the number of arguments of the supported attributes, Integer'First has none and Integer'Image one
+var attributeArity = map[string]int{
+	"first":  0,
+	"last":   0,
+	"pos":    1,
+	"val":    1,
+	"succ":   1,
+	"pred":   1,
+	"image":  1,
+	"length": 0,
+}
+
+// isDiscreteType reports whether the type can prefix the attributes of the scalar types
+func isDiscreteType(t string) bool {
+	return t == "integer" || t == "character" || t == "boolean"
+}
+
+// attributeResult returns the type of Prefix'Name when the prefix is a discrete type,
+// or the string object of S'Length
+func attributeResult(name string, prefix string) string {
+	switch name {
+	case "pos", "length":
+		return "integer"
+	case "image":
+		return "string"
+	}
+	return prefix
+}
+
+// attributeArgument returns the type of the argument of Prefix'Name
+func attributeArgument(name string, prefix string) string {
+	if name == "val" {
+		return "integer"
+	}
+	return prefix
+}
+
+// discreteBounds returns the values of T'First and T'Last, a character is its position and a boolean 0 or 1
+func discreteBounds(t string) (first int, last int) {
+	switch t {
+	case "character":
+		return 0, 255
+	case "boolean":
+		return 0, 1
+	}
+	return math.MinInt32, math.MaxInt32
+}
+
+// checkAttribute checks an attribute reference and returns its type
+func checkAttribute(graph *Graph, scope *Scope, attribute *ast.AttributeExpr) map[string]struct{} {
+	returnTypes := make(map[string]struct{})
+	name := getSymbolType(attribute.Name.Name)
+	arity, ok := attributeArity[name]
+	if !ok {
+		semError(graph, attribute.Name, "unknown attribute "+attribute.Name.Name)
+		returnTypes[Unknown] = struct{}{}
+		return returnTypes
+	}
+	if len(attribute.Args) != arity {
+		expected := "no argument"
+		if arity == 1 {
+			expected = "one argument"
+		}
+		semError(graph, attribute, "attribute "+attribute.Name.Name+" expects "+expected)
+		returnTypes[Unknown] = struct{}{}
+		return returnTypes
+	}
+
+	if name == "length" {
+		// The prefix of Length is an object
+		if !haveType(findIdentifierType(graph, scope, attribute.Prefix), "string") {
+			semError(graph, attribute.Prefix, "prefix of attribute Length should be a string")
+		}
+		returnTypes["integer"] = struct{}{}
+		return returnTypes
+	}
+
+	prefix := getSymbolType(attribute.Prefix.Name)
+	if !isDiscreteType(prefix) {
+		semError(graph, attribute.Prefix, "prefix of attribute "+attribute.Name.Name+" should be Integer, Character or Boolean")
+		returnTypes[Unknown] = struct{}{}
+		return returnTypes
+	}
+	if arity == 1 {
+		want := attributeArgument(name, prefix)
+		if !haveType(getReturnType(graph, scope, attribute.Args[0], map[string]struct{}{want: {}}), want) {
+			semError(graph, attribute.Args[0], "argument of "+attribute.Prefix.Name+"'"+attribute.Name.Name+" should be of type "+want)
+		}
+	}
+	returnTypes[attributeResult(name, prefix)] = struct{}{}
+	return returnTypes
+}
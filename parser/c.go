@@ -0,0 +1,640 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type cValue struct {
+	text string
+	typ  *adaType
+	// reference is true when text dereferences a pointer (*fr.x)
+	reference bool
+	// operation is true when text must be in parentheses to be an operand
+	operation bool
+}
+
+type cGenerator struct {
+	*program
+	// current is the subprogram being written and loops its loop variables
+	current *adaSubprogram
+	loops   []*adaVariable
+	out     strings.Builder
+	indent  int
+}
+
+// declaration returns the C declaration of a name with this type
+func (t *adaType) declaration(name string) string {
+	if t.name == "" {
+		return t.target.name + " *" + name
+	}
+	return t.name + " " + name
+}
+
+// GenerateC translates the program to C99. Records become structs, access types pointers and the
+// nested subprograms top level functions receiving the frame of their parent.
+func GenerateC(graph Graph) (string, error) {
+	p, err := newProgram(graph)
+	if err != nil {
+		return "", err
+	}
+	g := cGenerator{program: p}
+
+	g.writeTypes()
+	g.writeFrames()
+	g.line("/* Subprograms */")
+	for _, sub := range g.subprograms {
+		g.line(g.signature(sub) + ";")
+	}
+	for _, sub := range g.subprograms {
+		g.line("")
+		g.writeSubprogram(sub)
+	}
+	g.line("")
+	g.line("int main(void)")
+	g.line("{")
+	g.line("    " + g.main.symbol + "();")
+	g.line("    return 0;")
+	g.line("}")
+	if g.err != nil {
+		return "", g.err
+	}
+	return cPrelude + g.out.String(), nil
+}
+
+// cPrelude is written before the program. A string keeps its length, its characters can contain NUL
+// and a string variable that was never assigned is empty.
+const cPrelude = `#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+    int length;
+    const char *text;
+} gada_string;
+
+static inline void gada_put_string(gada_string s)
+{
+    if (s.length > 0)
+        fwrite(s.text, 1, s.length, stdout);
+}
+
+static inline gada_string gada_int_image(int n)
+{
+    char *image = malloc(12);
+    int length = snprintf(image, 12, "% d", n);
+    return (gada_string){ length, image };
+}
+
+static inline gada_string gada_char_image(char c)
+{
+    char *image = malloc(3);
+    image[0] = '\'';
+    image[1] = c;
+    image[2] = '\'';
+    return (gada_string){ 3, image };
+}
+
+static inline int gada_length(gada_string s)
+{
+    return s.length;
+}
+
+static inline bool gada_string_eq(gada_string a, gada_string b)
+{
+    return a.length == b.length && (a.length == 0 || memcmp(a.text, b.text, a.length) == 0);
+}
+
+`
+
+// cString writes a string as a C literal, the characters other than printable ASCII are written in octal
+func cString(s string) string {
+	var text strings.Builder
+	text.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			text.WriteByte('\\')
+			text.WriteByte(c)
+		case c >= ' ' && c <= '~':
+			text.WriteByte(c)
+		default:
+			text.WriteString(fmt.Sprintf("\\%03o", c))
+		}
+	}
+	text.WriteByte('"')
+	return text.String()
+}
+
+// cStringValue writes a string literal as a gada_string
+func cStringValue(s string) string {
+	return "(gada_string){ " + strconv.Itoa(len(s)) + ", " + cString(s) + " }"
+}
+
+func (g *cGenerator) line(text string) {
+	if text != "" {
+		g.out.WriteString(strings.Repeat("    ", g.indent))
+	}
+	g.out.WriteString(text + "\n")
+}
+
+// writeTypes writes the typedefs, the structs and their equality functions
+func (g *cGenerator) writeTypes() {
+	if len(g.types) == 0 {
+		return
+	}
+	g.line("/* Types */")
+	for _, t := range g.types {
+		if t.kind == recordKind {
+			g.line("typedef struct " + t.name + " " + t.name + ";")
+		} else {
+			g.line("typedef " + t.target.name + " *" + t.name + ";")
+		}
+	}
+	for _, t := range g.types {
+		if t.kind != recordKind {
+			continue
+		}
+		g.line("")
+		g.line("struct " + t.name + " {")
+		for _, field := range t.fields {
+			g.line("    " + field.typ.declaration(field.name) + ";")
+		}
+		g.line("};")
+		g.line("")
+		g.line("static inline bool " + t.name + "_eq(" + t.name + " a, " + t.name + " b)")
+		g.line("{")
+		var comparisons []string
+		for _, field := range t.fields {
+			comparisons = append(comparisons, g.equal(cValue{text: "a." + field.name, typ: field.typ}, cValue{text: "b." + field.name, typ: field.typ}))
+		}
+		if len(comparisons) == 0 {
+			comparisons = append(comparisons, "true")
+		}
+		g.line("    return " + strings.Join(comparisons, " && ") + ";")
+		g.line("}")
+	}
+	g.line("")
+}
+
+func (g *cGenerator) writeFrames() {
+	written := false
+	for _, sub := range g.subprograms {
+		if !sub.hasFrame() {
+			continue
+		}
+		if !written {
+			g.line("/* Frames, up is the frame of the enclosing subprogram */")
+			written = true
+		}
+		g.line("struct " + sub.symbol + "_frame {")
+		if sub.hasLink() {
+			g.line("    struct " + sub.parent.symbol + "_frame *up;")
+		}
+		for _, variable := range append(append([]*adaVariable{}, sub.params...), sub.locals...) {
+			name := variable.name
+			if variable.byReference {
+				name = "*" + name
+			}
+			g.line("    " + variable.typ.declaration(name) + ";")
+		}
+		g.line("};")
+		g.line("")
+	}
+}
+
+func (g *cGenerator) signature(sub *adaSubprogram) string {
+	var params []string
+	if sub.hasLink() {
+		params = append(params, "struct "+sub.parent.symbol+"_frame *up")
+	}
+	for _, param := range sub.params {
+		name := param.name
+		if param.byReference {
+			name = "*" + name
+		}
+		params = append(params, param.typ.declaration(name))
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	returnType := "void "
+	if sub.returnType != nil {
+		returnType = sub.returnType.declaration("")
+	}
+	return "static " + returnType + sub.symbol + "(" + strings.Join(params, ", ") + ")"
+}
+
+func (g *cGenerator) writeSubprogram(sub *adaSubprogram) {
+	g.current = sub
+	g.loops = nil
+	g.line(g.signature(sub))
+	g.line("{")
+	g.indent++
+	if sub.hasFrame() {
+		var fields []string
+		if sub.hasLink() {
+			fields = append(fields, ".up = up")
+		}
+		for _, param := range sub.params {
+			fields = append(fields, "."+param.name+" = "+param.name)
+		}
+		if len(fields) == 0 {
+			fields = append(fields, "0")
+		}
+		g.line("struct " + sub.symbol + "_frame fr = { " + strings.Join(fields, ", ") + " };")
+	}
+	for _, init := range sub.inits {
+		for _, variable := range init.variables {
+			value := g.convert(g.expression(init.value), variable.typ)
+			g.line("fr." + variable.name + " = " + value.text + ";")
+		}
+	}
+	g.statements(sub.body)
+	g.indent--
+	g.line("}")
+}
+
+func (g *cGenerator) statements(node int) {
+	for _, child := range g.graph.GetChildren(node) {
+		g.statement(child)
+	}
+}
+
+func (g *cGenerator) block(node int) {
+	g.indent++
+	g.statements(node)
+	g.indent--
+}
+
+func (g *cGenerator) statement(node int) {
+	g.graph.visit(node)
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		if g.graph.GetNode(node) == "return" {
+			g.line("return;")
+		} else {
+			// A procedure called without parameters
+			g.line(g.call(node, true).text + ";")
+		}
+		return
+	}
+	switch g.graph.GetNode(node) {
+	case ":=":
+		left := g.expression(children[0])
+		right := g.convert(g.expression(children[1]), left.typ)
+		g.line(left.text + " = " + right.text + ";")
+	case "call":
+		g.line(g.call(node, true).text + ";")
+	case "return":
+		if len(children) == 0 {
+			g.line("return;")
+		} else {
+			g.line("return " + g.convert(g.expression(children[0]), g.current.returnType).text + ";")
+		}
+	case "if":
+		g.line("if (" + g.expression(children[0]).text + ") {")
+		g.block(children[1])
+		for _, child := range children[2:] {
+			if g.graph.GetNode(child) == "elif" {
+				elif := g.graph.GetChildren(child)
+				g.line("} else if (" + g.expression(elif[0]).text + ") {")
+				g.block(elif[1])
+			} else {
+				g.line("} else {")
+				g.block(child)
+			}
+		}
+		g.line("}")
+	case "while":
+		g.line("while (" + g.expression(children[0]).text + ") {")
+		g.block(children[1])
+		g.line("}")
+	case "for":
+		g.forLoop(children)
+	default:
+		g.fail(node, "unsupported statement %s", g.graph.GetNode(node))
+	}
+}
+
+// forLoop evaluates the bounds once like Ada does
+func (g *cGenerator) forLoop(children []int) {
+	from := g.expression(children[2])
+	to := g.expression(children[3])
+	variable := &adaVariable{name: cIdentifier(g.graph.GetNode(children[0])), typ: from.typ}
+	reverse := g.graph.GetNode(children[1]) == "reverse"
+	if reverse {
+		from, to = to, from
+	}
+
+	last := to.text
+	opened := false
+	if _, err := strconv.Atoi(last); err != nil {
+		last = variable.name + "_last"
+		g.line("{")
+		g.indent++
+		g.line("const " + variable.typ.declaration(last) + " = " + to.text + ";")
+		opened = true
+	}
+	if reverse {
+		g.line("for (" + variable.typ.declaration(variable.name) + " = " + from.text + "; " + variable.name + " >= " + last + "; " + variable.name + "--) {")
+	} else {
+		g.line("for (" + variable.typ.declaration(variable.name) + " = " + from.text + "; " + variable.name + " <= " + last + "; " + variable.name + "++) {")
+	}
+	g.loops = append(g.loops, variable)
+	g.block(children[4])
+	g.loops = g.loops[:len(g.loops)-1]
+	g.line("}")
+	if opened {
+		g.indent--
+		g.line("}")
+	}
+}
+
+// convert gives the type of an assignment target to null
+func (g *cGenerator) convert(value cValue, typ *adaType) cValue {
+	if value.typ == nullType && typ != nil {
+		value.typ = typ
+	}
+	return value
+}
+
+// operand puts the value in parentheses if needed
+func operand(value cValue) string {
+	if value.operation {
+		return "(" + value.text + ")"
+	}
+	return value.text
+}
+
+// frame returns the C expression of a variable declared in the subprogram at the given distance
+func frame(distance int, name string) string {
+	if distance == 0 {
+		return "fr." + name
+	}
+	return "fr.up" + strings.Repeat("->up", distance-1) + "->" + name
+}
+
+// link returns the static link to give to a subprogram
+func (g *cGenerator) link(callee *adaSubprogram) string {
+	distance := 0
+	for s := g.current; s != nil && s != callee.parent; s = s.parent {
+		distance++
+	}
+	if distance == 0 {
+		return "&fr"
+	}
+	return "fr" + ".up" + strings.Repeat("->up", distance-1)
+}
+
+var cOperators = map[string]string{
+	"+": "+", "-": "-", "*": "*", "/": "/", "rem": "%",
+	"and": "&", "or": "|", "and then": "&&", "or else": "||",
+	"<": "<", "<=": "<=", ">": ">", ">=": ">=",
+}
+
+func (g *cGenerator) expression(node int) cValue {
+	children := g.graph.GetChildren(node)
+	name := g.graph.GetNode(node)
+	if len(children) == 0 {
+		return g.leaf(node)
+	}
+
+	switch name {
+	case "access":
+		// The fields are nested on the right: E.Next.Prev is access(E, access(Next, Prev))
+		value := g.expression(children[0])
+		for _, field := range g.fields(children[1]) {
+			value = g.field(field, value)
+		}
+		return value
+	case "memory":
+		record := g.lookupType(g.current, children[1])
+		return cValue{text: "calloc(1, sizeof(" + record.name + "))", typ: &adaType{kind: accessKind, target: record}}
+	case "attribute":
+		return g.attribute(node)
+	case "call":
+		return g.call(node, false)
+	case "=", "/=", "!=":
+		left := g.expression(children[0])
+		right := g.expression(children[1])
+		text := g.equal(left, right)
+		if name != "=" {
+			if left.typ.kind == recordKind {
+				text = "!" + text
+			} else {
+				text = operand(left) + " != " + operand(right)
+			}
+		}
+		return cValue{text: text, typ: booleanType, operation: true}
+	}
+
+	op, ok := cOperators[name]
+	if !ok || len(children) != 2 {
+		g.fail(node, "unsupported expression %s", name)
+		return cValue{text: "0", typ: integerType}
+	}
+	left := g.expression(children[0])
+	right := g.expression(children[1])
+	typ := left.typ
+	switch name {
+	case "and", "or", "and then", "or else", "<", "<=", ">", ">=":
+		typ = booleanType
+	}
+	return cValue{text: operand(left) + " " + op + " " + operand(right), typ: typ, operation: true}
+}
+
+// cBounds are the values of T'First and T'Last of the discrete types
+var cBounds = map[typeKind][2]string{
+	integerKind:   {"(-2147483647 - 1)", "2147483647"},
+	characterKind: {"(char)0", "(char)255"},
+	booleanKind:   {"false", "true"},
+}
+
+// attribute translates Prefix'Name, the strings of Image are allocated and never freed
+func (g *cGenerator) attribute(node int) cValue {
+	name, prefix, args := g.program.attribute(node)
+	if name == "length" {
+		return cValue{text: "gada_length(" + g.expression(prefix).text + ")", typ: integerType}
+	}
+	typ := g.lookupType(g.current, prefix)
+	switch name {
+	case "first":
+		return cValue{text: cBounds[typ.kind][0], typ: typ}
+	case "last":
+		return cValue{text: cBounds[typ.kind][1], typ: typ}
+	}
+
+	value := g.expression(args[0])
+	switch name {
+	case "pos":
+		if typ.kind == characterKind {
+			return cValue{text: "(unsigned char)" + operand(value), typ: integerType}
+		}
+		return cValue{text: "(int)" + operand(value), typ: integerType}
+	case "val":
+		return cValue{text: "(" + typ.name + ")" + operand(value), typ: typ}
+	case "succ", "pred":
+		op := " + 1"
+		if name == "pred" {
+			op = " - 1"
+		}
+		if typ.kind == integerKind {
+			return cValue{text: operand(value) + op, typ: typ, operation: true}
+		}
+		return cValue{text: "(" + typ.name + ")(" + operand(value) + op + ")", typ: typ}
+	}
+
+	switch typ.kind {
+	case characterKind:
+		return cValue{text: "gada_char_image(" + value.text + ")", typ: stringType}
+	case booleanKind:
+		return cValue{text: operand(value) + " ? " + cStringValue("TRUE") + " : " + cStringValue("FALSE"), typ: stringType, operation: true}
+	}
+	return cValue{text: "gada_int_image(" + value.text + ")", typ: stringType}
+}
+
+// field selects a field of a record or of the record designated by an access value
+func (g *cGenerator) field(node int, prefix cValue) cValue {
+	name := cIdentifier(g.graph.GetNode(node))
+	record, separator, text := prefix.typ, ".", prefix.text
+	switch {
+	case prefix.typ.kind == accessKind:
+		record, separator, text = prefix.typ.target, "->", operand(prefix)
+	case prefix.reference:
+		separator, text = "->", prefix.text[1:]
+	}
+	var typ *adaType
+	if record != nil {
+		typ = record.field(name)
+	}
+	if typ == nil {
+		g.fail(node, "unknown field %s", name)
+		typ = integerType
+	}
+	return cValue{text: text + separator + name, typ: typ}
+}
+
+// equal compares two values, records are compared field by field and strings character by character
+func (g *cGenerator) equal(left cValue, right cValue) string {
+	if left.typ != nil && left.typ.kind == recordKind {
+		return left.typ.name + "_eq(" + left.text + ", " + right.text + ")"
+	}
+	if left.typ != nil && left.typ.kind == stringKind {
+		return "gada_string_eq(" + left.text + ", " + right.text + ")"
+	}
+	return operand(left) + " == " + operand(right)
+}
+
+func (g *cGenerator) leaf(node int) cValue {
+	name := g.graph.GetNode(node)
+	switch {
+	case name == "true" || name == "false":
+		return cValue{text: name, typ: booleanType}
+	case name == "null":
+		return cValue{text: "NULL", typ: nullType}
+	case name[0] == '\'':
+		char := g.graph.GetRealNode(node)
+		switch char {
+		case "'''":
+			char = `'\''`
+		case `'\'`:
+			char = `'\\'`
+		}
+		return cValue{text: char, typ: characterType}
+	case name[0] == '"':
+		return cValue{text: cStringValue(unquoteString(g.graph.GetRealNode(node))), typ: stringType}
+	case name[0] >= '0' && name[0] <= '9':
+		return cValue{text: strings.ReplaceAll(name, "_", ""), typ: integerType}
+	}
+
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].name == cIdentifier(name) {
+			return cValue{text: g.loops[i].name, typ: g.loops[i].typ}
+		}
+	}
+	variable, distance, found := g.lookup(g.current, name)
+	switch {
+	case variable != nil:
+		text := frame(distance, variable.name)
+		if variable.byReference {
+			return cValue{text: "*" + text, typ: variable.typ, reference: true, operation: variable.typ.kind == accessKind}
+		}
+		return cValue{text: text, typ: variable.typ}
+	case found:
+		// A function without parameters is called without parentheses
+		return g.call(node, false)
+	}
+	g.fail(node, "unknown identifier %s", name)
+	return cValue{text: "0", typ: integerType}
+}
+
+// call translates a call node, or an identifier calling a function without parameters
+func (g *cGenerator) call(node int, statement bool) cValue {
+	nameNode := node
+	var args []int
+	if g.graph.GetNode(node) == "call" {
+		children := g.graph.GetChildren(node)
+		nameNode = children[0]
+		switch g.graph.GetNode(nameNode) {
+		case "-":
+			value := g.expression(children[1])
+			text := operand(value)
+			if strings.HasPrefix(text, "-") {
+				text = "(" + text + ")"
+			}
+			return cValue{text: "-" + text, typ: integerType}
+		case "not":
+			return cValue{text: "!" + operand(g.expression(children[1])), typ: booleanType}
+		}
+		if len(children) > 1 {
+			args = g.graph.GetChildren(children[1])
+		}
+	}
+
+	name := g.graph.GetNode(nameNode)
+	callee := g.resolve(g.current, nameNode, len(args))
+	if callee == nil {
+		switch {
+		case name == "new_line" && len(args) == 0:
+			return cValue{text: "putchar('\\n')"}
+		case name == "put" && len(args) == 1:
+			value := g.expression(args[0])
+			switch value.typ.kind {
+			case characterKind:
+				return cValue{text: "putchar(" + value.text + ")"}
+			case stringKind:
+				return cValue{text: "gada_put_string(" + value.text + ")"}
+			}
+			return cValue{text: "printf(\"%d\", " + value.text + ")"}
+		case name == "put_line" && len(args) == 1:
+			return cValue{text: "gada_put_string(" + g.expression(args[0]).text + "), putchar('\\n')"}
+		}
+		g.fail(node, "unknown subprogram %s", name)
+		return cValue{text: "0", typ: integerType}
+	}
+	if !statement && callee.returnType == nil {
+		g.fail(node, "%s is a procedure", name)
+	}
+
+	var values []string
+	if callee.hasLink() {
+		values = append(values, g.link(callee))
+	}
+	for i, arg := range args {
+		value := g.expression(arg)
+		if callee.params[i].byReference {
+			if value.reference {
+				values = append(values, value.text[1:])
+			} else {
+				values = append(values, "&"+value.text)
+			}
+			continue
+		}
+		values = append(values, value.text)
+	}
+	return cValue{text: callee.symbol + "(" + strings.Join(values, ", ") + ")", typ: callee.returnType}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"gada/token"
+)
+
+// binaryTypes are the parse tree nodes of the binary operators, nodeManagement names them after the operator
+var binaryTypes = map[token.Token]string{
+	token.OR:  "OrExprTail2",
+	token.AND: "AndExprTail2",
+	token.EQL: "EqualityExprTailEql",
+	token.NEQ: "EqualityExprTailNeq",
+	token.LSS: "RelationalExprTailLss",
+	token.LEQ: "RelationalExprTailLeq",
+	token.GTR: "RelationalExprTailGtr",
+	token.GEQ: "RelationalExprTailGeq",
+	token.ADD: "AdditiveExprTailAdd",
+	token.SUB: "AdditiveExprTailSub",
+	token.MUL: "MultiplicativeExprTailMul",
+	token.QUO: "MultiplicativeExprTailQuo",
+	token.REM: "MultiplicativeExprTailRem",
+}
+
+// shortCircuitTypes are the nodes of and then and or else, the operators written with two keywords
+var shortCircuitTypes = map[token.Token]string{
+	token.OR:  "OrExprTail2Else",
+	token.AND: "AndExprTail2Then",
+}
+
+// startsExpr reports whether an expression can start with the token
+func startsExpr(tkn token.Token) bool {
+	switch tkn {
+	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW:
+		return true
+	}
+	return false
+}
+
+// endsExpr reports whether the token can follow an expression
+func endsExpr(tkn token.Token) bool {
+	switch tkn {
+	case token.SEMICOLON, token.RPAREN, token.THEN, token.COMMA, token.LOOP, token.DOUBLE_DOT:
+		return true
+	}
+	return false
+}
+
+func readExpr(parser *Parser) Node {
+	var node Node
+	if !startsExpr(parser.peekToken()) {
+		unexpectedToken(parser, "ident ( not - int char string true false null new", parser.peekTokenToString())
+		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.ASSIGN, token.COMMA, token.RETURN, token.END})
+		parser.exprError = false
+		return node
+	}
+	node = Node{Type: "ExprIdent"}
+	node.setLineColumn(*parser)
+	node.addChild(readBinary_expr(parser, 0))
+	if !endsExpr(parser.peekToken()) {
+		if !parser.exprError {
+			unexpectedToken(parser, "operator ; ) then , loop ..", parser.peekTokenToString())
+		}
+		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.THEN, token.COMMA, token.LOOP, token.DOUBLE_DOT, token.COLON, token.ASSIGN, token.RETURN, token.END, token.BEGIN})
+		parser.exprError = false
+	}
+	return node
+}
+
+// readBinary_expr reads the operands joined by the binary operators of a precedence higher than the given one.
+// An operator takes as right operand the operators of a higher precedence, so the operators of a level
+// are left associative. As in Ada the relational operators cannot be chained and the logical operators
+// cannot be mixed without parentheses, the expression is still built after the error.
+func readBinary_expr(parser *Parser, precedence int) Node {
+	node := readUnary_expr(parser)
+	// logical and relational are the first operators of these levels, an error is reported once per level
+	var logical, relational string
+	mixed, chained := false, false
+	for {
+		tkn := parser.peekToken()
+		nodeType, ok := binaryTypes[tkn]
+		if !ok || tkn.Precedence() <= precedence {
+			return node
+		}
+		parser.readToken()
+		operator := tkn.String()
+		if short, ok := shortCircuitTypes[tkn]; ok && (parser.peekToken() == token.THEN || parser.peekToken() == token.ELSE) {
+			nodeType = short
+			operator += " " + parser.peekToken().String()
+			parser.readToken()
+		}
+
+		switch tkn {
+		case token.AND, token.OR:
+			if logical == "" {
+				logical = operator
+			} else if logical != operator && !mixed {
+				operatorError(parser, "Mixed logical operators \""+logical+"\" and \""+operator+"\" need parentheses")
+				mixed = true
+			}
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if relational == "" {
+				relational = operator
+			} else if !chained {
+				operatorError(parser, "Chained relational operators \""+relational+"\" and \""+operator+"\" need parentheses")
+				chained = true
+			}
+		}
+
+		prev := node
+		node = Node{Type: nodeType}
+		node.setLineColumn(*parser)
+		node.addChild(prev)
+		node.addChild(readOperand(parser, tkn.Precedence()))
+	}
+}
+
+// operatorError reports an error located at the operator just read, followed by its line up to the operator
+func operatorError(parser *Parser, message string) {
+	parser.unreadToken()
+	customError(parser, message+": "+parser.lexer.GetLineUpToTokenIncluded(parser.currentToken()))
+	parser.readToken()
+}
+
+// readOperand reads the right operand of an operator, a missing operand is reported after the operator.
+// In Ada only a simple expression starts with a minus, the operand of an adding, multiplying or unary operator cannot.
+func readOperand(parser *Parser, precedence int) Node {
+	if !startsExpr(parser.peekToken()) {
+		customError(parser, "Missing operand after: "+parser.lexer.GetLineUpToTokenIncluded(parser.lexer.Tokens[parser.index-1]))
+		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.ASSIGN, token.COMMA, token.RETURN, token.END, token.BEGIN})
+		parser.exprError = true
+		return Node{}
+	}
+	if precedence >= minusPrecedence && parser.peekToken() == token.SUB {
+		// the minus is still read as the start of the operand
+		customError(parser, "Unary minus must start a simple expression, it needs parentheses: "+parser.lexer.GetLineUpToTokenIncluded(parser.currentToken()))
+	}
+	return readBinary_expr(parser, precedence)
+}
+
+// As in Ada the minus sign applies to the first term of a sum, -A * B is -(A * B), while not applies to
+// a primary, not A and B is (not A) and B. The operand of an operator takes the operators of a higher level.
+var (
+	minusPrecedence = token.Token(token.ADD).Precedence()
+	notPrecedence   = token.Token(token.NOT).Precedence()
+)
+
+// readUnary_expr reads a primary with its unary operators, the callers check that an expression starts
+func readUnary_expr(parser *Parser) Node {
+	var node Node
+	switch parser.peekToken() {
+	case token.SUB:
+		parser.readToken()
+		node = Node{Type: "UnaryExprSub"}
+		node.setLineColumn(*parser)
+		node.addChild(readOperand(parser, minusPrecedence))
+	case token.NOT:
+		parser.readToken()
+		node = Node{Type: "UnaryExprNot"}
+		node.setLineColumn(*parser)
+		node.addChild(readOperand(parser, notPrecedence))
+	case token.IDENT, token.LPAREN, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW:
+		node = Node{Type: "UnaryExpr"}
+		node.setLineColumn(*parser)
+		node.addChild(readPrimary_expr(parser))
+	}
+	return node
+}
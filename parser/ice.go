@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+)
+
+// InternalError is a panic of the compiler turned into a diagnostic. It gives the phase that crashed
+// and the position of the node it was processing, 0:0 when the phase had not reached any node.
+type InternalError struct {
+	Phase  string
+	File   string
+	Line   int
+	Column int
+	Value  any
+	Stack  string
+	// AST is the JSON of the graph when the phase crashed, empty if there was no graph yet
+	AST string
+}
+
+func (e *InternalError) Error() string {
+	return e.File + ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + " internal compiler error in " + e.Phase + ": " + fmt.Sprint(e.Value)
+}
+
+// VisitHook is called with the phase and the position of every node visited by the phases, the tests set it
+// to make a phase panic on a node
+var VisitHook func(phase string, line int, column int)
+
+// visit records the node processed by the current phase, it is the position of an internal error
+func (g Graph) visit(node int) {
+	if g.current != nil {
+		*g.current = node
+	}
+	if VisitHook != nil && g.phase != nil {
+		VisitHook(*g.phase, g.line[node], g.column[node])
+	}
+}
+
+// runPhase runs a phase of the compiler on the graph and turns its panics into an InternalError
+func runPhase(graph *Graph, phase string, run func() error) (err error) {
+	if graph.phase != nil {
+		*graph.phase = phase
+	}
+	graph.visit(0)
+	defer func() {
+		if r := recover(); r != nil {
+			err = newInternalError(graph, phase, r)
+		}
+	}()
+	return run()
+}
+
+func newInternalError(graph *Graph, phase string, value any) *InternalError {
+	e := &InternalError{Phase: phase, File: graph.fileName, Value: value, Stack: string(debug.Stack())}
+	if graph.current != nil {
+		e.Line, e.Column = graph.line[*graph.current], graph.column[*graph.current]
+	}
+	e.AST = graphJson(graph)
+	return e
+}
+
+// graphJson returns the JSON of the graph, or nothing when the graph is too broken to be written
+func graphJson(graph *Graph) (text string) {
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	return graph.toJson()
+}
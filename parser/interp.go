@@ -0,0 +1,556 @@
+package parser
+
+import (
+	"fmt"
+	"gada/asm"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxInterpDepth is the number of nested calls after which the interpreted program is stopped
+const maxInterpDepth = 100_000
+
+// value is a value of the interpreted program: an int32 for the integers, the characters and the
+// booleans, a string for the strings, a *record for the records and the accesses, nil for null
+type value any
+
+type record struct {
+	fields []value
+}
+
+// clone copies a record value, the records nested in it are copied too but not the accesses
+func (r *record) clone(t *adaType) *record {
+	copied := &record{fields: make([]value, len(r.fields))}
+	for i, field := range t.fields {
+		copied.fields[i] = copyValue(r.fields[i], field.typ)
+	}
+	return copied
+}
+
+func copyValue(v value, t *adaType) value {
+	if t.kind == recordKind {
+		return v.(*record).clone(t)
+	}
+	return v
+}
+
+// zero returns the initial value of a variable, the records are allocated with their fields
+func zero(t *adaType) value {
+	switch t.kind {
+	case recordKind:
+		r := &record{fields: make([]value, len(t.fields))}
+		for i, field := range t.fields {
+			r.fields[i] = zero(field.typ)
+		}
+		return r
+	case accessKind:
+		return (*record)(nil)
+	case stringKind:
+		return ""
+	}
+	return int32(0)
+}
+
+// activation is the frame of a running subprogram, the in out parameters share the cell of the argument
+type activation struct {
+	sub    *adaSubprogram
+	up     *activation
+	cells  map[*adaVariable]*value
+	loops  []interpLoop
+	result value
+}
+
+type interpLoop struct {
+	name string
+	cell *value
+	typ  *adaType
+}
+
+// interpFault stops the interpreted program, it is recovered by Interpret
+type interpFault struct {
+	err error
+}
+
+type interpreter struct {
+	*program
+	output   io.Writer
+	depth    int
+	steps    int
+	maxSteps int
+}
+
+// Interpret runs the program directly from the Graph. Put and New_Line write to the output.
+// The program is stopped after maxSteps statements and loop iterations, 0 for no limit.
+// A panic of the interpreter itself is returned as an InternalError.
+func Interpret(graph Graph, output io.Writer, maxSteps int) (err error) {
+	p, err := newProgram(graph)
+	if err != nil {
+		return err
+	}
+	i := interpreter{program: p, output: output, maxSteps: maxSteps}
+	defer func() {
+		if r := recover(); r != nil {
+			fault, ok := r.(interpFault)
+			if !ok {
+				err = newInternalError(&i.graph, "interpretation", r)
+				return
+			}
+			err = fault.err
+		}
+	}()
+	i.invoke(i.main, nil, nil)
+	return nil
+}
+
+// stop ends the interpreted program with an error located at the node
+func (i *interpreter) stop(node int, format string, args ...any) {
+	err := fmt.Errorf(format, args...)
+	panic(interpFault{fmt.Errorf("%s:%d:%d: %w", i.graph.fileName, i.graph.line[node], i.graph.column[node], err)})
+}
+
+// step counts an executed statement or loop iteration
+func (i *interpreter) step(node int) {
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		i.stop(node, "program stopped after %d steps: %w", i.maxSteps, asm.ErrStepLimit)
+	}
+}
+
+// invoke runs a subprogram, the arguments of the in out parameters are cells
+func (i *interpreter) invoke(sub *adaSubprogram, up *activation, args []any) value {
+	i.depth++
+	defer func() { i.depth-- }()
+	if i.depth > maxInterpDepth {
+		i.stop(sub.node, "stack overflow in %s", sub.name)
+	}
+
+	frame := &activation{sub: sub, up: up, cells: map[*adaVariable]*value{}}
+	for n, param := range sub.params {
+		if param.byReference {
+			frame.cells[param] = args[n].(*value)
+			continue
+		}
+		v := args[n].(value)
+		frame.cells[param] = &v
+	}
+	for _, local := range sub.locals {
+		v := zero(local.typ)
+		frame.cells[local] = &v
+	}
+	for _, init := range sub.inits {
+		for _, variable := range init.variables {
+			v, _ := i.expression(frame, init.value)
+			*frame.cells[variable] = copyValue(v, variable.typ)
+		}
+	}
+	if !i.statements(frame, sub.body) && sub.returnType != nil {
+		i.stop(sub.node, "function %s ended without return", sub.name)
+	}
+	return frame.result
+}
+
+// statements runs a body and reports whether a return was executed
+func (i *interpreter) statements(frame *activation, node int) bool {
+	for _, child := range i.graph.GetChildren(node) {
+		if i.statement(frame, child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *interpreter) statement(frame *activation, node int) bool {
+	i.step(node)
+	i.graph.visit(node)
+	children := i.graph.GetChildren(node)
+	switch i.graph.GetNode(node) {
+	case "return":
+		if len(children) > 0 {
+			v, _ := i.expression(frame, children[0])
+			frame.result = copyValue(v, frame.sub.returnType)
+		}
+		return true
+	case ":=":
+		cell, typ := i.address(frame, children[0])
+		v, _ := i.expression(frame, children[1])
+		*cell = copyValue(v, typ)
+	case "if":
+		for n := 0; n < len(children); n++ {
+			switch i.graph.GetNode(children[n]) {
+			case "elif":
+				branch := i.graph.GetChildren(children[n])
+				if i.condition(frame, branch[0]) {
+					return i.statements(frame, branch[1])
+				}
+			case "else":
+				return i.statements(frame, children[n])
+			default:
+				if i.condition(frame, children[n]) {
+					return i.statements(frame, children[n+1])
+				}
+				n++
+			}
+		}
+	case "while":
+		for i.condition(frame, children[0]) {
+			i.step(node)
+			if i.statements(frame, children[1]) {
+				return true
+			}
+		}
+	case "for":
+		return i.forLoop(frame, children)
+	default:
+		// A call, or a procedure called without parameters
+		i.call(frame, node, true)
+	}
+	return false
+}
+
+// forLoop evaluates the bounds once, the loop variable is a constant of the loop
+func (i *interpreter) forLoop(frame *activation, children []int) bool {
+	first, typ := i.expression(frame, children[2])
+	last, _ := i.expression(frame, children[3])
+	from, to, step := first.(int32), last.(int32), int32(1)
+	if i.graph.GetNode(children[1]) == "reverse" {
+		from, to, step = to, from, -1
+	}
+	if (step > 0 && from > to) || (step < 0 && from < to) {
+		return false
+	}
+	var counter value = from
+	frame.loops = append(frame.loops, interpLoop{name: i.graph.GetNode(children[0]), cell: &counter, typ: typ})
+	defer func() { frame.loops = frame.loops[:len(frame.loops)-1] }()
+	for {
+		if i.statements(frame, children[4]) {
+			return true
+		}
+		if counter.(int32) == to {
+			return false
+		}
+		i.step(children[0])
+		counter = counter.(int32) + step
+	}
+}
+
+func (i *interpreter) condition(frame *activation, node int) bool {
+	v, _ := i.expression(frame, node)
+	return v.(int32) != 0
+}
+
+// variable returns the cell of a variable visible from the frame
+func (i *interpreter) variable(frame *activation, node int) (*value, *adaType, bool) {
+	name := i.graph.GetNode(node)
+	for n := len(frame.loops) - 1; n >= 0; n-- {
+		if frame.loops[n].name == name {
+			return frame.loops[n].cell, frame.loops[n].typ, true
+		}
+	}
+	for f := frame; f != nil; f = f.up {
+		if variable, ok := f.sub.variables[name]; ok {
+			return f.cells[variable], variable.typ, true
+		}
+		if _, ok := f.sub.subprograms[name]; ok {
+			return nil, nil, false
+		}
+	}
+	return nil, nil, false
+}
+
+// address returns the cell of a variable or of a field, a value that is not a variable gets a new cell
+func (i *interpreter) address(frame *activation, node int) (*value, *adaType) {
+	children := i.graph.GetChildren(node)
+	if len(children) == 0 {
+		if cell, typ, ok := i.variable(frame, node); ok {
+			return cell, typ
+		}
+	}
+	if i.graph.GetNode(node) == "access" && len(children) == 2 {
+		// The fields are nested on the right: E.Next.Prev is access(E, access(Next, Prev))
+		cell, typ := i.address(frame, children[0])
+		for _, field := range i.fields(children[1]) {
+			r := (*cell).(*record)
+			if typ.kind == accessKind && typ.target != nil {
+				typ = typ.target
+			}
+			if r == nil {
+				i.stop(field, "null access")
+			}
+			name := cIdentifier(i.graph.GetNode(field))
+			n := fieldIndex(typ, name)
+			if n < 0 {
+				i.stop(field, "unknown field %s", name)
+			}
+			cell, typ = &r.fields[n], typ.fields[n].typ
+		}
+		return cell, typ
+	}
+	v, typ := i.expression(frame, node)
+	return &v, typ
+}
+
+func fieldIndex(t *adaType, name string) int {
+	for n, field := range t.fields {
+		if field.name == name {
+			return n
+		}
+	}
+	return -1
+}
+
+// equal compares two values, the records are compared field by field
+func equal(left value, right value, t *adaType) bool {
+	if t.kind != recordKind {
+		return left == right
+	}
+	l, r := left.(*record), right.(*record)
+	for n, field := range t.fields {
+		if !equal(l.fields[n], r.fields[n], field.typ) {
+			return false
+		}
+	}
+	return true
+}
+
+func boolValue(b bool) value {
+	if b {
+		return int32(1)
+	}
+	return int32(0)
+}
+
+// expression evaluates a node, the records are returned without being copied
+func (i *interpreter) expression(frame *activation, node int) (value, *adaType) {
+	children := i.graph.GetChildren(node)
+	name := i.graph.GetNode(node)
+	if len(children) == 0 {
+		return i.leaf(frame, node)
+	}
+
+	switch name {
+	case "access":
+		cell, typ := i.address(frame, node)
+		return *cell, typ
+	case "memory":
+		target := i.lookupType(frame.sub, children[1])
+		return zero(target), &adaType{kind: accessKind, target: target}
+	case "attribute":
+		return i.attribute(frame, node)
+	case "call":
+		return i.call(frame, node, false)
+	case "and then":
+		return boolValue(i.condition(frame, children[0]) && i.condition(frame, children[1])), booleanType
+	case "or else":
+		return boolValue(i.condition(frame, children[0]) || i.condition(frame, children[1])), booleanType
+	}
+
+	left, typ := i.expression(frame, children[0])
+	right, rightType := i.expression(frame, children[1])
+	if typ == nullType {
+		typ = rightType
+	}
+	switch name {
+	case "=":
+		return boolValue(equal(left, right, typ)), booleanType
+	case "/=", "!=":
+		return boolValue(!equal(left, right, typ)), booleanType
+	}
+	l, r := left.(int32), right.(int32)
+	switch name {
+	case "<":
+		return boolValue(l < r), booleanType
+	case "<=":
+		return boolValue(l <= r), booleanType
+	case ">":
+		return boolValue(l > r), booleanType
+	case ">=":
+		return boolValue(l >= r), booleanType
+	case "+":
+		return l + r, typ
+	case "-":
+		return l - r, typ
+	case "*":
+		return l * r, typ
+	case "and":
+		return l & r, typ
+	case "or":
+		return l | r, typ
+	case "/", "rem":
+		if r == 0 {
+			i.stop(node, "division by zero")
+		}
+		if r == -1 {
+			// The division of the smallest integer wraps around like on ARM
+			if name == "/" {
+				return -l, typ
+			}
+			return int32(0), typ
+		}
+		if name == "/" {
+			return l / r, typ
+		}
+		return l % r, typ
+	}
+	i.stop(node, "unsupported expression %s", name)
+	return nil, nil
+}
+
+// attribute evaluates Prefix'Name, a character or a boolean is its position
+func (i *interpreter) attribute(frame *activation, node int) (value, *adaType) {
+	name, prefix, args := i.program.attribute(node)
+	if name == "length" {
+		v, _ := i.expression(frame, prefix)
+		return int32(len(v.(string))), integerType
+	}
+	typ := i.lookupType(frame.sub, prefix)
+	first, last := discreteBounds(i.graph.GetNode(prefix))
+	switch name {
+	case "first":
+		return int32(first), typ
+	case "last":
+		return int32(last), typ
+	}
+
+	v, _ := i.expression(frame, args[0])
+	n := v.(int32)
+	switch name {
+	case "pos":
+		return n, integerType
+	case "succ":
+		n++
+	case "pred":
+		n--
+	case "image":
+		switch typ.kind {
+		case characterKind:
+			return string([]byte{'\'', byte(n), '\''}), stringType
+		case booleanKind:
+			if n != 0 {
+				return "TRUE", stringType
+			}
+			return "FALSE", stringType
+		}
+		if n >= 0 {
+			return " " + strconv.Itoa(int(n)), stringType
+		}
+		return strconv.Itoa(int(n)), stringType
+	}
+	if typ.kind == characterKind {
+		n &= 255
+	}
+	return n, typ
+}
+
+func (i *interpreter) leaf(frame *activation, node int) (value, *adaType) {
+	name := i.graph.GetNode(node)
+	switch {
+	case name == "true":
+		return int32(1), booleanType
+	case name == "false":
+		return int32(0), booleanType
+	case name == "null":
+		return (*record)(nil), nullType
+	case name[0] == '\'':
+		char := []rune(i.graph.GetRealNode(node))
+		return int32(char[1]) & 255, characterType
+	case name[0] == '"':
+		return unquoteString(i.graph.GetRealNode(node)), stringType
+	case name[0] >= '0' && name[0] <= '9':
+		// Integers wrap around like on ARM
+		v, err := strconv.ParseInt(strings.ReplaceAll(name, "_", ""), 10, 64)
+		if err != nil {
+			i.stop(node, "invalid integer %s", name)
+		}
+		return int32(v), integerType
+	}
+	if cell, typ, ok := i.variable(frame, node); ok {
+		return *cell, typ
+	}
+	// A function without parameters is called without parentheses
+	return i.call(frame, node, false)
+}
+
+// overload finds the subprogram called by the node with the symbol chosen by the semantic analysis
+func (i *interpreter) overload(frame *activation, nameNode int, arity int) (*adaSubprogram, *activation) {
+	hash := i.graph.symbols[nameNode]
+	name := i.graph.GetNode(nameNode)
+	for f := frame; f != nil; f = f.up {
+		var matching []*adaSubprogram
+		for _, candidate := range f.sub.subprograms[name] {
+			if len(candidate.params) == arity {
+				matching = append(matching, candidate)
+			}
+		}
+		for _, candidate := range matching {
+			if hash != "" && i.graph.symbols[candidate.node] == hash {
+				return candidate, f
+			}
+		}
+		if len(matching) > 0 {
+			return matching[0], f
+		}
+	}
+	return nil, nil
+}
+
+// call runs a call node, or an identifier calling a subprogram without parameters
+func (i *interpreter) call(frame *activation, node int, statement bool) (value, *adaType) {
+	nameNode := node
+	var args []int
+	if i.graph.GetNode(node) == "call" {
+		children := i.graph.GetChildren(node)
+		nameNode = children[0]
+		switch i.graph.GetNode(nameNode) {
+		case "-":
+			v, _ := i.expression(frame, children[1])
+			return -v.(int32), integerType
+		case "not":
+			return boolValue(!i.condition(frame, children[1])), booleanType
+		}
+		if len(children) > 1 {
+			args = i.graph.GetChildren(children[1])
+		}
+	}
+
+	name := i.graph.GetNode(nameNode)
+	callee, up := i.overload(frame, nameNode, len(args))
+	if callee == nil {
+		switch {
+		case name == "new_line" && len(args) == 0:
+			io.WriteString(i.output, "\n")
+			return nil, nil
+		case name == "put" && len(args) == 1:
+			v, typ := i.expression(frame, args[0])
+			switch typ.kind {
+			case characterKind:
+				i.output.Write([]byte{byte(v.(int32))})
+			case stringKind:
+				io.WriteString(i.output, v.(string))
+			default:
+				io.WriteString(i.output, strconv.Itoa(int(v.(int32))))
+			}
+			return nil, nil
+		case name == "put_line" && len(args) == 1:
+			v, _ := i.expression(frame, args[0])
+			io.WriteString(i.output, v.(string)+"\n")
+			return nil, nil
+		}
+		i.stop(node, "unknown subprogram %s", name)
+	}
+	if !statement && callee.returnType == nil {
+		i.stop(node, "%s is a procedure", name)
+	}
+
+	values := make([]any, len(args))
+	for n, arg := range args {
+		if callee.params[n].byReference {
+			cell, _ := i.address(frame, arg)
+			values[n] = cell
+			continue
+		}
+		v, _ := i.expression(frame, arg)
+		values[n] = copyValue(v, callee.params[n].typ)
+	}
+	return i.invoke(callee, up, values), callee.returnType
+}
@@ -0,0 +1,865 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type llvmValue struct {
+	text string
+	typ  *adaType
+}
+
+type llvmLoop struct {
+	variable *adaVariable
+	address  string
+}
+
+type llvmGenerator struct {
+	*program
+	// current is the subprogram being written and loops its loop variables
+	current *adaSubprogram
+	loops   []llvmLoop
+	allocas []string
+	body    strings.Builder
+	temps   int
+	labels  int
+	// block is the label of the current basic block, terminated is true after a branch or a return
+	block      string
+	terminated bool
+	out        strings.Builder
+	// constants maps the string literals to their globals, written after the functions
+	constants map[string]string
+	data      strings.Builder
+}
+
+// llvmRuntime defines Put and New_Line with the C library. A string is a pair of its length and of
+// its characters, so that they can contain NUL.
+const llvmRuntime = `%gada_string = type { i32, i8* }
+
+@.int = private unnamed_addr constant [3 x i8] c"%d\00"
+@.image = private unnamed_addr constant [4 x i8] c"% d\00"
+
+declare i32 @putchar(i32)
+declare i32 @printf(i8*, ...)
+declare i32 @snprintf(i8*, i64, i8*, ...)
+declare i8* @malloc(i64)
+declare i8* @calloc(i64, i64)
+declare i32 @memcmp(i8*, i8*, i64)
+
+define internal void @gada_put_char(i8 %c) {
+entry:
+  %0 = zext i8 %c to i32
+  %1 = call i32 @putchar(i32 %0)
+  ret void
+}
+
+define internal void @gada_put_int(i32 %n) {
+entry:
+  %0 = getelementptr [3 x i8], [3 x i8]* @.int, i32 0, i32 0
+  %1 = call i32 (i8*, ...) @printf(i8* %0, i32 %n)
+  ret void
+}
+
+define internal void @gada_put_string(%gada_string %s) {
+entry:
+  %length = extractvalue %gada_string %s, 0
+  %text = extractvalue %gada_string %s, 1
+  br label %loop
+loop:
+  %i = phi i32 [ 0, %entry ], [ %next, %print ]
+  %more = icmp slt i32 %i, %length
+  br i1 %more, label %print, label %done
+print:
+  %0 = getelementptr i8, i8* %text, i32 %i
+  %1 = load i8, i8* %0
+  call void @gada_put_char(i8 %1)
+  %next = add i32 %i, 1
+  br label %loop
+done:
+  ret void
+}
+
+define internal %gada_string @gada_int_image(i32 %n) {
+entry:
+  %0 = call i8* @malloc(i64 12)
+  %1 = getelementptr [4 x i8], [4 x i8]* @.image, i32 0, i32 0
+  %2 = call i32 (i8*, i64, i8*, ...) @snprintf(i8* %0, i64 12, i8* %1, i32 %n)
+  %3 = insertvalue %gada_string undef, i32 %2, 0
+  %4 = insertvalue %gada_string %3, i8* %0, 1
+  ret %gada_string %4
+}
+
+define internal %gada_string @gada_char_image(i8 %c) {
+entry:
+  %0 = call i8* @malloc(i64 3)
+  store i8 39, i8* %0
+  %1 = getelementptr i8, i8* %0, i32 1
+  store i8 %c, i8* %1
+  %2 = getelementptr i8, i8* %0, i32 2
+  store i8 39, i8* %2
+  %3 = insertvalue %gada_string { i32 3, i8* undef }, i8* %0, 1
+  ret %gada_string %3
+}
+
+define internal i32 @gada_length(%gada_string %s) {
+entry:
+  %0 = extractvalue %gada_string %s, 0
+  ret i32 %0
+}
+
+define internal i1 @gada_string.eq(%gada_string %a, %gada_string %b) {
+entry:
+  %0 = extractvalue %gada_string %a, 0
+  %1 = extractvalue %gada_string %b, 0
+  %2 = icmp eq i32 %0, %1
+  br i1 %2, label %compare, label %done
+compare:
+  %3 = extractvalue %gada_string %a, 1
+  %4 = extractvalue %gada_string %b, 1
+  %5 = zext i32 %0 to i64
+  %6 = call i32 @memcmp(i8* %3, i8* %4, i64 %5)
+  %7 = icmp eq i32 %6, 0
+  br label %done
+done:
+  %8 = phi i1 [ false, %entry ], [ %7, %compare ]
+  ret i1 %8
+}
+
+define internal void @gada_new_line() {
+entry:
+  %0 = call i32 @putchar(i32 10)
+  ret void
+}
+`
+
+// GenerateLLVM translates the program to textual LLVM IR. Records become struct types, access types
+// pointers and the nested subprograms functions receiving a pointer to the frame of their parent.
+// Put and New_Line are runtime functions defined with putchar and printf.
+func GenerateLLVM(graph Graph) (string, error) {
+	p, err := newProgram(graph)
+	if err != nil {
+		return "", err
+	}
+	g := llvmGenerator{program: p, constants: map[string]string{}}
+
+	g.out.WriteString("; " + graph.fileName + "\n\n")
+	for _, t := range g.types {
+		if t.kind != recordKind {
+			continue
+		}
+		var fields []string
+		for _, field := range t.fields {
+			fields = append(fields, llvmType(field.typ))
+		}
+		g.out.WriteString("%" + t.name + " = type { " + strings.Join(fields, ", ") + " }\n")
+	}
+	for _, sub := range g.subprograms {
+		if !sub.hasFrame() {
+			continue
+		}
+		var fields []string
+		if sub.hasLink() {
+			fields = append(fields, frameType(sub.parent)+"*")
+		}
+		for _, variable := range frameVariables(sub) {
+			fields = append(fields, variableType(variable))
+		}
+		g.out.WriteString(frameType(sub) + " = type { " + strings.Join(fields, ", ") + " }\n")
+	}
+	g.out.WriteString("\n" + llvmRuntime)
+
+	for _, t := range g.types {
+		if t.kind == recordKind {
+			g.writeEquality(t)
+		}
+	}
+	for _, sub := range g.subprograms {
+		g.writeSubprogram(sub)
+	}
+	g.out.WriteString(g.data.String())
+	g.out.WriteString("\ndefine i32 @main() {\nentry:\n  call void @" + g.main.symbol + "()\n  ret i32 0\n}\n")
+	if g.err != nil {
+		return "", g.err
+	}
+	return g.out.String(), nil
+}
+
+func llvmType(t *adaType) string {
+	switch t.kind {
+	case characterKind:
+		return "i8"
+	case booleanKind:
+		return "i1"
+	case recordKind:
+		return "%" + t.name
+	case stringKind:
+		return "%gada_string"
+	case accessKind:
+		if t.target == nil {
+			return "i8*"
+		}
+		return "%" + t.target.name + "*"
+	}
+	return "i32"
+}
+
+func frameType(sub *adaSubprogram) string {
+	return "%" + sub.symbol + ".frame"
+}
+
+// frameVariables returns the variables of the frame, the static link comes before them
+func frameVariables(sub *adaSubprogram) []*adaVariable {
+	return append(append([]*adaVariable{}, sub.params...), sub.locals...)
+}
+
+// variableType returns the type of the variable in the frame
+func variableType(variable *adaVariable) string {
+	if variable.byReference {
+		return llvmType(variable.typ) + "*"
+	}
+	return llvmType(variable.typ)
+}
+
+// frameIndex returns the index of the variable in the frame of the subprogram
+func frameIndex(sub *adaSubprogram, variable *adaVariable) int {
+	index := 0
+	if sub.hasLink() {
+		index++
+	}
+	for _, v := range frameVariables(sub) {
+		if v == variable {
+			return index
+		}
+		index++
+	}
+	return -1
+}
+
+// writeEquality writes the function comparing two records field by field
+func (g *llvmGenerator) writeEquality(t *adaType) {
+	record := "%" + t.name
+	g.out.WriteString("\ndefine internal i1 @" + t.name + ".eq(" + record + " %a, " + record + " %b) {\nentry:\n")
+	result := "true"
+	for i, field := range t.fields {
+		index := strconv.Itoa(i)
+		fieldType := llvmType(field.typ)
+		g.out.WriteString("  %a" + index + " = extractvalue " + record + " %a, " + index + "\n")
+		g.out.WriteString("  %b" + index + " = extractvalue " + record + " %b, " + index + "\n")
+		if field.typ.kind == recordKind || field.typ.kind == stringKind {
+			g.out.WriteString("  %c" + index + " = call i1 @" + strings.TrimPrefix(fieldType, "%") + ".eq(" + fieldType + " %a" + index + ", " + fieldType + " %b" + index + ")\n")
+		} else {
+			g.out.WriteString("  %c" + index + " = icmp eq " + fieldType + " %a" + index + ", %b" + index + "\n")
+		}
+		if i == 0 {
+			result = "%c0"
+		} else {
+			g.out.WriteString("  %r" + index + " = and i1 " + result + ", %c" + index + "\n")
+			result = "%r" + index
+		}
+	}
+	g.out.WriteString("  ret i1 " + result + "\n}\n")
+}
+
+func (g *llvmGenerator) signature(sub *adaSubprogram) string {
+	var params []string
+	if sub.hasLink() {
+		params = append(params, frameType(sub.parent)+"* %up")
+	}
+	for _, param := range sub.params {
+		params = append(params, variableType(param)+" %param."+param.name)
+	}
+	return "define internal " + g.returnType(sub) + " @" + sub.symbol + "(" + strings.Join(params, ", ") + ")"
+}
+
+func (g *llvmGenerator) returnType(sub *adaSubprogram) string {
+	if sub.returnType == nil {
+		return "void"
+	}
+	return llvmType(sub.returnType)
+}
+
+func (g *llvmGenerator) writeSubprogram(sub *adaSubprogram) {
+	g.current = sub
+	g.loops = nil
+	g.allocas = nil
+	g.body.Reset()
+	g.temps = 0
+	g.labels = 0
+	g.block = "entry"
+	g.terminated = false
+
+	if sub.hasFrame() {
+		frame := frameType(sub)
+		g.allocas = append(g.allocas, "%fr = alloca "+frame)
+		g.emit("store " + frame + " zeroinitializer, " + frame + "* %fr")
+		if sub.hasLink() {
+			link := g.temp()
+			g.emit(link + " = getelementptr " + frame + ", " + frame + "* %fr, i32 0, i32 0")
+			g.emit("store " + frameType(sub.parent) + "* %up, " + frameType(sub.parent) + "** " + link)
+		}
+		for _, param := range sub.params {
+			slot := g.frameSlot(sub, "%fr", param)
+			g.emit("store " + variableType(param) + " %param." + param.name + ", " + variableType(param) + "* " + slot)
+		}
+	}
+	for _, init := range sub.inits {
+		for _, variable := range init.variables {
+			value := g.expression(init.value)
+			g.store(value, g.frameAddress(sub, "%fr", variable), variable.typ)
+		}
+	}
+	g.statements(sub.body)
+	if !g.terminated {
+		if sub.returnType == nil {
+			g.emit("ret void")
+		} else {
+			g.emit("unreachable")
+		}
+	}
+
+	g.out.WriteString("\n" + g.signature(sub) + " {\nentry:\n")
+	for _, alloca := range g.allocas {
+		g.out.WriteString("  " + alloca + "\n")
+	}
+	g.out.WriteString(g.body.String())
+	g.out.WriteString("}\n")
+}
+
+func (g *llvmGenerator) temp() string {
+	g.temps++
+	return "%t" + strconv.Itoa(g.temps)
+}
+
+func (g *llvmGenerator) label(name string) string {
+	g.labels++
+	return name + strconv.Itoa(g.labels)
+}
+
+// emit writes an instruction, a new block is opened if the previous one is terminated
+func (g *llvmGenerator) emit(instruction string) {
+	if g.terminated {
+		g.startBlock(g.label("dead"))
+	}
+	g.body.WriteString("  " + instruction + "\n")
+	if strings.HasPrefix(instruction, "br ") || strings.HasPrefix(instruction, "ret ") || instruction == "unreachable" {
+		g.terminated = true
+	}
+}
+
+func (g *llvmGenerator) startBlock(label string) {
+	if !g.terminated {
+		g.body.WriteString("  br label %" + label + "\n")
+	}
+	g.body.WriteString(label + ":\n")
+	g.block = label
+	g.terminated = false
+}
+
+func (g *llvmGenerator) store(value llvmValue, address string, typ *adaType) {
+	g.emit("store " + llvmType(typ) + " " + value.text + ", " + llvmType(typ) + "* " + address)
+}
+
+func (g *llvmGenerator) load(address string, typ *adaType) llvmValue {
+	result := g.temp()
+	g.emit(result + " = load " + llvmType(typ) + ", " + llvmType(typ) + "* " + address)
+	return llvmValue{text: result, typ: typ}
+}
+
+// frameSlot returns the address of the slot of a variable in a frame
+func (g *llvmGenerator) frameSlot(sub *adaSubprogram, frame string, variable *adaVariable) string {
+	address := g.temp()
+	g.emit(address + " = getelementptr " + frameType(sub) + ", " + frameType(sub) + "* " + frame + ", i32 0, i32 " + strconv.Itoa(frameIndex(sub, variable)))
+	return address
+}
+
+// frameAddress returns the address of a variable in a frame, the pointer of an in out parameter is loaded
+func (g *llvmGenerator) frameAddress(sub *adaSubprogram, frame string, variable *adaVariable) string {
+	address := g.frameSlot(sub, frame, variable)
+	if !variable.byReference {
+		return address
+	}
+	pointer := g.temp()
+	g.emit(pointer + " = load " + variableType(variable) + ", " + variableType(variable) + "* " + address)
+	return pointer
+}
+
+// frame follows the static links and returns the frame of the subprogram at the given distance
+func (g *llvmGenerator) frame(distance int) (*adaSubprogram, string) {
+	sub, frame := g.current, "%fr"
+	for i := 0; i < distance; i++ {
+		link := g.temp()
+		g.emit(link + " = getelementptr " + frameType(sub) + ", " + frameType(sub) + "* " + frame + ", i32 0, i32 0")
+		frame = g.temp()
+		g.emit(frame + " = load " + frameType(sub.parent) + "*, " + frameType(sub.parent) + "** " + link)
+		sub = sub.parent
+	}
+	return sub, frame
+}
+
+func (g *llvmGenerator) statements(node int) {
+	for _, child := range g.graph.GetChildren(node) {
+		g.statement(child)
+	}
+}
+
+func (g *llvmGenerator) statement(node int) {
+	g.graph.visit(node)
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		if g.graph.GetNode(node) == "return" {
+			g.emit("ret void")
+		} else {
+			// A procedure called without parameters
+			g.call(node, true)
+		}
+		return
+	}
+	switch g.graph.GetNode(node) {
+	case ":=":
+		address, typ := g.address(children[0])
+		g.store(g.expression(children[1]), address, typ)
+	case "call":
+		g.call(node, true)
+	case "return":
+		if len(children) == 0 {
+			g.emit("ret void")
+		} else {
+			value := g.expression(children[0])
+			g.emit("ret " + llvmType(g.current.returnType) + " " + value.text)
+		}
+	case "if":
+		end := g.label("endif")
+		g.branches(children, end)
+		g.startBlock(end)
+	case "while":
+		condition, body, end := g.label("while"), g.label("loop"), g.label("endwhile")
+		g.startBlock(condition)
+		value := g.expression(children[0])
+		g.emit("br i1 " + value.text + ", label %" + body + ", label %" + end)
+		g.startBlock(body)
+		g.statements(children[1])
+		g.emit("br label %" + condition)
+		g.startBlock(end)
+	case "for":
+		g.forLoop(children)
+	default:
+		g.fail(node, "unsupported statement %s", g.graph.GetNode(node))
+	}
+}
+
+// branches writes an if statement, children are the condition, the body then the elsif and else nodes
+func (g *llvmGenerator) branches(children []int, end string) {
+	then, next := g.label("then"), g.label("else")
+	condition := g.expression(children[0])
+	g.emit("br i1 " + condition.text + ", label %" + then + ", label %" + next)
+	g.startBlock(then)
+	g.statements(children[1])
+	g.emit("br label %" + end)
+	g.startBlock(next)
+	if len(children) < 3 {
+		return
+	}
+	if g.graph.GetNode(children[2]) == "elif" {
+		g.branches(append(g.graph.GetChildren(children[2]), children[3:]...), end)
+		return
+	}
+	g.statements(children[2])
+}
+
+// forLoop evaluates the bounds once and stops on the last value so that it cannot overflow
+func (g *llvmGenerator) forLoop(children []int) {
+	first := g.expression(children[2])
+	last := g.expression(children[3])
+	typ := llvmType(first.typ)
+	step, stop := "add", "sgt"
+	if first.typ.kind != integerKind {
+		stop = "ugt"
+	}
+	if g.graph.GetNode(children[1]) == "reverse" {
+		first, last = last, first
+		step, stop = "sub", "slt"
+		if first.typ.kind != integerKind {
+			stop = "ult"
+		}
+	}
+
+	variable := &adaVariable{name: cIdentifier(g.graph.GetNode(children[0])), typ: first.typ}
+	address := "%" + variable.name + ".addr" + strconv.Itoa(len(g.allocas))
+	g.allocas = append(g.allocas, address+" = alloca "+typ)
+	g.store(first, address, first.typ)
+
+	body, next, end := g.label("for"), g.label("next"), g.label("endfor")
+	empty := g.temp()
+	g.emit(empty + " = icmp " + stop + " " + typ + " " + first.text + ", " + last.text)
+	g.emit("br i1 " + empty + ", label %" + end + ", label %" + body)
+	g.startBlock(body)
+	g.loops = append(g.loops, llvmLoop{variable: variable, address: address})
+	g.statements(children[4])
+	g.loops = g.loops[:len(g.loops)-1]
+	current := g.load(address, first.typ)
+	done := g.temp()
+	g.emit(done + " = icmp eq " + typ + " " + current.text + ", " + last.text)
+	g.emit("br i1 " + done + ", label %" + end + ", label %" + next)
+	g.startBlock(next)
+	following := g.temp()
+	g.emit(following + " = " + step + " " + typ + " " + current.text + ", 1")
+	g.emit("store " + typ + " " + following + ", " + typ + "* " + address)
+	g.emit("br label %" + body)
+	g.startBlock(end)
+}
+
+// address returns the address of a variable or of a field, a value that is not a variable is copied
+// to the stack
+func (g *llvmGenerator) address(node int) (string, *adaType) {
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		name := g.graph.GetNode(node)
+		for i := len(g.loops) - 1; i >= 0; i-- {
+			if g.loops[i].variable.name == cIdentifier(name) {
+				return g.loops[i].address, g.loops[i].variable.typ
+			}
+		}
+		if variable, distance, _ := g.lookup(g.current, name); variable != nil {
+			sub, frame := g.frame(distance)
+			return g.frameAddress(sub, frame, variable), variable.typ
+		}
+	}
+	if g.graph.GetNode(node) == "access" && len(children) == 2 {
+		// The fields are nested on the right: E.Next.Prev is access(E, access(Next, Prev))
+		address, typ := g.address(children[0])
+		for _, field := range g.fields(children[1]) {
+			address, typ = g.field(field, address, typ)
+		}
+		return address, typ
+	}
+
+	value := g.expression(node)
+	address := "%tmp" + strconv.Itoa(len(g.allocas))
+	g.allocas = append(g.allocas, address+" = alloca "+llvmType(value.typ))
+	g.store(value, address, value.typ)
+	return address, value.typ
+}
+
+// field returns the address of a field of the record at the address, an access value is followed
+func (g *llvmGenerator) field(node int, address string, typ *adaType) (string, *adaType) {
+	if typ.kind == accessKind && typ.target != nil {
+		address = g.load(address, typ).text
+		typ = typ.target
+	}
+	name := cIdentifier(g.graph.GetNode(node))
+	for i, field := range typ.fields {
+		if field.name == name {
+			result := g.temp()
+			g.emit(result + " = getelementptr " + llvmType(typ) + ", " + llvmType(typ) + "* " + address + ", i32 0, i32 " + strconv.Itoa(i))
+			return result, field.typ
+		}
+	}
+	g.fail(node, "unknown field %s", name)
+	return address, integerType
+}
+
+var llvmOperators = map[string]string{
+	"+": "add", "-": "sub", "*": "mul", "/": "sdiv", "rem": "srem", "and": "and", "or": "or",
+}
+
+// llvmComparisons gives the signed and the unsigned predicate of the comparisons
+var llvmComparisons = map[string][2]string{
+	"=": {"eq", "eq"}, "/=": {"ne", "ne"}, "!=": {"ne", "ne"},
+	"<": {"slt", "ult"}, "<=": {"sle", "ule"}, ">": {"sgt", "ugt"}, ">=": {"sge", "uge"},
+}
+
+func (g *llvmGenerator) expression(node int) llvmValue {
+	children := g.graph.GetChildren(node)
+	name := g.graph.GetNode(node)
+	if len(children) == 0 {
+		return g.leaf(node)
+	}
+
+	switch name {
+	case "access":
+		address, typ := g.address(node)
+		return g.load(address, typ)
+	case "memory":
+		record := g.lookupType(g.current, children[1])
+		pointer := "%" + record.name + "*"
+		size, bytes, memory, result := g.temp(), g.temp(), g.temp(), g.temp()
+		g.emit(size + " = getelementptr %" + record.name + ", " + pointer + " null, i32 1")
+		g.emit(bytes + " = ptrtoint " + pointer + " " + size + " to i64")
+		g.emit(memory + " = call i8* @calloc(i64 1, i64 " + bytes + ")")
+		g.emit(result + " = bitcast i8* " + memory + " to " + pointer)
+		return llvmValue{text: result, typ: &adaType{kind: accessKind, target: record}}
+	case "attribute":
+		return g.attribute(node)
+	case "call":
+		return g.call(node, false)
+	case "and then", "or else":
+		return g.shortCircuit(name, children)
+	}
+
+	if predicates, ok := llvmComparisons[name]; ok {
+		return g.compare(name, predicates, g.expression(children[0]), g.expression(children[1]))
+	}
+	op, ok := llvmOperators[name]
+	if !ok || len(children) != 2 {
+		g.fail(node, "unsupported expression %s", name)
+		return llvmValue{text: "0", typ: integerType}
+	}
+	left := g.expression(children[0])
+	right := g.expression(children[1])
+	result := g.temp()
+	g.emit(result + " = " + op + " " + llvmType(left.typ) + " " + left.text + ", " + right.text)
+	return llvmValue{text: result, typ: left.typ}
+}
+
+// llvmBounds are the values of T'First and T'Last of the discrete types, a character is a signed i8
+var llvmBounds = map[typeKind][2]string{
+	integerKind:   {"-2147483648", "2147483647"},
+	characterKind: {"0", "-1"},
+	booleanKind:   {"false", "true"},
+}
+
+// attribute translates Prefix'Name, Image calls the runtime for integers and characters
+func (g *llvmGenerator) attribute(node int) llvmValue {
+	name, prefix, args := g.program.attribute(node)
+	if name == "length" {
+		value := g.expression(prefix)
+		result := g.temp()
+		g.emit(result + " = call i32 @gada_length(%gada_string " + value.text + ")")
+		return llvmValue{text: result, typ: integerType}
+	}
+	typ := g.lookupType(g.current, prefix)
+	switch name {
+	case "first":
+		return llvmValue{text: llvmBounds[typ.kind][0], typ: typ}
+	case "last":
+		return llvmValue{text: llvmBounds[typ.kind][1], typ: typ}
+	}
+
+	value := g.expression(args[0])
+	result := g.temp()
+	switch {
+	case name == "pos" && typ.kind == integerKind:
+		return value
+	case name == "pos":
+		g.emit(result + " = zext " + llvmType(typ) + " " + value.text + " to i32")
+		return llvmValue{text: result, typ: integerType}
+	case name == "val" && typ.kind == integerKind:
+		return value
+	case name == "val":
+		g.emit(result + " = trunc i32 " + value.text + " to " + llvmType(typ))
+	case name == "succ":
+		g.emit(result + " = add " + llvmType(typ) + " " + value.text + ", 1")
+	case name == "pred":
+		g.emit(result + " = sub " + llvmType(typ) + " " + value.text + ", 1")
+	case typ.kind == booleanKind:
+		image, truth := g.stringConstant("FALSE"), g.stringConstant("TRUE")
+		g.emit(result + " = select i1 " + value.text + ", %gada_string " + truth.text + ", %gada_string " + image.text)
+		return llvmValue{text: result, typ: stringType}
+	case typ.kind == characterKind:
+		g.emit(result + " = call %gada_string @gada_char_image(i8 " + value.text + ")")
+		return llvmValue{text: result, typ: stringType}
+	default:
+		g.emit(result + " = call %gada_string @gada_int_image(i32 " + value.text + ")")
+		return llvmValue{text: result, typ: stringType}
+	}
+	return llvmValue{text: result, typ: typ}
+}
+
+// compare compares two values, integers are signed while characters and booleans are not.
+// Records are compared field by field and strings character by character.
+func (g *llvmGenerator) compare(name string, predicates [2]string, left llvmValue, right llvmValue) llvmValue {
+	result := g.temp()
+	typ := left.typ
+	if typ == nullType {
+		typ = right.typ
+	}
+	if typ.kind == recordKind || typ.kind == stringKind {
+		g.emit(result + " = call i1 @" + typ.name + ".eq(" + llvmType(typ) + " " + left.text + ", " + llvmType(typ) + " " + right.text + ")")
+		if name != "=" {
+			negated := g.temp()
+			g.emit(negated + " = xor i1 " + result + ", true")
+			return llvmValue{text: negated, typ: booleanType}
+		}
+		return llvmValue{text: result, typ: booleanType}
+	}
+	predicate := predicates[0]
+	if typ.kind != integerKind {
+		predicate = predicates[1]
+	}
+	g.emit(result + " = icmp " + predicate + " " + llvmType(typ) + " " + left.text + ", " + right.text)
+	return llvmValue{text: result, typ: booleanType}
+}
+
+// shortCircuit evaluates the right operand only when the left one does not decide the result
+func (g *llvmGenerator) shortCircuit(name string, children []int) llvmValue {
+	left := g.expression(children[0])
+	from := g.block
+	right, end := g.label("right"), g.label("endcond")
+	decided := "false"
+	if name == "and then" {
+		g.emit("br i1 " + left.text + ", label %" + right + ", label %" + end)
+	} else {
+		decided = "true"
+		g.emit("br i1 " + left.text + ", label %" + end + ", label %" + right)
+	}
+	g.startBlock(right)
+	value := g.expression(children[1])
+	last := g.block
+	g.emit("br label %" + end)
+	g.startBlock(end)
+	result := g.temp()
+	g.emit(result + " = phi i1 [ " + decided + ", %" + from + " ], [ " + value.text + ", %" + last + " ]")
+	return llvmValue{text: result, typ: booleanType}
+}
+
+func (g *llvmGenerator) leaf(node int) llvmValue {
+	name := g.graph.GetNode(node)
+	switch {
+	case name == "true" || name == "false":
+		return llvmValue{text: name, typ: booleanType}
+	case name == "null":
+		return llvmValue{text: "null", typ: nullType}
+	case name[0] == '\'':
+		char := []rune(g.graph.GetRealNode(node))
+		return llvmValue{text: strconv.Itoa(int(int8(char[1]))), typ: characterType}
+	case name[0] == '"':
+		return g.stringConstant(unquoteString(g.graph.GetRealNode(node)))
+	case name[0] >= '0' && name[0] <= '9':
+		// Integers wrap around like on ARM
+		value, err := strconv.ParseInt(strings.ReplaceAll(name, "_", ""), 10, 64)
+		if err != nil {
+			g.fail(node, "invalid integer %s", name)
+		}
+		return llvmValue{text: strconv.Itoa(int(int32(value))), typ: integerType}
+	}
+
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].variable.name == cIdentifier(name) {
+			return g.load(g.loops[i].address, g.loops[i].variable.typ)
+		}
+	}
+	variable, distance, found := g.lookup(g.current, name)
+	switch {
+	case variable != nil:
+		sub, frame := g.frame(distance)
+		return g.load(g.frameAddress(sub, frame, variable), variable.typ)
+	case found:
+		// A function without parameters is called without parentheses
+		return g.call(node, false)
+	}
+	g.fail(node, "unknown identifier %s", name)
+	return llvmValue{text: "0", typ: integerType}
+}
+
+// stringConstant returns the length and the characters of a string literal, the literals with
+// the same value share one global
+func (g *llvmGenerator) stringConstant(value string) llvmValue {
+	global, ok := g.constants[value]
+	if !ok {
+		global = "@.str." + strconv.Itoa(len(g.constants))
+		g.constants[value] = global
+		var text strings.Builder
+		for i := 0; i < len(value); i++ {
+			c := value[i]
+			if c >= ' ' && c <= '~' && c != '"' && c != '\\' {
+				text.WriteByte(c)
+			} else {
+				text.WriteString(fmt.Sprintf("\\%02X", c))
+			}
+		}
+		g.data.WriteString("\n" + global + " = private unnamed_addr constant [" + strconv.Itoa(len(value)) + " x i8] c\"" + text.String() + "\"\n")
+	}
+	size := strconv.Itoa(len(value))
+	characters := "getelementptr ([" + size + " x i8], [" + size + " x i8]* " + global + ", i32 0, i32 0)"
+	return llvmValue{text: "{ i32 " + size + ", i8* " + characters + " }", typ: stringType}
+}
+
+// call writes a call node, or an identifier calling a function without parameters
+func (g *llvmGenerator) call(node int, statement bool) llvmValue {
+	nameNode := node
+	var args []int
+	if g.graph.GetNode(node) == "call" {
+		children := g.graph.GetChildren(node)
+		nameNode = children[0]
+		switch g.graph.GetNode(nameNode) {
+		case "-":
+			value := g.expression(children[1])
+			result := g.temp()
+			g.emit(result + " = sub i32 0, " + value.text)
+			return llvmValue{text: result, typ: integerType}
+		case "not":
+			value := g.expression(children[1])
+			result := g.temp()
+			g.emit(result + " = xor i1 " + value.text + ", true")
+			return llvmValue{text: result, typ: booleanType}
+		}
+		if len(children) > 1 {
+			args = g.graph.GetChildren(children[1])
+		}
+	}
+
+	name := g.graph.GetNode(nameNode)
+	callee := g.resolve(g.current, nameNode, len(args))
+	if callee == nil {
+		switch {
+		case name == "new_line" && len(args) == 0:
+			g.emit("call void @gada_new_line()")
+			return llvmValue{}
+		case name == "put" && len(args) == 1:
+			value := g.expression(args[0])
+			switch value.typ.kind {
+			case characterKind:
+				g.emit("call void @gada_put_char(i8 " + value.text + ")")
+			case stringKind:
+				g.emit("call void @gada_put_string(%gada_string " + value.text + ")")
+			case booleanKind:
+				extended := g.temp()
+				g.emit(extended + " = zext i1 " + value.text + " to i32")
+				g.emit("call void @gada_put_int(i32 " + extended + ")")
+			default:
+				g.emit("call void @gada_put_int(i32 " + value.text + ")")
+			}
+			return llvmValue{}
+		case name == "put_line" && len(args) == 1:
+			value := g.expression(args[0])
+			g.emit("call void @gada_put_string(%gada_string " + value.text + ")")
+			g.emit("call void @gada_new_line()")
+			return llvmValue{}
+		}
+		g.fail(node, "unknown subprogram %s", name)
+		return llvmValue{text: "0", typ: integerType}
+	}
+	if !statement && callee.returnType == nil {
+		g.fail(node, "%s is a procedure", name)
+		return llvmValue{text: "0", typ: integerType}
+	}
+
+	var values []string
+	if callee.hasLink() {
+		distance := 0
+		for s := g.current; s != nil && s != callee.parent; s = s.parent {
+			distance++
+		}
+		_, frame := g.frame(distance)
+		values = append(values, frameType(callee.parent)+"* "+frame)
+	}
+	for i, arg := range args {
+		param := callee.params[i]
+		if param.byReference {
+			address, _ := g.address(arg)
+			values = append(values, variableType(param)+" "+address)
+			continue
+		}
+		values = append(values, llvmType(param.typ)+" "+g.expression(arg).text)
+	}
+	text := "call " + g.returnType(callee) + " @" + callee.symbol + "(" + strings.Join(values, ", ") + ")"
+	if callee.returnType == nil {
+		g.emit(text)
+		return llvmValue{}
+	}
+	result := g.temp()
+	g.emit(result + " = " + text)
+	return llvmValue{text: result, typ: callee.returnType}
+}
@@ -6,8 +6,10 @@ import (
 	"gada/lexer"
 	"gada/token"
 	"github.com/charmbracelet/log"
+	"io"
 	"os"
 	"os/exec"
+	"runtime/debug"
 	"strconv"
 )
 
@@ -31,6 +33,14 @@ func init() {
 	logger = log.New(os.Stderr)
 }
 
+// SetLogOutput sets where the compilation errors are written, nil restores the standard error
+func SetLogOutput(w io.Writer) {
+	if w == nil {
+		w = os.Stderr
+	}
+	logger.SetOutput(w)
+}
+
 func (n *Node) addChild(child Node) {
 	n.Children = append(n.Children, &child)
 }
@@ -80,18 +90,26 @@ func (p *Parser) peekToken() token.Token {
 	return token.Token(p.lexer.Tokens[p.index].Value)
 }
 
+// currentToken is the token at the index, or the last one (EOF) when the whole file has been read
+func (p *Parser) currentToken() lexer.Token {
+	if p.index >= len(p.lexer.Tokens) {
+		return p.lexer.Tokens[len(p.lexer.Tokens)-1]
+	}
+	return p.lexer.Tokens[p.index]
+}
+
 func (p *Parser) peekTokenToString() string {
 	if p.index >= len(p.lexer.Tokens) {
 		return "EOF"
 	}
 	if p.lexer.Tokens[p.index].Value == token.IDENT {
-		return p.lexer.Lexi[p.lexer.Tokens[p.index].Position-1]
+		return p.lexer.Word(p.lexer.Tokens[p.index].Position)
 	}
-	if p.lexer.Tokens[p.index].Value == token.CHAR_TOK {
-		return "'" + p.lexer.Lexi[p.lexer.Tokens[p.index].Position-1] + "'"
+	if p.lexer.Tokens[p.index].Value == token.INT || p.lexer.Tokens[p.index].Value == token.REAL {
+		return p.lexer.Word(p.lexer.Tokens[p.index].Position)
 	}
-	if p.lexer.Tokens[p.index].Value == token.INT {
-		return p.lexer.Lexi[p.lexer.Tokens[p.index].Position-1]
+	if p.lexer.Tokens[p.index].Value == token.STRING {
+		return quoteString(p.lexer.Word(p.lexer.Tokens[p.index].Position))
 	}
 	return token.Token(p.lexer.Tokens[p.index].Value).String()
 }
@@ -107,7 +125,7 @@ func (p *Parser) printTokensBefore(i int) {
 	for j := i - 1; j >= 0; j-- {
 		t := token.Token(p.lexer.Tokens[p.index-j].Value)
 		if t == token.IDENT {
-			fmt.Print(p.lexer.Lexi[p.lexer.Tokens[p.index-j].Position-1], " ")
+			fmt.Print(p.lexer.Word(p.lexer.Tokens[p.index-j].Position), " ")
 			continue
 		}
 		fmt.Print(token.Token(p.lexer.Tokens[p.index-j].Value), " ")
@@ -115,17 +133,33 @@ func (p *Parser) printTokensBefore(i int) {
 	fmt.Println()
 }
 
-func Parse(lex *lexer.Lexer, printAst bool, pythonExecutable string) {
+// Parse compiles the tokens to the assembly file, the internal errors of the compiler are logged and returned
+func Parse(lex *lexer.Lexer, printAst bool, pythonExecutable string, optimizationLevel int) error {
 	parser := Parser{lexer: lex, index: 0, exprError: false, hadError: false}
 	lex.Tokens = append(lex.Tokens, lexer.Token{Value: token.EOF, Beginning: lexer.Position{Line: lex.Tokens[len(lex.Tokens)-1].End.Line, Column: lex.Tokens[len(lex.Tokens)-1].End.Column}, End: lexer.Position{Line: lex.Tokens[len(lex.Tokens)-1].End.Line, Column: lex.Tokens[len(lex.Tokens)-1].End.Column}})
-	node := readFichier(&parser)
+	node, graph, err := parseGraph(&parser)
+	if err != nil {
+		logger.Error(err.Error())
+		return err
+	}
 	os.WriteFile("./test/parser/parsetree.json", []byte(node.toJson()), 0644)
-	graph := toAst(node, *lex)
 	os.WriteFile("./test/parser/ast.json", []byte(graph.toJson()), 0644)
-	_, err := ReadAST(&graph, true)
+	if parser.hadError {
+		// the symbols and the semantics expect a complete tree
+		logger.Error("Compilation failed")
+		return fmt.Errorf("compilation of %s failed", lex.FileName)
+	}
+	err = runPhase(&graph, "symbols", func() error {
+		_, err := ReadAST(&graph, true)
+		return err
+	})
+	if _, ok := err.(*InternalError); ok {
+		logger.Error(err.Error())
+		return err
+	}
 	if err != nil {
 		logger.Error("Error while reading AST", "error", err)
-		return
+		return err
 	}
 	if printAst {
 		logger.Info("Rendering AST...")
@@ -135,28 +169,115 @@ func Parse(lex *lexer.Lexer, printAst bool, pythonExecutable string) {
 		err := cmd.Start()
 		if err != nil {
 			logger.Error("Error while running python script", "error", err)
-			return
+			return err
 		}
 		err = cmd.Wait()
 		if err != nil {
 			logger.Error("Error while running python script", "error", err)
-			return
+			return err
 		}
 		logger.Info("AST rendered")
 	}
 	logger.Info("Checking semantics...")
-	CheckSemantics(graph)
+	if err := runPhase(&graph, "semantics", func() error { return CheckSemantics(graph) }); err != nil {
+		logger.Error(err.Error())
+		return err
+	}
 
 	logger.Info("Compiling to ASM...")
 	os.WriteFile("./test/parser/astSem.json", []byte(graph.toJson()), 0644)
-	ReadASTToASM(graph)
+	if err := runPhase(&graph, "code generation", func() error { return ReadASTToASM(graph, optimizationLevel) }); err != nil {
+		logger.Error(err.Error())
+		return err
+	}
 	if parser.hadError {
 		// no crash for now
 		logger.Error("Compilation failed")
-		return
+		return fmt.Errorf("compilation of %s failed", lex.FileName)
 	} else {
 		logger.Info("Compilation successful")
 	}
+	return nil
+}
+
+// CompileToASM compiles the tokens of the lexer to assembly text without writing any file.
+func CompileToASM(lex *lexer.Lexer, optimizationLevel int) (string, error) {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return "", err
+	}
+	return generate(graph, func(graph Graph) (string, error) {
+		file, err := GenerateASM(graph, optimizationLevel)
+		return file.Text, err
+	})
+}
+
+// CompileToC translates the tokens to C without writing any file
+func CompileToC(lex *lexer.Lexer) (string, error) {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return "", err
+	}
+	return generate(graph, GenerateC)
+}
+
+// generate runs a backend on the analysed graph, a panic of the backend is an internal error
+func generate(graph Graph, backend func(Graph) (string, error)) (string, error) {
+	var text string
+	err := runPhase(&graph, "code generation", func() (err error) {
+		text, err = backend(graph)
+		return err
+	})
+	return text, err
+}
+
+// parseGraph reads the file and builds the AST, a panic of the parser is an internal error located
+// at the token being read
+func parseGraph(parser *Parser) (node Node, graph Graph, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			position := parser.currentToken().Beginning
+			err = &InternalError{Phase: "parsing", File: parser.lexer.FileName, Line: position.Line, Column: position.Column, Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+	node = readFichier(parser)
+	return node, toAst(node, *parser.lexer), nil
+}
+
+// ParseTokens parses the tokens and builds the AST, the graph is built even when there are syntax errors
+func ParseTokens(lex *lexer.Lexer) (Graph, error) {
+	parser := Parser{lexer: lex, index: 0, exprError: false, hadError: false}
+	end := lexer.Position{Line: 1, Column: 1}
+	if len(lex.Tokens) > 0 {
+		end = lex.Tokens[len(lex.Tokens)-1].End
+	}
+	lex.Tokens = append(lex.Tokens, lexer.Token{Value: token.EOF, Beginning: end, End: end})
+	_, graph, err := parseGraph(&parser)
+	if err != nil {
+		return graph, err
+	}
+	if parser.hadError {
+		return graph, fmt.Errorf("compilation of %s failed", lex.FileName)
+	}
+	return graph, nil
+}
+
+// Analyse parses the tokens and checks the semantics of the program, the lexer cannot be analysed twice.
+// The semantics are not checked after syntax errors. A panic of a phase is returned as an InternalError.
+func Analyse(lex *lexer.Lexer) (Graph, error) {
+	graph, err := ParseTokens(lex)
+	if err != nil {
+		return graph, err
+	}
+	err = runPhase(&graph, "symbols", func() error {
+		_, err := ReadAST(&graph, false)
+		return err
+	})
+	if err != nil {
+		return graph, err
+	}
+	err = runPhase(&graph, "semantics", func() error { return CheckSemantics(graph) })
+	return graph, err
 }
 
 func (parser *Parser) advanceExpr(tokens []token.Token) {
@@ -165,7 +286,7 @@ func (parser *Parser) advanceExpr(tokens []token.Token) {
 			if parser.peekToken() == tkn {
 				return
 			} else if parser.peekToken() == token.IDENT {
-				if parser.peekTokenFurther(1) == token.COLON || parser.peekTokenFurther(1) == token.EQL {
+				if parser.peekTokenFurther(1) == token.COLON || parser.peekTokenFurther(1) == token.ASSIGN || parser.peekTokenFurther(1) == token.EQL {
 					return
 				}
 			}
@@ -197,8 +318,8 @@ func (parser *Parser) advance2(tokens ...token.Token) {
 }
 
 func customError(parser *Parser, error string) {
-	line := parser.lexer.Tokens[parser.index].Beginning.Line
-	column := parser.lexer.Tokens[parser.index].Beginning.Column
+	line := parser.currentToken().Beginning.Line
+	column := parser.currentToken().Beginning.Column
 	file := parser.lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column)
 	logger.Error(file + " " + error)
 
@@ -208,10 +329,10 @@ func customError(parser *Parser, error string) {
 func unexpectedToken(parser *Parser, possible, got string) {
 	red := "\x1b[0;31m"
 	reset := "\x1b[0m"
-	line := parser.lexer.Tokens[parser.index].Beginning.Line
-	column := parser.lexer.Tokens[parser.index].Beginning.Column
+	line := parser.currentToken().Beginning.Line
+	column := parser.currentToken().Beginning.Column
 	file := parser.lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column)
-	logger.Error(file+" "+"Unexpected token: "+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "possible", possible, "got", got)
+	logger.Error(file+" "+"Unexpected token: "+red+parser.lexer.GetToken(parser.currentToken())+reset, "possible", possible, "got", got)
 
 	parser.hadError = true
 }
@@ -239,7 +360,7 @@ func expectToken(parser *Parser, tkn token.Token) bool {
 		line := parser.lexer.Tokens[parser.index].Beginning.Line
 		column := parser.lexer.Tokens[parser.index].Beginning.Column
 		file := parser.lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column)
-		if tkn == token.SEMICOLON {
+		if tkn == token.SEMICOLON && parser.index > 0 {
 			// There is a missing semicolon, specific message and line/column
 			// We can just continue parsing
 			parser.unreadToken()
@@ -249,7 +370,7 @@ func expectToken(parser *Parser, tkn token.Token) bool {
 			logger.Error(file + " " + "Missing semicolon after: " + parser.lexer.GetLineUpToTokenIncluded(parser.lexer.Tokens[parser.index]))
 			parser.readToken()
 		} else if parser.peekToken() == token.IDENT {
-			logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "expected", tkn, "got", parser.lexer.Lexi[parser.lexer.Tokens[parser.index].Position-1])
+			logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "expected", tkn, "got", parser.lexer.Word(parser.lexer.Tokens[parser.index].Position))
 			// no read to continue parsing
 		} else {
 			logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "expected", tkn, "got", parser.peekToken())
@@ -265,22 +386,24 @@ func peekExpectToken(parser *Parser, tkn token.Token) {
 	if parser.peekToken() != tkn {
 		red := "\x1b[0;31m"
 		reset := "\x1b[0m"
-		line := parser.lexer.Tokens[parser.index].Beginning.Line
-		column := parser.lexer.Tokens[parser.index].Beginning.Column
+		line := parser.currentToken().Beginning.Line
+		column := parser.currentToken().Beginning.Column
 		file := parser.lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column)
-		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "expected", tkn, "got", parser.peekToken())
+		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.currentToken())+red+parser.lexer.GetToken(parser.currentToken())+reset, "expected", tkn, "got", parser.peekToken())
+		parser.hadError = true
 	}
 }
 
 func expectTokenIdent(parser *Parser, ident string, recovery []any) string {
 	red := "\x1b[0;31m"
 	reset := "\x1b[0m"
-	line := parser.lexer.Tokens[parser.index].Beginning.Line
-	column := parser.lexer.Tokens[parser.index].Beginning.Column
+	line := parser.currentToken().Beginning.Line
+	column := parser.currentToken().Beginning.Column
 	file := parser.lexer.FileName + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(column)
 	if parser.peekToken() != token.IDENT {
 		// don't read, just assume it's there and raise the error
-		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index])+reset, "expected", ident, "got", parser.peekToken())
+		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.currentToken())+red+parser.lexer.GetToken(parser.currentToken())+reset, "expected", ident, "got", parser.peekToken())
+		parser.hadError = true
 		// if next token is the right one (in recovery), assume the current token is right to continue parsing
 		for _, r := range recovery {
 			if parser.peekTokenFurther(1) == token.Token(r.(int)) {
@@ -291,10 +414,11 @@ func expectTokenIdent(parser *Parser, ident string, recovery []any) string {
 		return ""
 	}
 	_, index := parser.readFullToken()
-	if parser.lexer.Lexi[index-1] != ident {
-		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index-1])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index-1])+reset, "expected", ident, "got", parser.lexer.Lexi[index-1])
+	if parser.lexer.Word(index) != ident {
+		logger.Error(file+" "+"Unexpected token: "+parser.lexer.GetLineUpToToken(parser.lexer.Tokens[parser.index-1])+red+parser.lexer.GetToken(parser.lexer.Tokens[parser.index-1])+reset, "expected", ident, "got", parser.lexer.Word(index))
+		parser.hadError = true
 	}
-	return parser.lexer.Lexi[index-1]
+	return parser.lexer.Word(index)
 }
 
 func expectTokens(parser *Parser, tkns []any) bool {
@@ -426,8 +550,8 @@ func readInit(parser *Parser) Node {
 	case token.SEMICOLON:
 		node = Node{Type: "InitSemicolon"}
 		node.setLineColumn(*parser)
-	case token.COLON:
-		expectTokens(parser, []any{token.COLON, token.EQL})
+	case token.ASSIGN:
+		parser.readToken()
 		node = Node{Type: "Init"}
 		node.setLineColumn(*parser)
 		node.addChild(readExpr(parser))
@@ -446,7 +570,7 @@ func readInit(parser *Parser) Node {
 			node.addChild(readExpr(parser))
 			return node
 		}
-		unexpectedToken(parser, "; :", parser.peekTokenToString())
+		unexpectedToken(parser, "; :=", parser.peekTokenToString())
 	}
 	return node
 }
@@ -664,447 +788,6 @@ func readModeOpt(parser *Parser) Node {
 	return node
 }
 
-func readExpr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "ExprIdent"}
-		node.setLineColumn(*parser)
-		node.addChild(readOr_expr(parser))
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readOr_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "OrExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readAnd_expr(parser))
-		node = readOr_expr_tail(parser, &node)
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readOr_expr_tail(parser *Parser, nd *Node) Node {
-	var node Node
-	if nd == nil {
-		node = Node{}
-		node.setLineColumn(*parser)
-	} else {
-		node = *nd
-	}
-	for parser.peekToken() == token.OR {
-		parser.readToken()
-		prev := node
-		node = Node{Type: "OrExprTailOr"}
-		node.setLineColumn(*parser)
-		if nd != nil {
-			node.addChild(prev)
-		}
-		node = readOr_expr_tail2(parser, &node)
-	}
-	switch parser.peekToken() {
-	case token.SEMICOLON, token.RPAREN, token.THEN, token.COMMA, token.LOOP:
-		//node.Type = "OrExprTail"
-		return node
-	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node.Type = "OrExprTail"
-			return node
-		}
-		node.Type = "OrExprTailPeriod"
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-	default:
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-		//logger.Fatal("Unexpected token", "possible", "or ; ) then , loop .", "got", parser.peekToken())
-	}
-	return node
-}
-
-func readOr_expr_tail2(parser *Parser, nd *Node) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.ELSE:
-		parser.readToken()
-		prev := *nd
-		node = Node{Type: "OrExprTail2Else"}
-		node.setLineColumn(*parser)
-		node.addChild(prev)
-		node.addChild(readAnd_expr(parser))
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		prev := *nd
-		node = Node{Type: "OrExprTail2"}
-		node.setLineColumn(*parser)
-		node.addChild(prev)
-		node.addChild(readAnd_expr(parser))
-	default:
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-		//logger.Fatal("Unexpected token", "possible", "else ident ( not - int char true false null new char", "got", parser.peekToken())
-	}
-	return node
-}
-
-func readAnd_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "AndExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readEquality_expr(parser))
-		node = readAnd_expr_tail(parser, &node)
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readAnd_expr_tail(parser *Parser, nd *Node) Node {
-	var node Node
-	if nd == nil {
-		node = Node{}
-		node.setLineColumn(*parser)
-	} else {
-		node = *nd
-	}
-	for parser.peekToken() == token.AND {
-		parser.readToken()
-		prev := node
-		node = Node{Type: "AndExprTailAnd"}
-		node.setLineColumn(*parser)
-		if nd != nil {
-			node.addChild(prev)
-		}
-		node = readAnd_expr_tail2(parser, &node)
-	}
-	switch parser.peekToken() {
-	case token.SEMICOLON, token.RPAREN, token.OR, token.THEN, token.COMMA, token.LOOP:
-		//node.Type = "AndExprTail"
-		return node
-	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node.Type = "OrExprTail"
-			return node
-		}
-		node.Type = "AndExprTailPeriod"
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-	default:
-		if !parser.exprError {
-			unexpectedToken(parser, "and ; ) or then , loop .", parser.peekTokenToString())
-			parser.exprError = true
-		}
-	}
-	return node
-}
-
-func readAnd_expr_tail2(parser *Parser, nd *Node) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.THEN:
-		parser.readToken()
-		prev := *nd
-		node = Node{Type: "AndExprTail2Then"}
-		node.setLineColumn(*parser)
-		node.addChild(prev)
-		node.addChild(readEquality_expr(parser))
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "AndExprTail2"}
-		node.setLineColumn(*parser)
-		prev := *nd
-		node.addChild(prev)
-		node.addChild(readEquality_expr(parser))
-	default:
-		if !parser.exprError {
-			unexpectedToken(parser, "then ident ( not - int char true false null new char", parser.peekTokenToString())
-			parser.exprError = true
-		}
-	}
-	return node
-}
-
-func readEquality_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "EqualityExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readRelational_expr(parser))
-		node.addChild(readEquality_expr_tail(parser))
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readEquality_expr_tail(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.EQL:
-		parser.readToken()
-		node = Node{Type: "EqualityExprTailEql"}
-		node.setLineColumn(*parser)
-		node.addChild(readRelational_expr(parser))
-		node.addChild(readEquality_expr_tail(parser))
-	case token.NEQ:
-		parser.readToken()
-		node = Node{Type: "EqualityExprTailNeq"}
-		node.setLineColumn(*parser)
-		node.addChild(readRelational_expr(parser))
-		node.addChild(readEquality_expr_tail(parser))
-	case token.SEMICOLON, token.RPAREN, token.OR, token.AND, token.THEN, token.NOT, token.COMMA, token.LOOP:
-		node = Node{Type: "EqualityExprTail"}
-		node.setLineColumn(*parser)
-	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		}
-		node = Node{Type: "EqualityExprTailPeriod"}
-		node.setLineColumn(*parser)
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-	default:
-		if !parser.exprError {
-			unexpectedToken(parser, "= /= ; ) or and then not , loop .", parser.peekTokenToString())
-			parser.exprError = true
-		}
-	}
-	return node
-}
-
-func readRelational_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "RelationalExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readAdditive_expr(parser))
-		node.addChild(readRelational_expr_tail(parser))
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readRelational_expr_tail(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.LSS:
-		parser.readToken()
-		node = Node{Type: "RelationalExprTailLss"}
-		node.setLineColumn(*parser)
-		node.addChild(readAdditive_expr(parser))
-		node.addChild(readRelational_expr_tail(parser))
-	case token.LEQ:
-		parser.readToken()
-		node = Node{Type: "RelationalExprTailLeq"}
-		node.setLineColumn(*parser)
-		node.addChild(readAdditive_expr(parser))
-		node.addChild(readRelational_expr_tail(parser))
-	case token.GTR:
-		parser.readToken()
-		node = Node{Type: "RelationalExprTailGtr"}
-		node.setLineColumn(*parser)
-		node.addChild(readAdditive_expr(parser))
-		node.addChild(readRelational_expr_tail(parser))
-	case token.GEQ:
-		parser.readToken()
-		node = Node{Type: "RelationalExprTailGeq"}
-		node.setLineColumn(*parser)
-		node.addChild(readAdditive_expr(parser))
-		node.addChild(readRelational_expr_tail(parser))
-	case token.SEMICOLON, token.RPAREN, token.OR, token.AND, token.THEN, token.NOT, token.EQL, token.NEQ, token.COMMA, token.LOOP:
-		node = Node{Type: "RelationalExprTail"}
-		node.setLineColumn(*parser)
-	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		}
-		node = Node{Type: "RelationalExprTailPeriod"}
-		node.setLineColumn(*parser)
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-	default:
-		if !parser.exprError {
-			unexpectedToken(parser, "< <= > >= ; ) or and then not = /= , loop .", parser.peekTokenToString())
-			parser.exprError = true
-		}
-	}
-	return node
-}
-
-func readAdditive_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "AdditiveExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readMultiplicative_expr(parser))
-		node = readAdditive_expr_tail(parser, &node)
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readAdditive_expr_tail(parser *Parser, nd *Node) Node {
-	node := *nd
-	for parser.peekToken() == token.ADD || parser.peekToken() == token.SUB {
-		switch parser.peekToken() {
-		case token.ADD:
-			parser.readToken()
-			prev := node
-			node = Node{Type: "AdditiveExprTailAdd"}
-			node.setLineColumn(*parser)
-			node.addChild(prev)
-			node.addChild(readMultiplicative_expr(parser))
-		case token.SUB:
-			parser.readToken()
-			prev := node
-			node = Node{Type: "AdditiveExprTailSub"}
-			node.setLineColumn(*parser)
-			node.addChild(prev)
-			node.addChild(readMultiplicative_expr(parser))
-		}
-	}
-	if parser.peekToken() == token.SEMICOLON || parser.peekToken() == token.RPAREN || parser.peekToken() == token.OR || parser.peekToken() == token.AND || parser.peekToken() == token.THEN || parser.peekToken() == token.NOT || parser.peekToken() == token.EQL || parser.peekToken() == token.NEQ || parser.peekToken() == token.LSS || parser.peekToken() == token.LEQ || parser.peekToken() == token.GTR || parser.peekToken() == token.GEQ || parser.peekToken() == token.COMMA || parser.peekToken() == token.LOOP {
-		return node
-	} else if parser.peekToken() == token.PERIOD {
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node.Type = "OrExprTail"
-			return node
-		}
-		node.Type = "AdditiveExprTailPeriod"
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-		return node
-	} else {
-		if !parser.exprError {
-			unexpectedToken(parser, "+ - ; ) or and then not = /= < <= > >= , loop .", parser.peekTokenToString())
-			parser.exprError = true
-		}
-		return node
-	}
-}
-
-func readMultiplicative_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "MultiplicativeExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readUnary_expr(parser))
-		node = readMultiplicative_expr_tail(parser, &node)
-	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
-func readMultiplicative_expr_tail(parser *Parser, nd *Node) Node {
-	node := *nd
-	for parser.peekToken() == token.MUL || parser.peekToken() == token.QUO || parser.peekToken() == token.REM {
-		switch parser.peekToken() {
-		case token.MUL:
-			parser.readToken()
-			prev := node
-			node = Node{Type: "MultiplicativeExprTailMul"}
-			node.setLineColumn(*parser)
-			node.addChild(prev)
-			node.addChild(readUnary_expr(parser))
-		case token.QUO:
-			parser.readToken()
-			prev := node
-			node = Node{Type: "MultiplicativeExprTailQuo"}
-			node.setLineColumn(*parser)
-			node.addChild(prev)
-			node.addChild(readUnary_expr(parser))
-		case token.REM:
-			parser.readToken()
-			prev := node
-			node = Node{Type: "MultiplicativeExprTailRem"}
-			node.setLineColumn(*parser)
-			node.addChild(prev)
-			node.addChild(readUnary_expr(parser))
-		}
-	}
-	if parser.peekToken() == token.SEMICOLON || parser.peekToken() == token.RPAREN || parser.peekToken() == token.OR || parser.peekToken() == token.AND || parser.peekToken() == token.THEN || parser.peekToken() == token.NOT || parser.peekToken() == token.EQL || parser.peekToken() == token.NEQ || parser.peekToken() == token.LSS || parser.peekToken() == token.LEQ || parser.peekToken() == token.GTR || parser.peekToken() == token.GEQ || parser.peekToken() == token.ADD || parser.peekToken() == token.SUB || parser.peekToken() == token.COMMA || parser.peekToken() == token.LOOP {
-		return node
-	} else if parser.peekToken() == token.PERIOD {
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node.Type = "OrExprTail"
-			return node
-		}
-		node.Type = "MultiplicativeExprTailPeriod"
-		parser.readToken()
-		expectTokens(parser, []any{token.PERIOD})
-		parser.readToken()
-		return node
-	} else {
-		if !parser.exprError {
-			unexpectedToken(parser, "* / rem ; ) or and then not = /= < <= > >= + - , loop .", parser.peekTokenToString())
-			parser.exprError = true
-		}
-		return node
-	}
-}
-
-func readUnary_expr(parser *Parser) Node {
-	var node Node
-	switch parser.peekToken() {
-	case token.SUB:
-		parser.readToken()
-		node = Node{Type: "UnaryExprSub"}
-		node.setLineColumn(*parser)
-		node.addChild(readUnary_expr(parser))
-	case token.NOT:
-		parser.readToken()
-		node = Node{Type: "UnaryExprNot"}
-		node.setLineColumn(*parser)
-		node.addChild(readUnary_expr(parser))
-	case token.IDENT, token.LPAREN, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
-		node = Node{Type: "UnaryExpr"}
-		node.setLineColumn(*parser)
-		node.addChild(readPrimary_expr(parser))
-	default:
-		unexpectedToken(parser, "- not ident ( int char true false null new char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
-		parser.exprError = false
-	}
-	return node
-}
-
 func readPrimary_expr(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
@@ -1116,6 +799,10 @@ func readPrimary_expr(parser *Parser) Node {
 		_, index := parser.readFullToken()
 		node = Node{Type: "PrimaryExprChar", Index: index}
 		node.setLineColumn(*parser)
+	case token.STRING:
+		_, index := parser.readFullToken()
+		node = Node{Type: "PrimaryExprString", Index: index}
+		node.setLineColumn(*parser)
 	case token.TRUE:
 		parser.readToken()
 		node = Node{Type: "PrimaryExprTrue"}
@@ -1148,16 +835,9 @@ func readPrimary_expr(parser *Parser) Node {
 		node.setLineColumn(*parser)
 		node.addChild(readIdent(parser))
 		node.addChild(readPrimary_expr2(parser))
-	case token.CHAR_TOK:
-		parser.readToken()
-		node = Node{Type: "PrimaryExprCharTok"}
-		node.setLineColumn(*parser)
-		expectTokens(parser, []any{token.CAST, token.VAL, token.LPAREN})
-		node.addChild(readExpr(parser))
-		expectTokens(parser, []any{token.RPAREN})
 	default:
-		unexpectedToken(parser, "int char true false null ( not new ident char", parser.peekTokenToString())
-		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.COMMA, token.RETURN, token.END, token.BEGIN})
+		unexpectedToken(parser, "int char string true false null ( not new ident", parser.peekTokenToString())
+		parser.advanceExpr([]token.Token{token.SEMICOLON, token.RPAREN, token.COLON, token.ASSIGN, token.COMMA, token.RETURN, token.END, token.BEGIN})
 		parser.exprError = false
 	}
 	return node
@@ -1177,45 +857,63 @@ func readPrimary_expr2(parser *Parser) Node {
 		node = Node{Type: "PrimaryExpr2"}
 		node.setLineColumn(*parser)
 		node.addChild(readAccess2(parser))
+	case token.DOUBLE_DOT:
+		node = Node{Type: "OrExprTail"}
+		node.setLineColumn(*parser)
+		return node
 	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		} else {
-			node = Node{Type: "PrimaryExpr2Period"}
-			node.setLineColumn(*parser)
-			node.addChild(readAccess2(parser))
-		}
+		node = Node{Type: "PrimaryExpr2Period"}
+		node.setLineColumn(*parser)
+		node.addChild(readAccess2(parser))
+	case token.TICK:
+		parser.readToken()
+		node = Node{Type: "PrimaryExpr2Tick"}
+		node.setLineColumn(*parser)
+		node.addChild(readIdent(parser))
+		node.addChild(readAttribute_args(parser))
 	default:
 		if !parser.exprError {
-			unexpectedToken(parser, "( ; ) or and then not = /= < <= > >= + - * / rem , loop .", parser.peekTokenToString())
+			unexpectedToken(parser, "( ; ) or and then not = /= < <= > >= + - * / rem , loop . '", parser.peekTokenToString())
 			parser.exprError = true
 		}
 	}
 	return node
 }
 
+// readAttribute_args reads the arguments of an attribute, Integer'Image(X) has one and Integer'First none
+func readAttribute_args(parser *Parser) Node {
+	var node Node
+	switch parser.peekToken() {
+	case token.LPAREN:
+		parser.readToken()
+		node = Node{Type: "AttributeArgsLparen"}
+		node.setLineColumn(*parser)
+		node.addChild(readExpr_plus_comma(parser))
+		expectTokens(parser, []any{token.RPAREN})
+	case token.SEMICOLON, token.RPAREN, token.OR, token.AND, token.THEN, token.NOT, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.COMMA, token.LOOP, token.DOUBLE_DOT:
+		node = Node{Type: "AttributeArgs"}
+		node.setLineColumn(*parser)
+	default:
+		node = Node{Type: "AttributeArgs"}
+		node.setLineColumn(*parser)
+		unexpectedToken(parser, "( ; ) or and then not = /= < <= > >= + - * / rem , loop ..", parser.peekTokenToString())
+	}
+	return node
+}
+
 func readPrimary_expr3(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
+	case token.DOUBLE_DOT:
+		node = Node{Type: "OrExprTail"}
+		node.setLineColumn(*parser)
+		return node
 	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		}
 		parser.readToken()
-		if parser.peekToken() == token.PERIOD {
-			node = Node{Type: "PrimaryExpr3DoublePeriod"}
-			node.setLineColumn(*parser)
-			parser.readToken()
-		} else {
-			node = Node{Type: "PrimaryExpr3Period"}
-			node.setLineColumn(*parser)
-			node.addChild(readIdent(parser))
-			node.addChild(readAccess2(parser))
-		}
+		node = Node{Type: "PrimaryExpr3Period"}
+		node.setLineColumn(*parser)
+		node.addChild(readIdent(parser))
+		node.addChild(readAccess2(parser))
 	case token.SEMICOLON, token.RPAREN, token.OR, token.AND, token.THEN, token.NOT, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.COMMA, token.LOOP:
 		node = Node{Type: "PrimaryExpr3"}
 		node.setLineColumn(*parser)
@@ -1228,22 +926,16 @@ func readPrimary_expr3(parser *Parser) Node {
 func readAccess2(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
+	case token.DOUBLE_DOT:
+		node = Node{Type: "OrExprTail"}
+		node.setLineColumn(*parser)
+		return node
 	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		}
 		parser.readToken()
-		if parser.peekToken() == token.PERIOD {
-			node = Node{Type: "Access2DoublePeriod"}
-			node.setLineColumn(*parser)
-		} else {
-			node = Node{Type: "Access2Period"}
-			node.setLineColumn(*parser)
-			node.addChild(readIdent(parser))
-			node.addChild(readAccess2(parser))
-		}
+		node = Node{Type: "Access2Period"}
+		node.setLineColumn(*parser)
+		node.addChild(readIdent(parser))
+		node.addChild(readAccess2(parser))
 	case token.SEMICOLON, token.RPAREN, token.OR, token.AND, token.THEN, token.NOT, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.COMMA, token.LOOP:
 		node = Node{Type: "Access2"}
 		node.setLineColumn(*parser)
@@ -1258,14 +950,14 @@ func readAccess2(parser *Parser) Node {
 func readExpr_plus_comma(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
+	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW:
 		node = Node{Type: "ExprPlusComma"}
 		node.setLineColumn(*parser)
 		node.addChild(readExpr(parser))
 		node.addChild(readExpr_plus_comma2(parser))
 	default:
 		// TODO look at this
-		unexpectedToken(parser, "ident ( not - int char true false null new char", parser.peekTokenToString())
+		unexpectedToken(parser, "ident ( not - int char string true false null new", parser.peekTokenToString())
 		parser.advance2(token.RPAREN)
 	}
 	return node
@@ -1294,7 +986,7 @@ func readExpr_plus_comma2(parser *Parser) Node {
 func readExpr_opt(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
+	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW:
 		node = Node{Type: "ExprOpt"}
 		node.setLineColumn(*parser)
 		node.addChild(readExpr(parser))
@@ -1304,7 +996,7 @@ func readExpr_opt(parser *Parser) Node {
 	default:
 		node = Node{Type: "ExprOptSemicolon"}
 		node.setLineColumn(*parser)
-		unexpectedToken(parser, "ident ( not - int char true false null new char ;", parser.peekTokenToString())
+		unexpectedToken(parser, "ident ( not - int char string true false null new ;", parser.peekTokenToString())
 	}
 	return node
 }
@@ -1317,7 +1009,7 @@ func readInstr(parser *Parser) Node {
 		parser.readToken()
 		node = Node{Type: "InstrAccess"}
 		node.setLineColumn(*parser)
-		expectTokens(parser, []any{token.COLON, token.EQL})
+		expectTokens(parser, []any{token.ASSIGN})
 		node.addChild(readExpr(parser))
 		expectTokens(parser, []any{token.SEMICOLON})
 	case token.IDENT:
@@ -1355,7 +1047,7 @@ func readInstr(parser *Parser) Node {
 		expectTokens(parser, []any{token.IN})
 		node.addChild(readReverse_instr(parser))
 		node.addChild(readExpr(parser))
-		expectTokens(parser, []any{token.PERIOD, token.PERIOD})
+		expectTokens(parser, []any{token.DOUBLE_DOT})
 		node.addChild(readExpr(parser))
 		expectTokens(parser, []any{token.LOOP})
 		node.addChild(readInstr_plus(parser))
@@ -1379,8 +1071,8 @@ func readInstr(parser *Parser) Node {
 func readInstr2(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
-	case token.IDENT, token.BEGIN, token.END, token.RETURN, token.ACCESS, token.COLON, token.ELSE, token.PERIOD, token.IF, token.FOR, token.WHILE, token.ELSIF:
-		if parser.peekToken() != token.COLON && parser.peekToken() != token.PERIOD {
+	case token.IDENT, token.BEGIN, token.END, token.RETURN, token.ACCESS, token.ASSIGN, token.COLON, token.ELSE, token.PERIOD, token.IF, token.FOR, token.WHILE, token.ELSIF:
+		if parser.peekToken() != token.ASSIGN && parser.peekToken() != token.COLON && parser.peekToken() != token.PERIOD {
 			parser.readToken()
 		}
 		node = Node{Type: "Instr2Ident"}
@@ -1390,11 +1082,11 @@ func readInstr2(parser *Parser) Node {
 			parser.readToken()
 			customError(parser, "Malformed assignment statement. Did you mean to use := instead of =?")
 		} else {
-			if parser.peekToken() == token.COLON && parser.peekTokenFurther(1) != token.EQL {
+			if parser.peekToken() == token.COLON {
 				parser.readToken()
 				customError(parser, "Malformed assignment statement. Did you mean to use := instead of :?")
 			} else {
-				expectTokens(parser, []any{token.COLON, token.EQL})
+				expectTokens(parser, []any{token.ASSIGN})
 			}
 		}
 		node.addChild(readExpr(parser))
@@ -1424,7 +1116,7 @@ func readInstr2(parser *Parser) Node {
 			expectTokens(parser, []any{token.SEMICOLON})
 			return node
 		}
-		unexpectedToken(parser, "ident begin end return access : else . if for while elsif ; (", parser.peekTokenToString())
+		unexpectedToken(parser, "ident begin end return access := : else . if for while elsif ; (", parser.peekTokenToString())
 	}
 	return node
 }
@@ -1432,18 +1124,14 @@ func readInstr2(parser *Parser) Node {
 func readInstr3(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
-	case token.COLON:
-		//
-		//expectTokens(parser, []any{token.COLON, token.EQL})
+	case token.ASSIGN, token.COLON:
 		node = Node{Type: ":="}
 		node.setLineColumn(*parser)
-		//parser.unreadTokens(2)
+	case token.DOUBLE_DOT:
+		node = Node{Type: "OrExprTail"}
+		node.setLineColumn(*parser)
+		return node
 	case token.PERIOD:
-		if parser.peekTokenFurther(1) == token.PERIOD {
-			node = Node{Type: "OrExprTail"}
-			node.setLineColumn(*parser)
-			return node
-		}
 		parser.readToken()
 		node = Node{Type: "Instr3Period"}
 		node.setLineColumn(*parser)
@@ -1458,8 +1146,8 @@ func readInstr3(parser *Parser) Node {
 			// Deal with error later
 			return node
 		}
-		parser.advance2(token.COLON, token.PERIOD)
-		unexpectedToken(parser, ": .", parser.peekTokenToString())
+		parser.advance2(token.ASSIGN, token.COLON, token.PERIOD)
+		unexpectedToken(parser, ":= .", parser.peekTokenToString())
 	}
 	return node
 }
@@ -1468,8 +1156,8 @@ func readInstr4(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
 	case token.SEMICOLON:
-	case token.COLON:
-		expectTokens(parser, []any{token.COLON, token.EQL})
+	case token.ASSIGN:
+		parser.readToken()
 		node = Node{Type: "Instr4Colon"}
 		node.setLineColumn(*parser)
 		node.addChild(readExpr(parser))
@@ -1478,7 +1166,7 @@ func readInstr4(parser *Parser) Node {
 		if parser.peekToken() == token.END || parser.peekToken() == token.SEMICOLON {
 			return node
 		}
-		unexpectedToken(parser, "; :", parser.peekTokenToString())
+		unexpectedToken(parser, "; :=", parser.peekTokenToString())
 		parser.advance([]token.Token{token.END, token.SEMICOLON, token.IF})
 	}
 	return node
@@ -1589,7 +1277,7 @@ func readElse_instr_opt(parser *Parser) Node {
 func readReverse_instr(parser *Parser) Node {
 	var node Node
 	switch parser.peekToken() {
-	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK:
+	case token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW:
 		node = Node{Type: "ReverseInstr"}
 		node.setLineColumn(*parser)
 	case token.REVERSE:
@@ -1597,8 +1285,8 @@ func readReverse_instr(parser *Parser) Node {
 		node.setLineColumn(*parser)
 		parser.readToken()
 	default:
-		unexpectedToken(parser, "ident ( not - int char true false null new char reverse", parser.peekTokenToString())
-		parser.advance2(token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.TRUE, token.FALSE, token.NULL, token.NEW, token.CHAR_TOK, token.REVERSE)
+		unexpectedToken(parser, "ident ( not - int char string true false null new reverse", parser.peekTokenToString())
+		parser.advance2(token.IDENT, token.LPAREN, token.NOT, token.SUB, token.INT, token.CHAR, token.STRING, token.TRUE, token.FALSE, token.NULL, token.NEW, token.REVERSE)
 	}
 	return node
 }
@@ -1671,3 +1359,39 @@ func readIdent_plus_comma2(parser *Parser) Node {
 	return node
 
 }
+
+// CompileToLLVM translates the tokens to LLVM IR without writing any file
+func CompileToLLVM(lex *lexer.Lexer) (string, error) {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return "", err
+	}
+	return generate(graph, GenerateLLVM)
+}
+
+// CompileToX86 translates the tokens to x86-64 assembly without writing any file
+func CompileToX86(lex *lexer.Lexer) (string, error) {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return "", err
+	}
+	return generate(graph, GenerateX86)
+}
+
+// CompileToWAT translates the tokens to the WebAssembly text format without writing any file
+func CompileToWAT(lex *lexer.Lexer) (string, error) {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return "", err
+	}
+	return generate(graph, GenerateWAT)
+}
+
+// InterpretTokens analyses the tokens and runs the program without generating any code
+func InterpretTokens(lex *lexer.Lexer, output io.Writer) error {
+	graph, err := Analyse(lex)
+	if err != nil {
+		return err
+	}
+	return Interpret(graph, output, 0)
+}
@@ -0,0 +1,358 @@
+package parser
+
+import (
+	"fmt"
+	"gada/token"
+	"strconv"
+	"strings"
+)
+
+// typeKind is the kind of an Ada type seen by the backends translating the Graph
+type typeKind int
+
+const (
+	integerKind typeKind = iota
+	characterKind
+	booleanKind
+	recordKind
+	accessKind
+	stringKind
+)
+
+type adaType struct {
+	kind typeKind
+	// name is the symbol of the type, empty for an anonymous access type
+	name   string
+	fields []adaField
+	// target is the record of an access type
+	target *adaType
+}
+
+type adaField struct {
+	name string
+	typ  *adaType
+}
+
+var (
+	integerType   = &adaType{kind: integerKind, name: "int"}
+	characterType = &adaType{kind: characterKind, name: "char"}
+	booleanType   = &adaType{kind: booleanKind, name: "bool"}
+	// stringType is a constant string with its length, its characters can contain NUL
+	stringType = &adaType{kind: stringKind, name: "gada_string"}
+	// nullType is the type of null, it can be compared with any access type
+	nullType = &adaType{kind: accessKind}
+)
+
+func (t *adaType) field(name string) *adaType {
+	for _, field := range t.fields {
+		if field.name == name {
+			return field.typ
+		}
+	}
+	return nil
+}
+
+type adaVariable struct {
+	name string
+	typ  *adaType
+	// byReference is true for the in out parameters, the frame keeps a pointer
+	byReference bool
+}
+
+// adaSubprogram is an Ada procedure or function, the backends give it a frame with its parameters
+// and variables. A nested subprogram receives a pointer to the frame of the enclosing subprogram:
+// the static link.
+type adaSubprogram struct {
+	node       int
+	name       string
+	symbol     string
+	parent     *adaSubprogram
+	params     []*adaVariable
+	returnType *adaType
+	// locals are the declared variables in declaration order
+	locals      []*adaVariable
+	inits       []adaInit
+	variables   map[string]*adaVariable
+	subprograms map[string][]*adaSubprogram
+	types       map[string]*adaType
+	children    []*adaSubprogram
+	body        int
+}
+
+type adaInit struct {
+	variables []*adaVariable
+	value     int
+}
+
+// hasFrame reports whether the subprogram needs a frame struct
+func (s *adaSubprogram) hasFrame() bool {
+	return s.hasLink() || len(s.params) > 0 || len(s.locals) > 0
+}
+
+// hasLink reports whether the subprogram receives the frame of its parent
+func (s *adaSubprogram) hasLink() bool {
+	return s.parent != nil && s.parent.hasFrame()
+}
+
+// reservedNames are the names that cannot be used as is in the generated programs
+var reservedNames = map[string]struct{}{
+	"auto": {}, "break": {}, "case": {}, "char": {}, "const": {}, "continue": {}, "default": {}, "do": {},
+	"double": {}, "else": {}, "enum": {}, "extern": {}, "float": {}, "for": {}, "goto": {}, "if": {},
+	"inline": {}, "int": {}, "long": {}, "register": {}, "restrict": {}, "return": {}, "short": {},
+	"signed": {}, "sizeof": {}, "static": {}, "struct": {}, "switch": {}, "typedef": {}, "union": {},
+	"unsigned": {}, "void": {}, "volatile": {}, "while": {}, "bool": {}, "true": {}, "false": {},
+	"main": {}, "printf": {}, "putchar": {}, "calloc": {}, "NULL": {}, "fr": {}, "up": {},
+}
+
+// program is the result of the declaration pass shared by the backends translating the Graph
+type program struct {
+	graph Graph
+	// used are the symbols already taken at file scope
+	used        map[string]struct{}
+	types       []*adaType
+	subprograms []*adaSubprogram
+	main        *adaSubprogram
+	err         error
+}
+
+// newProgram reads the declarations of the program, the main procedure is the file node
+func newProgram(graph Graph) (*program, error) {
+	g := &program{graph: graph, used: map[string]struct{}{}}
+	for name := range reservedNames {
+		g.used[name] = struct{}{}
+	}
+
+	file := graph.GetChildren(0)
+	g.main = &adaSubprogram{node: 0, name: graph.GetNode(file[0])}
+	g.main.symbol = g.unique(cIdentifier(g.main.name))
+	g.declareSubprogram(g.main, file[1:])
+	return g, g.err
+}
+
+func (g *program) fail(node int, format string, args ...any) {
+	if g.err == nil {
+		message := fmt.Sprintf(format, args...)
+		g.err = fmt.Errorf("%s:%d:%d: %s", g.graph.fileName, g.graph.line[node], g.graph.column[node], message)
+	}
+}
+
+// cIdentifier folds the case of an Ada identifier and renames it if it is reserved in C
+func cIdentifier(name string) string {
+	name = token.FoldIdentifier(name)
+	if _, ok := reservedNames[name]; ok {
+		return name + "_"
+	}
+	return name
+}
+
+// unique returns a file scope name that is not used yet
+func (g *program) unique(name string) string {
+	result := name
+	for i := 2; ; i++ {
+		if _, ok := g.used[result]; !ok {
+			break
+		}
+		result = name + "_" + strconv.Itoa(i)
+	}
+	g.used[result] = struct{}{}
+	return result
+}
+
+// names returns the declared names of a name or sameType node
+func (g *program) names(node int) []string {
+	if g.graph.GetNode(node) == "sametype" {
+		var names []string
+		for _, child := range g.graph.GetChildren(node) {
+			names = append(names, g.graph.GetNode(child))
+		}
+		return names
+	}
+	return []string{g.graph.GetNode(node)}
+}
+
+func (g *program) lookupType(sub *adaSubprogram, node int) *adaType {
+	name := g.graph.GetNode(node)
+	for s := sub; s != nil; s = s.parent {
+		if t, ok := s.types[name]; ok {
+			return t
+		}
+	}
+	switch name {
+	case "integer":
+		return integerType
+	case "character":
+		return characterType
+	case "boolean":
+		return booleanType
+	case "string":
+		return stringType
+	}
+	g.fail(node, "unknown type %s", name)
+	return integerType
+}
+
+// attribute returns the designator of an attribute node, the node of its prefix and its arguments.
+// The prefix is a type, except for S'Length.
+func (g *program) attribute(node int) (name string, prefix int, args []int) {
+	children := g.graph.GetChildren(node)
+	if len(children) > 2 {
+		args = g.graph.GetChildren(children[2])
+	}
+	return g.graph.GetNode(children[1]), children[0], args
+}
+
+// declareSubprogram reads the parameters and the declarations of a subprogram, children are the
+// children of its node without the name
+func (g *program) declareSubprogram(sub *adaSubprogram, children []int) {
+	sub.variables = map[string]*adaVariable{}
+	sub.subprograms = map[string][]*adaSubprogram{}
+	sub.types = map[string]*adaType{}
+	g.subprograms = append(g.subprograms, sub)
+
+	for i, child := range children {
+		switch g.graph.GetNode(child) {
+		case "params":
+			for _, param := range g.graph.GetChildren(child) {
+				g.declareParam(sub, param)
+			}
+		case "decl":
+			for _, decl := range g.graph.GetChildren(child) {
+				g.declare(sub, decl)
+			}
+		case "body":
+			sub.body = child
+		default:
+			// The return type follows the name or the parameters
+			if g.graph.GetNode(sub.node) == "function" && sub.returnType == nil && (i == 0 || g.graph.GetNode(children[i-1]) == "params") {
+				sub.returnType = g.lookupType(sub, child)
+			}
+		}
+	}
+}
+
+func (g *program) declareParam(sub *adaSubprogram, node int) {
+	children := g.graph.GetChildren(node)
+	typ := g.lookupType(sub, children[len(children)-1])
+	byReference := len(children) == 3 && strings.Contains(g.graph.GetNode(children[1]), "out")
+	for _, name := range g.names(children[0]) {
+		param := &adaVariable{name: cIdentifier(name), typ: typ, byReference: byReference}
+		sub.params = append(sub.params, param)
+		sub.variables[name] = param
+	}
+}
+
+func (g *program) declare(sub *adaSubprogram, node int) {
+	children := g.graph.GetChildren(node)
+	switch g.graph.GetNode(node) {
+	case "var":
+		typ := g.lookupType(sub, children[1])
+		init := adaInit{value: -1}
+		for _, name := range g.names(children[0]) {
+			variable := &adaVariable{name: cIdentifier(name), typ: typ}
+			sub.locals = append(sub.locals, variable)
+			sub.variables[name] = variable
+			init.variables = append(init.variables, variable)
+		}
+		if len(children) > 2 {
+			init.value = children[2]
+			sub.inits = append(sub.inits, init)
+		}
+	case "type":
+		g.declareType(sub, children)
+	case "procedure", "function":
+		child := &adaSubprogram{node: node, name: g.graph.GetNode(children[0]), parent: sub}
+		child.symbol = g.unique(sub.symbol + "_" + strings.TrimSuffix(cIdentifier(child.name), "_"))
+		sub.subprograms[child.name] = append(sub.subprograms[child.name], child)
+		sub.children = append(sub.children, child)
+		g.declareSubprogram(child, children[1:])
+	}
+}
+
+// declareType reads an incomplete type, an access type or a record
+func (g *program) declareType(sub *adaSubprogram, children []int) {
+	name := g.graph.GetNode(children[0])
+	record, declared := sub.types[name]
+	newRecord := func() *adaType {
+		if declared {
+			return record
+		}
+		symbol := cIdentifier(name)
+		if sub.parent != nil {
+			symbol = sub.symbol + "_" + name
+		}
+		record = &adaType{kind: recordKind, name: g.unique(symbol)}
+		sub.types[name] = record
+		g.types = append(g.types, record)
+		return record
+	}
+
+	switch {
+	case g.graph.GetNode(children[1]) == "endtype":
+		newRecord()
+	case g.graph.GetNode(children[1]) == "attribs":
+		record := newRecord()
+		for _, attrib := range g.graph.GetChildren(children[1]) {
+			attribChildren := g.graph.GetChildren(attrib)
+			typ := g.lookupType(sub, attribChildren[1])
+			for _, field := range g.names(attribChildren[0]) {
+				record.fields = append(record.fields, adaField{name: cIdentifier(field), typ: typ})
+			}
+		}
+	default:
+		target := g.lookupType(sub, children[1])
+		symbol := cIdentifier(name)
+		if sub.parent != nil {
+			symbol = sub.symbol + "_" + name
+		}
+		access := &adaType{kind: accessKind, name: g.unique(symbol), target: target}
+		sub.types[name] = access
+		g.types = append(g.types, access)
+	}
+}
+
+// fields returns the field names of the right side of an access node
+func (g *program) fields(node int) []int {
+	if g.graph.GetNode(node) == "access" && len(g.graph.GetChildren(node)) == 2 {
+		children := g.graph.GetChildren(node)
+		return append(g.fields(children[0]), g.fields(children[1])...)
+	}
+	return []int{node}
+}
+
+// resolve finds the subprogram called by the node, overloads are told apart with the semantic analysis
+func (g *program) resolve(from *adaSubprogram, nameNode int, arity int) *adaSubprogram {
+	name := g.graph.GetNode(nameNode)
+	for s := from; s != nil; s = s.parent {
+		candidates := s.subprograms[name]
+		var matching []*adaSubprogram
+		for _, candidate := range candidates {
+			if len(candidate.params) == arity {
+				matching = append(matching, candidate)
+			}
+		}
+		for _, candidate := range matching {
+			if hash, ok := g.graph.symbols[nameNode]; ok && hash == g.graph.symbols[candidate.node] {
+				return candidate
+			}
+		}
+		if len(matching) > 0 {
+			return matching[0]
+		}
+	}
+	return nil
+}
+
+// lookup finds the variable visible from a subprogram, the distance is the number of static links to follow.
+// It returns a nil variable and found set when the name is a subprogram.
+func (g *program) lookup(from *adaSubprogram, name string) (variable *adaVariable, distance int, found bool) {
+	for s := from; s != nil; s = s.parent {
+		if variable, ok := s.variables[name]; ok {
+			return variable, distance, true
+		}
+		if _, ok := s.subprograms[name]; ok {
+			return nil, distance, true
+		}
+		distance++
+	}
+	return nil, 0, false
+}
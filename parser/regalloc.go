@@ -0,0 +1,390 @@
+package parser
+
+import (
+	"fmt"
+	"gada/ast"
+	"strconv"
+)
+
+// Registers R0 to R8 hold the temporaries of an expression.
+// R9, R10 and R11 keep their role (saved frame pointer, current region and frame pointer)
+// and R12 is only used as a scratch register when walking the dynamic links.
+const nbTemporaryRegisters = 9
+
+// registerPool keeps track of the temporary registers used while evaluating an expression.
+type registerPool struct {
+	used [nbTemporaryRegisters]bool
+}
+
+func (p *registerPool) alloc() Register {
+	for i, used := range p.used {
+		if !used {
+			p.used[i] = true
+			return Register(i)
+		}
+	}
+	panic("no free register")
+}
+
+func (p *registerPool) release(register Register) {
+	p.used[register] = false
+}
+
+func (p *registerPool) free() int {
+	count := 0
+	for _, used := range p.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// live returns the registers in use among the given ones.
+func (p *registerPool) live(among ...Register) []Register {
+	var registers []Register
+	for _, register := range among {
+		if p.used[register] {
+			registers = append(registers, register)
+		}
+	}
+	return registers
+}
+
+func (p *registerPool) allLive() []Register {
+	var registers []Register
+	for i, used := range p.used {
+		if used {
+			registers = append(registers, Register(i))
+		}
+	}
+	return registers
+}
+
+// lookupVariable returns the variable named name visible from the scope.
+func lookupVariable(scope *Scope, name string) (Variable, *Scope, bool) {
+	for scope != nil {
+		if symbols, ok := scope.Table[name]; ok {
+			variable, isVariable := symbols[0].(Variable)
+			return variable, scope, isVariable
+		}
+		scope = scope.parent
+	}
+	return Variable{}, nil, false
+}
+
+func isScalarLeaf(graph Graph, expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.IntLit, *ast.BoolLit, *ast.CharLit, *ast.StringLit:
+		return true
+	case *ast.Ident:
+		scope := graph.getScope(e.ID())
+		if scope == nil {
+			return false
+		}
+		variable, _, ok := lookupVariable(scope, getSymbolType(e.Name))
+		return ok && getTypeSize(variable.SType, *scope) == 4
+	}
+	return false
+}
+
+// isRegisterOperand reports whether the expression can be evaluated in registers.
+// Function calls and record accesses inside the expression are still evaluated on the stack.
+func isRegisterOperand(graph Graph, expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		return isRegisterChild(graph, e.X) && isRegisterChild(graph, e.Y)
+	case *ast.AttributeExpr:
+		switch getSymbolType(e.Name.Name) {
+		case "first", "last":
+			return true
+		case "pos", "val", "succ", "pred":
+			return isRegisterChild(graph, e.Args[0])
+		}
+		return false
+	case *ast.UnaryExpr:
+		return isRegisterChild(graph, e.X)
+	}
+	return isScalarLeaf(graph, expr)
+}
+
+// isOpaque reports whether the expression is evaluated on the stack by ReadOperand.
+func isOpaque(graph Graph, expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return operandSize(graph, e) == 4
+	case *ast.CallExpr:
+		function, ok := graph.fullSymbols[e.Fun.ID()].(Function)
+		return ok && getTypeSize(function.ReturnType, *graph.getScope(e.ID())) == 4
+	case *ast.AttributeExpr:
+		// Image and Length call the runtime
+		name := getSymbolType(e.Name.Name)
+		return name == "image" || name == "length"
+	}
+	return false
+}
+
+func isRegisterChild(graph Graph, expr ast.Expr) bool {
+	return isRegisterOperand(graph, expr) || isOpaque(graph, expr)
+}
+
+// registerNeed is the Sethi-Ullman number of the expression: the number of registers needed to evaluate it without spilling.
+func registerNeed(graph Graph, expr ast.Expr) int {
+	if isOpaque(graph, expr) {
+		return 1
+	}
+	switch e := expr.(type) {
+	case *ast.AttributeExpr:
+		if len(e.Args) == 1 {
+			return registerNeed(graph, e.Args[0])
+		}
+	case *ast.UnaryExpr:
+		return registerNeed(graph, e.X)
+	case *ast.BinaryExpr:
+		left := registerNeed(graph, e.X)
+		right := registerNeed(graph, e.Y)
+		if left == right {
+			return left + 1
+		}
+		if left > right {
+			return left
+		}
+		return right
+	}
+	return 1
+}
+
+// ReadOperandToRegister evaluates the expression and puts its value in the register.
+// The expression is evaluated in registers if possible, on the stack otherwise.
+func (a *AssemblyFile) ReadOperandToRegister(graph Graph, expr ast.Expr, dest Register) {
+	if !isRegisterOperand(graph, expr) {
+		a.ReadOperand(graph, expr)
+		a.Ldr(dest, 0)
+		a.Add(SP, 4)
+		return
+	}
+	pool := registerPool{}
+	result := a.readExpr(graph, expr, &pool)
+	if result != dest {
+		a.MovRegister(dest, result)
+	}
+}
+
+// readExpr evaluates the expression in a register of the pool using the Sethi-Ullman order
+// and spills on the stack only when there is not enough registers left.
+func (a *AssemblyFile) readExpr(graph Graph, expr ast.Expr, pool *registerPool) Register {
+	if isOpaque(graph, expr) {
+		// Save the live temporaries since the call can use every register
+		live := pool.allLive()
+		if len(live) > 0 {
+			a.StmfdMultiple(live)
+		}
+		a.ReadOperand(graph, expr)
+		dest := pool.alloc()
+		a.Ldr(dest, 0)
+		a.Add(SP, 4)
+		if len(live) > 0 {
+			a.LdmfdMultiple(live)
+		}
+		return dest
+	}
+
+	var binary *ast.BinaryExpr
+	switch e := expr.(type) {
+	case *ast.AttributeExpr:
+		return a.readAttribute(graph, e, pool)
+	case *ast.UnaryExpr:
+		dest := a.readExpr(graph, e.X, pool)
+		if e.Op == "-" {
+			a.Negate(dest)
+		} else {
+			a.Xor(dest, 1)
+			a.CommentPreviousLine("Not " + dest.String())
+		}
+		return dest
+	case *ast.BinaryExpr:
+		binary = e
+	default:
+		dest := pool.alloc()
+		a.loadLeaf(graph, expr, dest)
+		return dest
+	}
+
+	// Evaluate the operand needing the most registers first
+	first, second := binary.X, binary.Y
+	swapped := registerNeed(graph, binary.Y) > registerNeed(graph, binary.X)
+	if swapped {
+		first, second = second, first
+	}
+	firstRegister := a.readExpr(graph, first, pool)
+	spilled := false
+	if pool.free() < registerNeed(graph, second) {
+		a.Stmfd(firstRegister)
+		a.CommentPreviousLine("Spill " + firstRegister.String())
+		pool.release(firstRegister)
+		spilled = true
+	}
+	secondRegister := a.readExpr(graph, second, pool)
+	if spilled {
+		firstRegister = pool.alloc()
+		a.Ldmfd(firstRegister)
+	}
+	left, right := firstRegister, secondRegister
+	if swapped {
+		left, right = secondRegister, firstRegister
+	}
+
+	switch op := binary.Op; op {
+	case "+":
+		a.OpRegisters("ADD", left, left, right)
+	case "-":
+		a.OpRegisters("SUB", left, left, right)
+	case "and", "and then":
+		a.OpRegisters("AND", left, left, right)
+	case "or", "or else":
+		a.OpRegisters("ORR", left, left, right)
+	case "*", "/", "rem":
+		a.callArithmetic(op, left, right, pool)
+	case ">", "=", "<", "<=", ">=", "/=", "!=":
+		a.CmpRegisters(left, right)
+		switch op {
+		case ">":
+			a.MovCond(left, 1, GT)
+			a.MovCond(left, 0, LE)
+		case "=":
+			a.MovCond(left, 1, EQ)
+			a.MovCond(left, 0, NE)
+		case "<":
+			a.MovCond(left, 1, LT)
+			a.MovCond(left, 0, GE)
+		case "<=":
+			a.MovCond(left, 1, LE)
+			a.MovCond(left, 0, GT)
+		case ">=":
+			a.MovCond(left, 1, GE)
+			a.MovCond(left, 0, LT)
+		case "/=", "!=":
+			a.MovCond(left, 1, NE)
+			a.MovCond(left, 0, EQ)
+		}
+	}
+	pool.release(right)
+	return left
+}
+
+// callArithmetic calls the mul or div32 routine with left in R1 and right in R2, the result is put in left.
+func (a *AssemblyFile) callArithmetic(op string, left, right Register, pool *registerPool) {
+	// R0, R1 and R2 are overwritten by the routines
+	var saved []Register
+	for _, register := range pool.live(R0, R1, R2) {
+		if register != left && register != right {
+			saved = append(saved, register)
+		}
+	}
+	if len(saved) > 0 {
+		a.StmfdMultiple(saved)
+	}
+	switch {
+	case left == R2 && right == R1:
+		a.MovRegister(R12, right)
+		a.MovRegister(R1, left)
+		a.MovRegister(R2, R12)
+	case right == R1:
+		a.MovRegister(R2, right)
+		a.MovRegister(R1, left)
+	default:
+		if left != R1 {
+			a.MovRegister(R1, left)
+		}
+		if right != R2 {
+			a.MovRegister(R2, right)
+		}
+	}
+	switch op {
+	case "*":
+		a.CallProcedure("mul")
+	case "/":
+		a.CallProcedure("div32")
+	case "rem":
+		a.CallProcedure("div32")
+		a.MovRegister(R0, R1)
+	}
+	if left != R0 {
+		a.MovRegister(left, R0)
+	}
+	if len(saved) > 0 {
+		a.LdmfdMultiple(saved)
+	}
+}
+
+// readAttribute evaluates the attributes computed in registers: the bounds are constants,
+// a character and a boolean are their position so Pos and Val keep the value.
+func (a *AssemblyFile) readAttribute(graph Graph, attribute *ast.AttributeExpr, pool *registerPool) Register {
+	switch name := getSymbolType(attribute.Name.Name); name {
+	case "first", "last":
+		first, last := discreteBounds(getSymbolType(attribute.Prefix.Name))
+		dest := pool.alloc()
+		if name == "first" {
+			a.Mov(dest, first)
+		} else {
+			a.Mov(dest, last)
+		}
+		return dest
+	case "succ":
+		dest := a.readExpr(graph, attribute.Args[0], pool)
+		a.Add(dest, 1)
+		return dest
+	case "pred":
+		dest := a.readExpr(graph, attribute.Args[0], pool)
+		a.Sub(dest, 1)
+		return dest
+	}
+	return a.readExpr(graph, attribute.Args[0], pool)
+}
+
+// loadLeaf loads a literal or the value of a variable in the register.
+func (a *AssemblyFile) loadLeaf(graph Graph, expr ast.Expr, dest Register) {
+	switch e := expr.(type) {
+	case *ast.IntLit:
+		a.Mov(dest, e.Value)
+		return
+	case *ast.BoolLit:
+		if e.Value {
+			a.Mov(dest, 1)
+		} else {
+			a.Mov(dest, 0)
+		}
+		return
+	case *ast.CharLit:
+		a.Mov(dest, int(e.Value))
+		return
+	case *ast.StringLit:
+		a.LdrAddr(dest, a.StringConstant(e.Value))
+		return
+	}
+
+	name := accessName(expr)
+	scope := graph.getScope(expr.ID())
+	endScope, offset := goUpScope(graph, scope, expr)
+	if scope == endScope {
+		a.LdrFromFramePointer(dest, offset)
+		a.CommentPreviousLine(fmt.Sprintf("(S) Load the value of %v", name))
+		return
+	}
+
+	// Walk the dynamic links with dest as frame pointer, R11 is left untouched
+	label := labelName(name) + "_" + strconv.Itoa(a.NewLabelID())
+	a.MovRegister(dest, R11)
+	a.LdrFrom(R12, dest, 4)
+	a.Cmp(R12, endScope.Region)
+	a.BranchToLabelWithCondition("notload_"+label, EQ)
+	a.AddLabel("load_" + label)
+	a.LdrFrom(dest, dest, 8)
+	a.LdrFrom(R12, dest, 4)
+	a.Cmp(R12, endScope.Region)
+	a.BranchToLabelWithCondition("load_"+label, NE)
+	a.AddLabel("notload_" + label)
+	a.LdrFrom(dest, dest, 8)
+	a.LdrFrom(dest, dest, offset)
+	a.CommentPreviousLine(fmt.Sprintf("(NS) Load the value of %v", name))
+}
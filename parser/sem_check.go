@@ -2,15 +2,27 @@ package parser
 
 import (
 	"fmt"
-	"golang.org/x/exp/maps"
-	"slices"
+	"gada/ast"
+	"math"
 	"strconv"
 	"strings"
 )
 
-func CheckSemantics(graph Graph) {
-	//dfsSemantics(graph, 0)
-	semCheck(&graph, 0)
+// CheckSemantics logs the semantic errors of the program, it fails when there is at least one
+func CheckSemantics(graph Graph) error {
+	checkFile(&graph, graph.File())
+	if *graph.semanticErrors > 0 {
+		return fmt.Errorf("compilation of %s failed with %d semantic errors", graph.fileName, *graph.semanticErrors)
+	}
+	return nil
+}
+
+// semError logs an error of the semantic analysis at the position of the node
+func semError(graph *Graph, node ast.Node, message string) {
+	line := strconv.Itoa(node.Pos().Line)
+	column := strconv.Itoa(node.Pos().Column)
+	logger.Error(graph.fileName + ":" + line + ":" + column + " " + message)
+	*graph.semanticErrors++
 }
 
 func getTypeSize(t string, scope Scope) int {
@@ -21,10 +33,17 @@ func getTypeSize(t string, scope Scope) int {
 		return 4
 	case "boolean":
 		return 4
+	case "string":
+		// A string is the address of its length followed by its characters
+		return 4
 	default:
 		// Is it a record?
 		for {
-			if symbol, ok := scope.Table[strings.ToLower(t)]; ok {
+			if symbol, ok := scope.Table[getSymbolType(t)]; ok {
+				if symbol[0].Type() == Acc {
+					// An access value is an address
+					return 4
+				}
 				if symbol[0].Type() == Rec {
 					size := 0
 					for _, field := range symbol[0].(Record).Fields {
@@ -43,299 +62,259 @@ func getTypeSize(t string, scope Scope) int {
 			}
 			scope = *scope.parent
 		}
-		return 0
 	}
 }
 
-func findAccessType(graph *Graph, scope *Scope, node int, curType string) string {
-	children := maps.Keys(graph.gmap[node])
-	slices.Sort(children)
+// selectorPath returns the variable of a field access and the fields read from it: a and [b, c] for a.b.c
+func selectorPath(sel *ast.SelectorExpr) (ast.Expr, []*ast.Ident) {
+	var fields []*ast.Ident
+	var x ast.Expr = sel
+	for {
+		sel, ok := x.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		fields = append([]*ast.Ident{sel.Sel}, fields...)
+		x = sel.X
+	}
+	return x, fields
+}
+
+// findAccessType returns the type of the last field of the record of type curType,
+// the fields of an access are the fields of the record it designates
+func findAccessType(graph *Graph, scope *Scope, fields []*ast.Ident, curType string) string {
+	field := fields[0]
 	if symbol, ok := scope.Table[curType]; ok {
+		if access, ok := symbol[0].(Access); ok {
+			return findAccessType(graph, scope, fields, access.Target)
+		}
 		if symbol[0].Type() == Rec {
-			if graph.types[node] == "access" {
-				if _, ok1 := symbol[0].(Record).Fields[getSymbolType(graph.types[children[0]])]; ok1 {
-					newType := symbol[0].(Record).Fields[getSymbolType(graph.types[children[0]])]
-					return findAccessType(graph, scope, children[1], newType)
-				} else {
-					fileName := graph.fileName
-					line := strconv.Itoa(graph.line[node])
-					column := strconv.Itoa(graph.column[node])
-					logger.Error(fileName + ":" + line + ":" + column + " " + graph.types[children[0]] + " is not a field of " + curType)
-				}
-			} else {
-				if _, ok1 := symbol[0].(Record).Fields[getSymbolType(graph.types[node])]; ok1 {
-					newType := symbol[0].(Record).Fields[getSymbolType(graph.types[node])]
-					return newType
-				} else {
-					fileName := graph.fileName
-					line := strconv.Itoa(graph.line[node])
-					column := strconv.Itoa(graph.column[node])
-					logger.Error(fileName + ":" + line + ":" + column + " " + graph.types[node] + " is not a field of " + curType)
+			if newType, ok1 := symbol[0].(Record).Fields[getSymbolType(field.Name)]; ok1 {
+				if len(fields) > 1 {
+					return findAccessType(graph, scope, fields[1:], newType)
 				}
+				return newType
 			}
+			semError(graph, field, field.Name+" is not a field of "+curType)
 		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + curType + " is a " + symbol[0].Type() + " and not a record")
+			semError(graph, field, curType+" is a "+symbol[0].Type()+" and not a record")
 		}
 	} else {
 		if scope.parent == nil {
 			if curType != "unknown" {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + curType + " type is undefined")
+				semError(graph, field, curType+" type is undefined")
 			}
 		} else {
-			return findAccessType(graph, scope.parent, node, curType)
+			return findAccessType(graph, scope.parent, fields, curType)
 		}
 	}
 	return Unknown
 }
 
-func hashFunction(function Function) string {
-	hash := function.FName
-	for i := 1; i <= function.ParamCount; i++ {
-		hash += function.Params[i].VName + function.Params[i].SType + "_"
+// hashFunction identifies a function declared in the scope by the subprograms enclosing it, its name,
+// its parameters and its return type
+func hashFunction(function Function, scope *Scope) string {
+	return scopePath(scope) + function.FName + "(" + hashParams(function.ParamCount, function.Params) + ")" + function.ReturnType
+}
+
+// hashProc identifies a procedure declared in the scope by the subprograms enclosing it, its name and its parameters
+func hashProc(proc Procedure, scope *Scope) string {
+	return scopePath(scope) + proc.PName + "(" + hashParams(proc.ParamCount, proc.Params) + ")"
+}
+
+// hashParams separates the parameters by commas and the name of a parameter from its type by a colon
+func hashParams(count int, params map[int]*Variable) string {
+	var parts []string
+	for i := 1; i <= count; i++ {
+		parts = append(parts, params[i].VName+":"+params[i].SType)
+	}
+	return strings.Join(parts, ",")
+}
+
+// scopePath returns the hashes of the subprograms enclosing the declarations of the scope, each followed by a dot.
+// The declarations of the main procedure are in the file scope which has no path.
+func scopePath(scope *Scope) string {
+	if scope == nil || scope.parent == nil {
+		return ""
 	}
-	hash = hash[:len(hash)-1] + function.ReturnType
-	return hash
+	switch symbol := scope.ScopeSymbol.(type) {
+	case Function:
+		return hashFunction(symbol, scope.parent) + "."
+	case Procedure:
+		return hashProc(symbol, scope.parent) + "."
+	}
+	return ""
 }
 
-func hashProc(proc Procedure) string {
-	hash := proc.PName
-	for i := 1; i <= proc.ParamCount; i++ {
-		hash += proc.Params[i].VName + proc.Params[i].SType + "_"
+// checkParamsOut returns the errors about the arguments given to in out parameters which are not variables,
+// the arguments are looked up from the scope of the call and not from the scope declaring the subprogram
+func checkParamsOut(graph *Graph, name *ast.Ident, params map[int]*Variable, args []ast.Expr) []string {
+	var errors []string
+	for i, arg := range args {
+		if params[i+1].IsParamOut && findStruct(graph, graph.getScope(arg.ID()), arg, false) == nil {
+			// the argument is written as it is in the graph, a function called without arguments is a call
+			errors = append(errors, "Parameter in out "+params[i+1].VName+" should be a variable currently is "+graph.GetRealNode(arg.ID()))
+		}
 	}
-	hash = hash[:len(hash)-1]
-	return hash
+	return errors
 }
 
-func matchFuncReturn(graph *Graph, scope *Scope, node int, args []int, argstype map[int]map[string]struct{}, returnType map[string]struct{}) map[string]struct{} {
+// matchArgs reports whether the types of the arguments are the types of the parameters
+func matchArgs(params map[int]*Variable, argstype map[int]map[string]struct{}) bool {
+	for i := 1; i <= len(argstype); i++ {
+		if !haveType(argstype[i], params[i].SType) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchFuncReturn(graph *Graph, scope *Scope, name *ast.Ident, args []ast.Expr, argstype map[int]map[string]struct{}, returnType map[string]struct{}) map[string]struct{} {
 	// match function with expected return types
 
 	matching := []Function{}
 	returnTypes := make(map[string]struct{})
 
-	name := getSymbolType(graph.types[node])
-	fileName := graph.fileName
-	line := strconv.Itoa(graph.line[node])
-	column := strconv.Itoa(graph.column[node])
+	funcName := getSymbolType(name.Name)
 
-	if symbol, ok := scope.Table[name]; ok {
+	if symbol, ok := scope.Table[funcName]; ok {
 		for _, f := range symbol {
 			if f.Type() == Func {
 				fun := f.(Function)
-				if fun.ParamCount == len(argstype) && haveType(returnType, fun.ReturnType) {
-					buffer := []string{}
-					breaked := false
-					for i := 1; i <= len(argstype); i++ {
-						if !haveType(argstype[i], fun.Params[i].SType) {
-							breaked = true
-							break
-						} else if fun.Params[i].IsParamOut {
-							if whichFinal(graph, args[i-1]) != "identifier" || findStruct(graph, scope, args[i-1], false) == nil {
-								buffer = append(buffer, fileName+":"+line+":"+column+" "+"Parameter in out "+fun.Params[i].VName+" should be a variable currently is "+graph.types[args[i-1]])
-							}
-						}
+				if fun.ParamCount == len(argstype) && haveType(returnType, fun.ReturnType) && matchArgs(fun.Params, argstype) {
+					for _, val := range checkParamsOut(graph, name, fun.Params, args) {
+						semError(graph, name, val)
 					}
-
-					if breaked {
-						continue
-					}
-					for _, val := range buffer {
-						logger.Error(val)
-					}
-					matching = append(matching, f.(Function))
+					matching = append(matching, fun)
 				}
-				continue
 			} else {
-				logger.Error(fileName + ":" + line + ":" + column + " " + name + " is a " + f.Type() + " and not a function")
+				semError(graph, name, funcName+" is a "+f.Type()+" and not a function")
 			}
 		}
 		if len(matching) > 1 {
-			logger.Error(fileName + ":" + line + ":" + column + " " + name + " call is ambiguous")
+			semError(graph, name, funcName+" call is ambiguous")
 			returnTypes[Unknown] = struct{}{}
 			return returnTypes
 		}
 		if len(matching) > 0 {
 			returnTypes[matching[0].ReturnType] = struct{}{}
-			addSymbol(graph, node, hashFunction(matching[0]), matching[0])
+			addSymbol(graph, name.ID(), hashFunction(matching[0], scope), matching[0])
 			return returnTypes
 		}
 
 	}
 	if scope.parent == nil {
-		logger.Error(fileName + ":" + line + ":" + column + " " + name + " function is undefined")
+		semError(graph, name, funcName+" function is undefined")
 		returnTypes[Unknown] = struct{}{}
 		return returnTypes
 	} else {
-		return matchFuncReturn(graph, scope.parent, node, args, argstype, returnType)
+		return matchFuncReturn(graph, scope.parent, name, args, argstype, returnType)
 	}
 }
 
-func matchFunc(graph *Graph, scope *Scope, node int, args []int, argstype map[int]map[string]struct{}) map[string]struct{} {
+func matchFunc(graph *Graph, scope *Scope, name *ast.Ident, args []ast.Expr, argstype map[int]map[string]struct{}) map[string]struct{} {
 
 	matching := []Function{}
 	returnTypes := make(map[string]struct{})
 
-	name := getSymbolType(graph.types[node])
-	fileName := graph.fileName
-	line := strconv.Itoa(graph.line[node])
-	column := strconv.Itoa(graph.column[node])
+	funcName := getSymbolType(name.Name)
 
-	if symbol, ok := scope.Table[name]; ok {
+	if symbol, ok := scope.Table[funcName]; ok {
 		for _, f := range symbol {
 			if f.Type() == Func {
 				fun := f.(Function)
-				if fun.ParamCount == len(argstype) {
-					buffer := []string{}
-					breaked := false
-					for i := 1; i <= len(argstype); i++ {
-						if !haveType(argstype[i], fun.Params[i].SType) {
-							breaked = true
-							break
-						} else if fun.Params[i].IsParamOut {
-							if whichFinal(graph, args[i-1]) != "identifier" || findStruct(graph, scope, args[i-1], false) == nil {
-								buffer = append(buffer, fileName+":"+line+":"+column+" "+"Parameter in out "+fun.Params[i].VName+" should be a variable currently is "+graph.types[args[i-1]])
-							}
-						}
-					}
-
-					if breaked {
-						continue
+				if fun.ParamCount == len(argstype) && matchArgs(fun.Params, argstype) {
+					for _, val := range checkParamsOut(graph, name, fun.Params, args) {
+						semError(graph, name, val)
 					}
-					for _, val := range buffer {
-						logger.Error(val)
-					}
-					matching = append(matching, f.(Function))
-					//return f.(Function).ReturnType
+					matching = append(matching, fun)
 				}
-				continue
 			} else {
-				logger.Error(fileName + ":" + line + ":" + column + " " + name + " is a " + f.Type() + " and not a function")
+				semError(graph, name, funcName+" is a "+f.Type()+" and not a function")
 			}
 		}
 		for _, f := range matching {
 			if _, ok := returnTypes[f.ReturnType]; ok {
-				fmt.Println(name + " call have multiple possibilities")
+				fmt.Println(funcName + " call have multiple possibilities")
 			} else {
 				returnTypes[f.ReturnType] = struct{}{}
 			}
 		}
 		if len(matching) > 0 {
-			addSymbol(graph, node, hashFunction(matching[0]), matching[0])
+			addSymbol(graph, name.ID(), hashFunction(matching[0], scope), matching[0])
 			return returnTypes
 		}
 
 	}
 	if scope.parent == nil {
-		logger.Error(fileName + ":" + line + ":" + column + " " + name + " function is undefined")
+		semError(graph, name, funcName+" function is undefined")
 		returnTypes[Unknown] = struct{}{}
 		return returnTypes
 	} else {
-		return matchFunc(graph, scope.parent, node, args, argstype)
+		return matchFunc(graph, scope.parent, name, args, argstype)
 	}
 }
 
-func matchProc(graph *Graph, scope *Scope, node int, args []int, argstype map[int]map[string]struct{}) string {
+func matchProc(graph *Graph, scope *Scope, name *ast.Ident, args []ast.Expr, argstype map[int]map[string]struct{}) string {
 
-	//fmt.Printf("proc %s, args:%v, node : %d\n", graph.types[node], argstype, node)
 	matching := []Procedure{}
 
-	name := getSymbolType(graph.types[node])
-	fileName := graph.fileName
-	line := strconv.Itoa(graph.line[node])
-	column := strconv.Itoa(graph.column[node])
+	procName := getSymbolType(name.Name)
 
-	if symbol, ok := scope.Table[name]; ok {
+	if symbol, ok := scope.Table[procName]; ok {
 		for _, f := range symbol {
 			if f.Type() == Proc {
-
-				fun := f.(Procedure)
-				if fun.ParamCount == len(argstype) {
-					buffer := []string{}
-					breaked := false
-					for i := 1; i <= len(argstype); i++ {
-						if !haveType(argstype[i], fun.Params[i].SType) {
-							breaked = true
-							break
-						} else if fun.Params[i].IsParamOut {
-							if whichFinal(graph, args[i-1]) != "identifier" || findStruct(graph, scope, args[i-1], false) == nil {
-								buffer = append(buffer, fileName+":"+line+":"+column+" "+"Parameter in out "+fun.Params[i].VName+" should be a variable currently is "+graph.types[args[i-1]])
-							}
-						}
-					}
-					if breaked {
-						continue
-					}
-					for _, val := range buffer {
-
-						logger.Error(val)
+				proc := f.(Procedure)
+				if proc.ParamCount == len(argstype) && matchArgs(proc.Params, argstype) {
+					for _, val := range checkParamsOut(graph, name, proc.Params, args) {
+						semError(graph, name, val)
 					}
-					matching = append(matching, f.(Procedure))
+					matching = append(matching, proc)
 				}
-				continue
 			} else {
-				logger.Error(fileName + ":" + line + ":" + column + " " + name + " is a " + f.Type() + " and not a procedure")
+				semError(graph, name, procName+" is a "+f.Type()+" and not a procedure")
 			}
 		}
 		if len(matching) > 1 {
-			logger.Error(fileName + ":" + line + ":" + column + " " + name + " call is ambiguous")
+			semError(graph, name, procName+" call is ambiguous")
 		} else if len(matching) == 1 {
-			addSymbol(graph, node, hashProc(matching[0]), matching[0])
+			addSymbol(graph, name.ID(), hashProc(matching[0], scope), matching[0])
 			return "found"
 		}
 	}
 	if scope.parent == nil {
-		logger.Error(fileName + ":" + line + ":" + column + " " + name + " procedure is undefined")
+		semError(graph, name, procName+" procedure is undefined")
 		return Unknown
 	} else {
-		return matchProc(graph, scope.parent, node, args, argstype)
+		return matchProc(graph, scope.parent, name, args, argstype)
 	}
 }
 
-func whichFinal(graph *Graph, node int) string {
-	// give the final type of the node
-	val := graph.types[node]
-	if val == "True" || val == "False" {
-		return "boolean"
-	}
-	if val[0] == '\'' {
-		return "character"
-	}
-	_, err := strconv.Atoi(val)
-	if err == nil {
-		return "integer"
-	} else {
-		return "identifier"
+// isName reports whether the expression names a variable or a field of a variable
+func isName(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		return true
 	}
+	return false
 }
 
-func getSymbol(graph *Graph, scope *Scope, node int) string {
+func getSymbol(graph *Graph, scope *Scope, name ast.Expr) string {
 	// give the symbol type of the identifier
-	name := getSymbolType(graph.types[node])
-
-	if name == "access" {
-		children := maps.Keys(graph.gmap[node])
-		if len(children) != 0 {
-			//mainType := findIdentifierType(graph, scope, children[0])
-			//finalType := findAccessType(graph, scope, children[1], mainType)
-			return "access"
-		}
-
+	if _, ok := name.(*ast.SelectorExpr); ok {
+		return "access"
 	}
-	if symbol, ok := scope.Table[name]; ok {
+	ident, ok := name.(*ast.Ident)
+	if !ok {
+		return Unknown
+	}
+	if symbol, ok := scope.Table[getSymbolType(ident.Name)]; ok {
 		return symbol[0].Type()
 	} else {
 		if scope.parent == nil {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + " ident " + name + " is undefined")
+			semError(graph, ident, " ident "+getSymbolType(ident.Name)+" is undefined")
 		} else {
-			return getSymbol(graph, scope.parent, node)
+			return getSymbol(graph, scope.parent, name)
 		}
 	}
 	return Unknown
@@ -349,143 +328,105 @@ func haveType(types map[string]struct{}, wantedtype string) bool {
 	}
 }
 
-//func getExpectedTypes(graph *Graph, scope *Scope, node int, ind int) map[string]struct{} {
-//	// give the expected types of the node
-//	switch graph.types[graph.fathers[node]] {
-//	case ":=":
-//		return getExpectedTypes(graph, scope, graph.fathers[node], -1)
-//	case "args":
-//		childs := maps.Keys(graph.gmap[graph.fathers[node]])
-//		slices.Sort(childs)
-//		for argsInd, child := range childs {
-//			if child == node {
-//				return getExpectedTypes(graph, scope, graph.fathers[node], argsInd)
-//			}
-//		}
-//	case "call":
-//		childs := maps.Keys(graph.gmap[graph.fathers[node]])
-//		slices.Sort(childs)
-//		return matchFunc(graph, scope, graph.types[childs[0]], maps.Keys(graph.gmap[childs[1]]))
-//	}
-//
-//	returnTypes := make(map[string]struct{})
-//	childs := maps.Keys(graph.gmap[node])
-//	slices.Sort(childs)
-//	switch graph.types[node] {
-//	case ":=":
-//		return getReturnType(graph, scope, childs[0])
-//	case "args":
-//
-//	}
-//	returnTypes[Unknown] = struct{}{}
-//	return returnTypes
-//}
-
-func getReturnType(graph *Graph, scope *Scope, node int, expectedReturn map[string]struct{}) map[string]struct{} {
-	// give the return type of the node
+func getReturnType(graph *Graph, scope *Scope, expr ast.Expr, expectedReturn map[string]struct{}) map[string]struct{} {
+	// give the return type of the expression
 	returnTypes := make(map[string]struct{})
-	children := maps.Keys(graph.gmap[node])
-	slices.Sort(children)
-	if len(children) == 0 {
-
-		theType := whichFinal(graph, node)
-		if theType == "identifier" {
-			//fmt.Printf("node: %s %s\n", graph.types[node], findIdentifierType(graph, scope, node))
-			return findIdentifierType(graph, scope, node)
+	switch e := expr.(type) {
+	case *ast.IntLit:
+		// The lexer accepts 2**31 for Integer'First, it must be negated
+		if e.Value > math.MaxInt32 {
+			semError(graph, e, strconv.Itoa(e.Value)+" does not fit in 32 bits")
 		}
-		returnTypes[theType] = struct{}{}
+		returnTypes["integer"] = struct{}{}
 		return returnTypes
-	}
-	switch graph.types[node] {
-	case "+", "-", "*", "/", "rem":
-		if haveType(getReturnType(graph, scope, children[0], expectedReturn), "integer") && haveType(getReturnType(graph, scope, children[1], expectedReturn), "integer") {
-			returnTypes["integer"] = struct{}{}
-			return returnTypes
-		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " Operator " + graph.types[node] + " should have integer operands")
-		}
-	case "and", "or", "and then", "or else":
-		if haveType(getReturnType(graph, scope, children[0], expectedReturn), "boolean") && haveType(getReturnType(graph, scope, children[1], expectedReturn), "boolean") {
-			returnTypes["boolean"] = struct{}{}
-			return returnTypes
-		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " Operator " + graph.types[node] + " should have boolean operands")
-		}
-	case "not":
-		if haveType(getReturnType(graph, scope, children[0], expectedReturn), "boolean") {
-			returnTypes["boolean"] = struct{}{}
-			return returnTypes
-		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " Operator not should have boolean operands")
-		}
-	case ">", "<", ">=", "<=", "=", "!=":
-		for rType, _ := range getReturnType(graph, scope, children[0], expectedReturn) {
-			if haveType(getReturnType(graph, scope, children[1], expectedReturn), rType) {
+	case *ast.CharLit:
+		returnTypes["character"] = struct{}{}
+		return returnTypes
+	case *ast.BoolLit:
+		returnTypes["boolean"] = struct{}{}
+		return returnTypes
+	case *ast.StringLit:
+		returnTypes["string"] = struct{}{}
+		return returnTypes
+	case *ast.Ident:
+		return findIdentifierType(graph, scope, e)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case "+", "-", "*", "/", "rem":
+			if haveType(getReturnType(graph, scope, e.X, expectedReturn), "integer") && haveType(getReturnType(graph, scope, e.Y, expectedReturn), "integer") {
+				returnTypes["integer"] = struct{}{}
+				return returnTypes
+			}
+			semError(graph, e, "Operator "+e.Op+" should have integer operands")
+		case "and", "or", "and then", "or else":
+			if haveType(getReturnType(graph, scope, e.X, expectedReturn), "boolean") && haveType(getReturnType(graph, scope, e.Y, expectedReturn), "boolean") {
 				returnTypes["boolean"] = struct{}{}
 				return returnTypes
 			}
+			semError(graph, e, "Operator "+e.Op+" should have boolean operands")
+		default:
+			// comparison, both operands need a common type which is not the boolean expected from the comparison
+			rightTypes := getReturnType(graph, scope, e.Y, make(map[string]struct{}))
+			for rType := range getReturnType(graph, scope, e.X, make(map[string]struct{})) {
+				if rType == "string" && haveType(rightTypes, rType) {
+					semError(graph, e, "Operator "+e.Op+" is not supported for strings")
+					returnTypes["boolean"] = struct{}{}
+					return returnTypes
+				}
+				if haveType(rightTypes, rType) {
+					returnTypes["boolean"] = struct{}{}
+					return returnTypes
+				}
+			}
+			semError(graph, e, "Operator "+e.Op+" should have integer operands")
 		}
-
-		fileName := graph.fileName
-		line := strconv.Itoa(graph.line[node])
-		column := strconv.Itoa(graph.column[node])
-		logger.Error(fileName + ":" + line + ":" + column + " Operator " + graph.types[node] + " should have integer operands")
-	case "call":
-		if graph.types[children[0]] == "-" {
-			if haveType(getReturnType(graph, scope, children[1], expectedReturn), "integer") {
+	case *ast.UnaryExpr:
+		if literal, ok := e.X.(*ast.IntLit); ok && e.Op == "-" && literal.Value <= -math.MinInt32 {
+			returnTypes["integer"] = struct{}{}
+			return returnTypes
+		}
+		if e.Op == "-" {
+			if haveType(getReturnType(graph, scope, e.X, expectedReturn), "integer") {
 				returnTypes["integer"] = struct{}{}
 				return returnTypes
-			} else {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " Operator - should have integer operands")
 			}
-		} else if graph.types[children[0]] == "not" {
-			if haveType(getReturnType(graph, scope, children[1], expectedReturn), "boolean") {
+			semError(graph, e, "Operator - should have integer operands")
+		} else {
+			if haveType(getReturnType(graph, scope, e.X, expectedReturn), "boolean") {
 				returnTypes["boolean"] = struct{}{}
 				return returnTypes
-			} else {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " Operator not should have boolean operands")
-			}
-		} else {
-			if len(expectedReturn) == 0 {
-				return matchFunc(graph, scope, children[0], maps.Keys(graph.gmap[children[1]]), genArgsMap(graph, scope, maps.Keys(graph.gmap[children[1]])))
-			} else {
-				return matchFuncReturn(graph, scope, children[0], maps.Keys(graph.gmap[children[1]]), genArgsMap(graph, scope, maps.Keys(graph.gmap[children[1]])), expectedReturn)
 			}
+			semError(graph, e, "Operator not should have boolean operands")
+		}
+	case *ast.CallExpr:
+		if len(expectedReturn) == 0 {
+			return matchFunc(graph, scope, e.Fun, e.Args, genArgsMap(graph, scope, e.Args))
 		}
-	case "access":
-		mainTypes := findIdentifierType(graph, scope, children[0])
+		return matchFuncReturn(graph, scope, e.Fun, e.Args, genArgsMap(graph, scope, e.Args), expectedReturn)
+	case *ast.SelectorExpr:
+		root, fields := selectorPath(e)
 		var mainType string
-		for k := range mainTypes {
-			mainType = k
-			break // Exit the loop after extracting the key
+		if ident, ok := root.(*ast.Ident); ok {
+			for k := range findIdentifierType(graph, scope, ident) {
+				mainType = k
+				break // Exit the loop after extracting the key
+			}
 		}
-		finalType := findAccessType(graph, scope, children[1], mainType)
+		finalType := findAccessType(graph, scope, fields, mainType)
 		returnTypes[finalType] = struct{}{}
 		return returnTypes
-	case "cast":
-		if haveType(getReturnType(graph, scope, children[1], expectedReturn), "integer") {
-			returnTypes["character"] = struct{}{}
-			return returnTypes
+	case *ast.AttributeExpr:
+		return checkAttribute(graph, scope, e)
+	case *ast.NullLit:
+		return accessTypes(scope, "")
+	case *ast.NewExpr:
+		name := getSymbolType(e.Type.Name)
+		if _, err := findType(scope, name); err != nil {
+			semError(graph, e, err.Error())
+		} else if types := accessTypes(scope, name); len(types) > 0 {
+			return types
 		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " Operator cast should have integer operands")
+			semError(graph, e, "no access type designates "+name)
 		}
 	}
 
@@ -493,19 +434,31 @@ func getReturnType(graph *Graph, scope *Scope, node int, expectedReturn map[stri
 	return returnTypes
 }
 
-func findIdentifierType(graph *Graph, scope *Scope, node int) map[string]struct{} {
+// accessTypes returns the access types visible from the scope which designate the target, or all of them
+// when the target is empty: null and an allocator belong to each of them
+func accessTypes(scope *Scope, target string) map[string]struct{} {
+	types := make(map[string]struct{})
+	for ; scope != nil; scope = scope.parent {
+		for _, symbols := range scope.Table {
+			if access, ok := symbols[0].(Access); ok && (target == "" || access.Target == target) {
+				types[access.AName] = struct{}{}
+			}
+		}
+	}
+	return types
+}
+
+func findIdentifierType(graph *Graph, scope *Scope, ident *ast.Ident) map[string]struct{} {
 	// give the return type of the identifier
-	name := getSymbolType(graph.types[node])
+	name := getSymbolType(ident.Name)
 	returnTypes := make(map[string]struct{})
-	//fmt.Printf("scope: %v\n", scope.Table)
 	if symbol, ok := scope.Table[name]; ok {
-		if symbol[0].Type() == "integer" || symbol[0].Type() == "character" || symbol[0].Type() == "boolean" {
+		if symbol[0].Type() == "integer" || symbol[0].Type() == "character" || symbol[0].Type() == "boolean" || symbol[0].Type() == "string" {
 			returnTypes[symbol[0].Type()] = struct{}{}
 			return returnTypes
 		} else {
 			if symbol[0].Type() == Func { //it means it's a function without arguments
-				newNode := makeChild2(graph, node, "call", symbol[0].Name())
-				return matchFunc(graph, scope, newNode, []int{}, make(map[int]map[string]struct{}))
+				return matchFunc(graph, scope, makeCall(graph, ident, ident.Name), []ast.Expr{}, make(map[int]map[string]struct{}))
 			} else {
 				returnTypes[symbol[0].Type()] = struct{}{}
 				return returnTypes
@@ -513,57 +466,106 @@ func findIdentifierType(graph *Graph, scope *Scope, node int) map[string]struct{
 		}
 	} else {
 		if scope.parent == nil {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " ident " + name + " is undefined")
+			semError(graph, ident, "ident "+name+" is undefined")
 		} else {
-			return findIdentifierType(graph, scope.parent, node)
+			return findIdentifierType(graph, scope.parent, ident)
 		}
 	}
 	returnTypes[Unknown] = struct{}{}
 	return returnTypes
 }
 
-func findStruct(graph *Graph, scope *Scope, node int, log bool) *Variable {
-	name := getSymbolType(graph.types[node])
-	if name == "access" {
-		children := maps.Keys(graph.gmap[node])
-		slices.Sort(children)
-		return findStruct(graph, scope, children[0], log)
+// makeCall turns the identifier of a subprogram called without arguments into a call in the graph and in
+// the expressions of the typed tree, it returns the new identifier naming the subprogram. The call keeps
+// the node of the identifier, which is turned into a call only once.
+func makeCall(graph *Graph, ident *ast.Ident, name string) *ast.Ident {
+	if graph.GetNode(ident.ID()) == "call" {
+		return &ast.Ident{Meta: ast.Meta{NodeID: graph.GetChildren(ident.ID())[0], Position: ident.Position, Extent: ident.Extent}, Name: name}
+	}
+	newNode := makeChild2(graph, ident.ID(), "call", name)
+	graph.line[newNode], graph.column[newNode] = graph.line[ident.ID()], graph.column[ident.ID()]
+	fun := &ast.Ident{Meta: ast.Meta{NodeID: newNode, Position: ident.Position, Extent: ident.Extent}, Name: name}
+	ast.Replace(graph.file, ident, &ast.CallExpr{Meta: ident.Meta, Fun: fun})
+	return fun
+}
+
+// findStruct returns the variable of the name, or of the record when it is a field access
+func findStruct(graph *Graph, scope *Scope, expr ast.Expr, log bool) *Variable {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		root, _ := selectorPath(sel)
+		return findStruct(graph, scope, root, log)
 	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		// the result of a function is a constant
+		if log {
+			semError(graph, expr, "Left side of assignment is not a variable")
+		}
+		return nil
+	}
+	name := getSymbolType(ident.Name)
 	if symbol, ok := scope.Table[name]; ok {
 		if variable, ok := symbol[0].(Variable); ok {
 			return &variable
 		} else {
 			if log {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " left side of assignment " + name + " is not a variable")
+				semError(graph, ident, "left side of assignment "+name+" is not a variable")
 			}
 		}
 	} else {
 		if scope.parent == nil {
 			if log {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " left side of assignment " + name + " is undefined")
+				semError(graph, ident, "left side of assignment "+name+" is undefined")
 			}
 		} else {
-			return findStruct(graph, scope.parent, node, log)
+			return findStruct(graph, scope.parent, expr, log)
 		}
 	}
 	return nil
 }
 
+// throughAccess reports whether the name is a field of an object designated by an access value,
+// such a field can be assigned even when the access is an in parameter
+func throughAccess(graph *Graph, scope *Scope, expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	root, fields := selectorPath(sel)
+	ident, ok := root.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for rootType := range findIdentifierType(graph, scope, ident) {
+		if isAccess(scope, rootType) {
+			return true
+		}
+		for _, field := range fields[:len(fields)-1] {
+			rootType = findAccessType(graph, scope, []*ast.Ident{field}, rootType)
+			if isAccess(scope, rootType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAccess reports whether the type visible from the scope is an access type
+func isAccess(scope *Scope, name string) bool {
+	for ; scope != nil; scope = scope.parent {
+		if symbol, ok := scope.Table[name]; ok {
+			return symbol[0].Type() == Acc
+		}
+	}
+	return false
+}
+
 func findType(scope *Scope, name string) (string, error) {
-	if name == "integer" || name == "character" || name == "boolean" {
+	if name == "integer" || name == "character" || name == "boolean" || name == "string" {
 		return name, nil
 	}
 	if symbol, ok := scope.Table[name]; ok {
-		if symbol[0].Type() == Rec {
+		if symbol[0].Type() == Rec || symbol[0].Type() == Acc {
 			return symbol[0].Name(), nil
 		} else {
 			return "", fmt.Errorf(name + " is a " + symbol[0].Type() + " and not a type")
@@ -612,63 +614,55 @@ func getDeclOffset(graph Graph, node int) int {
 }
 
 // goUpScope: get the scope containing the variable and the total offset to reach it
-func goUpScope(graph Graph, scope *Scope, node int, name string) (*Scope, int) {
-	name = strings.ToLower(name)
-	if name == "access" {
-		children := graph.GetChildren(node)
-		endScope, offset := goUpScope(graph, scope, children[0], graph.GetNode(children[0]))
-		// offset is the offset of the record itself, we need to add the offset of the field
-
-		var upperRec Record
-		for _, s := range endScope.Table {
-			for _, symbol := range s {
-				if symbol.Type() == Rec && symbol.Name() == endScope.Table[graph.GetNode(children[0])][0].Type() {
-					upperRec = symbol.(Record)
-				}
-			}
-		}
+func goUpScope(graph Graph, scope *Scope, expr ast.Expr) (*Scope, int) {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return goUpRecord(graph, scope, e)
+	case *ast.Ident:
+		return goUpVariable(scope, e.ID(), getSymbolType(e.Name))
+	}
+	return goUpVariable(scope, expr.ID(), graph.GetNode(expr.ID()))
+}
 
-		// the right child might be another record field (or not)
-		rightChild := children[1]
-		realOffset := 0
-		for graph.GetNode(rightChild) == "access" {
-			chdr := graph.GetChildren(rightChild)
-			recName := graph.GetNode(chdr[0])
-			recName = upperRec.Fields[recName]
-			var rec Record
-			for _, s := range endScope.Table {
-				for _, symbol := range s {
-					if symbol.Type() == Rec && symbol.Name() == recName {
-						rec = symbol.(Record)
-					}
-				}
-			}
-			realOffset += rec.FieldsOffset[graph.GetNode(chdr[1])]
-			rightChild = chdr[1]
-			upperRec = rec
-		}
-		// add the offset of the last field
-		for _, s := range endScope.Table {
-			for _, symbol := range s {
-				if symbol.Type() == Rec && symbol.Name() == endScope.Table[strings.ToLower(graph.GetNode(children[0]))][0].Type() {
-					upperRec = symbol.(Record)
-				}
-			}
-		}
-		if _, ok := endScope.Table[graph.GetNode(children[0])]; ok {
-			recName := endScope.Table[graph.GetNode(children[0])][0].Type()
-			//fmt.Println(recName, "parent:", endScope.parent)
-			es := getMeScope(recName, *endScope)
-			//fmt.Println(es.Table)
-			upperRec = es.Table[recName][0].(Record)
-		}
-		//fmt.Println(endScope.Table[graph.GetNode(children[0])][0].Type(), rec, graph.GetNode(children[1]))
-		realOffset += upperRec.FieldsOffset[getSymbolType(graph.GetNode(children[1]))]
-		fmt.Println("realOffset", offset, realOffset, offset-realOffset+8)
-		// offset should be -4 not  4
-		return endScope, offset - realOffset + 8
+// goUpRecord: get the scope containing the record and the offset of the field
+func goUpRecord(graph Graph, scope *Scope, sel *ast.SelectorExpr) (*Scope, int) {
+	root, _ := selectorPath(sel)
+	endScope, offset := goUpScope(graph, scope, root)
+	// offset is the offset of the record itself, we need to add the offset of the field
+	fieldOffset, _ := fieldLayout(scope, sel)
+	return endScope, offset + fieldOffset
+}
+
+// fieldLayout returns the offset of the field from the start of the outermost record and the type of the field,
+// the fields might be records themselves
+func fieldLayout(scope *Scope, sel *ast.SelectorExpr) (int, string) {
+	root, fields := selectorPath(sel)
+	ident, ok := root.(*ast.Ident)
+	if !ok {
+		return 0, ""
+	}
+	variable, _, _ := lookupVariable(scope, getSymbolType(ident.Name))
+	return recordLayout(scope, variable.SType, fields)
+}
+
+// recordLayout returns the offset of the last of the nested fields from the start of a record of the type
+// and the type of this field
+func recordLayout(scope *Scope, recordType string, fields []*ast.Ident) (int, string) {
+	offset, fieldType := 0, recordType
+	for _, field := range fields {
+		recScope := getMeScope(fieldType, *scope)
+		if recScope == nil {
+			return offset, ""
+		}
+		rec, _ := recScope.Table[fieldType][0].(Record)
+		offset += rec.FieldsOffset[getSymbolType(field.Name)]
+		fieldType = rec.Fields[getSymbolType(field.Name)]
 	}
+	return offset, fieldType
+}
 
+// goUpVariable: get the scope containing the variable name and the offset of the variable in its frame
+func goUpVariable(scope *Scope, node int, name string) (*Scope, int) {
 	if symbol, ok := scope.Table[name]; ok {
 		for _, s := range symbol {
 			if variable, ok := s.(Variable); ok {
@@ -722,11 +716,7 @@ func goUpScope(graph Graph, scope *Scope, node int, name string) (*Scope, int) {
 		logger.Warn(name + " variable is undefined the fuck")
 		logger.Info(node)
 	} else {
-		parentScope, offset := goUpScope(graph, scope.parent, node, name)
-		/*if _, ok := scope.ScopeSymbol.(Procedure); ok {
-			offset += 8
-		}*/
-		return parentScope, offset
+		return goUpVariable(scope.parent, node, name)
 	}
 	return nil, 0
 }
@@ -759,15 +749,15 @@ func compareFunc(f1 Function, f2 Function) bool {
 	return false
 }
 
-func findAccessName(graph *Graph, node int, buffer string) string {
-	if graph.types[node] == "access" {
-		children := maps.Keys(graph.gmap[node])
-		slices.Sort(children)
-		buffer = buffer + getSymbolType(graph.types[children[0]]) + "."
-		return findAccessName(graph, children[1], buffer)
-	} else {
-		return buffer + getSymbolType(graph.types[node])
+// findAccessName returns the name of the variable or of the field (a.b.c) as it is written in the errors
+func findAccessName(graph *Graph, expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return findAccessName(graph, e.X) + "." + getSymbolType(e.Sel.Name)
+	case *ast.Ident:
+		return getSymbolType(e.Name)
 	}
+	return graph.GetNode(expr.ID())
 }
 
 func compareProc(f1 Procedure, f2 Procedure) bool {
@@ -782,17 +772,13 @@ func compareProc(f1 Procedure, f2 Procedure) bool {
 	return false
 }
 
-func checkParam(graph *Graph, node int, funcScope *Scope) {
-	children := maps.Keys(graph.gmap[node])
-	slices.Sort(children)
-	paramType := getSymbolType(graph.types[children[len(children)-1]])
-	_, err := findType(funcScope, paramType)
+func checkParam(graph *Graph, param *ast.Param, funcScope *Scope) {
+	if param.Type == nil {
+		return
+	}
+	_, err := findType(funcScope, getSymbolType(param.Type.Name))
 	if err != nil {
-		fileName := graph.fileName
-		line := strconv.Itoa(graph.line[node])
-		column := strconv.Itoa(graph.column[node])
-		errorMessage := fileName + ":" + line + ":" + column + " " + err.Error()
-		logger.Error(errorMessage)
+		semError(graph, param, err.Error())
 	}
 }
 
@@ -804,24 +790,35 @@ func findMotherFunc(scope *Scope) Symbol {
 	}
 }
 
-func isHardReturn(graph *Graph, node int) bool {
-	hasHardReturn := false
-	if graph.types[node] == "return" {
-		return true
-	} else {
-		children := maps.Keys(graph.gmap[node])
-		slices.Sort(children)
-		for ind, child := range children {
-			if isHardReturn(graph, child) {
-				hasHardReturn = true
-			} else {
-				if ind != 0 && (graph.types[node] == "if" || graph.types[node] == "elif") {
-					return false
-				}
+// isHardReturn reports whether the statements end with a return on every path,
+// a loop counts as a return when its body has one
+func isHardReturn(stmts []ast.Stmt) bool {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.ReturnStmt:
+			return true
+		case *ast.IfStmt:
+			hard := isHardReturn(s.Body)
+			for _, elif := range s.Elifs {
+				hard = hard && isHardReturn(elif.Body)
+			}
+			if s.Else != nil {
+				hard = hard && isHardReturn(s.Else)
+			}
+			if hard {
+				return true
+			}
+		case *ast.ForStmt:
+			if isHardReturn(s.Body) {
+				return true
+			}
+		case *ast.WhileStmt:
+			if isHardReturn(s.Body) {
+				return true
 			}
 		}
 	}
-	return hasHardReturn
+	return false
 }
 
 func updateReturn(graph *Graph, node int) {
@@ -833,405 +830,274 @@ func updateReturn(graph *Graph, node int) {
 	}
 }
 
-func genArgsMap(graph *Graph, scope *Scope, args []int) map[int]map[string]struct{} {
+func genArgsMap(graph *Graph, scope *Scope, args []ast.Expr) map[int]map[string]struct{} {
 	argstype := make(map[int]map[string]struct{})
-	slices.Sort(args)
-	for ind, val := range args {
-		argstype[ind+1] = getReturnType(graph, scope, val, make(map[string]struct{}))
+	for ind, arg := range args {
+		argstype[ind+1] = getReturnType(graph, scope, arg, make(map[string]struct{}))
 	}
 	return argstype
 }
 
-func semCheck(graph *Graph, node int) {
-	sorted := maps.Keys(graph.gmap[node])
-	slices.Sort(sorted)
-	scope := graph.scopes[node]
-	trashScope := newScope(nil)
-	switch graph.types[node] {
-	case "file":
-		shift := 0
-		if getSymbolType(graph.types[sorted[0]]) != getSymbolType(graph.types[sorted[len(sorted)-1]]) {
-			if getSymbolType(graph.types[sorted[len(sorted)-1]]) != "end" {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[sorted[len(sorted)-1]])
-				column := strconv.Itoa(graph.column[sorted[len(sorted)-1]])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Procedure " + graph.types[sorted[0]] + " end name do not match")
-			}
-		}
-		if graph.types[sorted[1]] == "decl" {
-			children := maps.Keys(graph.gmap[sorted[1]])
-			for _, child := range children {
-				semCheck(graph, child)
-			}
-			shift++
-		}
-		semCheck(graph, sorted[1+shift])
-
-	case "function":
-		funcParam := make(map[int]*Variable)
-		funcElem := Function{FName: getSymbolType(graph.types[sorted[0]]), SType: Func, children: sorted, Params: funcParam}
-		shift := 0
-		if getSymbolType(graph.types[sorted[0]]) != getSymbolType(graph.types[sorted[len(sorted)-1]]) {
-			if getSymbolType(graph.types[sorted[len(sorted)-1]]) != "end" {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " Function " + graph.types[sorted[0]] + " end name do not match")
-			}
-		}
-		if graph.types[sorted[1]] == "params" {
-			child := maps.Keys(graph.gmap[sorted[1]])
-			slices.Sort(child)
-			for _, param := range child {
-				trashScope.parent = scope
-				trashScope.Table = scope.Table
-				addParam(graph, param, &funcElem, trashScope)
-				checkParam(graph, param, scope)
-			}
-			shift = 1
-		}
-		funcElem.ReturnType = getSymbolType(graph.types[sorted[1+shift]])
+func checkFile(graph *Graph, file *ast.File) {
+	graph.visit(file.ID())
+	if file.EndName != nil && getSymbolType(file.Name.Name) != getSymbolType(file.EndName.Name) {
+		semError(graph, file.EndName, "Procedure "+file.Name.Name+" end name do not match")
+	}
+	for _, decl := range file.Decls {
+		checkDecl(graph, decl)
+	}
+	checkStmts(graph, file.Body)
+}
 
-		_, err := findType(scope, funcElem.ReturnType)
-		if err != nil {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			errorMessage := fileName + ":" + line + ":" + column + " " + err.Error()
-			logger.Error(errorMessage)
-		}
-		addSymbol(graph, node, hashFunction(funcElem), funcElem)
-
-		countSame := 0
-		for _, fun := range scope.Table[funcElem.FName] {
-			if fun.Type() == Func {
-				if compareFunc(fun.(Function), funcElem) {
-					countSame++
-					if countSame > 1 {
-						fileName := graph.fileName
-						line := strconv.Itoa(graph.line[node])
-						column := strconv.Itoa(graph.column[node])
-						logger.Error(fileName + ":" + line + ":" + column + " " + funcElem.FName + " function redeclared with same parameters and return type")
-						//break is we stop at first conflict
-					}
-				}
-			} else {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + funcElem.FName + " is already declared in this scope")
-				//break is we stop at first conflict
-			}
-		}
-		if graph.types[sorted[2+shift]] == "decl" {
-			children := maps.Keys(graph.gmap[sorted[2+shift]])
-			for _, child := range children {
-				semCheck(graph, child)
-			}
-			shift++
-		}
-		semCheck(graph, sorted[2+shift])
-		if _, ok := graph.hasReturn[node]; !ok {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Function " + funcElem.FName + " has no return statement")
+func checkDecl(graph *Graph, decl ast.Decl) {
+	graph.visit(decl.ID())
+	scope := graph.scopes[decl.ID()]
+	switch d := decl.(type) {
+	case *ast.SubprogramDecl:
+		if d.IsFunction() {
+			checkFunction(graph, d)
 		} else {
-			if !isHardReturn(graph, sorted[2+shift]) {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Function " + funcElem.FName + " may miss return statement")
-			}
-		}
-	case "procedure":
-		procParam := make(map[int]*Variable)
-		procElem := Procedure{PName: getSymbolType(graph.types[sorted[0]]), PType: Proc, children: sorted, Params: procParam}
-		shift := 0
-		if getSymbolType(graph.types[sorted[0]]) != getSymbolType(graph.types[sorted[len(sorted)-1]]) {
-			if getSymbolType(graph.types[sorted[len(sorted)-1]]) != "end" {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Procedure " + graph.types[sorted[0]] + " end name do not match")
-			}
+			checkProcedure(graph, d)
 		}
-		if graph.types[sorted[1]] == "params" {
-			child := maps.Keys(graph.gmap[sorted[1]])
-			slices.Sort(child)
-			for _, param := range child {
-				addParamProc(graph, param, &procElem, trashScope)
-				checkParam(graph, param, scope)
+	case *ast.VarDecl:
+		// check if something is already declared with the same name
+		for _, name := range d.Names {
+			if r, ok := scope.Table[getSymbolType(name.Name)]; ok {
+				if len(r) > 1 {
+					semError(graph, d, name.Name+" is already declared in this scope")
+				}
 			}
-			shift = 1
 		}
-		addSymbol(graph, node, hashProc(procElem), procElem)
-
-		countSame := 0
-		for _, proc := range scope.Table[procElem.PName] {
-			if proc.Type() == Proc {
-				if compareProc(proc.(Procedure), procElem) {
-					countSame++
-					if countSame > 1 {
-						fileName := graph.fileName
-						line := strconv.Itoa(graph.line[node])
-						column := strconv.Itoa(graph.column[node])
-						logger.Error(fileName + ":" + line + ":" + column + " " + "Procedure redeclared with same parameters")
-					}
+		// check if the type exists
+		_, err := findType(scope, getSymbolType(d.Type.Name))
+		if err != nil {
+			semError(graph, d.Type, err.Error())
+		}
+	case *ast.RecordDecl:
+		fields := make(map[string]string)
+		for _, field := range d.Fields {
+			for _, name := range field.Names {
+				if _, ok := fields[getSymbolType(name.Name)]; ok {
+					semError(graph, d, "Field "+name.Name+" is duplicate in record "+d.Name.Name+" declaration")
 				}
-			} else {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + procElem.PName + " is already declared in this scope")
-				//break
+				fields[getSymbolType(name.Name)] = getSymbolType(field.Type.Name)
 			}
-		}
 
-		if graph.types[sorted[1+shift]] == "decl" {
-			children := maps.Keys(graph.gmap[sorted[1+shift]])
-			for _, child := range children {
-				semCheck(graph, child)
-			}
-			shift++
-		}
-		semCheck(graph, sorted[1+shift])
-	case "for":
-		if whichFinal(graph, sorted[0]) != "identifier" {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[sorted[0]])
-			column := strconv.Itoa(graph.column[sorted[0]])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Loop variable should be a variable")
-		} else {
-			if Contains([]string{Func, Proc, Rec}, getSymbol(graph, scope, sorted[0])) {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[sorted[0]])
-				column := strconv.Itoa(graph.column[sorted[0]])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Loop variable should be a variable")
+			_, err := findType(scope, getSymbolType(field.Type.Name))
+			if err != nil {
+				semError(graph, d, err.Error())
 			}
 		}
-		if !haveType(getReturnType(graph, scope, sorted[2], make(map[string]struct{})), "integer") {
-			logger.Error("left side of for loop should be an integer")
-		}
-		if !haveType(getReturnType(graph, scope, sorted[3], make(map[string]struct{})), "integer") {
-			logger.Error("right side of for loop should be an integer")
-		}
-		semCheck(graph, sorted[4])
-
-	case "while":
-		if !haveType(getReturnType(graph, scope, sorted[0], make(map[string]struct{})), "boolean") {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Condition should be boolean")
-		}
-		semCheck(graph, sorted[1])
+	}
+}
 
-	case "var":
-		// check if something is already declared with the same name
-		if graph.types[sorted[0]] == "sameType" {
-			for _, child := range maps.Keys(graph.gmap[sorted[0]]) {
-				if r, ok := scope.Table[getSymbolType(graph.types[child])]; ok {
-					if len(r) > 1 {
-						fileName := graph.fileName
-						line := strconv.Itoa(graph.line[node])
-						column := strconv.Itoa(graph.column[node])
-						logger.Error(fileName + ":" + line + ":" + column + " " + graph.types[child] + " is already declared in this scope")
-					}
+func checkFunction(graph *Graph, function *ast.SubprogramDecl) {
+	scope := graph.scopes[function.ID()]
+	trashScope := newScope(nil)
+	funcParam := make(map[int]*Variable)
+	funcElem := Function{FName: getSymbolType(function.Name.Name), SType: Func, children: graph.GetChildren(function.ID()), Params: funcParam}
+	if function.EndName != nil && funcElem.FName != getSymbolType(function.EndName.Name) {
+		semError(graph, function, "Function "+function.Name.Name+" end name do not match")
+	}
+	for _, param := range function.Params {
+		trashScope.parent = scope
+		trashScope.Table = scope.Table
+		addParam(graph, param.ID(), &funcElem, trashScope)
+		checkParam(graph, param, scope)
+	}
+	funcElem.ReturnType = getSymbolType(function.Result.Name)
+
+	_, err := findType(scope, funcElem.ReturnType)
+	if err != nil {
+		semError(graph, function, err.Error())
+	}
+	addSymbol(graph, function.ID(), hashFunction(funcElem, scope.parent), funcElem)
+
+	countSame := 0
+	for _, fun := range scope.Table[funcElem.FName] {
+		if fun.Type() == Func {
+			if compareFunc(fun.(Function), funcElem) {
+				countSame++
+				if countSame > 1 {
+					semError(graph, function, funcElem.FName+" function redeclared with same parameters and return type")
+					//break is we stop at first conflict
 				}
 			}
 		} else {
-			if r, ok := scope.Table[getSymbolType(graph.types[sorted[0]])]; ok {
-				if len(r) > 1 {
-					fileName := graph.fileName
-					line := strconv.Itoa(graph.line[node])
-					column := strconv.Itoa(graph.column[node])
-					logger.Error(fileName + ":" + line + ":" + column + " " + graph.types[sorted[0]] + " is already declared in this scope")
+			semError(graph, function, funcElem.FName+" is already declared in this scope")
+			//break is we stop at first conflict
+		}
+	}
+	for _, decl := range function.Decls {
+		checkDecl(graph, decl)
+	}
+	checkStmts(graph, function.Body)
+	if _, ok := graph.hasReturn[function.ID()]; !ok {
+		semError(graph, function, "Function "+funcElem.FName+" has no return statement")
+	} else if !isHardReturn(function.Body) {
+		semError(graph, function, "Function "+funcElem.FName+" may miss return statement")
+	}
+}
+
+func checkProcedure(graph *Graph, procedure *ast.SubprogramDecl) {
+	scope := graph.scopes[procedure.ID()]
+	trashScope := newScope(nil)
+	procParam := make(map[int]*Variable)
+	procElem := Procedure{PName: getSymbolType(procedure.Name.Name), PType: Proc, children: graph.GetChildren(procedure.ID()), Params: procParam}
+	if procedure.EndName != nil && procElem.PName != getSymbolType(procedure.EndName.Name) {
+		semError(graph, procedure, "Procedure "+procedure.Name.Name+" end name do not match")
+	}
+	for _, param := range procedure.Params {
+		addParamProc(graph, param.ID(), &procElem, trashScope)
+		checkParam(graph, param, scope)
+	}
+	addSymbol(graph, procedure.ID(), hashProc(procElem, scope.parent), procElem)
+
+	countSame := 0
+	for _, proc := range scope.Table[procElem.PName] {
+		if proc.Type() == Proc {
+			if compareProc(proc.(Procedure), procElem) {
+				countSame++
+				if countSame > 1 {
+					semError(graph, procedure, "Procedure redeclared with same parameters")
 				}
 			}
+		} else {
+			semError(graph, procedure, procElem.PName+" is already declared in this scope")
+			//break
 		}
-		// check if the type exists
-		declType := getSymbolType(getSymbolType(graph.types[sorted[1]]))
+	}
 
-		_, err := findType(scope, declType)
-		if err != nil {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[sorted[1]])
-			column := strconv.Itoa(graph.column[sorted[1]])
-			errorMessage := fileName + ":" + line + ":" + column + " " + err.Error()
-			logger.Error(errorMessage)
-		}
+	for _, decl := range procedure.Decls {
+		checkDecl(graph, decl)
+	}
+	checkStmts(graph, procedure.Body)
+}
 
-	case "type":
-		if r, ok := scope.Table[getSymbolType(graph.types[node])]; ok {
-			if len(r) > 1 {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + graph.types[node] + " is already declared in this scope")
-			}
-		}
-		recordElem := Record{RName: getSymbolType(graph.types[sorted[0]]), SType: Rec, Fields: make(map[string]string)}
-		for _, child := range maps.Keys(graph.gmap[sorted[1]]) {
-			childChild := maps.Keys(graph.gmap[child])
-			slices.Sort(childChild)
-			if _, ok := recordElem.Fields[getSymbolType(graph.types[childChild[0]])]; ok {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Field " + graph.types[childChild[0]] + " is duplicate in record " + graph.types[sorted[0]] + " declaration")
-			}
-			recordElem.Fields[getSymbolType(graph.types[childChild[0]])] = getSymbolType(graph.types[childChild[1]])
+func checkStmts(graph *Graph, stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		checkStmt(graph, stmt)
+	}
+}
 
-			_, err := findType(scope, getSymbolType(graph.types[childChild[1]]))
-			if err != nil {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				errorMessage := fileName + ":" + line + ":" + column + " " + err.Error()
-				logger.Error(errorMessage)
-			}
+func checkStmt(graph *Graph, stmt ast.Stmt) {
+	graph.visit(stmt.ID())
+	scope := graph.scopes[stmt.ID()]
+	switch s := stmt.(type) {
+	case *ast.ForStmt:
+		if Contains([]string{Func, Proc, Rec}, getSymbol(graph, scope, s.Var)) {
+			semError(graph, s.Var, "Loop variable should be a variable")
+		}
+		if !haveType(getReturnType(graph, scope, s.Low, make(map[string]struct{})), "integer") {
+			semError(graph, s.Low, "Left side of for loop should be an integer")
+		}
+		if !haveType(getReturnType(graph, scope, s.High, make(map[string]struct{})), "integer") {
+			semError(graph, s.High, "Right side of for loop should be an integer")
+		}
+		checkStmts(graph, s.Body)
 
+	case *ast.WhileStmt:
+		if !haveType(getReturnType(graph, scope, s.Cond, make(map[string]struct{})), "boolean") {
+			semError(graph, s, "Condition should be boolean")
 		}
-	case ":=":
-		if whichFinal(graph, sorted[0]) != "identifier" {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[sorted[0]])
-			column := strconv.Itoa(graph.column[sorted[0]])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Left side of assignment is not a variable")
-		} else {
-			if Contains([]string{Func, Proc, Rec}, getSymbol(graph, scope, sorted[0])) {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[sorted[0]])
-				column := strconv.Itoa(graph.column[sorted[0]])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Left side of assignment is not a variable")
-			}
+		checkStmts(graph, s.Body)
+
+	case *ast.AssignStmt:
+		if !isName(s.Target) {
+			semError(graph, s.Target, "Left side of assignment is not a variable")
+		} else if Contains([]string{Func, Proc, Rec}, getSymbol(graph, scope, s.Target)) {
+			semError(graph, s.Target, "Left side of assignment is not a variable")
 		}
 
-		varTypes := getReturnType(graph, scope, sorted[0], make(map[string]struct{}))
+		varTypes := getReturnType(graph, scope, s.Target, make(map[string]struct{}))
 		var varType string
 		for k := range varTypes {
 			varType = k
 			break
 		}
-		assignTypes := getReturnType(graph, scope, sorted[1], varTypes)
+		assignTypes := getReturnType(graph, scope, s.Value, varTypes)
 		var assignType string
 		for k := range assignTypes {
 			assignType = k
 			break
 		}
-		if varType != assignType {
+		if !haveType(assignTypes, varType) {
 			if varType != "unknown" && assignType != "unknown" {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Type mismatch for variable: " + findAccessName(graph, sorted[0], "") + " is " + varType + " and was assigned to " + assignType)
+				semError(graph, s, "Type mismatch for variable: "+findAccessName(graph, s.Target)+" is "+varType+" and was assigned to "+assignType)
 			}
 		}
-		varStruct := findStruct(graph, scope, sorted[0], true)
+		varStruct := findStruct(graph, scope, s.Target, true)
 		if varStruct != nil {
 			if varStruct.IsLoop {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[sorted[0]])
-				column := strconv.Itoa(graph.column[sorted[0]])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Loop variable " + varStruct.VName + " cannot be assigned")
+				semError(graph, s.Target, "Loop variable "+varStruct.VName+" cannot be assigned")
 			}
-			if !varStruct.IsParamOut && varStruct.IsParamIn {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[sorted[0]])
-				column := strconv.Itoa(graph.column[sorted[0]])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Variable " + varStruct.VName + " is an in parameter and cannot be assigned")
+			if !varStruct.IsParamOut && varStruct.IsParamIn && !throughAccess(graph, scope, s.Target) {
+				semError(graph, s.Target, "Variable "+varStruct.VName+" is an in parameter and cannot be assigned")
 			}
 		}
-	case "return":
+	case *ast.ReturnStmt:
 		scopeSymb := findMotherFunc(scope)
 		// return either func or proc symbol
 		if _, ok := scopeSymb.(Procedure); ok {
-			if len(sorted) != 0 {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "Procedure can't return a value")
+			if s.Value != nil {
+				semError(graph, s, "Procedure can't return a value")
 			}
 		} else {
-			if len(sorted) == 0 {
-				fileName := graph.fileName
-				line := strconv.Itoa(graph.line[node])
-				column := strconv.Itoa(graph.column[node])
-				logger.Error(fileName + ":" + line + ":" + column + " " + "return can't be standalone in function")
+			if s.Value == nil {
+				semError(graph, s, "return can't be standalone in function")
 			} else {
 				expectedType := make(map[string]struct{})
 				expectedType[scopeSymb.(Function).ReturnType] = struct{}{}
-				returnType := getReturnType(graph, scope, sorted[0], expectedType)
-				//fmt.Println(scopeSymb.(Function).ReturnType, returnType)
+				returnType := getReturnType(graph, scope, s.Value, expectedType)
 				if !haveType(returnType, scopeSymb.(Function).ReturnType) {
 					stringTypes := ""
 					for k := range returnType {
 						stringTypes = stringTypes + ", " + k
 					}
-					fileName := graph.fileName
-					line := strconv.Itoa(graph.line[node])
-					column := strconv.Itoa(graph.column[node])
-					logger.Error(fileName + ":" + line + ":" + column + " " + "Return types " + stringTypes[2:] + " don't match " + scopeSymb.(Function).FName + " return type " + scopeSymb.(Function).ReturnType)
+					semError(graph, s, "Return types "+stringTypes[2:]+" don't match "+scopeSymb.(Function).FName+" return type "+scopeSymb.(Function).ReturnType)
+				}
+			}
+		}
+		updateReturn(graph, s.ID())
+	case *ast.CallStmt:
+		if s.Args == nil {
+			// a name alone is a statement only if it is a procedure without parameters
+			identType := getSymbol(graph, scope, s.Name)
+			if identType == Proc {
+				if s.Name.ID() == s.ID() {
+					// the name alone is the node of the statement until it becomes a call
+					s.Name = makeCall(graph, s.Name, s.Name.Name)
 				}
+				matchProc(graph, scope, s.Name, []ast.Expr{}, genArgsMap(graph, scope, []ast.Expr{}))
+			} else {
+				semError(graph, s.Name, identType+" "+s.Name.Name+" is not a statement")
 			}
+			return
 		}
-		updateReturn(graph, node)
-	case "call":
-		//fmt.Printf("call scope: %d %v\n", scope.Region, scope.Table)
-		symbolType := getSymbol(graph, scope, sorted[0])
-		//fmt.Println("symbolType", symbolType, graph.types[sorted[0]])
+		symbolType := getSymbol(graph, scope, s.Name)
 		if symbolType == Func {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[sorted[0]]) // use sorted[0] instead of node to get the position of the ident
-			column := strconv.Itoa(graph.column[sorted[0]])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Cannot use call to function " + graph.types[sorted[0]] + " as a statement")
+			semError(graph, s.Name, "Cannot use call to function "+s.Name.Name+" as a statement")
 		} else if symbolType == Proc {
-			//fmt.Println("Proc", graph.types[sorted[0]], maps.Keys(graph.gmap[sorted[1]]))
-
-			matchProc(graph, scope, sorted[0], maps.Keys(graph.gmap[sorted[1]]), genArgsMap(graph, scope, maps.Keys(graph.gmap[sorted[1]])))
+			matchProc(graph, scope, s.Name, s.Args, genArgsMap(graph, scope, s.Args))
 		} else if symbolType == Rec {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Cannot use call to type " + graph.types[sorted[0]] + " as a statement")
+			semError(graph, s, "Cannot use call to type "+s.Name.Name+" as a statement")
 		} else if symbolType == Unknown {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Cannot use call to " + graph.types[sorted[0]] + " as a statement")
+			semError(graph, s, "Cannot use call to "+s.Name.Name+" as a statement")
 		} else {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Cannot use call to variable " + graph.types[sorted[0]] + " as a statement")
+			semError(graph, s, "Cannot use call to variable "+s.Name.Name+" as a statement")
 		}
-	case "if", "elif":
-		if !haveType(getReturnType(graph, scope, sorted[0], make(map[string]struct{})), "boolean") {
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[sorted[0]])
-			column := strconv.Itoa(graph.column[sorted[0]])
-			logger.Error(fileName + ":" + line + ":" + column + " " + "Condition should be boolean")
+	case *ast.IfStmt:
+		if !haveType(getReturnType(graph, scope, s.Cond, make(map[string]struct{})), "boolean") {
+			semError(graph, s.Cond, "Condition should be boolean")
 		}
-		for _, child := range sorted[1:] {
-			semCheck(graph, child)
-		}
-	default:
-		//is something not accepted
-		if len(sorted) == 0 && whichFinal(graph, node) == "identifier" {
-			identType := getSymbol(graph, scope, node)
-			fileName := graph.fileName
-			line := strconv.Itoa(graph.line[node])
-			column := strconv.Itoa(graph.column[node])
-			if identType == Proc {
-				newNode := makeChild2(graph, node, "call", graph.types[node])
-				matchProc(graph, scope, newNode, []int{}, genArgsMap(graph, scope, []int{}))
-			} else {
-				logger.Error(fileName + ":" + line + ":" + column + " " + identType + " " + graph.types[node] + " is not a statement")
+		checkStmts(graph, s.Body)
+		for _, elif := range s.Elifs {
+			graph.visit(elif.ID())
+			if !haveType(getReturnType(graph, graph.scopes[elif.ID()], elif.Cond, make(map[string]struct{})), "boolean") {
+				semError(graph, elif.Cond, "Condition should be boolean")
 			}
+			checkStmts(graph, elif.Body)
 		}
-		for _, child := range sorted {
-			semCheck(graph, child)
-		}
+		checkStmts(graph, s.Else)
 	}
 }
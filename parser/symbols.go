@@ -3,6 +3,7 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"gada/token"
 	"golang.org/x/exp/maps"
 	"slices"
 	"strings"
@@ -27,6 +28,7 @@ const (
 	Bool
 	Float
 	Rec     = "rec"
+	Acc     = "acc"
 	Func    = "func"
 	Proc    = "proc"
 	Unknown = "unknown"
@@ -72,6 +74,13 @@ type Record struct {
 	FieldsOffset map[string]int
 }
 
+// Access is an access type, its values designate objects of the type Target
+type Access struct {
+	AName  string
+	SType  string
+	Target string
+}
+
 func (v Variable) Name() string {
 	return v.VName
 }
@@ -104,12 +113,20 @@ func (r Record) Type() string {
 	return r.SType
 }
 
+func (a Access) Name() string {
+	return a.AName
+}
+
+func (a Access) Type() string {
+	return a.SType
+}
+
 func (r Record) Offset() int {
 	return 0
 }
 
 func getSymbolType(symbol string) string {
-	return strings.ToLower(symbol)
+	return token.FoldIdentifier(symbol)
 }
 
 func newScope(parent *Scope) *Scope {
@@ -161,6 +178,8 @@ func ReadAST(graph *Graph, printtds bool) (*Scope, error) {
 	fileNodeIndex := 0
 	currentScope.addSymbol(Procedure{PName: "put", PType: Proc, ParamCount: 1, Params: map[int]*Variable{1: &Variable{VName: "x", SType: "character"}}, children: []int{}})
 	currentScope.addSymbol(Procedure{PName: "put", PType: Proc, ParamCount: 1, Params: map[int]*Variable{1: &Variable{VName: "x", SType: "integer"}}, children: []int{}})
+	currentScope.addSymbol(Procedure{PName: "put", PType: Proc, ParamCount: 1, Params: map[int]*Variable{1: &Variable{VName: "x", SType: "string"}}, children: []int{}})
+	currentScope.addSymbol(Procedure{PName: "put_line", PType: Proc, ParamCount: 1, Params: map[int]*Variable{1: &Variable{VName: "x", SType: "string"}}, children: []int{}})
 	currentScope.addSymbol(Procedure{PName: "new_line", PType: Proc, children: []int{}})
 	dfsSymbols(graph, fileNodeIndex, &currentScope)
 
@@ -246,7 +265,9 @@ func addParamProc(graph *Graph, node int, currentProc *Procedure, procScope *Sco
 		children := maps.Keys(graph.gmap[node])
 		slices.Sort(children)
 		if graph.types[children[0]] == "sameType" {
-			for _, child := range maps.Keys(graph.gmap[children[0]]) {
+			childrenchildren := maps.Keys(graph.gmap[children[0]])
+			slices.Sort(childrenchildren)
+			for _, child := range childrenchildren {
 				var size int
 				if len(children) == 3 {
 					size = getTypeSize(getSymbolType(graph.types[children[2]]), *procScope)
@@ -284,6 +305,7 @@ func addParamProc(graph *Graph, node int, currentProc *Procedure, procScope *Sco
 }
 
 func dfsSymbols(graph *Graph, node int, currentScope *Scope) {
+	graph.visit(node)
 	sorted := maps.Keys(graph.gmap[node])
 	slices.Sort(sorted)
 
@@ -315,9 +337,15 @@ func dfsSymbols(graph *Graph, node int, currentScope *Scope) {
 
 					nameA := graph.types[sortedA[0]]
 					nameB := graph.types[sortedB[0]]
-					return strings.Compare(nameA, nameB)
+					if nameA != nameB {
+						return strings.Compare(nameA, nameB)
+					}
 				}
-				return strings.Compare(nodeA, nodeB)
+				if nodeA != nodeB {
+					return strings.Compare(nodeA, nodeB)
+				}
+				// Keep the source order between declarations of the same kind
+				return a - b
 			})
 			for _, child := range children {
 				dfsSymbols(graph, child, currentScope)
@@ -358,9 +386,15 @@ func dfsSymbols(graph *Graph, node int, currentScope *Scope) {
 
 					nameA := graph.types[sortedA[0]]
 					nameB := graph.types[sortedB[0]]
-					return strings.Compare(nameA, nameB)
+					if nameA != nameB {
+						return strings.Compare(nameA, nameB)
+					}
+				}
+				if nodeA != nodeB {
+					return strings.Compare(nodeA, nodeB)
 				}
-				return strings.Compare(nodeA, nodeB)
+				// Keep the source order between declarations of the same kind
+				return a - b
 			})
 			for _, child := range children {
 				dfsSymbols(graph, child, funcScope)
@@ -400,9 +434,15 @@ func dfsSymbols(graph *Graph, node int, currentScope *Scope) {
 
 					nameA := graph.types[sortedA[0]]
 					nameB := graph.types[sortedB[0]]
-					return strings.Compare(nameA, nameB)
+					if nameA != nameB {
+						return strings.Compare(nameA, nameB)
+					}
+				}
+				if nodeA != nodeB {
+					return strings.Compare(nodeA, nodeB)
 				}
-				return strings.Compare(nodeA, nodeB)
+				// Keep the source order between declarations of the same kind
+				return a - b
 			})
 			for _, child := range children {
 				dfsSymbols(graph, child, procScope)
@@ -444,21 +484,35 @@ func dfsSymbols(graph *Graph, node int, currentScope *Scope) {
 			}
 		}
 	case "type":
-		recordElem := Record{RName: getSymbolType(graph.types[sorted[0]]), SType: Rec, Fields: make(map[string]string), FieldsOffset: make(map[string]int)}
-		for _, child := range maps.Keys(graph.gmap[sorted[1]]) {
-			childChild := maps.Keys(graph.gmap[child])
-			slices.Sort(childChild)
-			recordElem.Fields[strings.ToLower(graph.types[childChild[0]])] = getSymbolType(graph.types[childChild[1]])
-
-			// Calculate offset
+		name := getSymbolType(graph.types[sorted[0]])
+		switch {
+		case len(sorted) < 2 || graph.GetNode(sorted[1]) == "endtype":
+			// The incomplete declaration is completed later in the same declarative part
+		case graph.GetNode(sorted[1]) == "attribs":
+			recordElem := Record{RName: name, SType: Rec, Fields: make(map[string]string), FieldsOffset: make(map[string]int)}
+			fields := maps.Keys(graph.gmap[sorted[1]])
+			slices.Sort(fields)
+			// The offset of a field is the start of its slot from the start of the record
 			currentOffset := 0
-			for _, offset := range recordElem.FieldsOffset {
-				currentOffset += offset
+			for _, child := range fields {
+				childChild := maps.Keys(graph.gmap[child])
+				slices.Sort(childChild)
+				fieldType := getSymbolType(graph.types[childChild[1]])
+				names := []int{childChild[0]}
+				if graph.GetNode(childChild[0]) == "sametype" {
+					names = maps.Keys(graph.gmap[childChild[0]])
+					slices.Sort(names)
+				}
+				for _, fieldName := range names {
+					recordElem.Fields[getSymbolType(graph.types[fieldName])] = fieldType
+					recordElem.FieldsOffset[getSymbolType(graph.types[fieldName])] = currentOffset
+					currentOffset += getTypeSize(fieldType, scope)
+				}
 			}
-			currentOffset += getTypeSize(getSymbolType(graph.types[childChild[1]]), scope)
-			recordElem.FieldsOffset[strings.ToLower(graph.types[childChild[0]])] = currentOffset
+			scope.addSymbol(recordElem)
+		default:
+			scope.addSymbol(Access{AName: name, SType: Acc, Target: getSymbolType(graph.types[sorted[1]])})
 		}
-		scope.addSymbol(recordElem)
 	default:
 		for _, child := range sorted {
 			dfsSymbols(graph, child, currentScope)
@@ -0,0 +1,383 @@
+package parser
+
+import (
+	"gada/ast"
+	"strconv"
+	"strings"
+)
+
+// treeBuilder turns the graph into the typed tree of the ast package
+type treeBuilder struct {
+	graph Graph
+	// ends gives the end of the token starting at a position
+	ends map[ast.Pos]ast.Pos
+}
+
+// File returns the typed tree built by the parser. The semantic analysis turns the identifiers naming
+// a function or a procedure called without arguments into calls, in the tree and in the graph.
+func (g Graph) File() *ast.File {
+	return g.file
+}
+
+// buildFile builds the typed tree of the graph, every node keeps the id of its node in the graph
+func buildFile(g Graph) *ast.File {
+	b := &treeBuilder{graph: g, ends: map[ast.Pos]ast.Pos{}}
+	if g.lexer != nil {
+		for _, tkn := range g.lexer.Tokens {
+			start := ast.Pos{Line: tkn.Beginning.Line, Column: tkn.Beginning.Column}
+			b.ends[start] = ast.Pos{Line: tkn.End.Line, Column: tkn.End.Column}
+		}
+	}
+	return b.file()
+}
+
+func (b *treeBuilder) kind(node int) string {
+	return b.graph.GetNode(node)
+}
+
+func (b *treeBuilder) children(node int) []int {
+	return b.graph.GetChildren(node)
+}
+
+func (b *treeBuilder) pos(node int) ast.Pos {
+	return ast.Pos{Line: b.graph.line[node], Column: b.graph.column[node]}
+}
+
+// span is the source covered by the leaves under the node, the leaves added by the compiler have no position
+func (b *treeBuilder) span(node int) ast.Span {
+	children := b.children(node)
+	if len(children) == 0 {
+		start := b.pos(node)
+		if end, ok := b.ends[start]; ok {
+			return ast.Span{Start: start, End: end}
+		}
+		return ast.Span{Start: start, End: start}
+	}
+	var span ast.Span
+	for _, child := range children {
+		span = join(span, b.span(child))
+	}
+	return span
+}
+
+func join(a, b ast.Span) ast.Span {
+	if !a.Start.IsValid() {
+		return b
+	}
+	if !b.Start.IsValid() {
+		return a
+	}
+	if b.Start.Before(a.Start) {
+		a.Start = b.Start
+	}
+	if a.End.Before(b.End) {
+		a.End = b.End
+	}
+	return a
+}
+
+func (b *treeBuilder) meta(node int) ast.Meta {
+	return ast.Meta{NodeID: node, Position: b.pos(node), Extent: b.span(node)}
+}
+
+// child returns the i-th child of the node, -1 when there is none
+func child(children []int, i int) int {
+	if i < len(children) {
+		return children[i]
+	}
+	return -1
+}
+
+func (b *treeBuilder) ident(node int) *ast.Ident {
+	if node < 0 {
+		return nil
+	}
+	return &ast.Ident{Meta: b.meta(node), Name: b.graph.GetRealNode(node)}
+}
+
+// names reads a name or the names of a list (a, b : Integer)
+func (b *treeBuilder) names(node int) []*ast.Ident {
+	if node < 0 {
+		return nil
+	}
+	if b.kind(node) == "sametype" && len(b.children(node)) > 0 {
+		var names []*ast.Ident
+		for _, name := range b.children(node) {
+			names = append(names, b.ident(name))
+		}
+		return names
+	}
+	return []*ast.Ident{b.ident(node)}
+}
+
+// endName returns the name after end, nil when it is omitted
+func (b *treeBuilder) endName(node int) *ast.Ident {
+	if node < 0 || b.kind(node) == "end" {
+		return nil
+	}
+	return b.ident(node)
+}
+
+func (b *treeBuilder) file() *ast.File {
+	children := b.children(0)
+	file := &ast.File{Meta: b.meta(0), Name: b.ident(child(children, 0))}
+	if len(children) == 0 {
+		return file
+	}
+	for _, node := range children[1:] {
+		switch b.kind(node) {
+		case "decl":
+			file.Decls = b.decls(node)
+		case "body":
+			file.Body = b.stmts(node)
+		}
+	}
+	if len(children) > 2 {
+		file.EndName = b.endName(children[len(children)-1])
+	}
+	return file
+}
+
+func (b *treeBuilder) decls(node int) []ast.Decl {
+	var decls []ast.Decl
+	for _, decl := range b.children(node) {
+		switch b.kind(decl) {
+		case "procedure", "function":
+			decls = append(decls, b.subprogram(decl))
+		case "var":
+			decls = append(decls, b.varDecl(decl))
+		case "type":
+			decls = append(decls, b.typeDecl(decl))
+		}
+	}
+	return decls
+}
+
+// subprogram reads [name, params?, return type (function only), decl?, body, end name]
+func (b *treeBuilder) subprogram(node int) *ast.SubprogramDecl {
+	children := b.children(node)
+	decl := &ast.SubprogramDecl{Meta: b.meta(node), Name: b.ident(child(children, 0))}
+	i := 1
+	if b.kind(child(children, i)) == "params" {
+		for _, param := range b.children(children[i]) {
+			decl.Params = append(decl.Params, b.param(param))
+		}
+		i++
+	}
+	if b.kind(node) == "function" {
+		decl.Result = b.ident(child(children, i))
+		i++
+	}
+	if b.kind(child(children, i)) == "decl" {
+		decl.Decls = b.decls(children[i])
+		i++
+	}
+	if b.kind(child(children, i)) == "body" {
+		decl.Body = b.stmts(children[i])
+		i++
+	}
+	decl.EndName = b.endName(child(children, i))
+	return decl
+}
+
+// param reads [name or names, mode?, type]
+func (b *treeBuilder) param(node int) *ast.Param {
+	children := b.children(node)
+	param := &ast.Param{Meta: b.meta(node), Names: b.names(child(children, 0))}
+	if len(children) == 3 && b.kind(children[1]) == "in out" {
+		param.Mode = ast.InOut
+	}
+	if len(children) > 1 {
+		param.Type = b.ident(children[len(children)-1])
+	}
+	return param
+}
+
+// varDecl reads [name or names, type, initial value?]
+func (b *treeBuilder) varDecl(node int) *ast.VarDecl {
+	children := b.children(node)
+	decl := &ast.VarDecl{Meta: b.meta(node), Names: b.names(child(children, 0)), Type: b.ident(child(children, 1))}
+	if len(children) > 2 {
+		decl.Value = b.expr(children[2])
+	}
+	return decl
+}
+
+// typeDecl reads [name, endType] for an incomplete type, [name, attribs] for a record and [name, target] for an access
+func (b *treeBuilder) typeDecl(node int) ast.Decl {
+	children := b.children(node)
+	name := b.ident(child(children, 0))
+	definition := child(children, 1)
+	switch {
+	case definition < 0 || b.kind(definition) == "endtype":
+		return &ast.TypeDecl{Meta: b.meta(node), Name: name}
+	case b.kind(definition) == "attribs":
+		record := &ast.RecordDecl{Meta: b.meta(node), Name: name}
+		for _, attrib := range b.children(definition) {
+			fieldChildren := b.children(attrib)
+			record.Fields = append(record.Fields, &ast.Field{
+				Meta:  b.meta(attrib),
+				Names: b.names(child(fieldChildren, 0)),
+				Type:  b.ident(child(fieldChildren, 1)),
+			})
+		}
+		return record
+	default:
+		return &ast.AccessTypeDecl{Meta: b.meta(node), Name: name, Target: b.ident(definition)}
+	}
+}
+
+func (b *treeBuilder) stmts(node int) []ast.Stmt {
+	var stmts []ast.Stmt
+	for _, stmt := range b.children(node) {
+		stmts = append(stmts, b.stmt(stmt))
+	}
+	return stmts
+}
+
+func (b *treeBuilder) stmt(node int) ast.Stmt {
+	children := b.children(node)
+	switch b.kind(node) {
+	case ":=":
+		return &ast.AssignStmt{Meta: b.meta(node), Target: b.expr(child(children, 0)), Value: b.expr(child(children, 1))}
+	case "return":
+		stmt := &ast.ReturnStmt{Meta: b.meta(node)}
+		if len(children) > 0 {
+			stmt.Value = b.expr(children[0])
+		}
+		return stmt
+	case "call":
+		if len(children) > 0 {
+			stmt := &ast.CallStmt{Meta: b.meta(node), Name: b.ident(children[0])}
+			if len(children) > 1 {
+				stmt.Args = b.exprs(children[1])
+			}
+			return stmt
+		}
+	case "if":
+		stmt := &ast.IfStmt{Meta: b.meta(node), Cond: b.expr(child(children, 0)), Body: b.stmts(child(children, 1))}
+		for i := 2; i < len(children); i++ {
+			clause := children[i]
+			if b.kind(clause) == "elif" {
+				clauseChildren := b.children(clause)
+				stmt.Elifs = append(stmt.Elifs, &ast.ElsifClause{
+					Meta: b.meta(clause),
+					Cond: b.expr(child(clauseChildren, 0)),
+					Body: b.stmts(child(clauseChildren, 1)),
+				})
+			} else {
+				stmt.Else = b.stmts(clause)
+			}
+		}
+		return stmt
+	case "for":
+		return &ast.ForStmt{
+			Meta:    b.meta(node),
+			Var:     b.ident(child(children, 0)),
+			Reverse: b.kind(child(children, 1)) == "reverse",
+			Low:     b.expr(child(children, 2)),
+			High:    b.expr(child(children, 3)),
+			Body:    b.stmts(child(children, 4)),
+		}
+	case "while":
+		return &ast.WhileStmt{Meta: b.meta(node), Cond: b.expr(child(children, 0)), Body: b.stmts(child(children, 1))}
+	}
+	if len(children) == 0 {
+		// A procedure called without parentheses is a single node until the semantic analysis
+		return &ast.CallStmt{Meta: b.meta(node), Name: b.ident(node)}
+	}
+	return &ast.BadStmt{Meta: b.meta(node)}
+}
+
+func (b *treeBuilder) exprs(node int) []ast.Expr {
+	exprs := []ast.Expr{}
+	for _, expr := range b.children(node) {
+		exprs = append(exprs, b.expr(expr))
+	}
+	return exprs
+}
+
+func (b *treeBuilder) expr(node int) ast.Expr {
+	if node < 0 {
+		return &ast.BadExpr{}
+	}
+	children := b.children(node)
+	if len(children) == 0 {
+		return b.leaf(node)
+	}
+	switch kind := b.kind(node); kind {
+	case "+", "-", "*", "/", "rem", "and", "or", "and then", "or else", ">", "=", "<", "<=", ">=", "!=", "/=":
+		if len(children) == 2 {
+			return &ast.BinaryExpr{Meta: b.meta(node), Op: kind, X: b.expr(children[0]), Y: b.expr(children[1])}
+		}
+	case "call":
+		op := b.kind(children[0])
+		if len(children) == 2 && len(b.children(children[0])) == 0 && (op == "-" || op == "not") {
+			return &ast.UnaryExpr{Meta: b.meta(node), Op: op, X: b.expr(children[1])}
+		}
+		call := &ast.CallExpr{Meta: b.meta(node), Fun: b.ident(children[0])}
+		if len(children) > 1 {
+			call.Args = b.exprs(children[1])
+		}
+		return call
+	case "access":
+		return b.selector(node)
+	case "memory":
+		return &ast.NewExpr{Meta: b.meta(node), Type: b.ident(child(children, 1))}
+	case "attribute":
+		attribute := &ast.AttributeExpr{Meta: b.meta(node), Prefix: b.ident(child(children, 0)), Name: b.ident(child(children, 1))}
+		if attribute.Prefix == nil || attribute.Name == nil {
+			break
+		}
+		if len(children) > 2 {
+			attribute.Args = b.exprs(children[2])
+		}
+		return attribute
+	}
+	return &ast.BadExpr{Meta: b.meta(node)}
+}
+
+func (b *treeBuilder) leaf(node int) ast.Expr {
+	text := b.graph.GetRealNode(node)
+	switch {
+	case strings.EqualFold(text, "true"), strings.EqualFold(text, "false"):
+		return &ast.BoolLit{Meta: b.meta(node), Value: strings.EqualFold(text, "true")}
+	case strings.EqualFold(text, "null"):
+		return &ast.NullLit{Meta: b.meta(node)}
+	case len(text) >= 2 && text[0] == '\'':
+		return &ast.CharLit{Meta: b.meta(node), Value: text[1]}
+	case len(text) >= 2 && text[0] == '"':
+		return &ast.StringLit{Meta: b.meta(node), Value: unquoteString(text)}
+	}
+	if value, err := strconv.Atoi(text); err == nil {
+		return &ast.IntLit{Meta: b.meta(node), Value: value}
+	}
+	return b.ident(node)
+}
+
+// selector turns the accesses of the graph, nested on the right (a.(b.c)), into selectors nested on the left ((a.b).c).
+// The outermost selector is the node of the whole access, the inner ones take the nodes of the nested accesses.
+func (b *treeBuilder) selector(node int) ast.Expr {
+	children := b.children(node)
+	var x ast.Expr = b.expr(child(children, 0))
+	accesses := []int{node}
+	right := child(children, 1)
+	var fields []int
+	for right >= 0 && b.kind(right) == "access" {
+		rightChildren := b.children(right)
+		fields = append(fields, child(rightChildren, 0))
+		accesses = append(accesses, right)
+		right = child(rightChildren, 1)
+	}
+	fields = append(fields, right)
+	for i, field := range fields {
+		sel := b.ident(field)
+		meta := b.meta(accesses[len(accesses)-1-i])
+		if sel == nil {
+			return &ast.BadExpr{Meta: meta}
+		}
+		meta.Extent = join(x.Span(), sel.Span())
+		x = &ast.SelectorExpr{Meta: meta, X: x, Sel: sel}
+	}
+	return x
+}
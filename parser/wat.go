@@ -0,0 +1,878 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// watMemoryPages is the size of the memory, the stack starts at its end and grows down
+	watMemoryPages = 64
+	// watDataStart is the address of the first string literal, 0 stays the null access.
+	// new allocates after the literals.
+	watDataStart = 8
+	// watFrameSize is replaced by the size of the frame once the function is written
+	watFrameSize = "FRAME_SIZE"
+)
+
+type watLoop struct {
+	variable *adaVariable
+	local    string
+}
+
+type watGenerator struct {
+	*program
+	// current is the subprogram being written and loops its loop variables
+	current *adaSubprogram
+	loops   []watLoop
+	// offsets are the offsets of the variables in the frames, frameSizes the size of the frames
+	offsets    map[*adaVariable]int
+	frameSizes map[*adaSubprogram]int
+	locals     []string
+	labels     int
+	body       strings.Builder
+	out        strings.Builder
+	// constants maps the string literals to their address, data holds their segments
+	constants map[string]int
+	dataEnd   int
+	data      strings.Builder
+}
+
+// GenerateWAT translates the program to the WebAssembly text format. The frames live in the linear
+// memory like the frames of the ARM backend: $fp points to the frame of the subprogram, the static
+// link to the frame of the enclosing subprogram is stored at offset 0 and the variables follow it.
+// The stack pointer is the global $sp and new allocates from the global $hp. Put and New_Line are
+// imported from the host.
+func GenerateWAT(graph Graph) (string, error) {
+	p, err := newProgram(graph)
+	if err != nil {
+		return "", err
+	}
+	g := watGenerator{program: p, offsets: map[*adaVariable]int{}, frameSizes: map[*adaSubprogram]int{},
+		constants: map[string]int{}, dataEnd: watDataStart}
+
+	for _, sub := range g.subprograms {
+		offset := 0
+		if sub.hasLink() {
+			offset = 4
+		}
+		for _, variable := range frameVariables(sub) {
+			g.offsets[variable] = offset
+			if variable.byReference {
+				offset += 4
+			} else {
+				offset += watSize(variable.typ)
+			}
+		}
+		g.frameSizes[sub] = offset
+	}
+
+	// The functions are written first, the heap starts after the string literals they use
+	for _, sub := range g.subprograms {
+		g.writeSubprogram(sub)
+	}
+	functions := g.out.String()
+	g.out.Reset()
+	heapStart := (g.dataEnd + 3) &^ 3
+
+	g.out.WriteString(";; " + graph.fileName + "\n(module\n")
+	g.out.WriteString("  (import \"gada\" \"put_char\" (func $gada_put_char (param i32)))\n")
+	g.out.WriteString("  (import \"gada\" \"put_int\" (func $gada_put_int (param i32)))\n")
+	g.out.WriteString("  (import \"gada\" \"put_string\" (func $gada_put_string (param i32)))\n")
+	g.out.WriteString("  (import \"gada\" \"new_line\" (func $gada_new_line))\n")
+	g.out.WriteString("  (memory (export \"memory\") " + strconv.Itoa(watMemoryPages) + ")\n")
+	g.out.WriteString(g.data.String())
+	g.out.WriteString("  (global $sp (mut i32) (i32.const " + strconv.Itoa(watMemoryPages*65536) + "))\n")
+	g.out.WriteString("  (global $hp (mut i32) (i32.const " + strconv.Itoa(heapStart) + "))\n")
+	g.out.WriteString(functions)
+	g.out.WriteString("  (export \"main\" (func $" + g.main.symbol + "))\n)\n")
+	if g.err != nil {
+		return "", g.err
+	}
+	return g.out.String(), nil
+}
+
+// watSize returns the size of a type in the linear memory, every scalar takes a word
+func watSize(t *adaType) int {
+	if t.kind != recordKind {
+		return 4
+	}
+	size := 0
+	for _, field := range t.fields {
+		size += watSize(field.typ)
+	}
+	return size
+}
+
+// watFieldOffset returns the offset of a field in a record
+func watFieldOffset(t *adaType, name string) (int, *adaType) {
+	offset := 0
+	for _, field := range t.fields {
+		if field.name == name {
+			return offset, field.typ
+		}
+		offset += watSize(field.typ)
+	}
+	return -1, nil
+}
+
+func (g *watGenerator) emit(instructions ...string) {
+	for _, instruction := range instructions {
+		g.body.WriteString("    " + instruction + "\n")
+	}
+}
+
+// local declares a new local of the function
+func (g *watGenerator) local(name string) string {
+	local := "$" + name + strconv.Itoa(len(g.locals))
+	g.locals = append(g.locals, local)
+	return local
+}
+
+func (g *watGenerator) label() string {
+	g.labels++
+	return strconv.Itoa(g.labels)
+}
+
+// temporary reserves a slot of the frame for a record value and pushes its address
+func (g *watGenerator) temporary(t *adaType) {
+	offset := g.frameSizes[g.current]
+	g.frameSizes[g.current] += watSize(t)
+	g.emit("local.get $fp", "i32.const "+strconv.Itoa(offset), "i32.add")
+}
+
+func (g *watGenerator) writeSubprogram(sub *adaSubprogram) {
+	g.current = sub
+	g.loops = nil
+	g.locals = nil
+	g.labels = 0
+	g.body.Reset()
+
+	var params []string
+	if sub.hasLink() {
+		params = append(params, "(param $up i32)")
+	}
+	for _, param := range sub.params {
+		params = append(params, "(param $p_"+param.name+" i32)")
+	}
+	returnsRecord := sub.returnType != nil && sub.returnType.kind == recordKind
+	if returnsRecord {
+		params = append(params, "(param $result i32)")
+	}
+	if sub.returnType != nil {
+		params = append(params, "(result i32)")
+	}
+
+	// Prologue: reserve the frame and fill it with zeros
+	g.emit("global.get $sp", "i32.const "+watFrameSize, "i32.sub", "local.tee $fp", "global.set $sp")
+	g.emit("local.get $fp", "i32.const 0", "i32.const "+watFrameSize, "call $gada_zero")
+	if sub.hasLink() {
+		g.emit("local.get $fp", "local.get $up", "i32.store")
+	}
+	for _, param := range sub.params {
+		if param.typ.kind == recordKind && !param.byReference {
+			g.emit("local.get $fp", "i32.const "+strconv.Itoa(g.offsets[param]), "i32.add", "local.get $p_"+param.name)
+			g.copy(param.typ)
+			continue
+		}
+		g.emit("local.get $fp", "local.get $p_"+param.name, "i32.store offset="+strconv.Itoa(g.offsets[param]))
+	}
+	for _, init := range sub.inits {
+		for _, variable := range init.variables {
+			g.assign(func() (int, *adaType) {
+				g.emit("local.get $fp")
+				return g.offsets[variable], variable.typ
+			}, init.value)
+		}
+	}
+	g.statements(sub.body)
+	if sub.returnType == nil {
+		g.epilogue()
+	} else {
+		g.emit("unreachable")
+	}
+
+	g.out.WriteString("  (func $" + sub.symbol)
+	for _, param := range params {
+		g.out.WriteString(" " + param)
+	}
+	g.out.WriteString("\n    (local $fp i32)")
+	for _, local := range g.locals {
+		g.out.WriteString(" (local " + local + " i32)")
+	}
+	g.out.WriteString("\n")
+	// The frames stay aligned on words
+	size := (g.frameSizes[sub] + 3) &^ 3
+	g.out.WriteString(strings.ReplaceAll(g.body.String(), watFrameSize, strconv.Itoa(size)))
+	g.out.WriteString("  )\n")
+	if sub == g.main {
+		g.writeZero()
+		g.writeImages()
+	}
+}
+
+// writeZero writes the runtime function clearing a frame word by word
+func (g *watGenerator) writeZero() {
+	g.out.WriteString(`  (func $gada_zero (param $address i32) (param $value i32) (param $size i32)
+    block $done
+      loop $next
+        local.get $size
+        i32.eqz
+        br_if $done
+        local.get $address
+        local.get $value
+        i32.store
+        local.get $address
+        i32.const 4
+        i32.add
+        local.set $address
+        local.get $size
+        i32.const 4
+        i32.sub
+        local.set $size
+        br $next
+      end
+    end
+  )
+`)
+}
+
+// writeImages writes the runtime functions of Integer'Image, Character'Image, S'Length and of the equality of strings.
+// The images are allocated from the heap like new, their length is stored before their characters.
+func (g *watGenerator) writeImages() {
+	g.out.WriteString(`  (func $gada_int_image (param $n i32) (result i32)
+    (local $digit i32) (local $magnitude i32) (local $end i32)
+    global.get $hp
+    i32.const 16
+    i32.add
+    local.tee $end
+    local.tee $digit
+    global.set $hp
+    i32.const 0
+    local.get $n
+    i32.sub
+    local.get $n
+    local.get $n
+    i32.const 0
+    i32.lt_s
+    select
+    local.set $magnitude
+    loop $next
+      local.get $digit
+      i32.const 1
+      i32.sub
+      local.tee $digit
+      local.get $magnitude
+      i32.const 10
+      i32.rem_u
+      i32.const 48
+      i32.add
+      i32.store8
+      local.get $magnitude
+      i32.const 10
+      i32.div_u
+      local.tee $magnitude
+      br_if $next
+    end
+    local.get $digit
+    i32.const 1
+    i32.sub
+    local.tee $digit
+    i32.const 45
+    i32.const 32
+    local.get $n
+    i32.const 0
+    i32.lt_s
+    select
+    i32.store8
+    local.get $digit
+    i32.const 4
+    i32.sub
+    local.tee $digit
+    local.get $end
+    local.get $digit
+    i32.sub
+    i32.const 4
+    i32.sub
+    i32.store
+    local.get $digit
+  )
+  (func $gada_char_image (param $c i32) (result i32)
+    (local $image i32)
+    global.get $hp
+    local.tee $image
+    i32.const 8
+    i32.add
+    global.set $hp
+    local.get $image
+    i32.const 3
+    i32.store
+    local.get $image
+    i32.const 39
+    i32.store8 offset=4
+    local.get $image
+    local.get $c
+    i32.store8 offset=5
+    local.get $image
+    i32.const 39
+    i32.store8 offset=6
+    local.get $image
+  )
+  (func $gada_length (param $s i32) (result i32)
+    local.get $s
+    if (result i32)
+      local.get $s
+      i32.load
+    else
+      i32.const 0
+    end
+  )
+  (func $gada_string_eq (param $a i32) (param $b i32) (result i32)
+    (local $length i32)
+    local.get $a
+    call $gada_length
+    local.tee $length
+    local.get $b
+    call $gada_length
+    i32.ne
+    if
+      i32.const 0
+      return
+    end
+    block $done
+      loop $next
+        local.get $length
+        i32.eqz
+        br_if $done
+        local.get $length
+        i32.const 1
+        i32.sub
+        local.tee $length
+        local.get $a
+        i32.add
+        i32.load8_u offset=4
+        local.get $length
+        local.get $b
+        i32.add
+        i32.load8_u offset=4
+        i32.ne
+        if
+          i32.const 0
+          return
+        end
+        br $next
+      end
+    end
+    i32.const 1
+  )
+`)
+}
+
+// epilogue releases the frame
+func (g *watGenerator) epilogue() {
+	g.emit("local.get $fp", "i32.const "+watFrameSize, "i32.add", "global.set $sp")
+}
+
+// copy copies a record, the destination and the source addresses are on the stack
+func (g *watGenerator) copy(t *adaType) {
+	source := g.local("src")
+	destination := g.local("dst")
+	g.emit("local.set "+source, "local.set "+destination)
+	for offset := 0; offset < watSize(t); offset += 4 {
+		g.emit("local.get "+destination, "local.get "+source, "i32.load offset="+strconv.Itoa(offset), "i32.store offset="+strconv.Itoa(offset))
+	}
+}
+
+// assign stores the value of the node at the address pushed by target, target returns the static offset
+// and the type of the destination
+func (g *watGenerator) assign(target func() (int, *adaType), value int) {
+	offset, t := target()
+	if t.kind == recordKind {
+		if offset != 0 {
+			g.emit("i32.const "+strconv.Itoa(offset), "i32.add")
+		}
+		g.expression(value)
+		g.copy(t)
+		return
+	}
+	g.expression(value)
+	g.emit("i32.store offset=" + strconv.Itoa(offset))
+}
+
+func (g *watGenerator) statements(node int) {
+	for _, child := range g.graph.GetChildren(node) {
+		g.statement(child)
+	}
+}
+
+func (g *watGenerator) statement(node int) {
+	g.graph.visit(node)
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		if g.graph.GetNode(node) == "return" {
+			g.epilogue()
+			g.emit("return")
+		} else {
+			// A procedure called without parameters
+			g.call(node, true)
+		}
+		return
+	}
+	switch g.graph.GetNode(node) {
+	case ":=":
+		g.assign(func() (int, *adaType) { return g.address(children[0]) }, children[1])
+	case "call":
+		g.call(node, true)
+	case "return":
+		if len(children) == 0 {
+			g.epilogue()
+			g.emit("return")
+			return
+		}
+		if g.current.returnType.kind == recordKind {
+			g.emit("local.get $result")
+			g.expression(children[0])
+			g.copy(g.current.returnType)
+			g.epilogue()
+			g.emit("local.get $result", "return")
+			return
+		}
+		g.expression(children[0])
+		g.epilogue()
+		g.emit("return")
+	case "if":
+		g.branches(children)
+	case "while":
+		label := g.label()
+		g.emit("block $endwhile"+label, "loop $while"+label)
+		g.expression(children[0])
+		g.emit("i32.eqz", "br_if $endwhile"+label)
+		g.statements(children[1])
+		g.emit("br $while"+label, "end", "end")
+	case "for":
+		g.forLoop(children)
+	default:
+		g.fail(node, "unsupported statement %s", g.graph.GetNode(node))
+	}
+}
+
+// branches writes an if statement, the elsif are nested in the else branches
+func (g *watGenerator) branches(children []int) {
+	g.expression(children[0])
+	g.emit("if")
+	g.statements(children[1])
+	if len(children) > 2 {
+		g.emit("else")
+		if g.graph.GetNode(children[2]) == "elif" {
+			g.branches(append(g.graph.GetChildren(children[2]), children[3:]...))
+		} else {
+			g.statements(children[2])
+		}
+	}
+	g.emit("end")
+}
+
+// forLoop evaluates the bounds once and stops on the last value so that it cannot overflow
+func (g *watGenerator) forLoop(children []int) {
+	variable := &adaVariable{name: cIdentifier(g.graph.GetNode(children[0]))}
+	counter := g.local(variable.name)
+	last := g.local(variable.name + "_last")
+	first, bound := children[2], children[3]
+	step, stop := "i32.add", "i32.gt_s"
+	if g.graph.GetNode(children[1]) == "reverse" {
+		first, bound = bound, first
+		step, stop = "i32.sub", "i32.lt_s"
+	}
+	variable.typ = g.expression(first)
+	g.emit("local.set " + counter)
+	if variable.typ.kind != integerKind {
+		stop = strings.Replace(stop, "_s", "_u", 1)
+	}
+	g.expression(bound)
+	g.emit("local.set " + last)
+
+	label := g.label()
+	g.emit("block $endfor"+label, "local.get "+counter, "local.get "+last, stop, "br_if $endfor"+label, "loop $for"+label)
+	g.loops = append(g.loops, watLoop{variable: variable, local: counter})
+	g.statements(children[4])
+	g.loops = g.loops[:len(g.loops)-1]
+	g.emit("local.get "+counter, "local.get "+last, "i32.eq", "br_if $endfor"+label)
+	g.emit("local.get "+counter, "i32.const 1", step, "local.set "+counter, "br $for"+label, "end", "end")
+}
+
+// frame pushes the frame of the subprogram at the given distance by following the static links
+func (g *watGenerator) frame(distance int) {
+	g.emit("local.get $fp")
+	for i := 0; i < distance; i++ {
+		g.emit("i32.load")
+	}
+}
+
+// address pushes the base address of a variable or of a field and returns the static offset to add to it.
+// A value that is not a variable is copied to the frame.
+func (g *watGenerator) address(node int) (int, *adaType) {
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		name := g.graph.GetNode(node)
+		variable, distance, _ := g.lookup(g.current, name)
+		if variable != nil {
+			g.frame(distance)
+			if variable.byReference {
+				g.emit("i32.load offset=" + strconv.Itoa(g.offsets[variable]))
+				return 0, variable.typ
+			}
+			return g.offsets[variable], variable.typ
+		}
+	}
+	if g.graph.GetNode(node) == "access" && len(children) == 2 {
+		// The fields are nested on the right: E.Next.Prev is access(E, access(Next, Prev))
+		offset, typ := g.address(children[0])
+		for _, field := range g.fields(children[1]) {
+			if typ.kind == accessKind && typ.target != nil {
+				g.emit("i32.load offset=" + strconv.Itoa(offset))
+				offset, typ = 0, typ.target
+			}
+			name := cIdentifier(g.graph.GetNode(field))
+			fieldOffset, fieldType := watFieldOffset(typ, name)
+			if fieldType == nil {
+				g.fail(field, "unknown field %s", name)
+				return offset, integerType
+			}
+			offset, typ = offset+fieldOffset, fieldType
+		}
+		return offset, typ
+	}
+
+	// A record returned by a function is already in the frame
+	typ := g.expression(node)
+	return 0, typ
+}
+
+var watOperators = map[string]string{
+	"+": "i32.add", "-": "i32.sub", "*": "i32.mul", "/": "i32.div_s", "rem": "i32.rem_s", "and": "i32.and", "or": "i32.or",
+}
+
+// watComparisons gives the signed and the unsigned comparison
+var watComparisons = map[string][2]string{
+	"=": {"i32.eq", "i32.eq"}, "/=": {"i32.ne", "i32.ne"}, "!=": {"i32.ne", "i32.ne"},
+	"<": {"i32.lt_s", "i32.lt_u"}, "<=": {"i32.le_s", "i32.le_u"}, ">": {"i32.gt_s", "i32.gt_u"}, ">=": {"i32.ge_s", "i32.ge_u"},
+}
+
+// expression pushes the value of the node, a record is pushed as its address
+func (g *watGenerator) expression(node int) *adaType {
+	children := g.graph.GetChildren(node)
+	name := g.graph.GetNode(node)
+	if len(children) == 0 {
+		return g.leaf(node)
+	}
+
+	switch name {
+	case "access":
+		offset, typ := g.address(node)
+		g.load(offset, typ)
+		return typ
+	case "memory":
+		record := g.lookupType(g.current, children[1])
+		g.emit("global.get $hp", "global.get $hp", "i32.const "+strconv.Itoa(watSize(record)), "i32.add", "global.set $hp")
+		return &adaType{kind: accessKind, target: record}
+	case "attribute":
+		return g.attribute(node)
+	case "call":
+		return g.call(node, false)
+	case "and then":
+		g.expression(children[0])
+		g.emit("if (result i32)")
+		g.expression(children[1])
+		g.emit("else", "i32.const 0", "end")
+		return booleanType
+	case "or else":
+		g.expression(children[0])
+		g.emit("if (result i32)", "i32.const 1", "else")
+		g.expression(children[1])
+		g.emit("end")
+		return booleanType
+	}
+
+	if comparisons, ok := watComparisons[name]; ok {
+		left := g.expression(children[0])
+		right := g.expression(children[1])
+		typ := left
+		if typ == nullType {
+			typ = right
+		}
+		if typ.kind == recordKind || typ.kind == stringKind {
+			if typ.kind == recordKind {
+				g.equalRecords(typ)
+			} else {
+				g.emit("call $gada_string_eq")
+			}
+			if name != "=" {
+				g.emit("i32.eqz")
+			}
+			return booleanType
+		}
+		if typ.kind == integerKind {
+			g.emit(comparisons[0])
+		} else {
+			g.emit(comparisons[1])
+		}
+		return booleanType
+	}
+	op, ok := watOperators[name]
+	if !ok || len(children) != 2 {
+		g.fail(node, "unsupported expression %s", name)
+		g.emit("i32.const 0")
+		return integerType
+	}
+	typ := g.expression(children[0])
+	g.expression(children[1])
+	g.emit(op)
+	return typ
+}
+
+// attribute pushes the value of Prefix'Name, a character stays between 0 and 255
+func (g *watGenerator) attribute(node int) *adaType {
+	name, prefix, args := g.program.attribute(node)
+	if name == "length" {
+		g.expression(prefix)
+		g.emit("call $gada_length")
+		return integerType
+	}
+	typ := g.lookupType(g.current, prefix)
+	first, last := discreteBounds(g.graph.GetNode(prefix))
+	switch name {
+	case "first":
+		g.emit("i32.const " + strconv.Itoa(first))
+		return typ
+	case "last":
+		g.emit("i32.const " + strconv.Itoa(last))
+		return typ
+	case "image":
+		if typ.kind == booleanKind {
+			g.emit("i32.const "+strconv.Itoa(g.stringConstant("TRUE")), "i32.const "+strconv.Itoa(g.stringConstant("FALSE")))
+			g.expression(args[0])
+			g.emit("select")
+			return stringType
+		}
+		g.expression(args[0])
+		if typ.kind == characterKind {
+			g.emit("call $gada_char_image")
+		} else {
+			g.emit("call $gada_int_image")
+		}
+		return stringType
+	}
+
+	g.expression(args[0])
+	switch name {
+	case "pos":
+		return integerType
+	case "succ":
+		g.emit("i32.const 1", "i32.add")
+	case "pred":
+		g.emit("i32.const 1", "i32.sub")
+	}
+	if typ.kind == characterKind {
+		g.emit("i32.const 255", "i32.and")
+	}
+	return typ
+}
+
+// watStrings returns the offsets of the strings of a record with the strings of its nested records
+func watStrings(t *adaType, offset int, texts map[int]bool) map[int]bool {
+	for _, field := range t.fields {
+		switch field.typ.kind {
+		case recordKind:
+			watStrings(field.typ, offset, texts)
+		case stringKind:
+			texts[offset] = true
+		}
+		offset += watSize(field.typ)
+	}
+	return texts
+}
+
+// equalRecords compares the two records whose addresses are on the stack word by word,
+// the strings are compared character by character
+func (g *watGenerator) equalRecords(t *adaType) {
+	right := g.local("right")
+	left := g.local("left")
+	g.emit("local.set "+right, "local.set "+left, "i32.const 1")
+	texts := watStrings(t, 0, map[int]bool{})
+	for offset := 0; offset < watSize(t); offset += 4 {
+		compare := "i32.eq"
+		if texts[offset] {
+			compare = "call $gada_string_eq"
+		}
+		g.emit("local.get "+left, "i32.load offset="+strconv.Itoa(offset), "local.get "+right, "i32.load offset="+strconv.Itoa(offset), compare, "i32.and")
+	}
+}
+
+// load replaces the base address on the stack by the value, the address of a record is kept
+func (g *watGenerator) load(offset int, t *adaType) {
+	if t.kind == recordKind {
+		if offset != 0 {
+			g.emit("i32.const "+strconv.Itoa(offset), "i32.add")
+		}
+		return
+	}
+	g.emit("i32.load offset=" + strconv.Itoa(offset))
+}
+
+func (g *watGenerator) leaf(node int) *adaType {
+	name := g.graph.GetNode(node)
+	switch {
+	case name == "true":
+		g.emit("i32.const 1")
+		return booleanType
+	case name == "false":
+		g.emit("i32.const 0")
+		return booleanType
+	case name == "null":
+		g.emit("i32.const 0")
+		return nullType
+	case name[0] == '\'':
+		char := []rune(g.graph.GetRealNode(node))
+		g.emit("i32.const " + strconv.Itoa(int(char[1])&255))
+		return characterType
+	case name[0] == '"':
+		g.emit("i32.const " + strconv.Itoa(g.stringConstant(unquoteString(g.graph.GetRealNode(node)))))
+		return stringType
+	case name[0] >= '0' && name[0] <= '9':
+		// Integers wrap around like on ARM
+		value, err := strconv.ParseInt(strings.ReplaceAll(name, "_", ""), 10, 64)
+		if err != nil {
+			g.fail(node, "invalid integer %s", name)
+		}
+		g.emit("i32.const " + strconv.Itoa(int(int32(value))))
+		return integerType
+	}
+
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].variable.name == cIdentifier(name) {
+			g.emit("local.get " + g.loops[i].local)
+			return g.loops[i].variable.typ
+		}
+	}
+	variable, _, found := g.lookup(g.current, name)
+	switch {
+	case variable != nil:
+		offset, typ := g.address(node)
+		g.load(offset, typ)
+		return typ
+	case found:
+		// A function without parameters is called without parentheses
+		return g.call(node, false)
+	}
+	g.fail(node, "unknown identifier %s", name)
+	g.emit("i32.const 0")
+	return integerType
+}
+
+// stringConstant returns the address of a string literal: its length in a word followed by its
+// characters. The literals with the same value share one data segment.
+func (g *watGenerator) stringConstant(value string) int {
+	address, ok := g.constants[value]
+	if !ok {
+		address = (g.dataEnd + 3) &^ 3
+		g.constants[value] = address
+		g.dataEnd = address + 4 + len(value)
+		var text strings.Builder
+		length := uint32(len(value))
+		for i := 0; i < 4; i++ {
+			text.WriteString(fmt.Sprintf("\\%02x", byte(length>>(8*i))))
+		}
+		for i := 0; i < len(value); i++ {
+			c := value[i]
+			if c >= ' ' && c <= '~' && c != '"' && c != '\\' {
+				text.WriteByte(c)
+			} else {
+				text.WriteString(fmt.Sprintf("\\%02x", c))
+			}
+		}
+		g.data.WriteString("  (data (i32.const " + strconv.Itoa(address) + ") \"" + text.String() + "\")\n")
+	}
+	return address
+}
+
+// call writes a call node, or an identifier calling a function without parameters
+func (g *watGenerator) call(node int, statement bool) *adaType {
+	nameNode := node
+	var args []int
+	if g.graph.GetNode(node) == "call" {
+		children := g.graph.GetChildren(node)
+		nameNode = children[0]
+		switch g.graph.GetNode(nameNode) {
+		case "-":
+			g.emit("i32.const 0")
+			g.expression(children[1])
+			g.emit("i32.sub")
+			return integerType
+		case "not":
+			g.expression(children[1])
+			g.emit("i32.eqz")
+			return booleanType
+		}
+		if len(children) > 1 {
+			args = g.graph.GetChildren(children[1])
+		}
+	}
+
+	name := g.graph.GetNode(nameNode)
+	callee := g.resolve(g.current, nameNode, len(args))
+	if callee == nil {
+		switch {
+		case name == "new_line" && len(args) == 0:
+			g.emit("call $gada_new_line")
+			return nil
+		case name == "put" && len(args) == 1:
+			switch g.expression(args[0]).kind {
+			case characterKind:
+				g.emit("call $gada_put_char")
+			case stringKind:
+				g.emit("call $gada_put_string")
+			default:
+				g.emit("call $gada_put_int")
+			}
+			return nil
+		case name == "put_line" && len(args) == 1:
+			g.expression(args[0])
+			g.emit("call $gada_put_string", "call $gada_new_line")
+			return nil
+		}
+		g.fail(node, "unknown subprogram %s", name)
+		g.emit("i32.const 0")
+		return integerType
+	}
+	if !statement && callee.returnType == nil {
+		g.fail(node, "%s is a procedure", name)
+		return integerType
+	}
+
+	if callee.hasLink() {
+		distance := 0
+		for s := g.current; s != nil && s != callee.parent; s = s.parent {
+			distance++
+		}
+		g.frame(distance)
+	}
+	for i, arg := range args {
+		if callee.params[i].byReference {
+			offset, _ := g.address(arg)
+			if offset != 0 {
+				g.emit("i32.const "+strconv.Itoa(offset), "i32.add")
+			}
+			continue
+		}
+		g.expression(arg)
+	}
+	if callee.returnType != nil && callee.returnType.kind == recordKind {
+		g.temporary(callee.returnType)
+	}
+	g.emit("call $" + callee.symbol)
+	if statement && callee.returnType != nil {
+		g.emit("drop")
+	}
+	return callee.returnType
+}
@@ -0,0 +1,1136 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// x86Address is a memory operand: a base register plus an offset
+type x86Address struct {
+	base   string
+	offset int
+}
+
+func (a x86Address) String() string {
+	switch {
+	case a.offset < 0:
+		return "[" + a.base + " - " + strconv.Itoa(-a.offset) + "]"
+	case a.offset > 0:
+		return "[" + a.base + " + " + strconv.Itoa(a.offset) + "]"
+	}
+	return "[" + a.base + "]"
+}
+
+func (a x86Address) plus(offset int) x86Address {
+	return x86Address{base: a.base, offset: a.offset + offset}
+}
+
+type x86Loop struct {
+	variable *adaVariable
+	address  x86Address
+}
+
+// x86Frame is the layout of the frame of a subprogram below rbp: the static link at rbp - 8, the
+// address of the returned record then the parameters and the variables. The temporaries come after them.
+type x86Frame struct {
+	slots  map[*adaVariable]int
+	result int
+	size   int
+}
+
+type x86Generator struct {
+	*program
+	frames map[*adaSubprogram]*x86Frame
+	// current is the subprogram being written and loops its loop variables
+	current *adaSubprogram
+	loops   []x86Loop
+	// size is the size of the frame of the current subprogram with its temporaries
+	size   int
+	body   strings.Builder
+	labels int
+	out    strings.Builder
+	// constants maps the string literals to their labels, written after the functions
+	constants map[string]string
+	data      strings.Builder
+}
+
+// x86Arguments are the registers of the integer arguments in the System V calling convention
+var x86Arguments = []string{"rdi", "rsi", "rdx", "rcx", "r8", "r9"}
+
+// x86Pointers are the size prefixes of the memory operands, x86Scratch the parts of rcx holding copied bytes
+var (
+	x86Pointers = map[int]string{1: "byte ptr ", 2: "word ptr ", 4: "dword ptr ", 8: "qword ptr "}
+	x86Scratch  = map[int]string{1: "cl", 2: "cx", 4: "ecx", 8: "rcx"}
+)
+
+// x86Runtime defines Put, New_Line, the images and the allocator with Linux system calls. The output
+// is buffered and written on New_Line, when the buffer is full and at the end of the program.
+// A string is the address of its length, a double word followed by its characters.
+const x86Runtime = `
+        .text
+        .globl  _start
+_start:
+        call    %s
+        call    gada_flush
+        mov     eax, 60
+        xor     edi, edi
+        syscall
+
+gada_put_char:
+        mov     rax, qword ptr [rip + gada_out_length]
+        lea     rcx, [rip + gada_out]
+        mov     byte ptr [rcx + rax], dil
+        inc     rax
+        mov     qword ptr [rip + gada_out_length], rax
+        cmp     rax, 4096
+        je      gada_flush
+        ret
+
+gada_new_line:
+        mov     edi, 10
+        call    gada_put_char
+        jmp     gada_flush
+
+gada_flush:
+        lea     rsi, [rip + gada_out]
+        mov     rdx, qword ptr [rip + gada_out_length]
+1:      test    rdx, rdx
+        jle     2f
+        mov     eax, 1
+        mov     edi, 1
+        syscall
+        test    rax, rax
+        jle     2f
+        add     rsi, rax
+        sub     rdx, rax
+        jmp     1b
+2:      mov     qword ptr [rip + gada_out_length], 0
+        ret
+
+gada_put_string:
+        push    rbx
+        push    r12
+        test    rdi, rdi
+        je      2f
+        mov     r12d, dword ptr [rdi]
+        lea     rbx, [rdi + 4]
+1:      test    r12d, r12d
+        jle     2f
+        movzx   edi, byte ptr [rbx]
+        call    gada_put_char
+        inc     rbx
+        dec     r12d
+        jmp     1b
+2:      pop     r12
+        pop     rbx
+        ret
+
+# gada_decimal writes the digits of edi before rsi, rax is the first character. r9 is kept.
+gada_decimal:
+        movsxd  rax, edi
+        mov     r8, rax
+        test    rax, rax
+        jns     1f
+        neg     rax
+1:      mov     ecx, 10
+2:      xor     edx, edx
+        div     rcx
+        add     dl, 48
+        dec     rsi
+        mov     byte ptr [rsi], dl
+        test    rax, rax
+        jne     2b
+        test    r8, r8
+        jns     3f
+        dec     rsi
+        mov     byte ptr [rsi], 45
+3:      mov     rax, rsi
+        ret
+
+# gada_text stores the length of the characters from rax to r9 before them, rax is the string
+gada_text:
+        mov     rcx, r9
+        sub     rcx, rax
+        sub     rax, 4
+        mov     dword ptr [rax], ecx
+        ret
+
+gada_put_int:
+        sub     rsp, 24
+        lea     r9, [rsp + 16]
+        mov     rsi, r9
+        call    gada_decimal
+        call    gada_text
+        mov     rdi, rax
+        call    gada_put_string
+        add     rsp, 24
+        ret
+
+gada_int_image:
+        push    rdi
+        mov     edi, 16
+        call    gada_alloc
+        pop     rdi
+        lea     r9, [rax + 16]
+        mov     rsi, r9
+        call    gada_decimal
+        test    edi, edi
+        js      gada_text
+        dec     rax
+        mov     byte ptr [rax], 32
+        jmp     gada_text
+
+gada_char_image:
+        push    rdi
+        mov     edi, 8
+        call    gada_alloc
+        pop     rdi
+        mov     dword ptr [rax], 3
+        mov     byte ptr [rax + 4], 39
+        mov     byte ptr [rax + 5], dil
+        mov     byte ptr [rax + 6], 39
+        ret
+
+gada_length:
+        xor     eax, eax
+        test    rdi, rdi
+        je      1f
+        mov     eax, dword ptr [rdi]
+1:      ret
+
+# gada_string_eq compares the characters of the strings rdi and rsi, eax is 1 when they are equal
+gada_string_eq:
+        xor     eax, eax
+        xor     ecx, ecx
+        test    rdi, rdi
+        je      1f
+        mov     eax, dword ptr [rdi]
+        add     rdi, 4
+1:      test    rsi, rsi
+        je      2f
+        mov     ecx, dword ptr [rsi]
+        add     rsi, 4
+2:      cmp     eax, ecx
+        jne     4f
+3:      test    ecx, ecx
+        je      5f
+        mov     dl, byte ptr [rdi]
+        cmp     dl, byte ptr [rsi]
+        jne     4f
+        inc     rdi
+        inc     rsi
+        dec     ecx
+        jmp     3b
+4:      xor     eax, eax
+        ret
+5:      mov     eax, 1
+        ret
+
+# gada_alloc returns rdi zeroed bytes of the heap, the memory is never freed
+gada_alloc:
+        add     rdi, 7
+        and     rdi, -8
+        mov     rax, qword ptr [rip + gada_heap_next]
+        test    rax, rax
+        jne     1f
+        lea     rax, [rip + gada_heap]
+1:      lea     rdx, [rax + rdi]
+        lea     rcx, [rip + gada_heap_end]
+        cmp     rdx, rcx
+        ja      2f
+        mov     qword ptr [rip + gada_heap_next], rdx
+        ret
+2:      call    gada_flush
+        mov     eax, 1
+        mov     edi, 2
+        lea     rsi, [rip + gada_out_of_memory]
+        mov     edx, 14
+        syscall
+        mov     eax, 60
+        mov     edi, 1
+        syscall
+
+        .section .rodata
+gada_out_of_memory:
+        .ascii  "out of memory\n"
+        .balign 4
+gada_true:
+        .long   4
+        .ascii  "TRUE"
+        .balign 4
+gada_false:
+        .long   5
+        .ascii  "FALSE"
+
+        .bss
+        .balign 8
+gada_out_length:
+        .zero   8
+gada_heap_next:
+        .zero   8
+gada_out:
+        .zero   4096
+gada_heap:
+        .zero   67108864
+gada_heap_end:
+`
+
+// GenerateX86 translates the program to x86-64 assembly for Linux in the Intel syntax of the GNU
+// assembler. The subprograms follow the System V calling convention, a nested subprogram receives the
+// frame pointer of its parent in r10 like the static chain of GCC. The runtime uses system calls only.
+func GenerateX86(graph Graph) (string, error) {
+	p, err := newProgram(graph)
+	if err != nil {
+		return "", err
+	}
+	g := x86Generator{program: p, frames: map[*adaSubprogram]*x86Frame{}, constants: map[string]string{}}
+	for _, sub := range g.subprograms {
+		g.frames[sub] = x86Layout(sub)
+	}
+
+	g.out.WriteString("# " + graph.fileName + "\n        .intel_syntax noprefix\n")
+	g.out.WriteString(fmt.Sprintf(x86Runtime, x86Symbol(g.main)))
+	g.out.WriteString("\n        .text\n")
+	for _, sub := range g.subprograms {
+		g.writeSubprogram(sub)
+	}
+	if g.data.Len() > 0 {
+		g.out.WriteString("\n        .section .rodata\n" + g.data.String())
+	}
+	if g.err != nil {
+		return "", g.err
+	}
+	return g.out.String(), nil
+}
+
+// x86Symbol returns the label of a subprogram, the prefix keeps the names of the registers free
+func x86Symbol(sub *adaSubprogram) string {
+	return "ada_" + sub.symbol
+}
+
+// x86Size returns the size and the alignment of a type, records are laid out like C structs
+func x86Size(t *adaType) (size int, align int) {
+	switch t.kind {
+	case integerKind:
+		return 4, 4
+	case characterKind, booleanKind:
+		return 1, 1
+	case recordKind:
+		align = 1
+		for _, field := range t.fields {
+			fieldSize, fieldAlign := x86Size(field.typ)
+			size = x86Round(size, fieldAlign) + fieldSize
+			if fieldAlign > align {
+				align = fieldAlign
+			}
+		}
+		return x86Round(size, align), align
+	}
+	return 8, 8
+}
+
+// x86Round rounds the size up to a multiple of the alignment
+func x86Round(size int, align int) int {
+	return (size + align - 1) / align * align
+}
+
+// x86Slot returns the size of a slot holding the type, a multiple of 8 bytes
+func x86Slot(t *adaType) int {
+	size, _ := x86Size(t)
+	if size == 0 {
+		return 8
+	}
+	return x86Round(size, 8)
+}
+
+// x86Field returns the offset and the type of a field of a record
+func x86Field(t *adaType, name string) (int, *adaType, bool) {
+	offset := 0
+	for _, field := range t.fields {
+		size, align := x86Size(field.typ)
+		offset = x86Round(offset, align)
+		if field.name == name {
+			return offset, field.typ, true
+		}
+		offset += size
+	}
+	return 0, nil, false
+}
+
+// x86Scalar is a field of a record that is not a record
+type x86Scalar struct {
+	offset int
+	size   int
+	// text is true for a string, its characters are compared
+	text bool
+}
+
+// x86Scalars returns the fields of a record with the fields of its nested records
+func x86Scalars(t *adaType, offset int, scalars []x86Scalar) []x86Scalar {
+	for _, field := range t.fields {
+		fieldOffset, _, _ := x86Field(t, field.name)
+		if field.typ.kind == recordKind {
+			scalars = x86Scalars(field.typ, offset+fieldOffset, scalars)
+			continue
+		}
+		size, _ := x86Size(field.typ)
+		scalars = append(scalars, x86Scalar{offset: offset + fieldOffset, size: size, text: field.typ.kind == stringKind})
+	}
+	return scalars
+}
+
+// x86InMemory reports whether a returned record does not fit in rax and rdx, the caller passes its address in rdi
+func x86InMemory(t *adaType) bool {
+	return t != nil && t.kind == recordKind && x86Slot(t) > 16
+}
+
+// x86ParamSize returns the size of a parameter in the argument registers or on the stack
+func x86ParamSize(param *adaVariable) int {
+	if param.byReference {
+		return 8
+	}
+	return x86Slot(param.typ)
+}
+
+// x86Registers returns the first argument register of each parameter, -1 when it is on the stack.
+// A parameter larger than 16 bytes or without enough registers left is passed on the stack.
+func x86Registers(sub *adaSubprogram) []int {
+	next := 0
+	if x86InMemory(sub.returnType) {
+		next++
+	}
+	registers := make([]int, len(sub.params))
+	for i, param := range sub.params {
+		count := x86ParamSize(param) / 8
+		if count > 2 || next+count > len(x86Arguments) {
+			registers[i] = -1
+			continue
+		}
+		registers[i] = next
+		next += count
+	}
+	return registers
+}
+
+func x86Layout(sub *adaSubprogram) *x86Frame {
+	frame := &x86Frame{slots: map[*adaVariable]int{}}
+	if sub.hasLink() {
+		frame.size = 8
+	}
+	if x86InMemory(sub.returnType) {
+		frame.size += 8
+		frame.result = -frame.size
+	}
+	for _, variable := range frameVariables(sub) {
+		if variable.byReference {
+			frame.size += 8
+		} else {
+			frame.size += x86Slot(variable.typ)
+		}
+		frame.slots[variable] = -frame.size
+	}
+	return frame
+}
+
+func (g *x86Generator) emit(format string, args ...any) {
+	g.body.WriteString("        " + fmt.Sprintf(format, args...) + "\n")
+}
+
+func (g *x86Generator) label() string {
+	g.labels++
+	return ".L" + strconv.Itoa(g.labels)
+}
+
+func (g *x86Generator) startLabel(label string) {
+	g.body.WriteString(label + ":\n")
+}
+
+// allocate returns a temporary of the current frame
+func (g *x86Generator) allocate(size int) x86Address {
+	g.size += x86Round(size, 8)
+	return x86Address{base: "rbp", offset: -g.size}
+}
+
+func (g *x86Generator) writeSubprogram(sub *adaSubprogram) {
+	g.current = sub
+	g.loops = nil
+	g.body.Reset()
+	frame := g.frames[sub]
+	g.size = frame.size
+
+	if sub.hasLink() {
+		g.emit("mov qword ptr [rbp - 8], r10")
+	}
+	if x86InMemory(sub.returnType) {
+		g.emit("mov qword ptr %s, rdi", x86Address{base: "rbp", offset: frame.result})
+	}
+	stack := 16
+	for i, register := range x86Registers(sub) {
+		param := sub.params[i]
+		slot := x86Address{base: "rbp", offset: frame.slots[param]}
+		size := x86ParamSize(param)
+		for offset := 0; offset < size; offset += 8 {
+			if register >= 0 {
+				g.emit("mov qword ptr %s, %s", slot.plus(offset), x86Arguments[register+offset/8])
+				continue
+			}
+			g.emit("mov rax, qword ptr %s", x86Address{base: "rbp", offset: stack + offset})
+			g.emit("mov qword ptr %s, rax", slot.plus(offset))
+		}
+		if register < 0 {
+			stack += size
+		}
+	}
+	for _, init := range sub.inits {
+		for _, variable := range init.variables {
+			g.expression(init.value)
+			g.store(x86Address{base: "rbp", offset: frame.slots[variable]}, variable.typ)
+		}
+	}
+	g.statements(sub.body)
+	if sub.returnType == nil {
+		g.emit("leave")
+		g.emit("ret")
+	} else {
+		// The end of a function is not reachable
+		g.emit("ud2")
+	}
+
+	g.out.WriteString("\n" + x86Symbol(sub) + ":\n")
+	g.out.WriteString("        push    rbp\n        mov     rbp, rsp\n")
+	if size := x86Round(g.size, 16); size > 0 {
+		g.out.WriteString("        sub     rsp, " + strconv.Itoa(size) + "\n")
+	}
+	// The variables start at zero, the temporaries are written before being read
+	if frame.size > 0 {
+		g.out.WriteString("        lea     r11, [rbp - " + strconv.Itoa(frame.size) + "]\n")
+		g.out.WriteString("1:      mov     qword ptr [r11], 0\n        add     r11, 8\n        cmp     r11, rbp\n        jb      1b\n")
+	}
+	g.out.WriteString(g.body.String())
+}
+
+// load puts the value at the address in rax, a record is represented by its address
+func (g *x86Generator) load(address x86Address, typ *adaType) *adaType {
+	switch typ.kind {
+	case integerKind:
+		g.emit("mov eax, dword ptr %s", address)
+	case characterKind, booleanKind:
+		g.emit("movzx eax, byte ptr %s", address)
+	case recordKind:
+		g.emit("lea rax, %s", address)
+	default:
+		g.emit("mov rax, qword ptr %s", address)
+	}
+	return typ
+}
+
+// store writes the value of rax at the address, a record is copied from the address in rax
+func (g *x86Generator) store(address x86Address, typ *adaType) {
+	switch typ.kind {
+	case integerKind:
+		g.emit("mov dword ptr %s, eax", address)
+	case characterKind, booleanKind:
+		g.emit("mov byte ptr %s, al", address)
+	case recordKind:
+		size, _ := x86Size(typ)
+		g.copy(address, x86Address{base: "rax"}, size)
+	default:
+		g.emit("mov qword ptr %s, rax", address)
+	}
+}
+
+// copy copies the bytes of a record through rcx, the addresses must not use rcx
+func (g *x86Generator) copy(to x86Address, from x86Address, size int) {
+	for offset := 0; offset < size; {
+		chunk := 8
+		for chunk > size-offset {
+			chunk /= 2
+		}
+		g.emit("mov %s, %s%s", x86Scratch[chunk], x86Pointers[chunk], from.plus(offset))
+		g.emit("mov %s%s, %s", x86Pointers[chunk], to.plus(offset), x86Scratch[chunk])
+		offset += chunk
+	}
+}
+
+// frame follows the static links from the current subprogram and loads the frame pointer of the
+// subprogram at the given distance in the register
+func (g *x86Generator) frame(register string, distance int) *adaSubprogram {
+	sub := g.current
+	g.emit("mov %s, rbp", register)
+	for i := 0; i < distance; i++ {
+		g.emit("mov %s, qword ptr [%s - 8]", register, register)
+		sub = sub.parent
+	}
+	return sub
+}
+
+func (g *x86Generator) statements(node int) {
+	for _, child := range g.graph.GetChildren(node) {
+		g.statement(child)
+	}
+}
+
+func (g *x86Generator) statement(node int) {
+	g.graph.visit(node)
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		if g.graph.GetNode(node) == "return" {
+			g.emit("leave")
+			g.emit("ret")
+		} else {
+			// A procedure called without parameters
+			g.call(node, true)
+		}
+		return
+	}
+	switch g.graph.GetNode(node) {
+	case ":=":
+		g.expression(children[1])
+		address, typ := g.address(children[0])
+		g.store(address, typ)
+	case "call":
+		g.call(node, true)
+	case "return":
+		g.expression(children[0])
+		g.ret(g.current.returnType)
+	case "if":
+		end := g.label()
+		g.branches(children, end)
+		g.startLabel(end)
+	case "while":
+		condition, end := g.label(), g.label()
+		g.startLabel(condition)
+		g.expression(children[0])
+		g.emit("test eax, eax")
+		g.emit("je %s", end)
+		g.statements(children[1])
+		g.emit("jmp %s", condition)
+		g.startLabel(end)
+	case "for":
+		g.forLoop(children)
+	default:
+		g.fail(node, "unsupported statement %s", g.graph.GetNode(node))
+	}
+}
+
+// ret returns the value of rax, a record is returned in rax and rdx or copied to the address given by the caller
+func (g *x86Generator) ret(typ *adaType) {
+	switch {
+	case x86InMemory(typ):
+		size, _ := x86Size(typ)
+		g.emit("mov rdx, qword ptr %s", x86Address{base: "rbp", offset: g.frames[g.current].result})
+		g.copy(x86Address{base: "rdx"}, x86Address{base: "rax"}, size)
+		g.emit("mov rax, rdx")
+	case typ.kind == recordKind:
+		size, _ := x86Size(typ)
+		temp := g.allocate(size)
+		g.copy(temp, x86Address{base: "rax"}, size)
+		g.emit("mov rax, qword ptr %s", temp)
+		if size > 8 {
+			g.emit("mov rdx, qword ptr %s", temp.plus(8))
+		}
+	}
+	g.emit("leave")
+	g.emit("ret")
+}
+
+// branches writes an if statement, children are the condition, the body then the elsif and else nodes
+func (g *x86Generator) branches(children []int, end string) {
+	next := g.label()
+	g.expression(children[0])
+	g.emit("test eax, eax")
+	g.emit("je %s", next)
+	g.statements(children[1])
+	g.emit("jmp %s", end)
+	g.startLabel(next)
+	if len(children) < 3 {
+		return
+	}
+	if g.graph.GetNode(children[2]) == "elif" {
+		g.branches(append(g.graph.GetChildren(children[2]), children[3:]...), end)
+		return
+	}
+	g.statements(children[2])
+}
+
+// forLoop evaluates the bounds once and stops on the last value so that it cannot overflow
+func (g *x86Generator) forLoop(children []int) {
+	typ := g.expression(children[2])
+	first := g.allocate(8)
+	g.store(first, typ)
+	g.expression(children[3])
+	last := g.allocate(8)
+	g.store(last, typ)
+	step, stop := "add", "jg"
+	if typ.kind != integerKind {
+		stop = "ja"
+	}
+	if g.graph.GetNode(children[1]) == "reverse" {
+		first, last = last, first
+		step, stop = "sub", "jl"
+		if typ.kind != integerKind {
+			stop = "jb"
+		}
+	}
+
+	// The first bound becomes the loop variable
+	variable := &adaVariable{name: cIdentifier(g.graph.GetNode(children[0])), typ: typ}
+	body, end := g.label(), g.label()
+	g.load(first, typ)
+	g.emit("mov ecx, eax")
+	g.load(last, typ)
+	g.emit("cmp ecx, eax")
+	g.emit("%s %s", stop, end)
+	g.startLabel(body)
+	g.loops = append(g.loops, x86Loop{variable: variable, address: first})
+	g.statements(children[4])
+	g.loops = g.loops[:len(g.loops)-1]
+	g.load(last, typ)
+	g.emit("mov ecx, eax")
+	g.load(first, typ)
+	g.emit("cmp eax, ecx")
+	g.emit("je %s", end)
+	g.emit("%s eax, 1", step)
+	g.store(first, typ)
+	g.emit("jmp %s", body)
+	g.startLabel(end)
+}
+
+// address returns the address of a variable or of a field, a value that is not a variable is copied
+// to a temporary. Only rdx and r11 are used to reach a variable, rax is kept.
+func (g *x86Generator) address(node int) (x86Address, *adaType) {
+	children := g.graph.GetChildren(node)
+	if len(children) == 0 {
+		name := g.graph.GetNode(node)
+		for i := len(g.loops) - 1; i >= 0; i-- {
+			if g.loops[i].variable.name == cIdentifier(name) {
+				return g.loops[i].address, g.loops[i].variable.typ
+			}
+		}
+		if variable, distance, _ := g.lookup(g.current, name); variable != nil {
+			base := "rbp"
+			sub := g.current
+			if distance > 0 {
+				base = "r11"
+				sub = g.frame(base, distance)
+			}
+			address := x86Address{base: base, offset: g.frames[sub].slots[variable]}
+			if variable.byReference {
+				g.emit("mov rdx, qword ptr %s", address)
+				address = x86Address{base: "rdx"}
+			}
+			return address, variable.typ
+		}
+	}
+	if g.graph.GetNode(node) == "access" && len(children) == 2 {
+		// The fields are nested on the right: E.Next.Prev is access(E, access(Next, Prev))
+		address, typ := g.address(children[0])
+		for _, field := range g.fields(children[1]) {
+			address, typ = g.field(field, address, typ)
+		}
+		return address, typ
+	}
+
+	typ := g.expression(node)
+	if typ.kind == recordKind {
+		g.emit("mov rdx, rax")
+		return x86Address{base: "rdx"}, typ
+	}
+	temp := g.allocate(8)
+	g.store(temp, typ)
+	return temp, typ
+}
+
+// field returns the address of a field of the record at the address, an access value is followed
+func (g *x86Generator) field(node int, address x86Address, typ *adaType) (x86Address, *adaType) {
+	if typ.kind == accessKind && typ.target != nil {
+		g.emit("mov rdx, qword ptr %s", address)
+		address = x86Address{base: "rdx"}
+		typ = typ.target
+	}
+	name := cIdentifier(g.graph.GetNode(node))
+	offset, fieldType, ok := x86Field(typ, name)
+	if !ok {
+		g.fail(node, "unknown field %s", name)
+		return address, integerType
+	}
+	return address.plus(offset), fieldType
+}
+
+var x86Operators = map[string]string{
+	"+": "add eax, ecx", "-": "sub eax, ecx", "*": "imul eax, ecx", "/": "cdq\n        idiv ecx",
+	"rem": "cdq\n        idiv ecx\n        mov eax, edx", "and": "and eax, ecx", "or": "or eax, ecx",
+}
+
+// x86Comparisons gives the signed and the unsigned condition of the comparisons
+var x86Comparisons = map[string][2]string{
+	"=": {"e", "e"}, "/=": {"ne", "ne"}, "!=": {"ne", "ne"},
+	"<": {"l", "b"}, "<=": {"le", "be"}, ">": {"g", "a"}, ">=": {"ge", "ae"},
+}
+
+// expression puts the value of the node in rax and returns its type. The intermediate values are kept
+// in temporaries of the frame, so rsp stays aligned for the calls.
+func (g *x86Generator) expression(node int) *adaType {
+	children := g.graph.GetChildren(node)
+	name := g.graph.GetNode(node)
+	if len(children) == 0 {
+		return g.leaf(node)
+	}
+
+	switch name {
+	case "access":
+		address, typ := g.address(node)
+		return g.load(address, typ)
+	case "memory":
+		record := g.lookupType(g.current, children[1])
+		size, _ := x86Size(record)
+		if size == 0 {
+			size = 1
+		}
+		g.emit("mov edi, %d", size)
+		g.emit("call gada_alloc")
+		return &adaType{kind: accessKind, target: record}
+	case "attribute":
+		return g.attribute(node)
+	case "call":
+		return g.call(node, false)
+	case "and then", "or else":
+		end := g.label()
+		g.expression(children[0])
+		g.emit("test eax, eax")
+		if name == "and then" {
+			g.emit("je %s", end)
+		} else {
+			g.emit("jne %s", end)
+		}
+		g.expression(children[1])
+		g.startLabel(end)
+		return booleanType
+	}
+
+	conditions, comparison := x86Comparisons[name]
+	op, ok := x86Operators[name]
+	if (!comparison && !ok) || len(children) != 2 {
+		g.fail(node, "unsupported expression %s", name)
+		return integerType
+	}
+	left := g.expression(children[0])
+	temp := g.allocate(8)
+	if left.kind == recordKind {
+		temp = g.allocate(x86Slot(left))
+	}
+	g.store(temp, left)
+	right := g.expression(children[1])
+	g.emit("mov rcx, rax")
+	if comparison {
+		return g.compare(name, conditions, temp, left, right)
+	}
+	g.emit("mov eax, dword ptr %s", temp)
+	g.emit(op)
+	return left
+}
+
+// compare compares the left value in the temporary with the right one in rcx, integers are signed while
+// characters and booleans are not. Records are compared field by field and their strings character by character.
+func (g *x86Generator) compare(name string, conditions [2]string, left x86Address, typ *adaType, right *adaType) *adaType {
+	if typ == nullType {
+		typ = right
+	}
+	switch typ.kind {
+	case recordKind:
+		different, end := g.label(), g.label()
+		right := g.allocate(8)
+		g.emit("mov qword ptr %s, rcx", right)
+		for _, scalar := range x86Scalars(typ, 0, nil) {
+			if scalar.text {
+				g.emit("mov rdi, qword ptr %s", left.plus(scalar.offset))
+				g.emit("mov rsi, qword ptr %s", x86Address{base: "rcx", offset: scalar.offset})
+				g.emit("call gada_string_eq")
+				g.emit("mov rcx, qword ptr %s", right)
+				g.emit("test eax, eax")
+				g.emit("je %s", different)
+				continue
+			}
+			register := map[int]string{1: "al", 4: "eax", 8: "rax"}[scalar.size]
+			g.emit("mov %s, %s%s", register, x86Pointers[scalar.size], left.plus(scalar.offset))
+			g.emit("cmp %s, %s%s", register, x86Pointers[scalar.size], x86Address{base: "rcx", offset: scalar.offset})
+			g.emit("jne %s", different)
+		}
+		g.emit("mov eax, 1")
+		g.emit("jmp %s", end)
+		g.startLabel(different)
+		g.emit("xor eax, eax")
+		g.startLabel(end)
+		if name != "=" {
+			g.emit("xor eax, 1")
+		}
+		return booleanType
+	case stringKind:
+		g.emit("mov rdi, qword ptr %s", left)
+		g.emit("mov rsi, rcx")
+		g.emit("call gada_string_eq")
+		if name != "=" {
+			g.emit("xor eax, 1")
+		}
+		return booleanType
+	case accessKind:
+		g.emit("cmp qword ptr %s, rcx", left)
+	default:
+		g.load(left, typ)
+		g.emit("cmp eax, ecx")
+	}
+	condition := conditions[0]
+	if typ.kind != integerKind {
+		condition = conditions[1]
+	}
+	g.emit("set%s al", condition)
+	g.emit("movzx eax, al")
+	return booleanType
+}
+
+// x86Bounds are the values of T'First and T'Last of the discrete types
+var x86Bounds = map[typeKind][2]int{
+	integerKind:   {-2147483648, 2147483647},
+	characterKind: {0, 255},
+	booleanKind:   {0, 1},
+}
+
+// attribute translates Prefix'Name, Image calls the runtime for integers and characters
+func (g *x86Generator) attribute(node int) *adaType {
+	name, prefix, args := g.program.attribute(node)
+	if name == "length" {
+		g.expression(prefix)
+		g.emit("mov rdi, rax")
+		g.emit("call gada_length")
+		return integerType
+	}
+	typ := g.lookupType(g.current, prefix)
+	switch name {
+	case "first":
+		g.emit("mov eax, %d", x86Bounds[typ.kind][0])
+		return typ
+	case "last":
+		g.emit("mov eax, %d", x86Bounds[typ.kind][1])
+		return typ
+	}
+
+	g.expression(args[0])
+	switch {
+	case name == "pos":
+		return integerType
+	case name == "val" || name == "succ" || name == "pred":
+		switch name {
+		case "succ":
+			g.emit("add eax, 1")
+		case "pred":
+			g.emit("sub eax, 1")
+		}
+		// The characters and the booleans wrap around
+		switch typ.kind {
+		case characterKind:
+			g.emit("movzx eax, al")
+		case booleanKind:
+			g.emit("and eax, 1")
+		}
+		return typ
+	case typ.kind == booleanKind:
+		g.emit("lea rcx, [rip + gada_true]")
+		g.emit("lea rdx, [rip + gada_false]")
+		g.emit("test eax, eax")
+		g.emit("mov rax, rdx")
+		g.emit("cmovne rax, rcx")
+	case typ.kind == characterKind:
+		g.emit("mov edi, eax")
+		g.emit("call gada_char_image")
+	default:
+		g.emit("mov edi, eax")
+		g.emit("call gada_int_image")
+	}
+	return stringType
+}
+
+func (g *x86Generator) leaf(node int) *adaType {
+	name := g.graph.GetNode(node)
+	switch {
+	case name == "true":
+		g.emit("mov eax, 1")
+		return booleanType
+	case name == "false":
+		g.emit("xor eax, eax")
+		return booleanType
+	case name == "null":
+		g.emit("xor eax, eax")
+		return nullType
+	case name[0] == '\'':
+		g.emit("mov eax, %d", g.graph.GetRealNode(node)[1])
+		return characterType
+	case name[0] == '"':
+		g.emit("lea rax, [rip + %s]", g.stringConstant(unquoteString(g.graph.GetRealNode(node))))
+		return stringType
+	case name[0] >= '0' && name[0] <= '9':
+		// Integers wrap around like on ARM
+		value, err := strconv.ParseInt(strings.ReplaceAll(name, "_", ""), 10, 64)
+		if err != nil {
+			g.fail(node, "invalid integer %s", name)
+		}
+		g.emit("mov eax, %d", int32(value))
+		return integerType
+	}
+
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].variable.name == cIdentifier(name) {
+			return g.load(g.loops[i].address, g.loops[i].variable.typ)
+		}
+	}
+	variable, _, found := g.lookup(g.current, name)
+	switch {
+	case variable != nil:
+		address, typ := g.address(node)
+		return g.load(address, typ)
+	case found:
+		// A function without parameters is called without parentheses
+		return g.call(node, false)
+	}
+	g.fail(node, "unknown identifier %s", name)
+	return integerType
+}
+
+// stringConstant returns the label of a string literal, its length followed by its characters. The
+// literals with the same value share one constant.
+func (g *x86Generator) stringConstant(value string) string {
+	label, ok := g.constants[value]
+	if !ok {
+		label = ".Lstr" + strconv.Itoa(len(g.constants))
+		g.constants[value] = label
+		g.data.WriteString("        .balign 4\n" + label + ":\n        .long   " + strconv.Itoa(len(value)) + "\n        .ascii  " + cString(value) + "\n")
+	}
+	return label
+}
+
+// call writes a call node, or an identifier calling a function without parameters
+func (g *x86Generator) call(node int, statement bool) *adaType {
+	nameNode := node
+	var args []int
+	if g.graph.GetNode(node) == "call" {
+		children := g.graph.GetChildren(node)
+		nameNode = children[0]
+		switch g.graph.GetNode(nameNode) {
+		case "-":
+			g.expression(children[1])
+			g.emit("neg eax")
+			return integerType
+		case "not":
+			g.expression(children[1])
+			g.emit("xor eax, 1")
+			return booleanType
+		}
+		if len(children) > 1 {
+			args = g.graph.GetChildren(children[1])
+		}
+	}
+
+	name := g.graph.GetNode(nameNode)
+	callee := g.resolve(g.current, nameNode, len(args))
+	if callee == nil {
+		switch {
+		case name == "new_line" && len(args) == 0:
+			g.emit("call gada_new_line")
+			return nil
+		case name == "put" && len(args) == 1:
+			switch g.expression(args[0]).kind {
+			case characterKind:
+				g.emit("mov edi, eax")
+				g.emit("call gada_put_char")
+			case stringKind:
+				g.emit("mov rdi, rax")
+				g.emit("call gada_put_string")
+			default:
+				g.emit("mov edi, eax")
+				g.emit("call gada_put_int")
+			}
+			return nil
+		case name == "put_line" && len(args) == 1:
+			g.expression(args[0])
+			g.emit("mov rdi, rax")
+			g.emit("call gada_put_string")
+			g.emit("call gada_new_line")
+			return nil
+		}
+		g.fail(node, "unknown subprogram %s", name)
+		return integerType
+	}
+	if !statement && callee.returnType == nil {
+		g.fail(node, "%s is a procedure", name)
+		return integerType
+	}
+
+	// The arguments are evaluated in temporaries before the registers are loaded
+	var result x86Address
+	if x86InMemory(callee.returnType) {
+		result = g.allocate(x86Slot(callee.returnType))
+	}
+	temps := make([]x86Address, len(args))
+	for i, arg := range args {
+		param := callee.params[i]
+		temps[i] = g.allocate(x86ParamSize(param))
+		if param.byReference {
+			address, _ := g.address(arg)
+			g.emit("lea rax, %s", address)
+			g.emit("mov qword ptr %s, rax", temps[i])
+			continue
+		}
+		g.expression(arg)
+		g.store(temps[i], param.typ)
+	}
+
+	registers := x86Registers(callee)
+	stack := 0
+	for i, register := range registers {
+		if register < 0 {
+			stack += x86ParamSize(callee.params[i])
+		}
+	}
+	stack = x86Round(stack, 16)
+	if stack > 0 {
+		g.emit("sub rsp, %d", stack)
+	}
+	offset := 0
+	for i, register := range registers {
+		size := x86ParamSize(callee.params[i])
+		for word := 0; word < size; word += 8 {
+			if register >= 0 {
+				g.emit("mov %s, qword ptr %s", x86Arguments[register+word/8], temps[i].plus(word))
+				continue
+			}
+			g.emit("mov rax, qword ptr %s", temps[i].plus(word))
+			g.emit("mov qword ptr %s, rax", x86Address{base: "rsp", offset: offset + word})
+		}
+		if register < 0 {
+			offset += size
+		}
+	}
+	if x86InMemory(callee.returnType) {
+		g.emit("lea rdi, %s", result)
+	}
+	if callee.hasLink() {
+		distance := 0
+		for s := g.current; s != nil && s != callee.parent; s = s.parent {
+			distance++
+		}
+		g.frame("r10", distance)
+	}
+	g.emit("call %s", x86Symbol(callee))
+	if stack > 0 {
+		g.emit("add rsp, %d", stack)
+	}
+
+	if callee.returnType != nil && callee.returnType.kind == recordKind && !x86InMemory(callee.returnType) {
+		// The record returned in rax and rdx is kept in a temporary
+		temp := g.allocate(16)
+		g.emit("mov qword ptr %s, rax", temp)
+		g.emit("mov qword ptr %s, rdx", temp.plus(8))
+		g.emit("lea rax, %s", temp)
+	}
+	return callee.returnType
+}
@@ -0,0 +1,146 @@
+package reader
+
+import (
+	"fmt"
+	"gada/parser"
+	"gada/wasm"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	TargetX86 = "x86_64-linux"
+	TargetC   = "c"
+	// TargetWasm writes the WebAssembly text format, run in-process by RunWasm
+	TargetWasm = "wasm"
+	// EmitLLVM writes LLVM IR and builds it with llc for the host
+	EmitLLVM = "llvm"
+)
+
+// BuildFile compiles the file for the target of the config and returns the path of the executable.
+// The assembly and the executable are written in examples/<target>, or in examples/llvm when LLVM IR is emitted.
+// There is no executable for wasm, the path of the module is returned.
+func BuildFile(config CompileConfig) (output string, err error) {
+	defer func() { err = reportCrash(config, err) }()
+	l := readTokens(config.Path)
+	if l == nil {
+		return "", fmt.Errorf("no valid token in %s", config.Path)
+	}
+	name := strings.TrimSuffix(filepath.Base(config.Path), filepath.Ext(config.Path))
+	target := config.Target
+	switch config.Emit {
+	case "":
+	case EmitLLVM:
+		target = EmitLLVM
+	default:
+		return "", fmt.Errorf("unknown output %s", config.Emit)
+	}
+	folder := filepath.Join("examples", target)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", err
+	}
+	output = filepath.Join(folder, name)
+
+	switch target {
+	case EmitLLVM:
+		source, err := parser.CompileToLLVM(l)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(output+".ll", []byte(source), 0644); err != nil {
+			return "", err
+		}
+		return output, BuildLLVM(output+".ll", output)
+	case TargetWasm:
+		source, err := parser.CompileToWAT(l)
+		if err != nil {
+			return "", err
+		}
+		return output + ".wat", os.WriteFile(output+".wat", []byte(source), 0644)
+	case TargetC:
+		source, err := parser.CompileToC(l)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(output+".c", []byte(source), 0644); err != nil {
+			return "", err
+		}
+		return output, BuildC(output+".c", output)
+	case TargetX86:
+		source, err := parser.CompileToX86(l)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(output+".s", []byte(source), 0644); err != nil {
+			return "", err
+		}
+		return output, BuildX86(output+".s", output)
+	}
+	return "", fmt.Errorf("unknown target %s", target)
+}
+
+// BuildC compiles a C program with the local C compiler, the signed overflows wrap like on ARM.
+func BuildC(source string, output string) error {
+	out, err := exec.Command("cc", "-std=c99", "-O2", "-fwrapv", "-o", output, source).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cc failed: %s %w", out, err)
+	}
+	return nil
+}
+
+// BuildX86 assembles an x86-64 program with the GNU assembler and links it statically, it does not use the C library.
+func BuildX86(source string, output string) error {
+	object := strings.TrimSuffix(source, filepath.Ext(source)) + ".o"
+	out, err := exec.Command("as", "--64", "-o", object, source).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("as failed: %s %w", out, err)
+	}
+	defer os.Remove(object)
+	out, err = exec.Command("ld", "-static", "-o", output, object).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ld failed: %s %w", out, err)
+	}
+	return nil
+}
+
+// BuildLLVM compiles LLVM IR with llc and links it with the local C compiler.
+func BuildLLVM(source string, output string) error {
+	object := strings.TrimSuffix(source, filepath.Ext(source)) + ".o"
+	out, err := exec.Command("llc", "-O2", "-filetype=obj", "-relocation-model=pic", "-o", object, source).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("llc failed: %s %w", out, err)
+	}
+	defer os.Remove(object)
+	out, err = exec.Command("cc", "-o", output, object).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cc failed: %s %w", out, err)
+	}
+	return nil
+}
+
+// RunWasm compiles the file to WebAssembly and runs it in-process, the program writes to the output.
+func RunWasm(config CompileConfig, output io.Writer) (err error) {
+	defer func() { err = reportCrash(config, err) }()
+	l := readTokens(config.Path)
+	if l == nil {
+		return fmt.Errorf("no valid token in %s", config.Path)
+	}
+	source, err := parser.CompileToWAT(l)
+	if err != nil {
+		return err
+	}
+	return wasm.Run(source, output, 0)
+}
+
+// InterpretFile runs the file with the interpreter, the program writes to the output.
+func InterpretFile(config CompileConfig, output io.Writer) (err error) {
+	defer func() { err = reportCrash(config, err) }()
+	l := readTokens(config.Path)
+	if l == nil {
+		return fmt.Errorf("no valid token in %s", config.Path)
+	}
+	return parser.InterpretTokens(l, output)
+}
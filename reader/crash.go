@@ -0,0 +1,80 @@
+package reader
+
+import (
+	"errors"
+	"gada/parser"
+	"github.com/charmbracelet/log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Flags returns the command line options equivalent to the config
+func (c CompileConfig) Flags() []string {
+	var flags []string
+	if c.OptimizationLevel == 1 {
+		flags = append(flags, "-O1")
+	}
+	if c.Target != "" {
+		flags = append(flags, "--target="+c.Target)
+	}
+	if c.Emit != "" {
+		flags = append(flags, "--emit="+c.Emit)
+	}
+	if c.PrintAst {
+		flags = append(flags, "--print-ast", "--python-executable="+c.PythonExecutable)
+	}
+	return flags
+}
+
+// WriteCrashReport writes a reproducer of the internal compiler error in a new folder of config.CrashReport:
+// the source, the flags, the AST when there is one and the error with the stack of the compiler.
+// It returns the folder of the report.
+func WriteCrashReport(config CompileConfig, ice *parser.InternalError) (string, error) {
+	if err := os.MkdirAll(config.CrashReport, 0755); err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(config.Path), filepath.Ext(config.Path))
+	folder, err := os.MkdirTemp(config.CrashReport, name+"-ice-")
+	if err != nil {
+		return "", err
+	}
+	source, err := os.ReadFile(config.Path)
+	if err != nil {
+		return "", err
+	}
+	files := []struct {
+		name    string
+		content string
+	}{
+		{filepath.Base(config.Path), string(source)},
+		{"flags", strings.Join(config.Flags(), " ") + "\n"},
+		{"ast.json", ice.AST},
+		{"error", ice.Error() + "\n\n" + ice.Stack},
+	}
+	for _, file := range files {
+		if file.content == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(folder, file.name), []byte(file.content), 0644); err != nil {
+			return "", err
+		}
+	}
+	return folder, nil
+}
+
+// reportCrash writes the crash report of an internal compiler error when the config asks for one,
+// the error is returned unchanged
+func reportCrash(config CompileConfig, err error) error {
+	var ice *parser.InternalError
+	if config.CrashReport == "" || !errors.As(err, &ice) {
+		return err
+	}
+	folder, writeErr := WriteCrashReport(config, ice)
+	if writeErr != nil {
+		log.Error("Cannot write the crash report", "error", writeErr)
+		return err
+	}
+	log.Error("Internal compiler error, reproducer written", "folder", folder)
+	return err
+}
@@ -0,0 +1,203 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"gada/asm"
+	"gada/parser"
+	"strings"
+)
+
+// diffContext is the number of identical lines shown before the first different line
+const diffContext = 2
+
+// Execution is the result of one execution path of a program
+type Execution struct {
+	Output string
+	// Status is ok, rejected when the program does not compile, unsupported when the ARM backend does not
+	// implement one of its constructs, crashed on an internal compiler error, failed when the program stops
+	// with an error and stopped when it runs for too many steps
+	Status string
+	Err    error
+}
+
+// Divergence is a program that does not behave the same way when it is interpreted and when it is
+// compiled to ARM and emulated
+type Divergence struct {
+	File      string
+	Reference Execution
+	ARM       Execution
+	// Diff shows the first different line of the outputs, or the statuses when the outputs are the same
+	Diff string
+}
+
+// Skip is a program whose execution paths are not compared: the interpreter rejects it, so it is not a valid
+// program, or the ARM backend does not support one of its constructs
+type Skip struct {
+	File string
+	// Reason is the status of the path that cannot run the program, rejected or unsupported
+	Reason string
+	Err    error
+}
+
+func (s Skip) String() string {
+	return fmt.Sprintf("%s: skipped, %s (%s)", s.File, s.Reason, s.Err)
+}
+
+func (d Divergence) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: the ARM program diverges from the interpreter\n", d.File)
+	fmt.Fprintf(&b, "interpreter: %s", d.Reference.Status)
+	if d.Reference.Err != nil {
+		fmt.Fprintf(&b, " (%s)", d.Reference.Err)
+	}
+	fmt.Fprintf(&b, "\nARM:         %s", d.ARM.Status)
+	if d.ARM.Err != nil {
+		fmt.Fprintf(&b, " (%s)", d.ARM.Err)
+	}
+	b.WriteString("\n" + d.Diff)
+	return b.String()
+}
+
+func status(err error, failure string) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, asm.ErrStepLimit):
+		return "stopped"
+	}
+	return failure
+}
+
+// interpretPath runs the program with the interpreter
+func interpretPath(path string, maxSteps int) Execution {
+	l := readTokens(path)
+	if l == nil {
+		return Execution{Status: "rejected", Err: fmt.Errorf("no valid token in %s", path)}
+	}
+	graph, err := parser.Analyse(l)
+	if err != nil {
+		return Execution{Status: "rejected", Err: err}
+	}
+	var output strings.Builder
+	err = parser.Interpret(graph, &output, maxSteps)
+	return Execution{Output: output.String(), Status: status(err, "failed"), Err: err}
+}
+
+// armPath compiles the program to ARM assembly and runs it in the emulator
+func armPath(path string, maxSteps int) Execution {
+	l := readTokens(path)
+	if l == nil {
+		return Execution{Status: "rejected", Err: fmt.Errorf("no valid token in %s", path)}
+	}
+	text, err := parser.CompileToASM(l, 0)
+	var ice *parser.InternalError
+	if errors.As(err, &ice) {
+		return Execution{Status: "crashed", Err: err}
+	}
+	var unsupported *parser.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return Execution{Status: "unsupported", Err: err}
+	}
+	if err != nil {
+		return Execution{Status: "rejected", Err: err}
+	}
+	machine, err := asm.NewMachine(text)
+	if err != nil {
+		return Execution{Status: "crashed", Err: err}
+	}
+	machine.MaxSteps = maxSteps
+	err = machine.Run()
+	return Execution{Output: machine.Output(), Status: status(err, "failed"), Err: err}
+}
+
+// DiffFile runs the program through the interpreter and through the ARM backend, it returns a divergence
+// unless they print the same thing and stop the same way. The step limits of the two paths do not count the
+// same things, so when one of them is stopped only the beginning of the outputs is compared. A program
+// rejected by the interpreter or not supported by the ARM backend is skipped instead.
+func DiffFile(path string, maxSteps int) (*Divergence, *Skip) {
+	reference := interpretPath(path, maxSteps)
+	if reference.Status == "rejected" {
+		return nil, &Skip{File: path, Reason: reference.Status, Err: reference.Err}
+	}
+	arm := armPath(path, maxSteps)
+	if arm.Status == "unsupported" {
+		return nil, &Skip{File: path, Reason: arm.Status, Err: arm.Err}
+	}
+
+	referenceOutput, armOutput := reference.Output, arm.Output
+	stopped := reference.Status == "stopped" || arm.Status == "stopped"
+	if stopped {
+		n := len(referenceOutput)
+		if len(armOutput) < n {
+			n = len(armOutput)
+		}
+		referenceOutput, armOutput = referenceOutput[:n], armOutput[:n]
+	}
+	if referenceOutput == armOutput && (stopped || reference.Status == arm.Status) {
+		return nil, nil
+	}
+	return &Divergence{File: path, Reference: reference, ARM: arm, Diff: MinimalDiff(referenceOutput, armOutput)}, nil
+}
+
+// MinimalDiff shows the first different line of two outputs with a few lines before it,
+// the lines of the expected output start with - and the lines of the actual output with +
+func MinimalDiff(expected string, actual string) string {
+	if expected == actual {
+		return "same output\n"
+	}
+	expectedLines := strings.SplitAfter(expected, "\n")
+	actualLines := strings.SplitAfter(actual, "\n")
+	first := 0
+	for first < len(expectedLines) && first < len(actualLines) && expectedLines[first] == actualLines[first] {
+		first++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ line %d @@\n", first+1)
+	start := first - diffContext
+	if start < 0 {
+		start = 0
+	}
+	for _, line := range expectedLines[start:first] {
+		b.WriteString(" " + quoteLine(line))
+	}
+	if first < len(expectedLines) {
+		b.WriteString("-" + quoteLine(expectedLines[first]))
+	}
+	if first < len(actualLines) {
+		b.WriteString("+" + quoteLine(actualLines[first]))
+	}
+	return b.String()
+}
+
+// quoteLine shows the end of the line, a missing new line is visible
+func quoteLine(line string) string {
+	if line == "" {
+		return "<end of output>\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line
+	}
+	return line + "\\ no new line\n"
+}
+
+// DiffTest compares the execution paths of the files in order, it stops at the first divergence
+// unless all is set. It also returns the files skipped before it stopped.
+func DiffTest(files []string, maxSteps int, all bool) ([]Divergence, []Skip) {
+	var divergences []Divergence
+	var skips []Skip
+	for _, file := range files {
+		divergence, skip := DiffFile(file, maxSteps)
+		if skip != nil {
+			skips = append(skips, *skip)
+		}
+		if divergence != nil {
+			divergences = append(divergences, *divergence)
+			if !all {
+				break
+			}
+		}
+	}
+	return divergences, skips
+}
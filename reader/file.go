@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"gada/lexer"
 	"gada/parser"
-	"gada/token"
 	"github.com/charmbracelet/log"
 	"os"
 )
@@ -13,6 +12,14 @@ type CompileConfig struct {
 	Path             string
 	PrintAst         bool
 	PythonExecutable string
+	// OptimizationLevel is 0 by default, 1 enables the peephole optimiser (-O1)
+	OptimizationLevel int
+	// Target is the platform of gada build (e.g. x86_64-linux)
+	Target string
+	// Emit is the output of gada build instead of the target assembly (e.g. llvm)
+	Emit string
+	// CrashReport is the folder of the reproducers of the internal compiler errors, none are written when empty
+	CrashReport string
 }
 
 func ReadFile(path string) (string, error) {
@@ -88,29 +95,32 @@ func FileLexer(path string) *lexer.Lexer {
 }
 
 func CompileFile(config CompileConfig) {
-	l := FileLexer(config.Path)
+	l := readTokens(config.Path)
 	if l == nil {
 		return
 	}
-	l.Read()
 
-	if len(l.Tokens) == 0 {
-		log.Error("The provided file is empty")
-		return
+	reportCrash(config, parser.Parse(l, config.PrintAst, config.PythonExecutable, config.OptimizationLevel))
+}
+
+// readTokens reads the tokens of the file, it returns nil if the file has lexical errors or no token
+func readTokens(path string) *lexer.Lexer {
+	l := FileLexer(path)
+	if l == nil {
+		return nil
 	}
+	l.Read()
 
-	// remove illegal tokens
-	for i := 0; i < len(l.Tokens); i++ {
-		if l.Tokens[i].Value == token.ILLEGAL {
-			l.Tokens = append(l.Tokens[:i], l.Tokens[i+1:]...)
-			i--
+	// The parser would see holes in the tokens where the errors are, so the compilation stops
+	if len(l.Errors) > 0 {
+		for _, err := range l.Errors {
+			log.Error(l.Report(err))
 		}
+		return nil
 	}
-
 	if len(l.Tokens) == 0 {
-		log.Error("The provided file has no valid tokens")
-		return
+		log.Error("The provided file is empty")
+		return nil
 	}
-
-	parser.Parse(l, config.PrintAst, config.PythonExecutable)
+	return l
 }
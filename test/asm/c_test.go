@@ -0,0 +1,86 @@
+package asm
+
+import (
+	"context"
+	"errors"
+	"gada/asm"
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestC checks that the programs translated to C print the same thing as the interpreter and as the
+// emulated ARM code, when the ARM backend supports them
+func TestC(t *testing.T) {
+	if _, err := exec.LookPath("cc"); err != nil {
+		t.Skip("cc is not installed")
+	}
+
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	folder := t.TempDir()
+	for _, file := range files {
+		expected, err := reference(t, file)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		source, translateErr := parser.CompileToC(l)
+		if !assert.NoError(t, translateErr, file) {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".adb")
+		output := filepath.Join(folder, name)
+		assert.NoError(t, os.WriteFile(output+".c", []byte(source), 0644))
+		if !assert.NoError(t, reader.BuildC(output+".c", output), file) {
+			continue
+		}
+
+		assertSameOutput(t, file, output, expected, err)
+		if text, ok := compile(t, file, 0); ok {
+			emulated, emulateErr := run(text)
+			assertSameOutput(t, file, output, emulated, emulateErr)
+		}
+	}
+}
+
+// reference runs the program with the interpreter, the backends must print the same thing
+func reference(t *testing.T, path string) (string, error) {
+	l := reader.FileLexer(path)
+	l.Read()
+	graph, err := parser.Analyse(l)
+	require.NoError(t, err, path)
+	var output strings.Builder
+	err = parser.Interpret(graph, &output, maxSteps)
+	return output.String(), err
+}
+
+// assertSameOutput runs the executable built from the file and compares its output with the reference
+func assertSameOutput(t *testing.T, file string, executable string, expected string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	native, nativeErr := exec.CommandContext(ctx, executable).Output()
+	cancel()
+	assertOutput(t, file, string(native), nativeErr, expected, err)
+}
+
+// assertOutput compares the output of a backend with the reference. When the reference reached its step limit
+// the backend may be stopped earlier or later, then only the beginning of the outputs is compared.
+func assertOutput(t *testing.T, file string, native string, nativeErr error, expected string, err error) {
+	if errors.Is(err, asm.ErrStepLimit) {
+		assert.True(t, strings.HasPrefix(native, expected) || strings.HasPrefix(expected, native), file)
+		return
+	}
+	if err == nil {
+		assert.NoError(t, nativeErr, file)
+	} else {
+		// The program stops with an error in both
+		assert.Error(t, nativeErr, file)
+	}
+	assert.Equal(t, expected, native, file)
+}
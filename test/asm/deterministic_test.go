@@ -0,0 +1,23 @@
+package asm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+// TestDeterministicOutput checks that compiling the same file several times gives byte-identical assembly
+func TestDeterministicOutput(t *testing.T) {
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	for _, file := range files {
+		expected, ok := compile(t, file, 0)
+		if !ok {
+			continue
+		}
+		for i := 0; i < 5; i++ {
+			text, _ := compile(t, file, 0)
+			assert.Equal(t, expected, text, file)
+		}
+	}
+}
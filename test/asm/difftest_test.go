@@ -0,0 +1,101 @@
+package asm
+
+import (
+	"gada/generator"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMinimalDiff(t *testing.T) {
+	assert.Equal(t, "same output\n", reader.MinimalDiff("a\n", "a\n"))
+	assert.Equal(t, "@@ line 4 @@\n 2\n 3\n-4\n+5\n", reader.MinimalDiff("1\n2\n3\n4\n6\n", "1\n2\n3\n5\n6\n"))
+	assert.Equal(t, "@@ line 2 @@\n a\n-b\n+<end of output>\n", reader.MinimalDiff("a\nb\n", "a\n"))
+	assert.Equal(t, "@@ line 1 @@\n-ab\\ no new line\n+ab\n", reader.MinimalDiff("ab", "ab\n"))
+}
+
+// divergingFile is a real divergence: the interpreter stops when a function ends without return, the ARM program
+// goes on with the value left on the stack
+const divergingFile = "testdata/no_return.adb"
+
+func TestDiffFile(t *testing.T) {
+	for _, name := range []string{"fact", "power", "for1", "for2", "function1", "return1", "record3", "record4"} {
+		divergence, skip := reader.DiffFile("../../examples/exec/"+name+".adb", maxSteps)
+		assert.Nil(t, divergence, name)
+		assert.Nil(t, skip, name)
+	}
+
+	divergence, skip := reader.DiffFile(divergingFile, maxSteps)
+	assert.Nil(t, skip)
+	if assert.NotNil(t, divergence) {
+		assert.Equal(t, "failed", divergence.Reference.Status)
+		assert.ErrorContains(t, divergence.Reference.Err, "function f ended without return")
+		assert.Equal(t, "ok", divergence.ARM.Status)
+		assert.Equal(t, "@@ line 1 @@\n-<end of output>\n+0\\ no new line\n", divergence.Diff)
+	}
+
+	// The ARM backend has no heap for the access types
+	divergence, skip = reader.DiffFile("../../examples/exec/bst.adb", maxSteps)
+	assert.Nil(t, divergence)
+	if assert.NotNil(t, skip) {
+		assert.Equal(t, "unsupported", skip.Reason)
+		assert.ErrorContains(t, skip.Err, "access types are not supported by the ARM backend")
+	}
+
+	// A program rejected by both paths is not an agreement
+	divergence, skip = reader.DiffFile("../../examples/correctsyntax/test_loop.ada", maxSteps)
+	assert.Nil(t, divergence)
+	if assert.NotNil(t, skip) {
+		assert.Equal(t, "rejected", skip.Reason)
+	}
+}
+
+func TestDiffTestStopsAtFirstDivergence(t *testing.T) {
+	files := []string{"../../examples/exec/fact.adb", divergingFile, "../../examples/exec/bst.adb", divergingFile}
+	divergences, skips := reader.DiffTest(files, maxSteps, false)
+	if assert.Len(t, divergences, 1) {
+		assert.Equal(t, divergingFile, divergences[0].File)
+	}
+	assert.Empty(t, skips)
+
+	divergences, skips = reader.DiffTest(files, maxSteps, true)
+	assert.Len(t, divergences, 2)
+	if assert.Len(t, skips, 1) {
+		assert.Equal(t, files[2], skips[0].File)
+	}
+}
+
+// TestGeneratedProgramsAgree checks that the ARM backend prints what the interpreter prints for random programs,
+// they have no access type so that the ARM backend supports them
+func TestGeneratedProgramsAgree(t *testing.T) {
+	folder := t.TempDir()
+	for seed := int64(1); seed <= 200; seed++ {
+		config := generator.DefaultConfig(seed)
+		config.NoAccess = true
+		file := filepath.Join(folder, "generated"+strconv.FormatInt(seed, 10)+".adb")
+		require.NoError(t, os.WriteFile(file, []byte(generator.Generate(config)), 0644))
+		divergence, skip := reader.DiffFile(file, maxSteps)
+		assert.Nil(t, skip, file)
+		if divergence != nil {
+			assert.Fail(t, "divergence", divergence.String())
+		}
+	}
+}
+
+// TestExamplesAgree checks that the ARM backend prints what the interpreter prints for every example it supports
+func TestExamplesAgree(t *testing.T) {
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	for _, file := range files {
+		divergence, skip := reader.DiffFile(file, maxSteps)
+		if skip != nil {
+			assert.Equal(t, "unsupported", skip.Reason, file)
+			assert.ErrorContains(t, skip.Err, "access types are not supported by the ARM backend", file)
+		}
+		assert.Nil(t, divergence, file)
+	}
+}
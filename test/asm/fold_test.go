@@ -0,0 +1,24 @@
+package asm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestInterpreterCaseFolding checks that the names differing by the case of non ASCII letters are the same
+func TestInterpreterCaseFolding(t *testing.T) {
+	output, err := interpret(`with Ada.Text_IO; use Ada.Text_IO;
+procedure Folding is
+   Été : Integer := 1;
+   ΣΟΦΟΣ : Integer := 2;
+   ſum : Integer;
+begin
+   éTÉ := ÉTÉ + 1;
+   SUM := été + σοφος;
+   Put(Sum); New_Line;
+   Put(σοφοσ); New_Line;
+end Folding;
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "4\n2\n", output)
+}
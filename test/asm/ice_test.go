@@ -0,0 +1,98 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const crashingFile = "../../examples/exec/function1.adb"
+
+// crashOn makes the phase panic on the first node of the line it visits, until the test ends.
+// It returns the column of this node once the phase crashed.
+func crashOn(t *testing.T, phase string, line int) *int {
+	column := new(int)
+	parser.VisitHook = func(visited string, visitedLine int, visitedColumn int) {
+		if visited == phase && visitedLine == line {
+			*column = visitedColumn
+			panic("injected crash")
+		}
+	}
+	t.Cleanup(func() { parser.VisitHook = nil })
+	return column
+}
+
+func TestInternalError(t *testing.T) {
+	column := crashOn(t, "code generation", 11)
+	l := reader.FileLexer(crashingFile)
+	l.Read()
+	_, err := parser.CompileToASM(l, 0)
+	var ice *parser.InternalError
+	if assert.True(t, errors.As(err, &ice)) {
+		assert.Equal(t, "code generation", ice.Phase)
+		assert.Equal(t, 11, ice.Line)
+		assert.NotZero(t, *column)
+		assert.Equal(t, *column, ice.Column)
+		assert.Equal(t, fmt.Sprintf("%s:11:%d internal compiler error in code generation: injected crash", crashingFile, *column), err.Error())
+		assert.Contains(t, ice.Stack, "ReadBody")
+		assert.Contains(t, ice.AST, "\"types\"")
+	}
+
+	// A crash in another phase is reported in this phase
+	crashOn(t, "semantics", 11)
+	l = reader.FileLexer(crashingFile)
+	l.Read()
+	_, err = parser.CompileToASM(l, 0)
+	if assert.True(t, errors.As(err, &ice)) {
+		assert.Equal(t, "semantics", ice.Phase)
+	}
+
+	// Without the crash the compilation succeeds
+	parser.VisitHook = nil
+	l = reader.FileLexer(crashingFile)
+	l.Read()
+	_, err = parser.CompileToASM(l, 0)
+	assert.NoError(t, err)
+}
+
+func TestCrashReport(t *testing.T) {
+	crashOn(t, "code generation", 12)
+	config := reader.CompileConfig{Path: crashingFile, OptimizationLevel: 1, CrashReport: t.TempDir()}
+	l := reader.FileLexer(crashingFile)
+	l.Read()
+	_, err := parser.CompileToASM(l, config.OptimizationLevel)
+	var ice *parser.InternalError
+	if !assert.True(t, errors.As(err, &ice)) {
+		return
+	}
+
+	folder, err := reader.WriteCrashReport(config, ice)
+	assert.NoError(t, err)
+	source, _ := os.ReadFile(crashingFile)
+	for name, expected := range map[string]string{"function1.adb": string(source), "flags": "-O1\n"} {
+		content, err := os.ReadFile(filepath.Join(folder, name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+	content, err := os.ReadFile(filepath.Join(folder, "error"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), ice.Error()+"\n\ngoroutine"))
+	_, err = os.Stat(filepath.Join(folder, "ast.json"))
+	assert.NoError(t, err)
+}
+
+// TestAccessTypesRejected checks that the ARM backend reports the access types it cannot allocate
+func TestAccessTypesRejected(t *testing.T) {
+	l := reader.FileLexer("../../examples/exec/bst.adb")
+	l.Read()
+	_, err := parser.CompileToASM(l, 0)
+	var ice *parser.InternalError
+	assert.False(t, errors.As(err, &ice))
+	assert.EqualError(t, err, "../../examples/exec/bst.adb:7:4: access types are not supported by the ARM backend")
+}
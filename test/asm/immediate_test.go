@@ -0,0 +1,24 @@
+package asm
+
+import (
+	"gada/asm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsEncodableImmediate(t *testing.T) {
+	for _, value := range []uint32{0, 1, 255, 256, 0xFF000000, 0xF000000F, 0x3FC, 65280} {
+		assert.True(t, asm.IsEncodableImmediate(value), value)
+	}
+	for _, value := range []uint32{257, 1000003, 0x1FE00001, 0xFFFFFFFF, 65535, 0x102} {
+		assert.False(t, asm.IsEncodableImmediate(value), value)
+	}
+}
+
+func TestLargeConstants(t *testing.T) {
+	text, ok := compile(t, "../../examples/exec/constants.adb", 0)
+	assert.True(t, ok)
+	output, err := run(text)
+	assert.NoError(t, err)
+	assert.Equal(t, "1000003\n3\n70000\n65535\n256\n", output)
+}
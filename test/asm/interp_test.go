@@ -0,0 +1,108 @@
+package asm
+
+import (
+	"gada/lexer"
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestInterpreter checks that the interpreted programs print the same thing as the emulated ARM programs
+func TestInterpreter(t *testing.T) {
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	for _, file := range files {
+		text, ok := compile(t, file, 0)
+		if !ok {
+			continue
+		}
+		expected, err := run(text)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		var output strings.Builder
+		interpretErr := parser.InterpretTokens(l, &output)
+		assertOutput(t, file, output.String(), interpretErr, expected, err)
+	}
+}
+
+func interpret(source string) (string, error) {
+	l := lexer.NewLexer("test.adb", source)
+	l.Read()
+	var output strings.Builder
+	err := parser.InterpretTokens(l, &output)
+	return output.String(), err
+}
+
+func TestInterpreterOverloads(t *testing.T) {
+	output, err := interpret(`with Ada.Text_IO; use Ada.Text_IO;
+procedure Overloads is
+   X : Integer := 1;
+   procedure Show(A : Integer) is
+   begin
+      Put(A); New_Line;
+   end Show;
+   procedure Show(C : Character) is
+   begin
+      Put(C); New_Line;
+   end Show;
+   procedure Increment(A : in out Integer) is
+   begin
+      A := A + X;
+   end Increment;
+begin
+   Show('a');
+   Show(42);
+   Increment(X);
+   Increment(X);
+   Show(X);
+end Overloads;
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\n42\n4\n", output)
+}
+
+func TestInterpreterNullAccess(t *testing.T) {
+	_, err := interpret(`with Ada.Text_IO; use Ada.Text_IO;
+procedure Lists is
+   type Node;
+   type List is access Node;
+   type Node is record
+      Value : Integer;
+      Next : List;
+   end record;
+   L : List := new Node;
+begin
+   L.Value := 1;
+   Put(L.Next.Value);
+end Lists;
+`)
+	assert.ErrorContains(t, err, "null access")
+}
+
+// TestPrintInt checks the bounds of Integer, -2147483648 is the negation of a literal beyond Integer'Last
+func TestPrintInt(t *testing.T) {
+	const file = "../../examples/exec/print_int.adb"
+	expected := "-2147483648\n"
+	for n := -17; n <= 42; n++ {
+		expected += strconv.Itoa(n) + "\n"
+	}
+	expected += "2147483647\n"
+
+	text, ok := compile(t, file, 0)
+	require.True(t, ok, file)
+	output, err := run(text)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, output)
+
+	l := reader.FileLexer(file)
+	l.Read()
+	var interpreted strings.Builder
+	assert.NoError(t, parser.InterpretTokens(l, &interpreted))
+	assert.Equal(t, expected, interpreted.String())
+}
@@ -0,0 +1,106 @@
+package asm
+
+import (
+	"gada/asm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestSubprogramLabels checks that the subprograms get distinct labels when their names, their parameters
+// or the routines of the runtime would give the same one
+func TestSubprogramLabels(t *testing.T) {
+	for name, test := range map[string]struct {
+		source   string
+		expected string
+	}{
+		"nested subprograms with the same name": {`with Ada.Text_IO; use Ada.Text_IO;
+procedure Nested is
+   procedure P1 is
+      procedure Inner is
+      begin
+         Put(1);
+      end Inner;
+   begin
+      Inner;
+   end P1;
+
+   procedure P2 is
+      procedure Inner is
+      begin
+         Put(2);
+      end Inner;
+   begin
+      Inner;
+   end P2;
+begin
+   P1;
+   P2;
+end Nested;`, "12"},
+		"names and parameters joined the same way": {`with Ada.Text_IO; use Ada.Text_IO;
+procedure Joined is
+   procedure Ab(C: Integer) is
+   begin
+      Put(C);
+   end Ab;
+
+   procedure A(Bc: Integer) is
+   begin
+      Put(Bc + 1);
+   end A;
+begin
+   Ab(1);
+   A(1);
+end Joined;`, "12"},
+		"name of a runtime routine": {`with Ada.Text_IO; use Ada.Text_IO;
+procedure Runtime is
+   X: Integer := 6;
+
+   procedure Mul is
+   begin
+      Put(X * 7);
+   end Mul;
+begin
+   Mul;
+end Runtime;`, "42"},
+	} {
+		_, emulated, interpreted := compileSource(t, test.source)
+		assert.Equal(t, test.expected, interpreted, name)
+		assert.Equal(t, test.expected, emulated, name)
+	}
+}
+
+// TestNonASCIILabels checks that the identifiers written in the labels of the dynamic links are made of ASCII
+// characters, the assemblers reject the other ones
+func TestNonASCIILabels(t *testing.T) {
+	text, emulated, interpreted := compileSource(t, `with Ada.Text_IO; use Ada.Text_IO;
+procedure Été is
+   Année: Integer := 2000;
+
+   procedure Décennie(Δ: Integer) is
+      procedure Ajoute is
+      begin
+         Année := Année + Δ;
+         Put(Année);
+      end Ajoute;
+   begin
+      if Δ > 0 then
+         Ajoute;
+      end if;
+   end Décennie;
+begin
+   Décennie(10);
+   while Année < 2030 loop
+      Décennie(10);
+   end loop;
+end Été;`)
+	assert.Equal(t, interpreted, emulated)
+	assert.Equal(t, "201020202030", emulated)
+	for _, instruction := range asm.Parse(text) {
+		for _, word := range append([]string{instruction.Label}, instruction.Args...) {
+			for _, r := range word {
+				assert.Less(t, r, rune(utf8.RuneSelf), "%s in %v", word, instruction)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package asm
+
+import (
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLLVM checks that the programs built from LLVM IR print the same thing as the interpreter
+func TestLLVM(t *testing.T) {
+	for _, tool := range []string{"llc", "cc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool + " is not installed")
+		}
+	}
+
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	folder := t.TempDir()
+	for _, file := range files {
+		expected, err := reference(t, file)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		source, translateErr := parser.CompileToLLVM(l)
+		if !assert.NoError(t, translateErr, file) {
+			continue
+		}
+		output := filepath.Join(folder, strings.TrimSuffix(filepath.Base(file), ".adb"))
+		assert.NoError(t, os.WriteFile(output+".ll", []byte(source), 0644))
+		if !assert.NoError(t, reader.BuildLLVM(output+".ll", output), file) {
+			continue
+		}
+		assertSameOutput(t, file, output, expected, err)
+	}
+}
@@ -0,0 +1,96 @@
+package asm
+
+import (
+	"errors"
+	"gada/asm"
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// maxSteps bounds the emulation of the programs that run for too long (or never end)
+const maxSteps = 5_000_000
+
+func optimize(text string) string {
+	return strings.TrimSpace(asm.OptimizeText(text))
+}
+
+func TestPeepholePatterns(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"add zero", "ADD R0, R0, #0\nMOV R1, #2", "MOV R1, #2"},
+		{"move to itself", "MOV R3, R3\nMOV R1, #2", "MOV R1, #2"},
+		{"store then load", "STR R0, [SP]\nLDR R0, [SP]\nBL f", "STR R0, [SP]\nBL f"},
+		{"store then load other register", "STR R0, [SP]\nLDR R1, [SP]\nBL f", "STR R0, [SP]\nMOV R1, R0\nBL f"},
+		{"stack slot", "SUB SP, SP, #4\nADD SP, SP, #4\nBL f", "BL f"},
+		{"frame pointer save", "MOV R9, R11\n; comment\nMOV R11, R9\nBL f", "; comment\nBL f"},
+		{"branch to next line", "B next\nnext MOV R0, #1", "next MOV R0, #1"},
+		{"conditional instructions are kept", "ADDEQ R0, R0, #0\nBEQ next\nnext MOV R0, #1", "ADDEQ R0, R0, #0\nBEQ next\nnext MOV R0, #1"},
+		{"label stops a pattern", "STR R0, [SP]\nloop LDR R1, [SP]", "STR R0, [SP]\nloop LDR R1, [SP]"},
+		{"label is kept", "l ADD R0, R0, #0\nB l", "l\nB l"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, optimize(c.input), c.name)
+	}
+}
+
+// TestDuplicateLabel checks that the emulator rejects a label defined twice instead of keeping one of them
+func TestDuplicateLabel(t *testing.T) {
+	_, err := asm.NewMachine("f MOV R0, #1\nB f\nf MOV R0, #2")
+	assert.EqualError(t, err, "label f is defined twice")
+	_, err = asm.NewMachine("value FILL 4\nVALUE MOV R0, #1")
+	assert.EqualError(t, err, "label value is defined twice")
+}
+
+// compile fails the test when the program does not compile, except for the access types the ARM backend rejects
+func compile(t *testing.T, path string, optimizationLevel int) (string, bool) {
+	l := reader.FileLexer(path)
+	l.Read()
+	text, err := parser.CompileToASM(l, optimizationLevel)
+	if err != nil && strings.HasSuffix(err.Error(), "access types are not supported by the ARM backend") {
+		t.Logf("%s is not supported: %v", path, err)
+		return "", false
+	}
+	return text, assert.NoError(t, err, path)
+}
+
+func run(text string) (string, error) {
+	machine, err := asm.NewMachine(text)
+	if err != nil {
+		return "", err
+	}
+	machine.MaxSteps = maxSteps
+	err = machine.Run()
+	return machine.Output(), err
+}
+
+// TestOutputUnchanged checks that the programs of examples/exec print the same thing with and without the optimiser
+func TestOutputUnchanged(t *testing.T) {
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	for _, file := range files {
+		text, ok := compile(t, file, 0)
+		if !ok {
+			continue
+		}
+		optimized := asm.OptimizeText(text)
+		assert.Less(t, len(asm.Parse(optimized)), len(asm.Parse(text)), file)
+
+		output, err := run(text)
+		optimizedOutput, optimizedErr := run(optimized)
+		if errors.Is(err, asm.ErrStepLimit) && errors.Is(optimizedErr, asm.ErrStepLimit) {
+			// The optimised program is faster, it prints more before reaching the step limit
+			assert.True(t, strings.HasPrefix(optimizedOutput, output), file)
+			continue
+		}
+		if assert.NoError(t, err, file) && assert.NoError(t, optimizedErr, file) {
+			assert.Equal(t, output, optimizedOutput, file)
+		}
+	}
+}
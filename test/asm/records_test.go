@@ -0,0 +1,60 @@
+package asm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestFieldOfCall checks the fields read from the record returned by a function, which has no address:
+// the scalar fields, the nested records and the fields of the nested records
+func TestFieldOfCall(t *testing.T) {
+	const file = "testdata/field_of_call.adb"
+	expected, err := reference(t, file)
+	require.NoError(t, err)
+	assert.Equal(t, "b121046t", expected)
+	for optimizationLevel := 0; optimizationLevel <= 1; optimizationLevel++ {
+		text, ok := compile(t, file, optimizationLevel)
+		require.True(t, ok)
+		output, err := run(text)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, output, "-O%d", optimizationLevel)
+	}
+}
+
+// TestRecordConditions checks the logical operators whose operands compare records, they are evaluated
+// on the stack and not in registers
+func TestRecordConditions(t *testing.T) {
+	_, emulated, interpreted := compileSource(t, `with Ada.Text_IO; use Ada.Text_IO;
+procedure Conditions is
+   type Pair is record
+      C: Character;
+      N: Integer;
+   end record;
+   X: Pair;
+   Y: Pair;
+   Unused: Integer;
+begin
+   X.C := 'x';
+   X.N := 1;
+   Y.C := 'y';
+   Y.N := 2;
+   if (1 > 2) or (X = Y) then
+      Put('a');
+   end if;
+   if (X = Y) or else (1 > 2) then
+      Put('b');
+   end if;
+   if (X = X) and then (X /= Y) then
+      Put('c');
+   end if;
+   if not (X = Y) then
+      Put('d');
+   end if;
+   if not (X = X) then
+      Put('e');
+   end if;
+end Conditions;`)
+	assert.Equal(t, "cd", interpreted)
+	assert.Equal(t, interpreted, emulated)
+}
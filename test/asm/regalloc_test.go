@@ -0,0 +1,95 @@
+package asm
+
+import (
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// balanced returns a complete binary expression of the given depth over the variables A to G and its value,
+// it needs depth + 1 registers to be evaluated without spilling
+func balanced(depth int, index int) (string, int) {
+	if depth == 0 {
+		return string(rune('A' + index%7)), index%7 + 1
+	}
+	left, leftValue := balanced(depth-1, 2*index)
+	right, rightValue := balanced(depth-1, 2*index+1)
+	if depth%2 == 0 {
+		return "(" + left + " - " + right + ")", leftValue - rightValue
+	}
+	return "(" + left + " + " + right + ")", leftValue + rightValue
+}
+
+// compileSource compiles the program for ARM, runs it on the emulator and with the interpreter
+func compileSource(t *testing.T, source string) (text string, emulated string, interpreted string) {
+	l := lexer.NewLexer("test.adb", source)
+	l.Read()
+	text, err := parser.CompileToASM(l, 0)
+	require.NoError(t, err)
+	emulated, err = run(text)
+	require.NoError(t, err)
+	interpreted, err = interpret(source)
+	require.NoError(t, err)
+	return text, emulated, interpreted
+}
+
+// TestRegisterSpilling checks that an expression needing nine registers uses R0 to R8 without spilling
+// and that one needing ten spills a register on the stack
+func TestRegisterSpilling(t *testing.T) {
+	for depth, spills := range map[int]bool{8: false, 9: true} {
+		expression, value := balanced(depth, 0)
+		text, emulated, interpreted := compileSource(t, `with Ada.Text_IO; use Ada.Text_IO;
+procedure Deep is
+   A: Integer := 1; B: Integer := 2; C: Integer := 3; D: Integer := 4;
+   E: Integer := 5; F: Integer := 6; G: Integer := 7; H: Integer := 8;
+begin
+   Put(`+expression+`);
+end Deep;`)
+
+		name := "depth " + strconv.Itoa(depth)
+		assert.Equal(t, spills, strings.Contains(text, "; Spill R"), name)
+		assert.Contains(t, text, "R8", name)
+		assert.Equal(t, strconv.Itoa(value), emulated, name)
+		assert.Equal(t, interpreted, emulated, name)
+	}
+}
+
+// TestCallsInExpressions checks that the live temporaries are kept across the calls, the multiplications and
+// the divisions of an expression deep enough to spill
+func TestCallsInExpressions(t *testing.T) {
+	expression, _ := balanced(9, 0)
+	text, emulated, interpreted := compileSource(t, `with Ada.Text_IO; use Ada.Text_IO;
+procedure Calls is
+   A: Integer := 1; B: Integer := 2; C: Integer := 3; D: Integer := 4;
+   E: Integer := 5; F: Integer := 6; G: Integer := 7; H: Integer := 8;
+   Count: Integer := 0;
+
+   function Twice(X: Integer) return Integer is
+   begin
+      Count := Count + 1;
+      return X + X;
+   end;
+
+   function Busy(X: Integer) return Integer is
+   begin
+      return `+expression+` + X * 0;
+   end;
+begin
+   Put(A + Twice(B) * (C - Twice(D + Twice(E))) + G rem Twice(H) - F / Twice(A));
+   New_Line;
+   Put((A * B + C * D) - (E / B + F rem C) * Busy(Twice(G) - H) + Busy(A));
+   New_Line;
+   Put(`+expression+` * Twice(`+expression+` + Busy(Count)) - Busy(Twice(A) * Twice(B)));
+   New_Line;
+   Put(Count);
+end Calls;`)
+
+	assert.Contains(t, text, "; Spill R")
+	assert.Contains(t, text, "STMFD SP!, {R0, R1")
+	assert.Equal(t, interpreted, emulated)
+	assert.Equal(t, "-95\n21\n203\n9", emulated)
+}
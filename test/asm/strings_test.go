@@ -0,0 +1,23 @@
+package asm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// TestStringLengths checks that the emulated strings keep their length: they can contain NUL, be empty,
+// and the records holding them are equal when their characters are
+func TestStringLengths(t *testing.T) {
+	for _, file := range []string{"../../examples/exec/lengths.adb", "../../examples/exec/record5.adb"} {
+		expected, err := reference(t, file)
+		require.NoError(t, err, file)
+		for optimizationLevel := 0; optimizationLevel <= 1; optimizationLevel++ {
+			text, ok := compile(t, file, optimizationLevel)
+			require.True(t, ok, file)
+			output, err := run(text)
+			assert.NoError(t, err, file)
+			assert.Equal(t, expected, output, file)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+procedure Field is
+   type P is record X, Y: Integer; end record;
+   type R is record A, B: Character; Q: P; N: Integer; end record;
+   X: R;
+   function F return R is
+   begin
+      return X;
+   end;
+   Z: P;
+begin
+   X.B := 'b';
+   Put(F.B);
+   X.Q.X := 6;
+   X.Q.Y := 12;
+   X.N := 104;
+   Put(F.Q.Y);
+   Put(F.N);
+   Z := F.Q;
+   Put(Z.X);
+   if F.Q.X = 6 then
+      Put('t');
+   end if;
+end Field;
@@ -0,0 +1,13 @@
+with Ada.Text_IO; use Ada.Text_IO;
+
+-- The interpreter stops when a function ends without return, the ARM program returns what is on the stack
+procedure No_Return is
+   function F(X: Integer) return Integer is
+   begin
+      if X > 0 then
+         return 1;
+      end if;
+   end F;
+begin
+   Put(F(0));
+end No_Return;
@@ -0,0 +1,27 @@
+// Runs a module built from the WAT of gada with the host functions of wasm.Run: node run_wasm.js program.wasm
+const fs = require('fs');
+
+const output = [];
+let memory;
+const imports = {
+  gada: {
+    put_char: c => output.push(c & 255),
+    put_int: n => output.push(...Buffer.from(String(n))),
+    put_string: s => {
+      // The length of the string is in the word before its characters, the null access is the empty string
+      if (s !== 0) {
+        const length = new DataView(memory.buffer).getUint32(s, true);
+        output.push(...new Uint8Array(memory.buffer, s + 4, length));
+      }
+    },
+    new_line: () => output.push(10),
+  },
+};
+
+const instance = new WebAssembly.Instance(new WebAssembly.Module(fs.readFileSync(process.argv[2])), imports);
+memory = instance.exports.memory;
+try {
+  instance.exports.main();
+} finally {
+  process.stdout.write(Buffer.from(output));
+}
@@ -0,0 +1,72 @@
+package asm
+
+import (
+	"context"
+	"gada/parser"
+	"gada/reader"
+	"gada/wasm"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWasm checks that the WebAssembly modules run in-process print the same thing as the interpreter
+func TestWasm(t *testing.T) {
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	for _, file := range files {
+		expected, err := reference(t, file)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		source, translateErr := parser.CompileToWAT(l)
+		if !assert.NoError(t, translateErr, file) {
+			continue
+		}
+		var output strings.Builder
+		runErr := wasm.Run(source, &output, 0)
+		assertOutput(t, file, output.String(), runErr, expected, err)
+	}
+}
+
+// TestWatToolchain checks that the modules are valid for wat2wasm, the assembler of the WebAssembly text format,
+// and when node is installed that they print the same thing as the interpreter in its engine
+func TestWatToolchain(t *testing.T) {
+	if _, err := exec.LookPath("wat2wasm"); err != nil {
+		t.Skip("wat2wasm is not installed")
+	}
+	_, nodeErr := exec.LookPath("node")
+
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	folder := t.TempDir()
+	for _, file := range files {
+		expected, err := reference(t, file)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		source, translateErr := parser.CompileToWAT(l)
+		if !assert.NoError(t, translateErr, file) {
+			continue
+		}
+		output := filepath.Join(folder, strings.TrimSuffix(filepath.Base(file), ".adb"))
+		assert.NoError(t, os.WriteFile(output+".wat", []byte(source), 0644))
+		message, buildErr := exec.Command("wat2wasm", output+".wat", "-o", output+".wasm").CombinedOutput()
+		if !assert.NoError(t, buildErr, "%s: %s", file, message) || nodeErr != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		native, nativeErr := exec.CommandContext(ctx, "node", "testdata/run_wasm.js", output+".wasm").Output()
+		cancel()
+		if err == nil {
+			// A module rejected or trapped by the engine prints nothing, which starts any output
+			assert.NoError(t, nativeErr, file)
+		}
+		assertOutput(t, file, string(native), nativeErr, expected, err)
+	}
+}
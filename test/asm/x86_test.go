@@ -0,0 +1,47 @@
+package asm
+
+import (
+	"gada/parser"
+	"gada/reader"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestX86 checks that the native x86-64 programs print the same thing as the interpreter
+func TestX86(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("the x86-64 backend targets Linux")
+	}
+	for _, tool := range []string{"as", "ld"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool + " is not installed")
+		}
+	}
+
+	files, err := filepath.Glob("../../examples/exec/*.adb")
+	assert.NoError(t, err)
+	folder := t.TempDir()
+	for _, file := range files {
+		expected, err := reference(t, file)
+
+		l := reader.FileLexer(file)
+		l.Read()
+		source, translateErr := parser.CompileToX86(l)
+		if !assert.NoError(t, translateErr, file) {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".adb")
+		output := filepath.Join(folder, name)
+		assert.NoError(t, os.WriteFile(output+".s", []byte(source), 0644))
+		if !assert.NoError(t, reader.BuildX86(output+".s", output), file) {
+			continue
+		}
+
+		assertSameOutput(t, file, output, expected, err)
+	}
+}
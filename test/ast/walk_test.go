@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"gada/ast"
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const program = `with Ada.Text_IO; use Ada.Text_IO;
+procedure Walk is
+   X : Integer := 1;
+   function Square(N : Integer) return Integer is
+   begin
+      return N * N;
+   end Square;
+begin
+   while X < 10 loop
+      X := Square(X + 1);
+   end loop;
+end Walk;
+`
+
+func parse(t *testing.T) *ast.File {
+	l := lexer.NewLexer("walk.adb", program)
+	l.Read()
+	graph, err := parser.ParseTokens(l)
+	assert.NoError(t, err)
+	return graph.File()
+}
+
+// names returns the identifiers of the tree in the order they are visited
+func names(node ast.Node) []string {
+	var names []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			names = append(names, ident.Name)
+		}
+		return true
+	})
+	return names
+}
+
+func TestInspect(t *testing.T) {
+	file := parse(t)
+	assert.Equal(t, []string{"Walk", "X", "Integer", "Square", "N", "Integer", "Integer", "N", "N", "Square", "X", "X", "Square", "X", "Walk"}, names(file))
+
+	// Pruning the functions skips their parameters and body
+	var visited []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			visited = append(visited, ident.Name)
+		}
+		_, isSubprogram := n.(*ast.SubprogramDecl)
+		return !isSubprogram
+	})
+	assert.Equal(t, []string{"Walk", "X", "Integer", "X", "X", "Square", "X", "Walk"}, visited)
+}
+
+type depth struct {
+	current int
+	max     int
+}
+
+func (d *depth) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		d.current--
+		return nil
+	}
+	d.current++
+	if d.current > d.max {
+		d.max = d.current
+	}
+	return d
+}
+
+func TestWalk(t *testing.T) {
+	d := &depth{}
+	// while > assign > call > binary > ident
+	ast.Walk(d, parse(t).Body[0])
+	assert.Equal(t, 0, d.current)
+	assert.Equal(t, 5, d.max)
+}
+
+func TestTraverse(t *testing.T) {
+	var pre, post []string
+	ast.Traverse(parse(t).Body[0], func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.WhileStmt:
+			pre = append(pre, "while")
+		case *ast.AssignStmt:
+			pre = append(pre, ":=")
+		case *ast.BinaryExpr:
+			pre = append(pre, n.Op)
+			return false
+		}
+		return true
+	}, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.WhileStmt:
+			post = append(post, "while")
+		case *ast.AssignStmt:
+			post = append(post, ":=")
+		case *ast.BinaryExpr:
+			post = append(post, n.Op)
+		}
+	})
+	assert.Equal(t, []string{"while", "<", ":=", "+"}, pre)
+	// The pruned binary expressions are not left
+	assert.Equal(t, []string{":=", "while"}, post)
+}
+
+func TestReplace(t *testing.T) {
+	file := parse(t)
+	loop := file.Body[0].(*ast.WhileStmt)
+	assign := loop.Body[0].(*ast.AssignStmt)
+	argument := assign.Value.(*ast.CallExpr).Args[0]
+	one := &ast.IntLit{Value: 1}
+	assert.True(t, ast.Replace(file, argument, one))
+	assert.Same(t, one, assign.Value.(*ast.CallExpr).Args[0])
+	// The expression is found by identity, it is no longer in the tree
+	assert.False(t, ast.Replace(file, argument, one))
+	assert.Equal(t, []string{"Walk", "X", "Integer", "Square", "N", "Integer", "Integer", "N", "N", "Square", "X", "X", "Square", "Walk"}, names(file))
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"gada/asm"
+	"gada/generator"
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	assert.Equal(t, generator.Generate(generator.DefaultConfig(7)), generator.Generate(generator.DefaultConfig(7)))
+	assert.NotEqual(t, generator.Generate(generator.DefaultConfig(7)), generator.Generate(generator.DefaultConfig(8)))
+}
+
+// TestWellTyped checks that the generated programs are accepted by the front end and run without error,
+// with and without the access types
+func TestWellTyped(t *testing.T) {
+	var logs bytes.Buffer
+	parser.SetLogOutput(&logs)
+	defer parser.SetLogOutput(nil)
+	for seed := int64(1); seed <= 200; seed++ {
+		config := generator.DefaultConfig((seed + 1) / 2)
+		config.NoAccess = seed%2 == 0
+		source := generator.Generate(config)
+		l := lexer.NewLexer("generated"+strconv.FormatInt(seed, 10)+".adb", source)
+		l.Read()
+		logs.Reset()
+		graph, err := parser.Analyse(l)
+		assert.NotContains(t, logs.String(), "ERRO", source)
+		if !assert.NoError(t, err, source) {
+			continue
+		}
+		var output strings.Builder
+		err = parser.Interpret(graph, &output, 1_000_000)
+		if err != nil && !errors.Is(err, asm.ErrStepLimit) {
+			assert.NoError(t, err, source)
+		}
+	}
+}
+
+// TestNoAccess checks that the programs for the backends without a heap declare and use no access type
+func TestNoAccess(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		config := generator.DefaultConfig(seed)
+		config.NoAccess = true
+		source := generator.Generate(config)
+		for _, construct := range []string{" access ", "new Node", "List", ".Value", ".Next"} {
+			assert.NotContains(t, source, construct, source)
+		}
+	}
+}
+
+// TestCoverage checks that the programs use the whole subset
+func TestCoverage(t *testing.T) {
+	var all strings.Builder
+	for seed := int64(1); seed <= 20; seed++ {
+		all.WriteString(generator.Generate(generator.DefaultConfig(seed)))
+	}
+	programs := all.String()
+	for _, construct := range []string{"function ", "procedure ", ": in out ", " is record", "new Node", ".Value", ".Next :=",
+		"for ", " in reverse ", "while ", "elsif ", "else\n", "Character'Val(", "and then", "or else", "return "} {
+		assert.Contains(t, programs, construct)
+	}
+	// A nested subprogram and an overloaded one
+	assert.Regexp(t, `\n {6}(procedure|function) `, programs)
+	declared := map[string]int{}
+	for _, match := range regexp.MustCompile(`(?:procedure|function) ([PF]\d+)\(`).FindAllStringSubmatch(programs, -1) {
+		declared[match[1]]++
+	}
+	overloaded := false
+	for _, count := range declared {
+		overloaded = overloaded || count > 1
+	}
+	assert.True(t, overloaded)
+}
@@ -0,0 +1,107 @@
+package grammar
+
+import (
+	"gada/grammar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+const arrows = `e -> t e2
+
+e2 -> + t e2
+e2 -> ''
+
+t -> f t2
+
+t2 -> * f t2
+t2 -> ''
+
+f -> ( e )
+f -> id
+`
+
+const yacc = `%token id
+%% /* the same grammar */
+
+e : t e2 ;
+e2
+    : '+' t e2
+    | /*eps*/ ;
+t : f t2 ;
+t2 : '*' f t2 | ;
+f : '(' e ')' | id ;
+`
+
+func TestSets(t *testing.T) {
+	g, err := grammar.Parse("expr", arrows)
+	require.NoError(t, err)
+	assert.Equal(t, "e", g.Start)
+	assert.Equal(t, []string{"e", "e2", "t", "t2", "f"}, g.Nonterminals)
+	assert.Equal(t, "e2 -> ''", g.Productions[2].String())
+
+	a := grammar.Analyse(g)
+	assert.Equal(t, map[string]bool{"e2": true, "t2": true}, a.Nullable)
+	assert.Equal(t, []string{"(", "id"}, a.First["e"].Sorted())
+	assert.Equal(t, []string{"+"}, a.First["e2"].Sorted())
+	assert.Equal(t, []string{"$", ")"}, a.Follow["e2"].Sorted())
+	assert.Equal(t, []string{"$", ")", "+"}, a.Follow["t"].Sorted())
+	assert.Equal(t, []string{"$", ")", "*", "+"}, a.Follow["f"].Sorted())
+	assert.Equal(t, []int{2}, a.Table["e2"]["$"])
+	assert.Equal(t, []int{6}, a.Table["f"]["("])
+	assert.Empty(t, a.Conflicts())
+}
+
+func TestYaccSyntax(t *testing.T) {
+	g, err := grammar.Parse("expr.g", yacc)
+	require.NoError(t, err)
+	assert.Len(t, g.Productions, 8)
+	assert.Equal(t, 7, g.Productions[2].Line)
+	assert.Equal(t, []string{"'('", "e", "')'"}, g.Productions[6].Right)
+
+	a := grammar.Analyse(g)
+	assert.Equal(t, []string{"$", "')'", "'*'", "'+'"}, a.Follow["f"].Sorted())
+	assert.Empty(t, a.Conflicts())
+
+	_, err = grammar.Parse("bad.g", "e : 'a' \n f : 'b' ;")
+	assert.EqualError(t, err, "bad.g:1: rule e is not ended by ;")
+}
+
+func TestConflicts(t *testing.T) {
+	g, err := grammar.Parse("conflicts", `s -> a b
+s -> a c
+a -> x
+a -> ''
+b -> x
+c -> y
+`)
+	require.NoError(t, err)
+	conflicts := grammar.Analyse(g).Conflicts()
+	if assert.Len(t, conflicts, 2) {
+		// the conflicts are in the order of the nonterminals
+		assert.Equal(t, "s", conflicts[0].Nonterminal)
+		assert.Equal(t, "x", conflicts[0].Terminal)
+		assert.False(t, conflicts[0].FirstFollow)
+		assert.Equal(t, []grammar.Production{g.Productions[0], g.Productions[1]}, conflicts[0].Productions)
+		// a can be empty and followed by x
+		assert.Equal(t, "a", conflicts[1].Nonterminal)
+		assert.Equal(t, "x", conflicts[1].Terminal)
+		assert.True(t, conflicts[1].FirstFollow)
+	}
+}
+
+// TestRepositoryGrammars checks that the grammars of the repository are read and that grammar_ll1.g is LL(1)
+func TestRepositoryGrammars(t *testing.T) {
+	for _, file := range []string{"grammar.g", "grammar_ll1.g", "grammar_ll1_v2", "grammar_ll1_v2_simple"} {
+		text, err := os.ReadFile("../../" + file)
+		require.NoError(t, err)
+		g, err := grammar.Parse(file, string(text))
+		if assert.NoError(t, err, file) {
+			assert.Equal(t, "fichier", g.Start, file)
+			if file == "grammar_ll1.g" {
+				assert.Empty(t, grammar.Analyse(g).Conflicts())
+			}
+		}
+	}
+}
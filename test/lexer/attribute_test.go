@@ -0,0 +1,27 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAttributeTick(t *testing.T) {
+	for text, values := range map[string][]int{
+		"Integer'Image(X)": {token.IDENT, token.TICK, token.IDENT, token.LPAREN, token.IDENT, token.RPAREN},
+		"F(X)'Length":      {token.IDENT, token.LPAREN, token.IDENT, token.RPAREN, token.TICK, token.IDENT},
+		"C := 'a'":         {token.IDENT, token.ASSIGN, token.CHAR},
+		"Put(''')":         {token.IDENT, token.LPAREN, token.CHAR, token.RPAREN},
+		"Character'Val(3)": {token.IDENT, token.TICK, token.IDENT, token.LPAREN, token.INT, token.RPAREN},
+		"Character'('a')":  {token.IDENT, token.TICK, token.LPAREN, token.CHAR, token.RPAREN},
+	} {
+		l := lexer.NewLexer("attribute.adb", text)
+		tokens, _ := l.Read()
+		var read []int
+		for _, tkn := range tokens {
+			read = append(read, tkn.Value)
+		}
+		assert.Equal(t, values, read, text)
+	}
+}
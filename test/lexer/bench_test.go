@@ -0,0 +1,53 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// generatedSource returns a program of about the given number of lines
+func generatedSource(lines int) string {
+	var text strings.Builder
+	text.WriteString("with Ada.Text_IO; use Ada.Text_IO;\nprocedure Big is\n   X : Integer := 0;\nbegin\n")
+	for i := 4; i < lines-1; i++ {
+		text.WriteString("   X := X + " + strconv.Itoa(i) + " * Character'Pos('a'); -- line " + strconv.Itoa(i) + "\n")
+	}
+	text.WriteString("end Big;\n")
+	return text.String()
+}
+
+// The time per line stays the same from 10k to 100k lines
+func BenchmarkNextToken(b *testing.B) {
+	for _, lines := range []int{10_000, 100_000} {
+		text := generatedSource(lines)
+		b.Run(strconv.Itoa(lines), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				l := lexer.NewLexer("big.adb", text)
+				for tkn := l.NextToken(); tkn.Value != token.EOF; tkn = l.NextToken() {
+				}
+			}
+		})
+	}
+}
+
+// GetToken finds the text of every token without splitting the whole text each time
+func BenchmarkGetToken(b *testing.B) {
+	for _, lines := range []int{10_000, 100_000} {
+		text := generatedSource(lines)
+		l := lexer.NewLexer("big.adb", text)
+		tokens, _ := l.Read()
+		b.Run(strconv.Itoa(lines), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				for _, tkn := range tokens {
+					l.GetToken(tkn)
+					l.GetLineUpToToken(tkn)
+				}
+			}
+		})
+	}
+}
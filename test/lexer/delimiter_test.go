@@ -0,0 +1,50 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompoundDelimiters(t *testing.T) {
+	for text, kind := range map[string]int{
+		":=": token.ASSIGN,
+		"..": token.DOUBLE_DOT,
+		"=>": token.ARROW,
+		"**": token.EXPON,
+		"<>": token.BOX,
+		"/=": token.NEQ,
+		"<=": token.LEQ,
+		">=": token.GEQ,
+		"<<": token.LLABEL,
+		">>": token.RLABEL,
+	} {
+		l := lexer.NewLexer("delimiter.adb", "x"+text+"y")
+		tokens, _ := l.Read()
+		if assert.Len(t, tokens, 3, text) {
+			assert.Equal(t, kind, tokens[1].Value, text)
+			assert.Equal(t, lexer.Position{Line: 1, Column: 2}, tokens[1].Beginning, text)
+			assert.Equal(t, lexer.Position{Line: 1, Column: 4}, tokens[1].End, text)
+			assert.Equal(t, text, token.Token(kind).String())
+		}
+	}
+}
+
+func TestSpacedDelimiters(t *testing.T) {
+	for text, kinds := range map[string][]int{
+		": =": {token.COLON, token.EQL},
+		". .": {token.PERIOD, token.PERIOD},
+		"* *": {token.MUL, token.MUL},
+		"...": {token.DOUBLE_DOT, token.PERIOD},
+		":==": {token.ASSIGN, token.EQL},
+	} {
+		l := lexer.NewLexer("delimiter.adb", text)
+		tokens, _ := l.Read()
+		var found []int
+		for _, tkn := range tokens {
+			found = append(found, tkn.Value)
+		}
+		assert.Equal(t, kinds, found, text)
+	}
+}
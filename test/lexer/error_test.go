@@ -0,0 +1,35 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLexicalErrors(t *testing.T) {
+	for text, expected := range map[string]lexer.Error{
+		"x := 'ab';":  {Code: lexer.InvalidCharacter, Span: lexer.Span{Start: 5, End: 9}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 10}, Reason: "a character literal holds a single character"},
+		"x := 'a\ny;": {Code: lexer.NewlineInCharacter, Span: lexer.Span{Start: 5, End: 7}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 8}},
+		"x := '":      {Code: lexer.UnterminatedCharacter, Span: lexer.Span{Start: 5, End: 6}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 7}},
+		"x := \"a":    {Code: lexer.UnterminatedString, Span: lexer.Span{Start: 5, End: 7}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 8}, Reason: "a string ends on its line with \""},
+		"x := 1 $ 2;": {Code: lexer.InvalidCharacter, Span: lexer.Span{Start: 7, End: 8}, Beginning: lexer.Position{Line: 1, Column: 8}, End: lexer.Position{Line: 1, Column: 9}},
+		"x := 2#12#;": {Code: lexer.InvalidNumber, Span: lexer.Span{Start: 5, End: 10}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 11}, Reason: "the digit 2 is not a base 2 digit"},
+	} {
+		l := lexer.NewLexer("error.adb", text)
+		l.Read()
+		if assert.Len(t, l.Errors, 1, text) {
+			assert.Equal(t, expected, l.Errors[0], text)
+		}
+	}
+}
+
+func TestErrorReport(t *testing.T) {
+	l := lexer.NewLexer("error.adb", "procedure P is\n   x := 1 $ 2;")
+	tokens, _ := l.Read()
+	// The parser sees the tokens around the error
+	assert.Len(t, tokens, 8)
+	if assert.Len(t, l.Errors, 1) {
+		assert.Equal(t, "2:11 Unexpected character", l.Errors[0].Error())
+		assert.Equal(t, "error.adb:2:11 Unexpected character: x := 1 \x1b[0;31m$\x1b[0m", l.Report(l.Errors[0]))
+	}
+}
@@ -0,0 +1,50 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// addExamples seeds the fuzzer with the programs of the examples folder
+func addExamples(f *testing.F) {
+	err := filepath.WalkDir("../../examples", func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || (!strings.HasSuffix(path, ".adb") && !strings.HasSuffix(path, ".ada")) {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err == nil {
+			f.Add(string(content))
+		}
+		return err
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+}
+
+// FuzzLexer checks that any text is turned into tokens, the invalid characters become lexical errors
+func FuzzLexer(f *testing.F) {
+	addExamples(f)
+	f.Add("'")
+	f.Add("'é")
+	f.Add("\"abc")
+	f.Fuzz(func(t *testing.T, text string) {
+		l := lexer.NewLexer("fuzz.adb", text)
+		tokens, lexi := l.Read()
+		lines := strings.Count(text, "\n") + 1
+		for _, tkn := range tokens {
+			if tkn.Beginning.Line < 1 || tkn.Beginning.Line > lines || tkn.End.Line < tkn.Beginning.Line {
+				t.Fatalf("token %v out of the text", tkn)
+			}
+		}
+		for _, word := range lexi {
+			if utf8.ValidString(text) && !utf8.ValidString(word) {
+				t.Fatalf("invalid word %q", word)
+			}
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIdentifierRules(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"X":         true,
+		"Put_Line":  true,
+		"A1_B2":     true,
+		"Été":       true,
+		"π_2":       true,
+		"Ⅻ_Roman":   true,
+		"_X":        false,
+		"1X":        false,
+		"double__u": false,
+		"trailing_": false,
+		"X-Y":       false,
+		"begin":     false,
+		"BEGIN":     false,
+		"":          false,
+	} {
+		assert.Equal(t, valid, token.IsIdentifier(name), name)
+	}
+}
+
+func TestBadIdentifiers(t *testing.T) {
+	for text, expected := range map[string]lexer.Error{
+		"A__B := 1;": {Code: lexer.BadIdentifier, Span: lexer.Span{Start: 0, End: 4}, Beginning: lexer.Position{Line: 1, Column: 1}, End: lexer.Position{Line: 1, Column: 5}, Reason: "an identifier cannot contain two consecutive underscores"},
+		"x := B_;":   {Code: lexer.BadIdentifier, Span: lexer.Span{Start: 5, End: 7}, Beginning: lexer.Position{Line: 1, Column: 6}, End: lexer.Position{Line: 1, Column: 8}, Reason: "an identifier cannot end with an underscore"},
+	} {
+		l := lexer.NewLexer("identifier.adb", text)
+		l.Read()
+		if assert.Len(t, l.Errors, 1, text) {
+			assert.Equal(t, expected, l.Errors[0], text)
+		}
+	}
+}
+
+func TestFoldIdentifier(t *testing.T) {
+	assert.Equal(t, "été", token.FoldIdentifier("ÉTÉ"))
+	assert.Equal(t, token.FoldIdentifier("Straße"), token.FoldIdentifier("STRAẞE"))
+	// The long s and the final sigma fold like s and σ
+	assert.Equal(t, token.FoldIdentifier("SUM"), token.FoldIdentifier("ſum"))
+	assert.Equal(t, token.FoldIdentifier("ΣΟΦΟΣ"), token.FoldIdentifier("σοφος"))
+	// The Kelvin sign folds like k
+	assert.Equal(t, "kelvin", token.FoldIdentifier("\u212Aelvin"))
+	assert.Equal(t, token.FoldIdentifier("KELVIN"), token.FoldIdentifier("\u212Aelvin"))
+	// The dotted capital I and the dotless small i only fold in Turkish, they are not i
+	assert.NotEqual(t, "if", token.FoldIdentifier("İf"))
+	assert.NotEqual(t, "if", token.FoldIdentifier("ıf"))
+	assert.Equal(t, token.Token(token.IDENT), token.LookupIdent("İf"))
+	assert.Equal(t, token.Token(token.IDENT), token.LookupIdent("ıf"))
+	assert.Equal(t, token.Token(token.IF), token.LookupIdent("IF"))
+	assert.Equal(t, token.Token(token.IDENT), token.LookupIdent("Élan"))
+	assert.Equal(t, token.Token(token.BEGIN), token.LookupIdent("BEGIN"))
+}
@@ -49,6 +49,15 @@ func TestAll(t *testing.T) {
 				file.Name(), ind, tok, tokenLit1, expecTokens[ind], tokenLit2)
 		}
 
+		expecErrors := expected[nameNoExt].errors
+		if assert.Lenf(t, fileLexer.Errors, len(expecErrors), "The error count doesn't match in file %s", file.Name()) {
+			for ind, err := range fileLexer.Errors {
+				assert.Equalf(t, expecErrors[ind].Code, err.Code, "The error code doesn't match in file %s", file.Name())
+				assert.Equalf(t, expecErrors[ind].Beginning, err.Beginning, "The error doesn't begin at the same position in file %s", file.Name())
+				assert.Equalf(t, expecErrors[ind].End, err.End, "The error doesn't end at the same position in file %s", file.Name())
+			}
+		}
+
 		t.Logf("Test %s ending\n", file.Name())
 	}
 }
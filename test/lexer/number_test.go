@@ -0,0 +1,92 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestIntegerLiterals(t *testing.T) {
+	for text, value := range map[string]int64{
+		"0":               0,
+		"1_000_000":       1000000,
+		"16#FF#":          255,
+		"16#ff#":          255,
+		"2#1010_1010#":    170,
+		"8#777#":          511,
+		"1E6":             1000000,
+		"1e+3":            1000,
+		"16#F#E1":         240,
+		"2147483647":      2147483647,
+		"2147483648":      2147483648,
+		"2#1111_1111#E2":  1020,
+		"0E99":            0,
+		"10#2147483647#":  2147483647,
+		"1_2_3_4_5_6_7_8": 12345678,
+	} {
+		l := lexer.NewLexer("number.adb", text)
+		tokens, lexi := l.Read()
+		if assert.Len(t, tokens, 1, text) {
+			assert.Equal(t, token.INT, tokens[0].Value, text)
+			assert.Equal(t, value, tokens[0].IntValue, text)
+			assert.Equal(t, len(text)+1, tokens[0].End.Column, text)
+			// The lexicon holds the decimal value
+			assert.Equal(t, []string{strconv.FormatInt(value, 10)}, lexi, text)
+		}
+	}
+}
+
+func TestRealLiterals(t *testing.T) {
+	for text, value := range map[string]float64{
+		"3.14":        3.14,
+		"0.5":         0.5,
+		"1_000.000_1": 1000.0001,
+		"6.02E23":     6.02e23,
+		"1.0e-3":      0.001,
+		"2#1.1#":      1.5,
+		"16#F.8#E1":   248,
+	} {
+		l := lexer.NewLexer("number.adb", text)
+		tokens, _ := l.Read()
+		if assert.Len(t, tokens, 1, text) {
+			assert.Equal(t, token.REAL, tokens[0].Value, text)
+			assert.InDelta(t, value, tokens[0].RealValue, 1e-9*value, text)
+		}
+	}
+}
+
+func TestRangeIsNotReal(t *testing.T) {
+	l := lexer.NewLexer("number.adb", "1..10")
+	tokens, lexi := l.Read()
+	if assert.Len(t, tokens, 3) {
+		assert.Equal(t, token.INT, tokens[0].Value)
+		assert.Equal(t, token.DOUBLE_DOT, tokens[1].Value)
+		assert.Equal(t, token.INT, tokens[2].Value)
+		assert.Equal(t, []string{"1", "10"}, lexi)
+	}
+}
+
+func TestInvalidNumericLiterals(t *testing.T) {
+	for _, text := range []string{
+		"2147483649",
+		"16#FFFF_FFFF#",
+		"1E10",
+		"1__000",
+		"1000_",
+		"2#102#",
+		"17#1#",
+		"16#FF",
+		"1E-2",
+	} {
+		l := lexer.NewLexer("number.adb", text)
+		tokens, _ := l.Read()
+		assert.Empty(t, tokens, text)
+		if assert.Len(t, l.Errors, 1, text) {
+			assert.Equal(t, lexer.InvalidNumber, l.Errors[0].Code, text)
+			assert.Equal(t, lexer.Span{Start: 0, End: len(text)}, l.Errors[0].Span, text)
+			assert.NotEmpty(t, l.Errors[0].Reason, text)
+		}
+	}
+}
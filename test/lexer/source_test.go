@@ -0,0 +1,50 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSourceFile(t *testing.T) {
+	file := lexer.NewSourceFile("source.adb", "ab\n\nçé x\n")
+	assert.Equal(t, 4, file.LineCount())
+	assert.Equal(t, "ab", file.Line(1))
+	assert.Equal(t, "", file.Line(2))
+	assert.Equal(t, "çé x", file.Line(3))
+	assert.Equal(t, 4, file.LineStart(3))
+
+	// The columns count runes while the offsets count bytes
+	for offset, position := range map[int]lexer.Position{
+		0:  {Line: 1, Column: 1},
+		2:  {Line: 1, Column: 3},
+		3:  {Line: 2, Column: 1},
+		6:  {Line: 3, Column: 2},
+		9:  {Line: 3, Column: 4},
+		11: {Line: 4, Column: 1},
+	} {
+		assert.Equal(t, position, file.Position(offset), offset)
+		assert.Equal(t, offset, file.Offset(position), position)
+	}
+	assert.Equal(t, 2, file.Offset(lexer.Position{Line: 1, Column: 10}))
+	assert.Equal(t, "é x", file.Slice(lexer.Span{Start: 6, End: 10}))
+}
+
+func TestNextToken(t *testing.T) {
+	text := "X := Integer'Image(42); -- comment\nPut_Line(\"é\");"
+	l := lexer.NewLexer("next.adb", text)
+	var words []string
+	for tkn := l.NextToken(); tkn.Value != token.EOF; tkn = l.NextToken() {
+		words = append(words, l.File.Slice(tkn.Span))
+		assert.Equal(t, tkn.Beginning, l.File.Position(tkn.Span.Start))
+	}
+	assert.Equal(t, []string{"X", ":=", "Integer", "'", "Image", "(", "42", ")", ";", "Put_Line", "(", `"é"`, ")", ";"}, words)
+	assert.Equal(t, []string{"X", "Integer", "Image", "42", "Put_Line", "é"}, l.Lexi)
+
+	// Read returns the same tokens
+	tokens, _ := lexer.NewLexer("next.adb", text).Read()
+	if assert.Len(t, tokens, len(words)) {
+		assert.Equal(t, `"é"`, l.GetToken(tokens[11]))
+	}
+}
@@ -0,0 +1,41 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStringLiterals(t *testing.T) {
+	for text, value := range map[string]string{
+		`""`:                 "",
+		`"abc"`:              "abc",
+		`"say ""hi"""`:       `say "hi"`,
+		`""""`:               `"`,
+		`"--not a comment"`:  "--not a comment",
+		`"a 'quoted' thing"`: "a 'quoted' thing",
+	} {
+		l := lexer.NewLexer("string.adb", text)
+		tokens, _ := l.Read()
+		if assert.Len(t, tokens, 1, text) {
+			assert.Equal(t, token.STRING, tokens[0].Value, text)
+			assert.Equal(t, value, l.Word(tokens[0].Position), text)
+			assert.Equal(t, len(text)+1, tokens[0].End.Column, text)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := lexer.NewLexer("string.adb", "x := \"abc\ny := 1;")
+	tokens, _ := l.Read()
+	if assert.Len(t, l.Errors, 1) {
+		assert.Equal(t, lexer.UnterminatedString, l.Errors[0].Code)
+		assert.Equal(t, `"abc`, l.File.Slice(l.Errors[0].Span))
+	}
+	if assert.Len(t, tokens, 6) {
+		// The newline ends the literal, the next line is read as usual
+		assert.Equal(t, token.IDENT, tokens[2].Value)
+		assert.Equal(t, lexer.Position{Line: 2, Column: 1}, tokens[2].Beginning)
+	}
+}
@@ -0,0 +1,53 @@
+package lexer
+
+import (
+	"gada/lexer"
+	"gada/token"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrivia(t *testing.T) {
+	l := lexer.NewTriviaLexer("trivia.adb", "-- header\n\nX := 1; -- one\r\n  Y\t:= 2;\n-- end")
+	tokens, _ := l.Read()
+	if !assert.Len(t, tokens, 9) {
+		return
+	}
+
+	assert.Equal(t, []lexer.Trivia{{Kind: lexer.Comment, Text: "-- header"}, {Kind: lexer.Newline, Text: "\n"}, {Kind: lexer.Newline, Text: "\n"}}, tokens[0].Leading)
+	assert.Equal(t, []lexer.Trivia{{Kind: lexer.Whitespace, Text: " "}}, tokens[0].Trailing)
+	assert.Equal(t, []lexer.Trivia{{Kind: lexer.Whitespace, Text: " "}, {Kind: lexer.Comment, Text: "-- one"}, {Kind: lexer.Newline, Text: "\r\n"}}, tokens[3].Trailing)
+	assert.Equal(t, []lexer.Trivia{{Kind: lexer.Whitespace, Text: "  "}}, tokens[4].Leading)
+	assert.Equal(t, lexer.Position{Line: 4, Column: 3}, tokens[4].Beginning)
+	assert.Equal(t, token.EOF, tokens[8].Value)
+	assert.Equal(t, []lexer.Trivia{{Kind: lexer.Comment, Text: "-- end"}}, tokens[8].Leading)
+}
+
+func TestTriviaSkippedText(t *testing.T) {
+	for _, text := range []string{"X := 'a", "X -", "'", "", "  \n"} {
+		l := lexer.NewTriviaLexer("trivia.adb", text)
+		tokens, _ := l.Read()
+		assert.Equal(t, text, l.Text(tokens))
+	}
+}
+
+// Every file of the examples is written back byte for byte from its tokens
+func TestTriviaExamples(t *testing.T) {
+	err := filepath.WalkDir("../../examples", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		l := lexer.NewTriviaLexer(path, string(content))
+		tokens, _ := l.Read()
+		assert.Equal(t, string(content), l.Text(tokens), path)
+		return nil
+	})
+	assert.NoError(t, err)
+}
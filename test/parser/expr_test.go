@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"gada/ast"
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// parenthesize writes an expression with the parentheses given by the precedence of its operators
+func parenthesize(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		return "(" + parenthesize(e.X) + " " + e.Op + " " + parenthesize(e.Y) + ")"
+	case *ast.UnaryExpr:
+		return "(" + e.Op + " " + parenthesize(e.X) + ")"
+	case *ast.Ident:
+		return e.Name
+	case *ast.IntLit:
+		return fmt.Sprint(e.Value)
+	case *ast.CallExpr:
+		return e.Fun.Name + "(...)"
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// parseExpr parses X := expr; in a procedure and returns the value assigned
+func parseExpr(t *testing.T, expr string) (ast.Expr, error) {
+	l := lexer.NewLexer("expr.adb", "with Ada.Text_IO; use Ada.Text_IO;\nprocedure P is\nbegin\n   X := "+expr+";\nend P;\n")
+	l.Read()
+	graph, err := parser.ParseTokens(l)
+	file := graph.File()
+	if !assert.Len(t, file.Body, 1, expr) {
+		return nil, err
+	}
+	return file.Body[0].(*ast.AssignStmt).Value, err
+}
+
+func TestExprPrecedence(t *testing.T) {
+	for expr, expected := range map[string]string{
+		"A + B * C":           "(A + (B * C))",
+		"A - B + C":           "((A - B) + C)",
+		"A * B rem C / D":     "(((A * B) rem C) / D)",
+		"-A * B":              "(- (A * B))",
+		"- N / 10 + 1":        "((- (N / 10)) + 1)",
+		"not A = B":           "((not A) = B)",
+		"A + 1 < B * 2":       "((A + 1) < (B * 2))",
+		"A = B and C /= D":    "((A = B) and (C != D))",
+		"A or B or C":         "((A or B) or C)",
+		"A and then B":        "(A and then B)",
+		"(A or B) and C":      "((A or B) and C)",
+		"A or else (B and C)": "(A or else (B and C))",
+		"(A = B) = C":         "((A = B) = C)",
+		"F(1) - (-2)":         "(F(...) - (- 2))",
+		"A < -B":              "(A < (- B))",
+	} {
+		value, err := parseExpr(t, expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, parenthesize(value), expr)
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	var logs bytes.Buffer
+	parser.SetLogOutput(&logs)
+	defer parser.SetLogOutput(nil)
+	for expr, message := range map[string]string{
+		"A and B or C":      `expr.adb:4:17 Mixed logical operators "and" and "or" need parentheses: X := A and B or`,
+		"A or B and then C": `expr.adb:4:20 Mixed logical operators "or" and "and then" need parentheses: X := A or B and then`,
+		"A < B < C":         `expr.adb:4:15 Chained relational operators "<" and "<" need parentheses: X := A < B <`,
+		"A = B /= C = D":    `expr.adb:4:15 Chained relational operators "=" and "/=" need parentheses: X := A = B /=`,
+		"A + * B":           `expr.adb:4:13 Missing operand after: X := A +`,
+		"F(1) - -2":         `expr.adb:4:16 Unary minus must start a simple expression, it needs parentheses: X := F(1) - -`,
+		"A * -B":            `expr.adb:4:13 Unary minus must start a simple expression, it needs parentheses: X := A * -`,
+		"- -A":              `expr.adb:4:11 Unary minus must start a simple expression, it needs parentheses: X := - -`,
+	} {
+		logs.Reset()
+		_, err := parseExpr(t, expr)
+		assert.Error(t, err, expr)
+		assert.Contains(t, logs.String(), message, expr)
+		assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("ERRO")), expr)
+	}
+}
+
+// TestIntegerLiteralRange checks that 2**31 is only accepted as the magnitude of Integer'First
+func TestIntegerLiteralRange(t *testing.T) {
+	var logs bytes.Buffer
+	parser.SetLogOutput(&logs)
+	defer parser.SetLogOutput(nil)
+	for expr, message := range map[string]string{
+		"-2147483648":     "",
+		"- 2147483648":    "",
+		"2147483648":      "expr.adb:4:12 2147483648 does not fit in 32 bits",
+		"1 - 2147483648":  "expr.adb:4:16 2147483648 does not fit in 32 bits",
+		"-(2147483648)":   "",
+		"-2147483648 + 1": "",
+	} {
+		logs.Reset()
+		l := lexer.NewLexer("expr.adb", "with Ada.Text_IO; use Ada.Text_IO;\nprocedure P is\n   X : Integer;\nbegin X := "+expr+";\nend P;\n")
+		l.Read()
+		_, err := parser.Analyse(l)
+		assert.Equal(t, message == "", err == nil, expr)
+		if message == "" {
+			assert.Empty(t, logs.String(), expr)
+		} else {
+			assert.Contains(t, logs.String(), message, expr)
+		}
+	}
+}
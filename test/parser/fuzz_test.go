@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"errors"
+	"gada/lexer"
+	"gada/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// addExamples seeds the fuzzer with the programs of the examples folder
+func addExamples(f *testing.F) {
+	err := filepath.WalkDir("../../examples", func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || (!strings.HasSuffix(path, ".adb") && !strings.HasSuffix(path, ".ada")) {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err == nil {
+			f.Add(string(content))
+		}
+		return err
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+}
+
+// tokens reads the text, the tokens around the lexical errors are parsed even if the compiler stops
+func tokens(text string) *lexer.Lexer {
+	l := lexer.NewLexer("fuzz.adb", text)
+	l.Read()
+	return l
+}
+
+// failOnInternalError fails the fuzz target when a phase crashed, the compiler turns its panics into errors
+func failOnInternalError(t *testing.T, err error) {
+	var ice *parser.InternalError
+	if errors.As(err, &ice) {
+		t.Fatalf("%v\n%s", ice, ice.Stack)
+	}
+}
+
+// FuzzParse checks that any text gives an AST or syntax errors
+func FuzzParse(f *testing.F) {
+	addExamples(f)
+	f.Add("")
+	f.Add("with Ada.Text_IO; use Ada.Text_IO; procedure P is begin end")
+	f.Fuzz(func(t *testing.T, text string) {
+		_, err := parser.ParseTokens(tokens(text))
+		failOnInternalError(t, err)
+	})
+}
+
+// FuzzCheckSemantics checks that any program is checked without crashing, even with syntax errors
+func FuzzCheckSemantics(f *testing.F) {
+	addExamples(f)
+	f.Fuzz(func(t *testing.T, text string) {
+		_, err := parser.Analyse(tokens(text))
+		failOnInternalError(t, err)
+	})
+}
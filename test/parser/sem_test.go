@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bytes"
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const accessDecls = `with Ada.Text_IO; use Ada.Text_IO;
+procedure P is
+   type Node;
+   type List is access Node;
+   type Node is record
+      Value, Count: Integer;
+      Next: List;
+   end record;
+   type Pair is record A, B: Character; end record;
+   L: List := new Node;
+   X: Pair;
+   function First return Pair is
+   begin
+      return X;
+   end First;
+   procedure Set(M: List) is
+   begin
+      M.Next.Value := 1;
+   end Set;
+begin
+`
+
+// TestSemanticErrors checks that the analysis fails with the errors of the statements, and only with them
+func TestSemanticErrors(t *testing.T) {
+	var logs bytes.Buffer
+	parser.SetLogOutput(&logs)
+	defer parser.SetLogOutput(nil)
+	for body, message := range map[string]string{
+		"L.Next := null; L.Value := L.Count + 1;":                         "",
+		"if L /= null and then L.Next = null then Set(new Node); end if;": "",
+		"X.B := X.A; Put(First.A);":                                       "",
+		"L.Prev := null;":                                                 "sem.adb:21:3 Prev is not a field of node",
+		"X.C := 'c';":                                                     "sem.adb:21:3 C is not a field of pair",
+		"L := new Pair;":                                                  "sem.adb:21:6 no access type designates pair",
+		"X := null;":                                                      "sem.adb:21:1 Type mismatch for variable: x is pair and was assigned to list",
+		"First.A := 'a';":                                                 "sem.adb:21:1 Left side of assignment is not a variable",
+		"for I in 1 .. X loop end loop;":                                  "sem.adb:21:15 Right side of for loop should be an integer",
+	} {
+		logs.Reset()
+		l := lexer.NewLexer("sem.adb", accessDecls+body+"\nend P;\n")
+		l.Read()
+		_, err := parser.Analyse(l)
+		if message == "" {
+			assert.NoError(t, err, body)
+			assert.Empty(t, logs.String(), body)
+		} else {
+			assert.Error(t, err, body)
+			assert.Contains(t, logs.String(), message, body)
+		}
+	}
+}
+
+// TestInOutArguments checks that the arguments of the in out parameters are looked up from the call,
+// the variables of the caller are not visible from the procedure called
+func TestInOutArguments(t *testing.T) {
+	var logs bytes.Buffer
+	parser.SetLogOutput(&logs)
+	defer parser.SetLogOutput(nil)
+	for call, message := range map[string]string{
+		"Increment(Y);": "",
+		"for I in 1 .. 2 loop Increment(Y); end loop;": "",
+		"Increment(Y + 1);":                            "sem.adb:10:1 Parameter in out a should be a variable currently is +",
+	} {
+		logs.Reset()
+		l := lexer.NewLexer("sem.adb", `with Ada.Text_IO; use Ada.Text_IO;
+procedure P is
+   procedure Increment(A: in out Integer) is
+   begin
+      A := A + 1;
+   end Increment;
+   procedure Q is
+      Y: Integer := 1;
+   begin
+`+call+`
+      Put(Y);
+   end Q;
+begin
+   Q;
+end P;
+`)
+		l.Read()
+		_, err := parser.Analyse(l)
+		if message == "" {
+			assert.NoError(t, err, call)
+			assert.Empty(t, logs.String(), call)
+		} else {
+			assert.Error(t, err, call)
+			assert.Contains(t, logs.String(), message, call)
+		}
+	}
+}
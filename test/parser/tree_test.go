@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"gada/ast"
+	"gada/lexer"
+	"gada/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const treeProgram = `with Ada.Text_IO; use Ada.Text_IO;
+procedure Tree is
+   type Point is record
+      X, Y : Integer;
+   end record;
+   P : Point;
+   function Twice(N : in out Integer) return Integer is
+   begin
+      return N * 2;
+   end Twice;
+begin
+   for I in reverse 1 .. 10 loop
+      P.X := -I + Twice(I);
+   end loop;
+   if P.X = 3 then
+      Put('a');
+   elsif not True then
+      New_Line;
+   else
+      return;
+   end if;
+end Tree;
+`
+
+func TestTree(t *testing.T) {
+	l := lexer.NewLexer("tree.adb", treeProgram)
+	l.Read()
+	graph, err := parser.ParseTokens(l)
+	if !assert.NoError(t, err) {
+		return
+	}
+	file := graph.File()
+
+	assert.Equal(t, "Tree", file.Name.Name)
+	assert.Equal(t, "Tree", file.EndName.Name)
+	if !assert.Len(t, file.Decls, 3) {
+		return
+	}
+
+	record := file.Decls[0].(*ast.RecordDecl)
+	assert.Equal(t, "Point", record.Name.Name)
+	assert.Len(t, record.Fields, 1)
+	assert.Len(t, record.Fields[0].Names, 2)
+	assert.Equal(t, ast.Pos{Line: 3, Column: 4}, record.Pos())
+
+	twice := file.Decls[2].(*ast.SubprogramDecl)
+	assert.True(t, twice.IsFunction())
+	assert.Equal(t, ast.InOut, twice.Params[0].Mode)
+	ret := twice.Body[0].(*ast.ReturnStmt)
+	assert.Equal(t, "*", ret.Value.(*ast.BinaryExpr).Op)
+
+	if !assert.Len(t, file.Body, 2) {
+		return
+	}
+	loop := file.Body[0].(*ast.ForStmt)
+	assert.True(t, loop.Reverse)
+	assert.Equal(t, 1, loop.Low.(*ast.IntLit).Value)
+	assign := loop.Body[0].(*ast.AssignStmt)
+	selector := assign.Target.(*ast.SelectorExpr)
+	assert.Equal(t, "P", selector.X.(*ast.Ident).Name)
+	assert.Equal(t, "X", selector.Sel.Name)
+	assert.Equal(t, ast.Span{Start: ast.Pos{Line: 13, Column: 7}, End: ast.Pos{Line: 13, Column: 10}}, selector.Span())
+	sum := assign.Value.(*ast.BinaryExpr)
+	assert.Equal(t, "-", sum.X.(*ast.UnaryExpr).Op)
+	assert.Equal(t, "Twice", sum.Y.(*ast.CallExpr).Fun.Name)
+
+	cond := file.Body[1].(*ast.IfStmt)
+	assert.Equal(t, byte('a'), cond.Body[0].(*ast.CallStmt).Args[0].(*ast.CharLit).Value)
+	if assert.Len(t, cond.Elifs, 1) {
+		assert.Equal(t, "not", cond.Elifs[0].Cond.(*ast.UnaryExpr).Op)
+		call := cond.Elifs[0].Body[0].(*ast.CallStmt)
+		assert.Equal(t, "New_Line", call.Name.Name)
+		assert.Nil(t, call.Args)
+	}
+	assert.Nil(t, cond.Else[0].(*ast.ReturnStmt).Value)
+}
+
+func TestAttributeTree(t *testing.T) {
+	l := lexer.NewLexer("attribute.adb", `with Ada.Text_IO; use Ada.Text_IO;
+procedure Attribute is
+   S : String := "abc";
+begin
+   Put_Line(Integer'Image(S'Length + Character'Pos('a')));
+end Attribute;
+`)
+	l.Read()
+	graph, err := parser.ParseTokens(l)
+	if !assert.NoError(t, err) {
+		return
+	}
+	file := graph.File()
+	if !assert.Len(t, file.Body, 1) {
+		return
+	}
+
+	image := file.Body[0].(*ast.CallStmt).Args[0].(*ast.AttributeExpr)
+	assert.Equal(t, "Integer", image.Prefix.Name)
+	assert.Equal(t, "Image", image.Name.Name)
+	if !assert.Len(t, image.Args, 1) {
+		return
+	}
+	sum := image.Args[0].(*ast.BinaryExpr)
+	length := sum.X.(*ast.AttributeExpr)
+	assert.Equal(t, "S", length.Prefix.Name)
+	assert.Equal(t, "Length", length.Name.Name)
+	assert.Nil(t, length.Args)
+	pos := sum.Y.(*ast.AttributeExpr)
+	assert.Equal(t, byte('a'), pos.Args[0].(*ast.CharLit).Value)
+}
+
+// TestTreeAfterSemantics checks that the graph keeps its tree and that the analysis turns the names of
+// subprograms called without arguments into calls
+func TestTreeAfterSemantics(t *testing.T) {
+	l := lexer.NewLexer("calls.adb", `with Ada.Text_IO; use Ada.Text_IO;
+procedure Calls is
+   function Seven return Integer is
+   begin
+      return 7;
+   end Seven;
+   procedure Show is
+   begin
+      Put(Seven);
+   end Show;
+begin
+   Show;
+end Calls;
+`)
+	l.Read()
+	graph, err := parser.Analyse(l)
+	if !assert.NoError(t, err) {
+		return
+	}
+	file := graph.File()
+	assert.Same(t, file, graph.File())
+
+	show := file.Decls[1].(*ast.SubprogramDecl)
+	put := show.Body[0].(*ast.CallStmt)
+	if call, ok := put.Args[0].(*ast.CallExpr); assert.True(t, ok) {
+		assert.Equal(t, "Seven", call.Fun.Name)
+		assert.Nil(t, call.Args)
+		assert.Equal(t, ast.Pos{Line: 9, Column: 11}, call.Fun.Pos())
+	}
+	stmt := file.Body[0].(*ast.CallStmt)
+	assert.Equal(t, "Show", stmt.Name.Name)
+	assert.NotEqual(t, stmt.ID(), stmt.Name.ID())
+}
@@ -0,0 +1,125 @@
+package wasm
+
+import (
+	"gada/wasm"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRunImports(t *testing.T) {
+	text := `(module
+  (import "gada" "put_char" (func $put_char (param i32)))
+  (import "gada" "put_int" (func $put_int (param i32)))
+  (import "gada" "new_line" (func $new_line))
+  (func $main (export "main")
+    i32.const 72
+    call $put_char
+    i32.const -42
+    call $put_int
+    call $new_line))`
+	var output strings.Builder
+	assert.NoError(t, wasm.Run(text, &output, 0))
+	assert.Equal(t, "H-42\n", output.String())
+}
+
+func TestCallLoopAndMemory(t *testing.T) {
+	text := `(module
+  (memory 1)
+  (global $total (mut i32) (i32.const 0))
+  (func $sum (param $n i32) (result i32) (local $i i32)
+    block $done
+      loop $next
+        local.get $i
+        local.get $n
+        i32.gt_s
+        br_if $done
+        global.get $total
+        local.get $i
+        i32.add
+        global.set $total
+        local.get $i
+        i32.const 1
+        i32.add
+        local.set $i
+        br $next
+      end
+    end
+    i32.const 16
+    global.get $total
+    i32.store offset=4
+    i32.const 20
+    i32.load)
+  (export "sum" (func $sum)))`
+	module, err := wasm.Parse(text)
+	assert.NoError(t, err)
+	instance, err := module.Instantiate(nil)
+	assert.NoError(t, err)
+	results, err := instance.Call("sum", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{55}, results)
+}
+
+func TestIfElse(t *testing.T) {
+	text := `(module
+  (func (export "abs") (param $x i32) (result i32)
+    local.get $x
+    i32.const 0
+    i32.lt_s
+    if (result i32)
+      i32.const 0
+      local.get $x
+      i32.sub
+    else
+      local.get $x
+    end))`
+	module, err := wasm.Parse(text)
+	assert.NoError(t, err)
+	instance, err := module.Instantiate(nil)
+	assert.NoError(t, err)
+	for _, value := range []int32{-7, 0, 7} {
+		results, err := instance.Call("abs", value)
+		assert.NoError(t, err)
+		expected := value
+		if expected < 0 {
+			expected = -expected
+		}
+		assert.Equal(t, []int32{expected}, results)
+	}
+}
+
+func TestTraps(t *testing.T) {
+	text := `(module
+  (func (export "divide") (param i32) (result i32)
+    i32.const 1
+    local.get 0
+    i32.div_s)
+  (func $loop (export "loop")
+    loop $forever
+      br $forever
+    end))`
+	module, err := wasm.Parse(text)
+	assert.NoError(t, err)
+	instance, err := module.Instantiate(nil)
+	assert.NoError(t, err)
+	_, err = instance.Call("divide", 0)
+	assert.ErrorAs(t, err, &wasm.Trap{})
+	instance.MaxSteps = 1000
+	_, err = instance.Call("loop")
+	assert.Error(t, err)
+}
+
+func TestDataSegments(t *testing.T) {
+	text := `(module
+  (import "gada" "put_string" (func $put_string (param i32)))
+  (memory 1)
+  (data (i32.const 8) "\0a\00\00\00say \22hi\22\00\0a")
+  (func $main (export "main")
+    i32.const 8
+    call $put_string
+    i32.const 0
+    call $put_string))`
+	var output strings.Builder
+	assert.NoError(t, wasm.Run(text, &output, 0))
+	assert.Equal(t, "say \"hi\"\x00\n", output.String())
+}
@@ -19,6 +19,7 @@ const (
 	literals_beg
 	IDENT
 	INT    // 12345
+	REAL   // 3.14
 	CHAR   // 'a'
 	STRING // "abc"
 	literals_end
@@ -35,12 +36,20 @@ const (
 	LSS // <
 	GTR // >
 
-	NEQ // !=
+	NEQ // /=
 	LEQ // <=
 	GEQ // >=
 
-	PERIOD // .
-	CAST   // '
+	EXPON // **
+
+	PERIOD     // .
+	TICK       // '
+	DOUBLE_DOT // ..
+	ASSIGN     // :=
+	ARROW      // =>
+	BOX        // <>
+	LLABEL     // <<
+	RLABEL     // >>
 	operator_end
 
 	// Separators
@@ -58,7 +67,6 @@ const (
 	ACCESS
 	AND
 	BEGIN
-	CHAR_TOK
 	ELSE
 	ELSIF
 	END
@@ -83,7 +91,6 @@ const (
 	TRUE
 	TYPE
 	USE
-	VAL
 	WHILE
 	WITH
 	keywords_end
@@ -96,6 +103,7 @@ var Tokens = [...]string{
 
 	IDENT:  "IDENT",
 	INT:    "INT",
+	REAL:   "REAL",
 	CHAR:   "CHAR",
 	STRING: "STRING",
 
@@ -109,13 +117,23 @@ var Tokens = [...]string{
 	LSS: "<",
 	GTR: ">",
 
-	NEQ: "!=",
+	NEQ: "/=",
 	LEQ: "<=",
 	GEQ: ">=",
 
+	EXPON: "**",
+
+	DOUBLE_DOT: "..",
+	ASSIGN:     ":=",
+	ARROW:      "=>",
+	BOX:        "<>",
+	LLABEL:     "<<",
+	RLABEL:     ">>",
+
 	LPAREN: "(",
 	COMMA:  ",",
 	PERIOD: ".",
+	TICK:   "'",
 
 	RPAREN:    ")",
 	SEMICOLON: ";",
@@ -124,7 +142,6 @@ var Tokens = [...]string{
 	ACCESS:    "access",
 	AND:       "and",
 	BEGIN:     "begin",
-	CHAR_TOK:  "character",
 	ELSE:      "else",
 	ELSIF:     "elsif",
 	END:       "end",
@@ -149,7 +166,6 @@ var Tokens = [...]string{
 	TRUE:      "true",
 	TYPE:      "type",
 	USE:       "use",
-	VAL:       "val",
 	WHILE:     "while",
 	WITH:      "with",
 }
@@ -158,24 +174,22 @@ func (t Token) String() string {
 	return Tokens[t]
 }
 
+// Precedence is the level of an operator in Ada, the operators of a higher level bind first.
+// The logical operators share the lowest level and the relational operators the next one.
 func (t Token) Precedence() int {
 	switch t {
-	case PERIOD:
-		return 8
-	case MUL, QUO, REM_OP:
+	case PERIOD, TICK:
 		return 7
-	case ADD, SUB:
+	case EXPON, NOT:
 		return 6
-	case LSS, LEQ, GTR, GEQ:
+	case MUL, QUO, REM_OP, REM:
 		return 5
-	case EQL, NEQ:
+	case ADD, SUB:
 		return 4
-	case NOT:
+	case EQL, NEQ, LSS, LEQ, GTR, GEQ:
 		return 3
-	case AND:
+	case AND, OR:
 		return 2
-	case OR:
-		return 1
 	default:
 		return 0
 	}
@@ -201,10 +215,7 @@ func init() {
 }
 
 func LookupIdent(ident string) Token {
-	if strings.ToLower(ident) == "character" {
-		return IDENT
-	}
-	if tok, ok := keywords[strings.ToLower(ident)]; ok {
+	if tok, ok := keywords[FoldIdentifier(ident)]; ok {
 		// The token is a keyword.
 		return tok
 	}
@@ -242,33 +253,78 @@ func IsKeyword(tok Token) bool {
 }
 
 func IsKeywordString(s string) bool {
-	if strings.ToLower(s) == "character" {
+	name := FoldIdentifier(s)
+	if name == "character" {
 		return false
 	}
-	if _, ok := keywords[strings.ToLower(s)]; ok {
+	if _, ok := keywords[name]; ok {
 		return true
 	}
 	return false
 }
 
-func IsIdentifier(name string) bool {
-	if len(name) == 0 {
-		return false
+// FoldIdentifier normalises an identifier with the simple case folding of Unicode,
+// two identifiers are the same when their foldings are equal (Ada RM 2.3)
+func FoldIdentifier(name string) string {
+	return strings.Map(foldRune, name)
+}
+
+// foldRune returns the representative of the characters equivalent to r under simple case folding:
+// the smallest lower case letter of the orbit of unicode.SimpleFold, or its smallest character.
+// İ and ı have no simple folding, so they stay distinct from i.
+func foldRune(r rune) rune {
+	folded, lower := r, unicode.IsLower(r)
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		switch {
+		case unicode.IsLower(f) && (!lower || f < folded):
+			folded, lower = f, true
+		case !lower && f < folded:
+			folded = f
+		}
 	}
-	if _, ok := keywords[name]; ok {
+	return folded
+}
+
+// IsIdentifier reports whether a name is an identifier that is not a reserved word
+func IsIdentifier(name string) bool {
+	if len(name) == 0 || IsKeywordString(name) {
 		return false
 	}
 	for i, c := range name {
-		if i == 0 && !unicode.IsLetter(c) {
+		if i == 0 && !IsIdentifierStart(c) {
 			return false
 		}
-		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c == '_' {
+		if !CanBeIdentifier(c) {
 			return false
 		}
 	}
-	return true
+	return CheckIdentifier(name) == ""
+}
+
+// IsIdentifierStart reports whether an identifier can start with c: a letter or a letter number
+func IsIdentifierStart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.Is(unicode.Nl, c)
 }
 
+// CanBeIdentifier reports whether c can follow the first character of an identifier:
+// a letter, a mark, a digit or a connector like the underscore
 func CanBeIdentifier(c rune) bool {
-	return unicode.IsLetter(c) || c == '_' || unicode.IsDigit(c)
+	return IsIdentifierStart(c) || unicode.In(c, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc)
+}
+
+// CheckIdentifier returns why the characters of an identifier break the rules of Ada,
+// or an empty string. The connectors like the underscore can neither follow each other nor end it.
+func CheckIdentifier(name string) string {
+	connector := false
+	for _, c := range name {
+		isConnector := unicode.Is(unicode.Pc, c)
+		if isConnector && connector {
+			return "an identifier cannot contain two consecutive underscores"
+		}
+		connector = isConnector
+	}
+	if connector {
+		return "an identifier cannot end with an underscore"
+	}
+	return ""
 }
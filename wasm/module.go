@@ -0,0 +1,647 @@
+package wasm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Module is a WebAssembly module read from the text format. Only the subset produced by the
+// compiler is understood: i32 values, one memory, mutable globals, imported host functions and
+// instructions written one after the other (not folded), data segments have a constant offset.
+type Module struct {
+	Imports   []Import
+	Functions []*Function
+	Globals   []Global
+	// MemoryPages is the initial size of the memory in pages of 64 KiB
+	MemoryPages int
+	Exports     map[string]int
+	// Data are the bytes copied into the memory when the module is instantiated
+	Data []Segment
+}
+
+// Segment is a data segment, Bytes are written at Offset.
+type Segment struct {
+	Offset int
+	Bytes  []byte
+}
+
+// Import is a function given by the host.
+type Import struct {
+	Module string
+	Name   string
+	// Params is the number of i32 parameters
+	Params  int
+	Results int
+}
+
+// Function is a function defined by the module.
+type Function struct {
+	Name    string
+	Params  int
+	Results int
+	// Locals are the parameters then the declared locals
+	Locals []string
+	code   []instruction
+}
+
+// Global is a global variable with its initial value.
+type Global struct {
+	Name    string
+	Mutable bool
+	Value   int32
+}
+
+type opcode int
+
+const (
+	opUnreachable opcode = iota
+	opNop
+	opBlock
+	opLoop
+	opIf
+	opElse
+	opEnd
+	opBr
+	opBrIf
+	opReturn
+	opCall
+	opDrop
+	opSelect
+	opLocalGet
+	opLocalSet
+	opLocalTee
+	opGlobalGet
+	opGlobalSet
+	opLoad
+	opLoad8U
+	opStore
+	opStore8
+	opConst
+	opEqz
+	opEq
+	opNe
+	opLtS
+	opLtU
+	opGtS
+	opGtU
+	opLeS
+	opLeU
+	opGeS
+	opGeU
+	opAdd
+	opSub
+	opMul
+	opDivS
+	opDivU
+	opRemS
+	opRemU
+	opAnd
+	opOr
+	opXor
+	opShl
+	opShrS
+	opShrU
+)
+
+var simpleOpcodes = map[string]opcode{
+	"unreachable": opUnreachable, "nop": opNop, "return": opReturn, "drop": opDrop, "select": opSelect,
+	"i32.eqz": opEqz, "i32.eq": opEq, "i32.ne": opNe,
+	"i32.lt_s": opLtS, "i32.lt_u": opLtU, "i32.gt_s": opGtS, "i32.gt_u": opGtU,
+	"i32.le_s": opLeS, "i32.le_u": opLeU, "i32.ge_s": opGeS, "i32.ge_u": opGeU,
+	"i32.add": opAdd, "i32.sub": opSub, "i32.mul": opMul, "i32.div_s": opDivS, "i32.div_u": opDivU,
+	"i32.rem_s": opRemS, "i32.rem_u": opRemU, "i32.and": opAnd, "i32.or": opOr, "i32.xor": opXor,
+	"i32.shl": opShl, "i32.shr_s": opShrS, "i32.shr_u": opShrU,
+}
+
+var memoryOpcodes = map[string]opcode{
+	"i32.load": opLoad, "i32.load8_u": opLoad8U, "i32.store": opStore, "i32.store8": opStore8,
+}
+
+type instruction struct {
+	op opcode
+	// value is the constant, the index, the memory offset or the branch depth
+	value int32
+	// arity is the number of results of a block, target the index of its end (or of the else of an if)
+	arity  int
+	target int
+}
+
+// sexpr is a parenthesized list or an atom of the text format
+type sexpr struct {
+	atom string
+	list []sexpr
+	// isList tells an empty list from an atom
+	isList bool
+}
+
+func tokenize(text string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(text[i:], ";;"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(text[i:], "(;"):
+			end := strings.Index(text[i:], ";)")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated block comment")
+			}
+			i += end + 2
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(text) && text[j] != '"' {
+				if text[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(text) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, text[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(text) && !strings.ContainsRune(" \t\n\r()", rune(text[j])) {
+				j++
+			}
+			tokens = append(tokens, text[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func parseSexpr(tokens []string, i int) (sexpr, int, error) {
+	if i >= len(tokens) {
+		return sexpr{}, i, fmt.Errorf("unexpected end of module")
+	}
+	if tokens[i] == ")" {
+		return sexpr{}, i, fmt.Errorf("unexpected )")
+	}
+	if tokens[i] != "(" {
+		return sexpr{atom: tokens[i]}, i + 1, nil
+	}
+	result := sexpr{isList: true}
+	i++
+	for i < len(tokens) && tokens[i] != ")" {
+		var child sexpr
+		var err error
+		child, i, err = parseSexpr(tokens, i)
+		if err != nil {
+			return result, i, err
+		}
+		result.list = append(result.list, child)
+	}
+	if i >= len(tokens) {
+		return result, i, fmt.Errorf("missing )")
+	}
+	return result, i + 1, nil
+}
+
+// head returns the keyword of a list
+func (s sexpr) head() string {
+	if !s.isList || len(s.list) == 0 {
+		return ""
+	}
+	return s.list[0].atom
+}
+
+// unquote returns the bytes of a string, \hh is the byte written in hexadecimal and \t \n \" \' \\ the usual characters
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a string, got %s", s)
+	}
+	s = s[1 : len(s)-1]
+	var text strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			text.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("invalid escape at the end of %s", s)
+		}
+		i++
+		switch s[i] {
+		case 't':
+			text.WriteByte('\t')
+		case 'n':
+			text.WriteByte('\n')
+		case 'r':
+			text.WriteByte('\r')
+		case '"', '\'', '\\':
+			text.WriteByte(s[i])
+		default:
+			if i+1 >= len(s) {
+				return "", fmt.Errorf("invalid escape in %s", s)
+			}
+			value, err := strconv.ParseUint(s[i:i+2], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape \\%s", s[i:i+2])
+			}
+			text.WriteByte(byte(value))
+			i++
+		}
+	}
+	return text.String(), nil
+}
+
+func parseInt(s string) (int32, error) {
+	s = strings.ReplaceAll(s, "_", "")
+	value, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", s)
+	}
+	if value < -1<<31 || value >= 1<<32 {
+		return 0, fmt.Errorf("%s does not fit in i32", s)
+	}
+	return int32(value), nil
+}
+
+// Parse reads a module in the WebAssembly text format.
+func Parse(text string) (*Module, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	root, next, err := parseSexpr(tokens, 0)
+	if err != nil {
+		return nil, err
+	}
+	if next != len(tokens) || root.head() != "module" {
+		return nil, fmt.Errorf("expected a single module")
+	}
+
+	m := &Module{Exports: map[string]int{}}
+	functions := map[string]int{}
+	globals := map[string]int{}
+	var bodies [][]sexpr
+	var exports []sexpr
+
+	// The imports come first in the function index space
+	for _, field := range root.list[1:] {
+		if field.head() != "import" {
+			continue
+		}
+		if len(field.list) != 4 || field.list[3].head() != "func" {
+			return nil, fmt.Errorf("only function imports are supported")
+		}
+		module, err := unquote(field.list[1].atom)
+		if err != nil {
+			return nil, err
+		}
+		name, err := unquote(field.list[2].atom)
+		if err != nil {
+			return nil, err
+		}
+		f, _, err := parseSignature(field.list[3].list[1:])
+		if err != nil {
+			return nil, err
+		}
+		if f.Name != "" {
+			functions[f.Name] = len(m.Imports)
+		}
+		m.Imports = append(m.Imports, Import{Module: module, Name: name, Params: f.Params, Results: f.Results})
+	}
+
+	for _, field := range root.list[1:] {
+		switch field.head() {
+		case "import":
+		case "memory":
+			for _, item := range field.list[1:] {
+				if item.isList || strings.HasPrefix(item.atom, "$") {
+					continue
+				}
+				pages, err := parseInt(item.atom)
+				if err != nil {
+					return nil, err
+				}
+				m.MemoryPages = int(pages)
+			}
+		case "data":
+			segment, err := parseData(field.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			m.Data = append(m.Data, segment)
+		case "global":
+			global, err := parseGlobal(field.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			if global.Name != "" {
+				globals[global.Name] = len(m.Globals)
+			}
+			m.Globals = append(m.Globals, global)
+		case "func":
+			f, body, err := parseSignature(field.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			index := len(m.Imports) + len(m.Functions)
+			if f.Name != "" {
+				functions[f.Name] = index
+			}
+			for _, item := range field.list[1:] {
+				if item.head() == "export" && len(item.list) == 2 {
+					name, err := unquote(item.list[1].atom)
+					if err != nil {
+						return nil, err
+					}
+					m.Exports[name] = index
+				}
+			}
+			m.Functions = append(m.Functions, f)
+			bodies = append(bodies, body)
+		case "export":
+			if len(field.list) != 3 || field.list[2].head() != "func" || len(field.list[2].list) != 2 {
+				return nil, fmt.Errorf("only function exports are supported")
+			}
+			exports = append(exports, field)
+		default:
+			return nil, fmt.Errorf("unsupported module field %s", field.head())
+		}
+	}
+
+	for _, export := range exports {
+		name, err := unquote(export.list[1].atom)
+		if err != nil {
+			return nil, err
+		}
+		index, ok := functions[export.list[2].list[1].atom]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s", export.list[2].list[1].atom)
+		}
+		m.Exports[name] = index
+	}
+
+	for i, f := range m.Functions {
+		f.code, err = compile(f, bodies[i], functions, globals)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %w", f.Name, err)
+		}
+	}
+	return m, nil
+}
+
+// parseData reads (data (i32.const offset) "bytes" ...), the strings are concatenated
+func parseData(items []sexpr) (Segment, error) {
+	if len(items) == 0 || items[0].head() != "i32.const" || len(items[0].list) != 2 {
+		return Segment{}, fmt.Errorf("data segment without a constant offset")
+	}
+	offset, err := parseInt(items[0].list[1].atom)
+	if err != nil {
+		return Segment{}, err
+	}
+	segment := Segment{Offset: int(uint32(offset))}
+	for _, item := range items[1:] {
+		text, err := unquote(item.atom)
+		if err != nil {
+			return Segment{}, err
+		}
+		segment.Bytes = append(segment.Bytes, text...)
+	}
+	return segment, nil
+}
+
+func parseGlobal(items []sexpr) (Global, error) {
+	var global Global
+	for _, item := range items {
+		switch {
+		case !item.isList && strings.HasPrefix(item.atom, "$"):
+			global.Name = item.atom
+		case item.head() == "mut":
+			global.Mutable = true
+		case item.head() == "i32.const" && len(item.list) == 2:
+			value, err := parseInt(item.list[1].atom)
+			if err != nil {
+				return global, err
+			}
+			global.Value = value
+		}
+	}
+	return global, nil
+}
+
+// parseSignature reads the name, the parameters, the results and the locals of a function.
+// It returns the remaining items: the instructions.
+func parseSignature(items []sexpr) (*Function, []sexpr, error) {
+	f := &Function{}
+	i := 0
+	if i < len(items) && !items[i].isList && strings.HasPrefix(items[i].atom, "$") {
+		f.Name = items[i].atom
+		i++
+	}
+	for ; i < len(items) && items[i].isList; i++ {
+		item := items[i]
+		switch item.head() {
+		case "export":
+		case "param", "local":
+			names := item.list[1:]
+			if len(names) == 2 && strings.HasPrefix(names[0].atom, "$") {
+				// A named parameter or local
+				names = names[:1]
+			}
+			for _, name := range names {
+				if name.atom != "i32" && !strings.HasPrefix(name.atom, "$") {
+					return nil, nil, fmt.Errorf("unsupported type %s", name.atom)
+				}
+				if item.head() == "param" {
+					f.Params++
+				}
+				f.Locals = append(f.Locals, name.atom)
+			}
+		case "result":
+			f.Results += len(item.list) - 1
+		default:
+			return f, items[i:], nil
+		}
+	}
+	return f, items[i:], nil
+}
+
+type openBlock struct {
+	label string
+	start int
+}
+
+// compile reads the flat instructions of a function and links the blocks to their end
+func compile(f *Function, items []sexpr, functions map[string]int, globals map[string]int) ([]instruction, error) {
+	var code []instruction
+	var blocks []openBlock
+	locals := map[string]int{}
+	for i, name := range f.Locals {
+		if strings.HasPrefix(name, "$") {
+			locals[name] = i
+		}
+	}
+
+	index := func(atom string, names map[string]int) (int32, error) {
+		if strings.HasPrefix(atom, "$") {
+			value, ok := names[atom]
+			if !ok {
+				return 0, fmt.Errorf("unknown name %s", atom)
+			}
+			return int32(value), nil
+		}
+		return parseInt(atom)
+	}
+	depth := func(atom string) (int32, error) {
+		if strings.HasPrefix(atom, "$") {
+			for i := len(blocks) - 1; i >= 0; i-- {
+				if blocks[i].label == atom {
+					return int32(len(blocks) - 1 - i), nil
+				}
+			}
+			return 0, fmt.Errorf("unknown label %s", atom)
+		}
+		return parseInt(atom)
+	}
+
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		if item.isList {
+			return nil, fmt.Errorf("folded instructions are not supported")
+		}
+		// argument returns the next atom as the immediate of the instruction
+		argument := func() (string, error) {
+			if i+1 >= len(items) || items[i+1].isList {
+				return "", fmt.Errorf("%s expects an argument", item.atom)
+			}
+			i++
+			return items[i].atom, nil
+		}
+
+		name := item.atom
+		if op, ok := simpleOpcodes[name]; ok {
+			code = append(code, instruction{op: op})
+			continue
+		}
+		if op, ok := memoryOpcodes[name]; ok {
+			inst := instruction{op: op}
+			for i+1 < len(items) && !items[i+1].isList && (strings.HasPrefix(items[i+1].atom, "offset=") || strings.HasPrefix(items[i+1].atom, "align=")) {
+				i++
+				if offset, found := strings.CutPrefix(items[i].atom, "offset="); found {
+					value, err := parseInt(offset)
+					if err != nil {
+						return nil, err
+					}
+					inst.value = value
+				}
+			}
+			code = append(code, inst)
+			continue
+		}
+
+		switch name {
+		case "block", "loop", "if":
+			op := map[string]opcode{"block": opBlock, "loop": opLoop, "if": opIf}[name]
+			block := openBlock{start: len(code)}
+			if i+1 < len(items) && !items[i+1].isList && strings.HasPrefix(items[i+1].atom, "$") {
+				i++
+				block.label = items[i].atom
+			}
+			inst := instruction{op: op}
+			for i+1 < len(items) && items[i+1].head() == "result" {
+				i++
+				inst.arity += len(items[i].list) - 1
+			}
+			blocks = append(blocks, block)
+			code = append(code, inst)
+		case "else":
+			if len(blocks) == 0 || code[blocks[len(blocks)-1].start].op != opIf {
+				return nil, fmt.Errorf("else without if")
+			}
+			code[blocks[len(blocks)-1].start].target = len(code)
+			code = append(code, instruction{op: opElse})
+			skipLabel(items, &i)
+		case "end":
+			code = append(code, instruction{op: opEnd})
+			if len(blocks) == 0 {
+				return nil, fmt.Errorf("end without block")
+			}
+			block := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+			start := &code[block.start]
+			if start.op == opIf && start.target != 0 {
+				// The else jumps to the end
+				code[start.target].target = len(code) - 1
+			} else {
+				start.target = len(code) - 1
+			}
+			code[len(code)-1].target = block.start
+			skipLabel(items, &i)
+		case "br", "br_if":
+			atom, err := argument()
+			if err != nil {
+				return nil, err
+			}
+			value, err := depth(atom)
+			if err != nil {
+				return nil, err
+			}
+			op := opBr
+			if name == "br_if" {
+				op = opBrIf
+			}
+			code = append(code, instruction{op: op, value: value})
+		case "call":
+			atom, err := argument()
+			if err != nil {
+				return nil, err
+			}
+			value, err := index(atom, functions)
+			if err != nil {
+				return nil, err
+			}
+			code = append(code, instruction{op: opCall, value: value})
+		case "local.get", "local.set", "local.tee", "global.get", "global.set":
+			atom, err := argument()
+			if err != nil {
+				return nil, err
+			}
+			names := locals
+			if strings.HasPrefix(name, "global") {
+				names = globals
+			}
+			value, err := index(atom, names)
+			if err != nil {
+				return nil, err
+			}
+			op := map[string]opcode{"local.get": opLocalGet, "local.set": opLocalSet, "local.tee": opLocalTee, "global.get": opGlobalGet, "global.set": opGlobalSet}[name]
+			code = append(code, instruction{op: op, value: value})
+		case "i32.const":
+			atom, err := argument()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseInt(atom)
+			if err != nil {
+				return nil, err
+			}
+			code = append(code, instruction{op: opConst, value: value})
+		default:
+			return nil, fmt.Errorf("unsupported instruction %s", name)
+		}
+	}
+	if len(blocks) != 0 {
+		return nil, fmt.Errorf("missing end")
+	}
+	return code, nil
+}
+
+// skipLabel skips the optional label repeated after else and end
+func skipLabel(items []sexpr, i *int) {
+	if *i+1 < len(items) && !items[*i+1].isList && strings.HasPrefix(items[*i+1].atom, "$") {
+		*i++
+	}
+}
@@ -0,0 +1,362 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	pageSize = 65536
+
+	defaultMaxSteps = 200_000_000
+	maxCallDepth    = 100_000
+)
+
+// HostFunction is a function imported by the module, it receives the arguments and returns the results.
+type HostFunction func(instance *Instance, args []int32) []int32
+
+// Instance is a module ready to run, it owns the memory and the globals.
+type Instance struct {
+	Memory  []byte
+	Globals []int32
+
+	// MaxSteps is the number of executed instructions after which the execution is stopped, 0 for the default
+	MaxSteps int
+	Steps    int
+
+	module *Module
+	host   []HostFunction
+	depth  int
+}
+
+// Trap is the error of a program stopped by the runtime.
+type Trap struct {
+	Message string
+}
+
+func (t Trap) Error() string {
+	return "wasm trap: " + t.Message
+}
+
+type label struct {
+	loop   bool
+	start  int
+	end    int
+	height int
+	arity  int
+}
+
+// Instantiate links the imports of the module with host functions indexed by module then name.
+func (m *Module) Instantiate(imports map[string]map[string]HostFunction) (*Instance, error) {
+	instance := &Instance{Memory: make([]byte, m.MemoryPages*pageSize), module: m}
+	for _, imported := range m.Imports {
+		host, ok := imports[imported.Module][imported.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing import %s.%s", imported.Module, imported.Name)
+		}
+		instance.host = append(instance.host, host)
+	}
+	for _, global := range m.Globals {
+		instance.Globals = append(instance.Globals, global.Value)
+	}
+	for _, segment := range m.Data {
+		if segment.Offset+len(segment.Bytes) > len(instance.Memory) {
+			return nil, fmt.Errorf("data segment at %d does not fit in the memory", segment.Offset)
+		}
+		copy(instance.Memory[segment.Offset:], segment.Bytes)
+	}
+	return instance, nil
+}
+
+// Call runs an exported function.
+func (i *Instance) Call(name string, args ...int32) ([]int32, error) {
+	index, ok := i.module.Exports[name]
+	if !ok {
+		return nil, fmt.Errorf("no exported function %s", name)
+	}
+	if i.MaxSteps == 0 {
+		i.MaxSteps = defaultMaxSteps
+	}
+	return i.call(index, args)
+}
+
+func (i *Instance) call(index int, args []int32) (results []int32, err error) {
+	if index < len(i.host) {
+		return i.host[index](i, args), nil
+	}
+	f := i.module.Functions[index-len(i.host)]
+	if len(args) != f.Params {
+		return nil, fmt.Errorf("%s expects %d arguments", f.Name, f.Params)
+	}
+	i.depth++
+	defer func() { i.depth-- }()
+	if i.depth > maxCallDepth {
+		return nil, Trap{"call stack exhausted"}
+	}
+
+	locals := make([]int32, len(f.Locals))
+	copy(locals, args)
+	var stack []int32
+	var labels []label
+	pop := func() int32 {
+		value := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return value
+	}
+	address := func(offset int32, size int) (int, error) {
+		addr := int(uint32(pop())) + int(uint32(offset))
+		if addr+size > len(i.Memory) {
+			return 0, Trap{"out of bounds memory access at " + strconv.Itoa(addr)}
+		}
+		return addr, nil
+	}
+
+	for pc := 0; pc < len(f.code); pc++ {
+		i.Steps++
+		if i.Steps > i.MaxSteps {
+			return nil, fmt.Errorf("execution stopped after %d steps", i.MaxSteps)
+		}
+		inst := f.code[pc]
+		switch inst.op {
+		case opUnreachable:
+			return nil, Trap{"unreachable executed"}
+		case opNop:
+		case opBlock, opLoop:
+			end := inst.target
+			labels = append(labels, label{loop: inst.op == opLoop, start: pc, end: end, height: len(stack), arity: inst.arity})
+		case opIf:
+			end := inst.target
+			hasElse := f.code[end].op == opElse
+			if hasElse {
+				end = f.code[end].target
+			}
+			condition := pop()
+			labels = append(labels, label{start: pc, end: end, height: len(stack), arity: inst.arity})
+			if condition == 0 {
+				if hasElse {
+					pc = inst.target
+				} else {
+					pc = end - 1
+				}
+			}
+		case opElse:
+			// End of the then branch
+			pc = inst.target - 1
+		case opEnd:
+			labels = labels[:len(labels)-1]
+		case opBr, opBrIf:
+			if inst.op == opBrIf && pop() == 0 {
+				continue
+			}
+			target := labels[len(labels)-1-int(inst.value)]
+			if target.loop {
+				stack = stack[:target.height]
+				labels = labels[:len(labels)-int(inst.value)]
+				pc = target.start
+				continue
+			}
+			kept := append([]int32{}, stack[len(stack)-target.arity:]...)
+			stack = append(stack[:target.height], kept...)
+			labels = labels[:len(labels)-1-int(inst.value)]
+			pc = target.end
+		case opReturn:
+			return stack[len(stack)-f.Results:], nil
+		case opCall:
+			callee := int(inst.value)
+			params := 0
+			if callee < len(i.host) {
+				params = i.module.Imports[callee].Params
+			} else {
+				params = i.module.Functions[callee-len(i.host)].Params
+			}
+			args := append([]int32{}, stack[len(stack)-params:]...)
+			stack = stack[:len(stack)-params]
+			results, err := i.call(callee, args)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, results...)
+		case opDrop:
+			pop()
+		case opSelect:
+			condition := pop()
+			second := pop()
+			first := pop()
+			if condition != 0 {
+				stack = append(stack, first)
+			} else {
+				stack = append(stack, second)
+			}
+		case opLocalGet:
+			stack = append(stack, locals[inst.value])
+		case opLocalSet:
+			locals[inst.value] = pop()
+		case opLocalTee:
+			locals[inst.value] = stack[len(stack)-1]
+		case opGlobalGet:
+			stack = append(stack, i.Globals[inst.value])
+		case opGlobalSet:
+			i.Globals[inst.value] = pop()
+		case opLoad, opLoad8U:
+			size := 4
+			if inst.op == opLoad8U {
+				size = 1
+			}
+			addr, err := address(inst.value, size)
+			if err != nil {
+				return nil, err
+			}
+			if size == 1 {
+				stack = append(stack, int32(i.Memory[addr]))
+			} else {
+				stack = append(stack, int32(binary.LittleEndian.Uint32(i.Memory[addr:])))
+			}
+		case opStore, opStore8:
+			value := pop()
+			size := 4
+			if inst.op == opStore8 {
+				size = 1
+			}
+			addr, err := address(inst.value, size)
+			if err != nil {
+				return nil, err
+			}
+			if size == 1 {
+				i.Memory[addr] = byte(value)
+			} else {
+				binary.LittleEndian.PutUint32(i.Memory[addr:], uint32(value))
+			}
+		case opConst:
+			stack = append(stack, inst.value)
+		case opEqz:
+			stack = append(stack, boolean(pop() == 0))
+		default:
+			right := pop()
+			left := pop()
+			result, err := binaryOperation(inst.op, left, right)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, result)
+		}
+	}
+	return stack[len(stack)-f.Results:], nil
+}
+
+func boolean(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func binaryOperation(op opcode, left int32, right int32) (int32, error) {
+	uleft, uright := uint32(left), uint32(right)
+	switch op {
+	case opEq:
+		return boolean(left == right), nil
+	case opNe:
+		return boolean(left != right), nil
+	case opLtS:
+		return boolean(left < right), nil
+	case opLtU:
+		return boolean(uleft < uright), nil
+	case opGtS:
+		return boolean(left > right), nil
+	case opGtU:
+		return boolean(uleft > uright), nil
+	case opLeS:
+		return boolean(left <= right), nil
+	case opLeU:
+		return boolean(uleft <= uright), nil
+	case opGeS:
+		return boolean(left >= right), nil
+	case opGeU:
+		return boolean(uleft >= uright), nil
+	case opAdd:
+		return left + right, nil
+	case opSub:
+		return left - right, nil
+	case opMul:
+		return left * right, nil
+	case opDivS, opDivU, opRemS, opRemU:
+		if right == 0 {
+			return 0, Trap{"integer divide by zero"}
+		}
+		switch op {
+		case opDivS:
+			if left == -1<<31 && right == -1 {
+				return 0, Trap{"integer overflow"}
+			}
+			return left / right, nil
+		case opDivU:
+			return int32(uleft / uright), nil
+		case opRemS:
+			if right == -1 {
+				return 0, nil
+			}
+			return left % right, nil
+		}
+		return int32(uleft % uright), nil
+	case opAnd:
+		return left & right, nil
+	case opOr:
+		return left | right, nil
+	case opXor:
+		return left ^ right, nil
+	case opShl:
+		return left << (uright & 31), nil
+	case opShrS:
+		return left >> (uright & 31), nil
+	case opShrU:
+		return int32(uleft >> (uright & 31)), nil
+	}
+	return 0, fmt.Errorf("unknown opcode %d", op)
+}
+
+// Run executes the main function of a module compiled by gada, Put and New_Line write to the output.
+func Run(text string, output io.Writer, maxSteps int) error {
+	module, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	imports := map[string]map[string]HostFunction{
+		"gada": {
+			"put_char": func(_ *Instance, args []int32) []int32 {
+				output.Write([]byte{byte(args[0])})
+				return nil
+			},
+			"put_int": func(_ *Instance, args []int32) []int32 {
+				io.WriteString(output, strconv.Itoa(int(args[0])))
+				return nil
+			},
+			"put_string": func(instance *Instance, args []int32) []int32 {
+				// The length of the string is in the word before its characters, the null access is the empty string
+				address := int(uint32(args[0]))
+				if address == 0 || address+4 > len(instance.Memory) {
+					return nil
+				}
+				start := address + 4
+				end := start + int(binary.LittleEndian.Uint32(instance.Memory[address:start]))
+				if end > len(instance.Memory) || end < start {
+					end = len(instance.Memory)
+				}
+				output.Write(instance.Memory[start:end])
+				return nil
+			},
+			"new_line": func(_ *Instance, args []int32) []int32 {
+				io.WriteString(output, "\n")
+				return nil
+			},
+		},
+	}
+	instance, err := module.Instantiate(imports)
+	if err != nil {
+		return err
+	}
+	instance.MaxSteps = maxSteps
+	_, err = instance.Call("main")
+	return err
+}